@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/my-network/fsutil/pkg/file/storage/fsdgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// fsdDialOptions returns the options to connect to an fsd server. TLS is
+// used if the CA certificate is set.
+func fsdDialOptions(caFile, certFile, keyFile, tokenFile string) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	isTLS := caFile != ""
+	if isTLS {
+		tlsConfig := &tls.Config{}
+		caPEM, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the CA certificate: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates in '%s'", caFile)
+		}
+		if certFile != "" {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load the client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		if certFile != "" {
+			return nil, fmt.Errorf("-fsd-cert requires -fsd-ca")
+		}
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if tokenFile != "" {
+		token, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the token: %w", err)
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(fsdgrpc.BearerToken{
+			Token:         strings.TrimSpace(string(token)),
+			AllowInsecure: !isTLS,
+		}))
+	}
+	return opts, nil
+}
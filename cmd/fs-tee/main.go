@@ -3,18 +3,28 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
 	"log"
 	"os"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/my-network/fsutil/pkg/file/event/polling"
 	"github.com/my-network/fsutil/pkg/file/storage/cached"
+	"github.com/my-network/fsutil/pkg/file/storage/fsdgrpc"
 	"github.com/my-network/fsutil/pkg/file/storage/localfs"
+	s3storage "github.com/my-network/fsutil/pkg/file/storage/s3"
+	sftpstorage "github.com/my-network/fsutil/pkg/file/storage/sftp"
 	"github.com/my-network/fsutil/pkg/syncer"
+	"google.golang.org/grpc"
 )
 
+// fsdScheme is the prefix of a destination served by a remote fsd daemon
+// (for example: "fsd://backup.example.org:7325").
+const fsdScheme = "fsd://"
+
 func syntaxExit() {
 	flag.Usage()
 	os.Exit(int(syscall.EINVAL))
@@ -54,7 +64,7 @@ func watchErrorHandler(err error) error {
 
 func main() {
 	profile := flag.String("profile", "", "enable a profile: \"huge-latency-on-dst\""+
-		" (effectively: -checksum -cache-data-dst=1000000 -cache-metadata-dst=1000000 -keep-open-dst=1000)")
+		" (effectively: -checksum -cache-data-dst=1000000 -cache-metadata-dst=1000000 -keep-open-dst=1000 -fsd-compressors=zstd)")
 	skipInitialSync := flag.Bool("skip-initial-sync", false, "do not start re-syncing everything on start")
 	aggregationTimeMin := flag.String("aggregation-time-min", "1s",
 		`minimal time to wait for more events on a file`)
@@ -71,6 +81,53 @@ func main() {
 	keepOpenDst := flag.Uint("keep-open-dst", 0,
 		`keep files of the destination opened to avoid extra syscalls (open()/close()) for the specified amount of files.`+
 			`The destination data should not be changed bypass the fs-tee instance!`)
+	taskCountMax := flag.Int("task-count-max", syncer.DefaultConfig.TaskCountMax,
+		`maximal amount of queued tasks; if reached, tasks are collapsed into subtree resyncs (0 means no limit)`)
+	subtreeCoalesceThreshold := flag.Int("subtree-coalesce-threshold", syncer.DefaultConfig.SubtreeCoalesceThreshold,
+		`amount of queued tasks inside of one directory to collapse them into a subtree resync (0 disables)`)
+	eventQueueSize := flag.Uint("event-queue-size", localfs.DefaultEventQueueSize,
+		`capacity of the queue of filesystem events`)
+	scrubInterval := flag.String("scrub-interval", "",
+		`periodically verify the destination against the source (for example: "24h")`)
+	scrubRate := flag.Uint("scrub-rate", 0,
+		`maximal amount of objects checked per second by a scrub (0 means no limit)`)
+	scrubChecksum := flag.Bool("scrub-checksum", false,
+		`compare the content of files (not only metadata) while scrubbing`)
+	watcherBackend := flag.String("watcher-backend", localfs.WatcherBackendAuto.String(),
+		`the source of filesystem events: "auto", "inotify", "fanotify" or "fsnotify"`)
+	pollInterval := flag.String("poll-interval", "",
+		`detect changes by scanning the source periodically instead of filesystem events (for example: "10s"); `+
+			`useful for NFS, CIFS and FUSE mounts`)
+	pollIntervalMax := flag.String("poll-interval-max", polling.DefaultConfig.IntervalMax.String(),
+		`maximal period of scanning of directories which do not change`)
+	eventTypes := flag.String("event-types", event.DefaultWatchTypeMask.String(),
+		`the types of filesystem events to react on (for example: "close_write|move|delete")`)
+	recordEvents := flag.String("record-events", "",
+		`append all the received filesystem events to the specified file (for debugging)`)
+	recordFormat := flag.String("record-format", event.RecordFormatJSONLines.String(),
+		`the format of -record-events: "jsonl" or "msgp"`)
+	fsdCA := flag.String("fsd-ca", "",
+		`the CA certificate (PEM) to verify the fsd server with; enables TLS`)
+	fsdCert := flag.String("fsd-cert", "",
+		`the client certificate (PEM) for mTLS with the fsd server; requires -fsd-key`)
+	fsdKey := flag.String("fsd-key", "",
+		`the key (PEM) of -fsd-cert`)
+	fsdTokenFile := flag.String("fsd-token-file", "",
+		`the file with the bearer token to authenticate to the fsd server`)
+	sftpKey := flag.String("sftp-key", "",
+		`the private SSH key to authenticate to an sftp:// destination with (default: ~/.ssh/id_ed25519)`)
+	sftpKnownHosts := flag.String("sftp-known-hosts", "",
+		`the known_hosts file to verify the host of an sftp:// destination with (default: ~/.ssh/known_hosts)`)
+	s3Endpoint := flag.String("s3-endpoint", "",
+		`the endpoint of the S3-compatible server of an s3:// destination (default: `+s3DefaultEndpoint+`)`)
+	s3Region := flag.String("s3-region", "",
+		`the region of an s3:// destination (default: `+s3storage.DefaultRegion+`)`)
+	webdavRangedPut := flag.Bool("webdav-ranged-put", false,
+		`send the writes to a webdav:// or webdavs:// destination as ranged PUT requests (only some servers support it)`)
+	tarFlushDelay := flag.String("tar-flush-delay", "10s",
+		`append the pending changes to a tar:// destination after this time without other changes`)
+	fsdCompressors := flag.String("fsd-compressors", "",
+		`comma-separated compressors of the data streams to propose to the fsd server, in the order of preference (for example: "zstd")`)
 	flag.Parse()
 
 	if flag.NArg() != 2 {
@@ -86,6 +143,7 @@ func main() {
 		*cacheDataDst = 1000000
 		*cacheMetadataDst = 1000000
 		*keepOpenDst = 1000
+		*fsdCompressors = fsdgrpc.CompressorZstd
 	}
 
 	if *aggregationTimeMin != "" {
@@ -104,6 +162,27 @@ func main() {
 		syncerOpts = append(syncerOpts, syncer.OptionChecksum{Enable: true})
 	}
 
+	if *scrubInterval != "" {
+		duration, err := time.ParseDuration(*scrubInterval)
+		assertNoError(err)
+		syncerOpts = append(syncerOpts,
+			syncer.OptionScrubInterval{Value: duration},
+			syncer.OptionScrubRateLimit{ObjectsPerSecond: *scrubRate},
+			syncer.OptionScrubChecksums{Enable: *scrubChecksum},
+			syncer.OptionScrubReportHandler{Handler: func(report *syncer.ScrubReport) {
+				log.Println(report)
+				for _, drift := range report.Drifts {
+					log.Printf("drift: '%s': %v", drift.Path.LocalPath(), drift.Reason)
+				}
+			}},
+		)
+	}
+
+	syncerOpts = append(syncerOpts,
+		syncer.OptionTaskCountMax{Value: *taskCountMax},
+		syncer.OptionSubtreeCoalesceThreshold{Value: *subtreeCoalesceThreshold},
+	)
+
 	var dstStorageOpts []cached.Option
 
 	if *cacheDataDst > 0 {
@@ -121,9 +200,46 @@ func main() {
 	pathSrc := flag.Arg(0)
 	pathDst := flag.Arg(1)
 
-	srcStorage := localfs.NewStorage(pathSrc)
+	srcWatcherBackend, err := localfs.ParseWatcherBackend(*watcherBackend)
+	assertNoError(err)
+
+	srcStorage := localfs.NewStorage(pathSrc,
+		localfs.OptionEventQueueSize{Size: *eventQueueSize},
+		localfs.OptionWatcherBackend{Backend: srcWatcherBackend},
+	)
 
-	dstStorageBackend := localfs.NewStorage(pathDst)
+	var dstStorageBackend file.Storage
+	switch {
+	case strings.HasPrefix(pathDst, fsdScheme):
+		dialOpts, err := fsdDialOptions(*fsdCA, *fsdCert, *fsdKey, *fsdTokenFile)
+		assertNoError(err)
+		conn, err := grpc.Dial(strings.TrimPrefix(pathDst, fsdScheme), dialOpts...)
+		assertNoError(err)
+		var fsdOpts []fsdgrpc.Option
+		if *fsdCompressors != "" {
+			fsdOpts = append(fsdOpts, fsdgrpc.OptionCompressors{Names: strings.Split(*fsdCompressors, ",")})
+		}
+		dstStorageBackend, err = fsdgrpc.NewStorage(conn, fsdOpts...)
+		assertNoError(err)
+	case strings.HasPrefix(pathDst, sftpScheme):
+		client, root, err := sftpDial(pathDst, *sftpKey, *sftpKnownHosts)
+		assertNoError(err)
+		dstStorageBackend, err = sftpstorage.NewStorage(client, root)
+		assertNoError(err)
+	case strings.HasPrefix(pathDst, s3Scheme):
+		dstStorageBackend, err = s3Open(pathDst, *s3Endpoint, *s3Region)
+		assertNoError(err)
+	case strings.HasPrefix(pathDst, webdavScheme), strings.HasPrefix(pathDst, webdavsScheme):
+		dstStorageBackend, err = webdavOpen(pathDst, *webdavRangedPut)
+		assertNoError(err)
+	case strings.HasPrefix(pathDst, tarScheme):
+		flushDelay, err := time.ParseDuration(*tarFlushDelay)
+		assertNoError(err)
+		dstStorageBackend, err = tarOpen(pathDst, flushDelay)
+		assertNoError(err)
+	default:
+		dstStorageBackend = localfs.NewStorage(pathDst)
+	}
 	dstStorage := cached.NewStorage(dstStorageBackend, dstStorageOpts...)
 
 	syncerCfg := syncer.NewConfig(syncerOpts...)
@@ -134,27 +250,46 @@ func main() {
 	syncerInstance, err := syncer.NewSyncer(ctx, srcStorage, dstStorage, syncerCfg)
 	assertNoError(err)
 
-	eventEmitter, err := srcStorage.Watch(nil, nil, nil, nil, watchErrorHandler)
+	var srcWatcher event.Watcher = srcStorage
+	if *pollInterval != "" {
+		interval, err := time.ParseDuration(*pollInterval)
+		assertNoError(err)
+		intervalMax, err := time.ParseDuration(*pollIntervalMax)
+		assertNoError(err)
+		srcWatcher, err = polling.NewWatcher(ctx, srcStorage,
+			polling.OptionInterval{Value: interval},
+			polling.OptionIntervalMax{Value: intervalMax},
+		)
+		assertNoError(err)
+	}
+
+	eventTypeMask, err := event.ParseTypeMask(*eventTypes)
+	assertNoError(err)
+
+	var eventEmitter event.Emitter
+	eventEmitter, err = srcWatcher.Watch(nil, nil, nil, nil, watchErrorHandler,
+		event.OptionTypeMask{Mask: eventTypeMask})
 	assertNoError(err)
 
+	if *recordEvents != "" {
+		format, err := event.ParseRecordFormat(*recordFormat)
+		assertNoError(err)
+		recordFile, err := os.OpenFile(*recordEvents, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		assertNoError(err)
+		eventEmitter, err = event.NewRecorder(ctx, eventEmitter, recordFile, format)
+		assertNoError(err)
+	}
+
 	go func() {
-		for {
-			select {
-			case ev := <-eventEmitter.C():
-				fmt.Println("EVENT", ev.Path().LocalPath(), ev.Timestamp(), ev.TypeMask())
-				fileInfo, err := srcStorage.Stat(ctx, nil, ev.Path(), true)
-				assertNoError(err)
-				if fileInfo.IsDir() {
-					err := eventEmitter.Watch(nil, ev.Path(), nil, nil, watchErrorHandler)
-					assertNoError(err)
-
-					err = syncerInstance.QueueRecursive(ctx, ev.Path(), nil, walkErrorHandler)
-					assertNoError(err)
-				} else {
-					err = syncerInstance.Queue(ev.Path())
-					assertNoError(err)
-				}
-			}
+		for ev := range eventEmitter.C() {
+			// new directories are watched (and scanned) by the emitter itself
+			assertNoError(syncerInstance.QueueEvent(ev))
+		}
+	}()
+
+	go func() {
+		for err := range eventEmitter.Errors() {
+			log.Println("watcher error:", err)
 		}
 	}()
 
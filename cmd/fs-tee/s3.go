@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	s3storage "github.com/my-network/fsutil/pkg/file/storage/s3"
+)
+
+// s3Scheme is the prefix of a destination in a bucket of an S3-compatible
+// storage; the path is the prefix of the keys (for example:
+// "s3://backups/host1"). The credentials are taken from the environment
+// variables AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+const s3Scheme = "s3://"
+
+// s3DefaultEndpoint is the endpoint of AWS S3.
+const s3DefaultEndpoint = "https://s3.amazonaws.com"
+
+// s3Open returns the storage of the destination URL.
+func s3Open(dst, endpoint, region string) (*s3storage.Storage, error) {
+	dstURL, err := url.Parse(dst)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse '%s': %w", dst, err)
+	}
+	if endpoint == "" {
+		endpoint = s3DefaultEndpoint
+	}
+	return s3storage.NewStorage(endpoint, dstURL.Host,
+		s3storage.OptionPrefix{Prefix: strings.Trim(dstURL.Path, "/")},
+		s3storage.OptionRegion{Region: region},
+		s3storage.OptionCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		},
+	)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpScheme is the prefix of a destination on an SSH host. The path is
+// relative to the home directory of the user, an absolute one starts
+// with "//" (for example: "sftp://backup@backup.example.org//srv/backup").
+const sftpScheme = "sftp://"
+
+const sshDefaultPort = "22"
+
+// sftpDial connects to the SSH host of the destination URL and returns
+// the SFTP client and the path on the host. The host key is verified by
+// known_hosts.
+func sftpDial(dst, keyFile, knownHostsFile string) (*sftp.Client, string, error) {
+	dstURL, err := url.Parse(dst)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to parse '%s': %w", dst, err)
+	}
+	user := dstURL.User.Username()
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	addr := dstURL.Host
+	if dstURL.Port() == "" {
+		addr = net.JoinHostPort(dstURL.Hostname(), sshDefaultPort)
+	}
+
+	home, _ := os.UserHomeDir()
+	if keyFile == "" {
+		keyFile = filepath.Join(home, ".ssh", "id_ed25519")
+	}
+	if knownHostsFile == "" {
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read the SSH key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to parse the SSH key '%s': %w", keyFile, err)
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to load the known hosts: %w", err)
+	}
+
+	sshClient, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to connect to '%s': %w", addr, err)
+	}
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+		return nil, "", fmt.Errorf("unable to start an SFTP session: %w", err)
+	}
+	return client, strings.TrimPrefix(dstURL.Path, "/"), nil
+}
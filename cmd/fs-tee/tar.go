@@ -0,0 +1,22 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file/storage/archive"
+)
+
+// tarScheme is the prefix of a destination which is a tar archive (for
+// example: "tar:///backup/home.tar"). The changes are appended to
+// the archive, with whiteout entries for the removed objects; an
+// existing archive is continued.
+const tarScheme = "tar://"
+
+// tarOpen returns the storage of the destination archive.
+func tarOpen(dst string, flushDelay time.Duration) (*archive.Storage, error) {
+	return archive.OpenTarStorage(strings.TrimPrefix(dst, tarScheme),
+		archive.OptionWhiteouts{Enable: true},
+		archive.OptionFlushDelay{Delay: flushDelay},
+	)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	webdavstorage "github.com/my-network/fsutil/pkg/file/storage/webdav"
+)
+
+// webdavScheme and webdavsScheme are the prefixes of a destination on
+// a WebDAV share, over HTTP and HTTPS (for example:
+// "webdavs://user@dav.example.org/backup"). The password is taken from
+// the environment variable WEBDAV_PASSWORD.
+const (
+	webdavScheme  = "webdav://"
+	webdavsScheme = "webdavs://"
+)
+
+// webdavOpen returns the storage of the destination URL.
+func webdavOpen(dst string, rangedPut bool) (*webdavstorage.Storage, error) {
+	dstURL, err := url.Parse(dst)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse '%s': %w", dst, err)
+	}
+	opts := []webdavstorage.Option{
+		webdavstorage.OptionRangedPut{Enable: rangedPut},
+	}
+	if dstURL.User != nil {
+		opts = append(opts, webdavstorage.OptionCredentials{
+			Username: dstURL.User.Username(),
+			Password: os.Getenv("WEBDAV_PASSWORD"),
+		})
+		dstURL.User = nil
+	}
+	dstURL.Scheme = "http"
+	if strings.HasPrefix(dst, webdavsScheme) {
+		dstURL.Scheme = "https"
+	}
+	return webdavstorage.NewStorage(dstURL.String(), opts...)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/my-network/fsutil/pkg/file/storage/fsdgrpc"
+	"github.com/my-network/fsutil/pkg/file/storage/localfs"
+	"github.com/my-network/fsutil/pkg/file/storage/webdav"
+	"google.golang.org/grpc"
+)
+
+func syntaxExit() {
+	flag.Usage()
+	os.Exit(int(syscall.EINVAL))
+}
+
+func assertNoError(err error) {
+	if err == nil {
+		return
+	}
+
+	log.Panic(err)
+}
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:7325",
+		`the address to accept connections on (for example: ":7325")`)
+	chunkSize := flag.Uint("chunk-size", fsdgrpc.DefaultChunkSize,
+		`maximal size of the data in one message of a read stream`)
+	tlsCert := flag.String("tls-cert", "",
+		`the certificate (PEM) of the server; enables TLS (requires -tls-key)`)
+	tlsKey := flag.String("tls-key", "",
+		`the key (PEM) of -tls-cert`)
+	tlsClientCA := flag.String("tls-client-ca", "",
+		`the CA certificate (PEM) to verify the client certificates with (mTLS)`)
+	compressors := flag.String("compressors", fsdgrpc.CompressorZstd,
+		`comma-separated compressors of the data streams allowed to the clients (empty disables compression)`)
+	aclFile := flag.String("acl", "",
+		`the JSON file with the tokens and the subtrees allowed to the clients; without it any client has the full access`)
+	webdavListen := flag.String("webdav-listen", "",
+		`the address to serve the tree over WebDAV (plain HTTP, without authentication) on; empty disables it`)
+	webdavReadOnly := flag.Bool("webdav-read-only", false,
+		`deny the WebDAV requests which modify the tree`)
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		syntaxExit()
+	}
+
+	rootPath := flag.Arg(0)
+
+	serverOpts := []fsdgrpc.Option{
+		fsdgrpc.OptionChunkSize{Size: *chunkSize},
+	}
+	if *compressors != "" {
+		serverOpts = append(serverOpts, fsdgrpc.OptionCompressors{Names: strings.Split(*compressors, ",")})
+	}
+	if *aclFile != "" {
+		acl, err := loadACL(*aclFile)
+		assertNoError(err)
+		serverOpts = append(serverOpts, fsdgrpc.OptionACL{ACL: acl})
+	}
+
+	var grpcOpts []grpc.ServerOption
+	switch {
+	case *tlsCert != "":
+		creds, err := serverCredentials(*tlsCert, *tlsKey, *tlsClientCA)
+		assertNoError(err)
+		grpcOpts = append(grpcOpts, grpc.Creds(creds))
+	case *tlsClientCA != "":
+		syntaxExit()
+	}
+
+	storage := localfs.NewStorage(rootPath)
+	server := fsdgrpc.NewServer(storage, serverOpts...)
+
+	grpcServer := grpc.NewServer(grpcOpts...)
+	server.Register(grpcServer)
+
+	listener, err := net.Listen("tcp", *listen)
+	assertNoError(err)
+
+	if *webdavListen != "" {
+		webdavServer := webdav.NewServer(storage, webdav.OptionReadOnly{Enable: *webdavReadOnly})
+		webdavListener, err := net.Listen("tcp", *webdavListen)
+		assertNoError(err)
+		log.Printf("serving '%s' over WebDAV on %s", rootPath, webdavListener.Addr())
+		go func() {
+			assertNoError(http.Serve(webdavListener, webdavServer))
+		}()
+	}
+
+	log.Printf("serving '%s' on %s", rootPath, listener.Addr())
+	assertNoError(grpcServer.Serve(listener))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/my-network/fsutil/pkg/file/storage/fsdgrpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// serverCredentials returns the TLS credentials of the server. If the CA
+// certificate of the clients is set then the client certificates are
+// verified (mTLS); the clients without a certificate may still use
+// a bearer token.
+func serverCredentials(certFile, keyFile, clientCAFile string) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		caPEM, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the client CA certificate: %w", err)
+		}
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates in '%s'", clientCAFile)
+		}
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// loadACL reads the ACL from a JSON file, for example:
+//
+//	{
+//	    "tokens": {"secret-token": "backup"},
+//	    "grants": {
+//	        "backup": [{"root": ["backups"], "access": "rw"}],
+//	        "client.example.org": [{"root": [], "access": "ro"}]
+//	    }
+//	}
+func loadACL(aclFile string) (*fsdgrpc.ACL, error) {
+	content, err := ioutil.ReadFile(aclFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the ACL: %w", err)
+	}
+	acl := &fsdgrpc.ACL{}
+	if err := json.Unmarshal(content, acl); err != nil {
+		return nil, fmt.Errorf("unable to parse the ACL: %w", err)
+	}
+	return acl, nil
+}
@@ -1,6 +1,7 @@
 package file
 
 import (
+	"errors"
 	"fmt"
 	"os"
 )
@@ -27,6 +28,24 @@ func (err ErrWatch) Error() string {
 		err.Path.LocalPath(), err.Err)
 }
 
+func (err ErrWatch) Unwrap() error {
+	return err.Err
+}
+
+type ErrUnwatch struct {
+	Path Path
+	Err  error
+}
+
+func (err ErrUnwatch) Error() string {
+	return fmt.Sprintf("unable to stop watching '%s': %v",
+		err.Path.LocalPath(), err.Err)
+}
+
+func (err ErrUnwatch) Unwrap() error {
+	return err.Err
+}
+
 type ErrGetChildrenInfo struct {
 	Dir Directory
 	Err error
@@ -80,9 +99,10 @@ type ErrWalkNotDir struct {
 }
 
 func (err ErrWalkNotDir) Error() string {
-	if err.Dir == err.Child {
+	if err.Dir == nil {
+		// the root of the walk itself is not a directory
 		return fmt.Sprintf("root '%s' is not a directory: %T",
-			err.Dir.Path().LocalPath(), err.Child)
+			err.Child.Path().LocalPath(), err.Child)
 	}
 	return fmt.Sprintf("child '%s' of '%s' is not a directory: %T",
 		err.Child.Name(), err.Dir.Path().LocalPath(), err.Child)
@@ -101,3 +121,9 @@ func (err ErrWatchMark) Error() string {
 func (err ErrWatchMark) Unwrap() error {
 	return err.Err
 }
+
+// IsNotExist returns true if the error (or any error it wraps) reports
+// that an object does not exist.
+func IsNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
@@ -0,0 +1,276 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// Broadcaster delivers every event of an Emitter to each of its
+// subscribers (see Subscribe).
+//
+// Each Subscriber has an own queue and DropPolicy, so a slow subscriber
+// does not affect the others unless it uses DropPolicyBlock.
+type Broadcaster struct {
+	ctx      context.Context
+	cancelFn context.CancelFunc
+	emitter  Emitter
+	wg       sync.WaitGroup
+
+	// locker protects the fields below; it is held during the delivery
+	// of an event
+	locker      sync.Mutex
+	subscribers []*Subscriber
+	isClosed    bool
+}
+
+// NewBroadcaster returns a Broadcaster of events of `emitter`. The
+// Broadcaster takes the ownership of `emitter` (it is closed by
+// Broadcaster.Close).
+//
+// Events are read from `emitter` even if there are no subscribers,
+// so subscribers should be added before watching anything.
+func NewBroadcaster(ctx context.Context, emitter Emitter) *Broadcaster {
+	broadcaster := &Broadcaster{
+		emitter: emitter,
+	}
+	broadcaster.ctx, broadcaster.cancelFn = context.WithCancel(ctx)
+	broadcaster.wg.Add(1)
+	go func() {
+		defer func() {
+			broadcaster.closeSubscribers()
+			broadcaster.wg.Done()
+		}()
+		broadcaster.loop()
+	}()
+	return broadcaster
+}
+
+func (broadcaster *Broadcaster) loop() {
+	inChan := broadcaster.emitter.C()
+	errChan := broadcaster.emitter.Errors()
+	for {
+		select {
+		case ev, ok := <-inChan:
+			if !ok {
+				return
+			}
+			broadcaster.broadcast(ev)
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			broadcaster.broadcastError(err)
+		case <-broadcaster.ctx.Done():
+			return
+		}
+	}
+}
+
+func (broadcaster *Broadcaster) broadcast(ev Event) {
+	broadcaster.locker.Lock()
+	defer broadcaster.locker.Unlock()
+	for _, subscriber := range broadcaster.subscribers {
+		subscriber.deliver(ev)
+	}
+}
+
+// broadcastError delivers the error to the subscribers which have
+// room for it.
+func (broadcaster *Broadcaster) broadcastError(err error) {
+	broadcaster.locker.Lock()
+	defer broadcaster.locker.Unlock()
+	for _, subscriber := range broadcaster.subscribers {
+		select {
+		case subscriber.errChan <- err:
+		default:
+		}
+	}
+}
+
+func (broadcaster *Broadcaster) closeSubscribers() {
+	broadcaster.locker.Lock()
+	defer broadcaster.locker.Unlock()
+	broadcaster.isClosed = true
+	for _, subscriber := range broadcaster.subscribers {
+		close(subscriber.eventChan)
+		close(subscriber.errChan)
+	}
+	broadcaster.subscribers = nil
+}
+
+// Subscribe returns a new Subscriber which receives all the events
+// emitted since this moment.
+func (broadcaster *Broadcaster) Subscribe(opts ...SubscriberOption) (*Subscriber, error) {
+	cfg := NewSubscriberConfig(opts...)
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	subscriber := &Subscriber{
+		broadcaster: broadcaster,
+		config:      *cfg,
+		eventChan:   make(chan Event, cfg.QueueSize),
+		errChan:     make(chan error, ErrorQueueSize),
+	}
+	subscriber.ctx, subscriber.cancelFn = context.WithCancel(broadcaster.ctx)
+
+	broadcaster.locker.Lock()
+	defer broadcaster.locker.Unlock()
+	if broadcaster.isClosed {
+		subscriber.cancelFn()
+		return nil, ErrBroadcasterClosed{}
+	}
+	broadcaster.subscribers = append(broadcaster.subscribers, subscriber)
+	return subscriber, nil
+}
+
+func (broadcaster *Broadcaster) unsubscribe(subscriber *Subscriber) {
+	broadcaster.locker.Lock()
+	defer broadcaster.locker.Unlock()
+	for idx, candidate := range broadcaster.subscribers {
+		if candidate != subscriber {
+			continue
+		}
+		broadcaster.subscribers = append(broadcaster.subscribers[:idx], broadcaster.subscribers[idx+1:]...)
+		close(subscriber.eventChan)
+		close(subscriber.errChan)
+		return
+	}
+}
+
+// Close closes the source Emitter and all the subscribers.
+func (broadcaster *Broadcaster) Close() error {
+	broadcaster.cancelFn()
+	err := broadcaster.emitter.Close()
+	broadcaster.wg.Wait()
+	return err
+}
+
+var _ Emitter = &Subscriber{}
+
+// Subscriber is an Emitter of the events of a Broadcaster.
+type Subscriber struct {
+	ctx         context.Context
+	cancelFn    context.CancelFunc
+	broadcaster *Broadcaster
+	config      SubscriberConfig
+	eventChan   chan Event
+	errChan     chan error
+	dropped     uint64
+}
+
+// deliver queues the event according to the DropPolicy.
+//
+// It is called with broadcaster.locker held.
+func (subscriber *Subscriber) deliver(ev Event) {
+	select {
+	case subscriber.eventChan <- ev:
+		return
+	default:
+	}
+
+	// the queue is full
+	switch subscriber.config.DropPolicy {
+	case DropPolicyBlock:
+		select {
+		case subscriber.eventChan <- ev:
+		case <-subscriber.ctx.Done():
+		}
+	case DropPolicyDropNewest:
+		subscriber.drop(1)
+	case DropPolicyDropOldest:
+		select {
+		case <-subscriber.eventChan:
+			subscriber.drop(1)
+		default:
+		}
+		select {
+		case subscriber.eventChan <- ev:
+		default:
+			subscriber.drop(1)
+		}
+	case DropPolicyOverflow:
+		overflow := Event{
+			Path:      overflowScope(ev),
+			TypeMask:  TypeOverflow,
+			Timestamp: ev.Timestamp,
+			Source:    ev.Source,
+		}
+		subscriber.drop(1)
+	drain:
+		for {
+			select {
+			case old := <-subscriber.eventChan:
+				overflow.Path = overflow.Path.CommonPrefix(overflowScope(old))
+				subscriber.drop(1)
+			default:
+				break drain
+			}
+		}
+		// the queue is empty and nobody else sends to it
+		subscriber.eventChan <- overflow
+	}
+}
+
+func (subscriber *Subscriber) drop(count uint64) {
+	atomic.AddUint64(&subscriber.dropped, count)
+}
+
+// Dropped returns the amount of events discarded due to the DropPolicy.
+func (subscriber *Subscriber) Dropped() uint64 {
+	return atomic.LoadUint64(&subscriber.dropped)
+}
+
+func (subscriber *Subscriber) C() <-chan Event {
+	return subscriber.eventChan
+}
+
+// Errors returns the errors of the source Emitter of the Broadcaster.
+func (subscriber *Subscriber) Errors() <-chan error {
+	return subscriber.errChan
+}
+
+// Close unsubscribes from the Broadcaster. The Broadcaster and its
+// source Emitter are not closed.
+func (subscriber *Subscriber) Close() error {
+	subscriber.cancelFn()
+	subscriber.broadcaster.unsubscribe(subscriber)
+	return nil
+}
+
+// Watch calls Watch of the source Emitter of the Broadcaster, so
+// the events of the new watch are delivered to all the subscribers.
+func (subscriber *Subscriber) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...WatchOption,
+) error {
+	return subscriber.broadcaster.emitter.Watch(dirAt, path, shouldWatchFunc, shouldWalkFunc, errorHandler, opts...)
+}
+
+// Unwatch calls Unwatch of the source Emitter of the Broadcaster, so
+// it affects all the subscribers.
+func (subscriber *Subscriber) Unwatch(path file.Path, recursive bool) error {
+	return subscriber.broadcaster.emitter.Unwatch(path, recursive)
+}
+
+// overflowScope returns the narrowest directory which should be
+// rescanned if the event is lost.
+func overflowScope(ev Event) file.Path {
+	if ev.TypeMask.Has(TypeOverflow) {
+		return ev.Path
+	}
+	scope := ev.Path.Up()
+	if ev.MovedTo != nil {
+		scope = scope.CommonPrefix(ev.MovedTo.Up())
+	}
+	return scope
+}
@@ -0,0 +1,105 @@
+package event
+
+import (
+	"fmt"
+)
+
+// DropPolicy defines what happens with an event if the queue of
+// a Subscriber is full.
+type DropPolicy uint8
+
+const (
+	// DropPolicyBlock makes the Broadcaster wait until the Subscriber
+	// reads an event (which delays the delivery to all the other
+	// subscribers).
+	DropPolicyBlock = DropPolicy(iota)
+
+	// DropPolicyDropNewest discards the new event.
+	DropPolicyDropNewest
+
+	// DropPolicyDropOldest discards the oldest queued event to make room
+	// for the new one.
+	DropPolicyDropOldest
+
+	// DropPolicyOverflow discards all the queued events and the new one,
+	// and queues a single TypeOverflow event instead. Its path is
+	// the narrowest directory which contains all the discarded events.
+	DropPolicyOverflow
+)
+
+func (policy DropPolicy) String() string {
+	switch policy {
+	case DropPolicyBlock:
+		return "block"
+	case DropPolicyDropNewest:
+		return "drop_newest"
+	case DropPolicyDropOldest:
+		return "drop_oldest"
+	case DropPolicyOverflow:
+		return "overflow"
+	}
+	return fmt.Sprintf("unknown_%d", uint8(policy))
+}
+
+// ParseDropPolicy parses the output of DropPolicy.String.
+func ParseDropPolicy(s string) (DropPolicy, error) {
+	for policy := DropPolicyBlock; policy <= DropPolicyOverflow; policy++ {
+		if policy.String() == s {
+			return policy, nil
+		}
+	}
+	return DropPolicyBlock, fmt.Errorf("unknown drop policy: '%s'", s)
+}
+
+var (
+	DefaultSubscriberConfig = SubscriberConfig{
+		QueueSize:  1 << 16,
+		DropPolicy: DropPolicyBlock,
+	}
+)
+
+type SubscriberConfig struct {
+	// QueueSize is the capacity of the channel of a Subscriber.
+	QueueSize uint
+
+	// DropPolicy defines what happens if the channel is full.
+	DropPolicy DropPolicy
+}
+
+func NewSubscriberConfig(opts ...SubscriberOption) *SubscriberConfig {
+	cfg := DefaultSubscriberConfig
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	return &cfg
+}
+
+func (cfg SubscriberConfig) Validate() error {
+	if cfg.DropPolicy > DropPolicyOverflow {
+		return fmt.Errorf("unknown drop policy: %v", cfg.DropPolicy)
+	}
+	if cfg.QueueSize == 0 && cfg.DropPolicy != DropPolicyBlock {
+		return fmt.Errorf("drop policy %v requires a non-zero queue size", cfg.DropPolicy)
+	}
+	return nil
+}
+
+type SubscriberOption interface {
+	apply(*SubscriberConfig)
+}
+
+type OptionSubscriberQueueSize struct {
+	Size uint
+}
+
+func (opt OptionSubscriberQueueSize) apply(cfg *SubscriberConfig) {
+	cfg.QueueSize = opt.Size
+}
+
+type OptionDropPolicy struct {
+	Policy DropPolicy
+}
+
+func (opt OptionDropPolicy) apply(cfg *SubscriberConfig) {
+	cfg.DropPolicy = opt.Policy
+}
@@ -0,0 +1,81 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcaster(t *testing.T) {
+	source := make(chanEmitter)
+	broadcaster := NewBroadcaster(context.Background(), source)
+
+	subscribe := func(opts ...SubscriberOption) *Subscriber {
+		subscriber, err := broadcaster.Subscribe(opts...)
+		require.NoError(t, err)
+		return subscriber
+	}
+	dropNewest := subscribe(OptionSubscriberQueueSize{Size: 2}, OptionDropPolicy{Policy: DropPolicyDropNewest})
+	dropOldest := subscribe(OptionSubscriberQueueSize{Size: 2}, OptionDropPolicy{Policy: DropPolicyDropOldest})
+	overflow := subscribe(OptionSubscriberQueueSize{Size: 2}, OptionDropPolicy{Policy: DropPolicyOverflow})
+	unsubscribed := subscribe()
+	// subscribers get events in the order of subscription, so once
+	// the unbuffered reader gets an event the others have it already
+	reader := subscribe(OptionSubscriberQueueSize{Size: 0})
+	require.NoError(t, unsubscribed.Close())
+
+	events := []Event{
+		{Path: file.Path{"a", "b", "1"}, TypeMask: TypeCreate},
+		{Path: file.Path{"a", "b", "2"}, TypeMask: TypeCreate},
+		{Path: file.Path{"a", "c", "3"}, TypeMask: TypeCreate},
+		{Path: file.Path{"a", "c", "4"}, TypeMask: TypeCreate},
+	}
+	go func() {
+		for _, ev := range events {
+			source <- ev
+		}
+	}()
+	for _, ev := range events {
+		select {
+		case received := <-reader.C():
+			require.Equal(t, ev, received)
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	require.Equal(t, events[:2], readAll(dropNewest))
+	require.Equal(t, uint64(2), dropNewest.Dropped())
+	require.Equal(t, events[2:], readAll(dropOldest))
+	require.Equal(t, uint64(2), dropOldest.Dropped())
+	require.Equal(t, []Event{
+		{Path: file.Path{"a"}, TypeMask: TypeOverflow},
+		events[3],
+	}, readAll(overflow))
+	require.Equal(t, uint64(3), overflow.Dropped())
+
+	_, ok := <-unsubscribed.C()
+	require.False(t, ok)
+
+	require.NoError(t, broadcaster.Close())
+	_, ok = <-reader.C()
+	require.False(t, ok)
+	_, err := broadcaster.Subscribe()
+	require.ErrorIs(t, err, ErrBroadcasterClosed{})
+}
+
+// readAll returns the queued events of the subscriber.
+func readAll(subscriber *Subscriber) []Event {
+	var result []Event
+	for {
+		select {
+		case ev := <-subscriber.C():
+			result = append(result, ev)
+		default:
+			return result
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package event
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pkgbytes "github.com/my-network/fsutil/pkg/bytes"
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ Emitter = &Coalescer{}
+
+// Coalescer is an Emitter which merges events of another Emitter.
+//
+// Events on the same path are accumulated while they keep coming (but
+// not longer than AggregationTimeMax) and are reported as a single event
+// with the merged TypeMask. For example "create", "write" and
+// "close_write" become one "write|close_write|create" event. A path
+// created and deleted within the window is not reported at all.
+//
+// Moves and overflows are not delayed: the accumulated events on
+// the affected paths are reported first and then the event itself.
+type Coalescer struct {
+	ctx       context.Context
+	cancelFn  context.CancelFunc
+	emitter   Emitter
+	config    CoalescerConfig
+	wg        sync.WaitGroup
+	eventChan chan Event
+
+	pendingMap  map[string]*pendingEvent
+	pendingHeap pendingEventHeap
+}
+
+type pendingEvent struct {
+	Event
+	FirstEventTS time.Time
+	LastEventTS  time.Time
+	HeapIdx      int
+}
+
+func (ev *pendingEvent) deadline(cfg CoalescerConfig) time.Time {
+	deadline := ev.LastEventTS.Add(cfg.AggregationTimeMin)
+	if deadlineMax := ev.FirstEventTS.Add(cfg.AggregationTimeMax); deadlineMax.Before(deadline) {
+		return deadlineMax
+	}
+	return deadline
+}
+
+// NewCoalescer returns a Coalescer of events of `emitter`. The Coalescer
+// takes the ownership of `emitter` (it is closed by Coalescer.Close).
+func NewCoalescer(ctx context.Context, emitter Emitter, opts ...CoalescerOption) (*Coalescer, error) {
+	cfg := NewCoalescerConfig(opts...)
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	coalescer := &Coalescer{
+		emitter:    emitter,
+		config:     *cfg,
+		eventChan:  make(chan Event, cfg.QueueSize),
+		pendingMap: map[string]*pendingEvent{},
+	}
+	coalescer.pendingHeap.config = &coalescer.config
+	coalescer.ctx, coalescer.cancelFn = context.WithCancel(ctx)
+	coalescer.wg.Add(1)
+	go func() {
+		defer func() {
+			close(coalescer.eventChan)
+			coalescer.wg.Done()
+		}()
+		coalescer.loop()
+	}()
+	return coalescer, nil
+}
+
+func (coalescer *Coalescer) loop() {
+	inChan := coalescer.emitter.C()
+	for {
+		var (
+			timer       *time.Timer
+			timeoutChan <-chan time.Time
+		)
+		if coalescer.pendingHeap.Len() > 0 {
+			timer = time.NewTimer(time.Until(coalescer.pendingHeap.Peek().deadline(coalescer.config)))
+			timeoutChan = timer.C
+		}
+
+		ok := coalescer.step(inChan, timeoutChan)
+		if timer != nil {
+			timer.Stop()
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// step waits for and handles the next event or timeout.
+//
+// Returns false if the loop should be stopped.
+func (coalescer *Coalescer) step(inChan <-chan Event, timeoutChan <-chan time.Time) bool {
+	select {
+	case ev, ok := <-inChan:
+		if !ok {
+			// the source is closed, report everything we have
+			coalescer.flushAll()
+			return false
+		}
+		return coalescer.handle(ev)
+	case <-timeoutChan:
+		return coalescer.flushExpired(time.Now())
+	case <-coalescer.ctx.Done():
+		return false
+	}
+}
+
+// handle accumulates the event.
+//
+// Returns false if the coalescer is closed.
+func (coalescer *Coalescer) handle(ev Event) bool {
+	switch {
+	case ev.TypeMask.Has(TypeOverflow):
+		if !coalescer.flushAll() {
+			return false
+		}
+		return coalescer.send(ev)
+	case ev.MovedTo != nil:
+		if !coalescer.flush(ev.Path) || !coalescer.flush(ev.MovedTo) {
+			return false
+		}
+		return coalescer.send(ev)
+	}
+
+	now := time.Now()
+	key := ev.Path.Key()
+	pending := coalescer.pendingMap[key]
+	if pending == nil {
+		pending = &pendingEvent{
+			Event:        ev,
+			FirstEventTS: now,
+			LastEventTS:  now,
+		}
+		coalescer.pendingMap[key] = pending
+		coalescer.pendingHeap.Push(pending)
+		return true
+	}
+
+	if ev.TypeMask.Any(TypeDelete|TypeDeleteSelf) &&
+		pending.TypeMask.Has(TypeCreate) && !pending.TypeMask.Any(TypeDelete|TypeDeleteSelf) {
+		// the object has appeared and disappeared, nothing has changed
+		coalescer.forget(pending)
+		return true
+	}
+
+	pending.TypeMask |= ev.TypeMask
+	pending.Timestamp = ev.Timestamp
+	pending.LastEventTS = now
+	if !ev.ObjID.IsZero() {
+		pending.ObjID = ev.ObjID
+	}
+	pending.Range = mergeRanges(pending.Range, ev.Range)
+	coalescer.pendingHeap.Fix(pending)
+	return true
+}
+
+// flushExpired reports the events which are not expected to be
+// merged anymore.
+//
+// Returns false if the coalescer is closed.
+func (coalescer *Coalescer) flushExpired(now time.Time) bool {
+	for coalescer.pendingHeap.Len() > 0 {
+		pending := coalescer.pendingHeap.Peek()
+		if now.Before(pending.deadline(coalescer.config)) {
+			return true
+		}
+		coalescer.forget(pending)
+		if !coalescer.send(pending.Event) {
+			return false
+		}
+	}
+	return true
+}
+
+// flush reports the accumulated event on the path (if any).
+//
+// Returns false if the coalescer is closed.
+func (coalescer *Coalescer) flush(path file.Path) bool {
+	pending := coalescer.pendingMap[path.Key()]
+	if pending == nil {
+		return true
+	}
+	coalescer.forget(pending)
+	return coalescer.send(pending.Event)
+}
+
+// flushAll reports all the accumulated events.
+//
+// Returns false if the coalescer is closed.
+func (coalescer *Coalescer) flushAll() bool {
+	for coalescer.pendingHeap.Len() > 0 {
+		pending := coalescer.pendingHeap.Peek()
+		coalescer.forget(pending)
+		if !coalescer.send(pending.Event) {
+			return false
+		}
+	}
+	return true
+}
+
+func (coalescer *Coalescer) forget(pending *pendingEvent) {
+	delete(coalescer.pendingMap, pending.Path.Key())
+	coalescer.pendingHeap.Remove(pending)
+}
+
+func (coalescer *Coalescer) send(ev Event) bool {
+	select {
+	case coalescer.eventChan <- ev:
+		return true
+	case <-coalescer.ctx.Done():
+		return false
+	}
+}
+
+func (coalescer *Coalescer) C() <-chan Event {
+	return coalescer.eventChan
+}
+
+func (coalescer *Coalescer) Errors() <-chan error {
+	return coalescer.emitter.Errors()
+}
+
+func (coalescer *Coalescer) Close() error {
+	coalescer.cancelFn()
+	err := coalescer.emitter.Close()
+	coalescer.wg.Wait()
+	return err
+}
+
+func (coalescer *Coalescer) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...WatchOption,
+) error {
+	return coalescer.emitter.Watch(dirAt, path, shouldWatchFunc, shouldWalkFunc, errorHandler, opts...)
+}
+
+func (coalescer *Coalescer) Unwatch(path file.Path, recursive bool) error {
+	return coalescer.emitter.Unwatch(path, recursive)
+}
+
+// mergeRanges returns a range which covers both ranges. A nil range
+// means the whole file.
+func mergeRanges(a, b *pkgbytes.Range) *pkgbytes.Range {
+	if a == nil || b == nil {
+		return nil
+	}
+	start := a.Offset
+	if b.Offset < start {
+		start = b.Offset
+	}
+	end := a.Offset + a.Length
+	if bEnd := b.Offset + b.Length; bEnd > end {
+		end = bEnd
+	}
+	return &pkgbytes.Range{Offset: start, Length: end - start}
+}
+
+type pendingEventHeapInt struct {
+	events []*pendingEvent
+	config *CoalescerConfig
+}
+
+type pendingEventHeap pendingEventHeapInt
+
+func (evHeap *pendingEventHeapInt) Less(i, j int) bool {
+	return evHeap.events[i].deadline(*evHeap.config).Before(evHeap.events[j].deadline(*evHeap.config))
+}
+
+func (evHeap *pendingEventHeapInt) Len() int {
+	return len(evHeap.events)
+}
+
+func (evHeap *pendingEventHeapInt) Swap(i, j int) {
+	evHeap.events[i], evHeap.events[j] = evHeap.events[j], evHeap.events[i]
+	evHeap.events[i].HeapIdx, evHeap.events[j].HeapIdx = i, j
+}
+
+func (evHeap *pendingEventHeapInt) Push(evI interface{}) {
+	ev := evI.(*pendingEvent)
+	ev.HeapIdx = len(evHeap.events)
+	evHeap.events = append(evHeap.events, ev)
+}
+
+func (evHeap *pendingEventHeapInt) Pop() interface{} {
+	result := evHeap.events[len(evHeap.events)-1]
+	evHeap.events = evHeap.events[:len(evHeap.events)-1]
+	result.HeapIdx = -1
+	return result
+}
+
+func (evHeap *pendingEventHeap) int() *pendingEventHeapInt {
+	return (*pendingEventHeapInt)(evHeap)
+}
+
+func (evHeap *pendingEventHeap) Len() int {
+	return evHeap.int().Len()
+}
+
+func (evHeap *pendingEventHeap) Push(ev *pendingEvent) {
+	heap.Push(evHeap.int(), ev)
+}
+
+func (evHeap *pendingEventHeap) Peek() *pendingEvent {
+	return evHeap.events[0]
+}
+
+func (evHeap *pendingEventHeap) Fix(ev *pendingEvent) {
+	heap.Fix(evHeap.int(), ev.HeapIdx)
+}
+
+func (evHeap *pendingEventHeap) Remove(ev *pendingEvent) {
+	heap.Remove(evHeap.int(), ev.HeapIdx)
+}
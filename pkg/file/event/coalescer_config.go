@@ -0,0 +1,72 @@
+package event
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	DefaultCoalescerConfig = CoalescerConfig{
+		AggregationTimeMin: time.Second,
+		AggregationTimeMax: 10 * time.Second,
+		QueueSize:          1 << 16,
+	}
+)
+
+type CoalescerConfig struct {
+	// AggregationTimeMin is the time to wait for more events on a path
+	// after the last one.
+	AggregationTimeMin time.Duration
+
+	// AggregationTimeMax is the maximal time to wait for more events on
+	// a path after the first one (so continuously changing paths are
+	// still reported).
+	AggregationTimeMax time.Duration
+
+	// QueueSize is the capacity of the channel of a Coalescer.
+	QueueSize uint
+}
+
+func NewCoalescerConfig(opts ...CoalescerOption) *CoalescerConfig {
+	cfg := DefaultCoalescerConfig
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	return &cfg
+}
+
+func (cfg CoalescerConfig) Validate() error {
+	if cfg.AggregationTimeMax < cfg.AggregationTimeMin {
+		return fmt.Errorf("cfg.AggregationTimeMax (%v) < cfg.AggregationTimeMin (%v)",
+			cfg.AggregationTimeMax, cfg.AggregationTimeMin)
+	}
+	return nil
+}
+
+type CoalescerOption interface {
+	apply(*CoalescerConfig)
+}
+
+type OptionAggregationTimeMin struct {
+	Value time.Duration
+}
+
+func (opt OptionAggregationTimeMin) apply(cfg *CoalescerConfig) {
+	cfg.AggregationTimeMin = opt.Value
+}
+
+type OptionAggregationTimeMax struct {
+	Value time.Duration
+}
+
+func (opt OptionAggregationTimeMax) apply(cfg *CoalescerConfig) {
+	cfg.AggregationTimeMax = opt.Value
+}
+
+type OptionQueueSize struct {
+	Size uint
+}
+
+func (opt OptionQueueSize) apply(cfg *CoalescerConfig) {
+	cfg.QueueSize = opt.Size
+}
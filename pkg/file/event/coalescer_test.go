@@ -0,0 +1,77 @@
+package event
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/stretchr/testify/require"
+)
+
+type chanEmitter chan Event
+
+func (emitter chanEmitter) Close() error {
+	close(emitter)
+	return nil
+}
+
+func (emitter chanEmitter) C() <-chan Event {
+	return emitter
+}
+
+func (emitter chanEmitter) Errors() <-chan error {
+	return nil
+}
+
+func (emitter chanEmitter) Watch(file.Directory, file.Path, ShouldWatchFunc, file.ShouldWalkFunc, file.ErrorHandlerFunc, ...WatchOption) error {
+	return file.ErrNotImplemented{}
+}
+
+func (emitter chanEmitter) Unwatch(file.Path, bool) error {
+	return file.ErrNotImplemented{}
+}
+
+func TestCoalescer(t *testing.T) {
+	source := make(chanEmitter, 16)
+	coalescer, err := NewCoalescer(context.Background(), source,
+		OptionAggregationTimeMin{Value: 20 * time.Millisecond},
+		OptionAggregationTimeMax{Value: time.Second},
+	)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, coalescer.Close()) }()
+
+	nextEvent := func() Event {
+		select {
+		case ev := <-coalescer.C():
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+		panic("unreachable")
+	}
+
+	source <- Event{Path: file.Path{"a"}, TypeMask: TypeCreate}
+	source <- Event{Path: file.Path{"tmp"}, TypeMask: TypeCreate}
+	source <- Event{Path: file.Path{"a"}, TypeMask: TypeWrite}
+	source <- Event{Path: file.Path{"tmp"}, TypeMask: TypeDelete}
+	source <- Event{Path: file.Path{"a"}, TypeMask: TypeCloseWrite}
+
+	ev := nextEvent()
+	require.Equal(t, file.Path{"a"}, ev.Path)
+	require.Equal(t, TypeCreate|TypeWrite|TypeCloseWrite, ev.TypeMask)
+
+	source <- Event{Path: file.Path{"b"}, TypeMask: TypeWrite}
+	source <- Event{Path: file.Path{"b"}, MovedTo: file.Path{"c"}, TypeMask: TypeMove}
+	ev = nextEvent()
+	require.Equal(t, file.Path{"b"}, ev.Path)
+	require.Equal(t, TypeWrite, ev.TypeMask)
+	ev = nextEvent()
+	require.Equal(t, TypeMove, ev.TypeMask)
+
+	select {
+	case ev := <-coalescer.C():
+		t.Fatalf("unexpected event: %v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -4,8 +4,26 @@ import (
 	"github.com/my-network/fsutil/pkg/file"
 )
 
+const (
+	// ErrorQueueSize is the capacity of the channels returned by
+	// Emitter.Errors of the emitters of this module.
+	ErrorQueueSize = 64
+)
+
 type Emitter interface {
 	Close() error
 	C() <-chan Event
-	Watch(file.Directory, file.Path, ShouldWatchFunc, file.ShouldWalkFunc, file.ErrorHandlerFunc) error
+
+	// Errors returns the channel of errors which happen in background
+	// (for example, failures to watch a new directory). An error is
+	// dropped if the channel is full, so not reading it does not block
+	// the events. The channel is closed together with C().
+	Errors() <-chan error
+
+	Watch(file.Directory, file.Path, ShouldWatchFunc, file.ShouldWalkFunc, file.ErrorHandlerFunc, ...WatchOption) error
+
+	// Unwatch stops watching the directory (watched by Watch directly or
+	// as a part of a subtree). If recursive is true then the whole subtree
+	// is unwatched, including the directories watched by other Watch calls.
+	Unwatch(path file.Path, recursive bool) error
 }
@@ -0,0 +1,33 @@
+package event
+
+import (
+	"fmt"
+)
+
+type ErrUnknownType struct {
+	Name string
+}
+
+func (err ErrUnknownType) Error() string {
+	return fmt.Sprintf("unknown event type '%s'", err.Name)
+}
+
+type ErrBroadcasterClosed struct{}
+
+func (err ErrBroadcasterClosed) Error() string {
+	return "the broadcaster is closed"
+}
+
+// ErrSource is an error of a source Emitter of a Merger.
+type ErrSource struct {
+	Source string
+	Err    error
+}
+
+func (err ErrSource) Error() string {
+	return fmt.Sprintf("source '%s': %v", err.Source, err.Err)
+}
+
+func (err ErrSource) Unwrap() error {
+	return err.Err
+}
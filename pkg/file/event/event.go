@@ -8,11 +8,16 @@ import (
 )
 
 type Event struct {
-	ObjID     interface{}
+	// ObjID is the identity of the object (zero if unknown). It allows
+	// to tell a renamed object from another object at the same path.
+	ObjID     file.ObjectID
 	Path      file.Path
 	TypeMask  TypeMask
 	Timestamp time.Time
 
 	Range   *pkgbytes.Range
 	MovedTo file.Path
+
+	// Source is the tag of the Emitter the event came from (see Merger).
+	Source string
 }
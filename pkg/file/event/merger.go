@@ -0,0 +1,164 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ Emitter = &Merger{}
+
+// Merger is an Emitter which combines events of several Emitters (for
+// example, of different storages) into one stream.
+//
+// Each event is tagged with the tag of its source Emitter in
+// Event.Source. If the event is already tagged (for example, it comes
+// from another Merger) then the tags are joined with "/".
+type Merger struct {
+	ctx       context.Context
+	cancelFn  context.CancelFunc
+	sources   map[string]Emitter
+	wg        sync.WaitGroup
+	eventChan chan Event
+	errChan   chan error
+}
+
+// NewMerger returns a Merger of events of `sources` (a source Emitter
+// per tag). The Merger takes the ownership of the sources (they are
+// closed by Merger.Close).
+//
+// The channel of the Merger is closed when all the sources are closed.
+func NewMerger(ctx context.Context, sources map[string]Emitter, opts ...MergerOption) (*Merger, error) {
+	cfg := NewMergerConfig(opts...)
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	merger := &Merger{
+		sources:   map[string]Emitter{},
+		eventChan: make(chan Event, cfg.QueueSize),
+		errChan:   make(chan error, ErrorQueueSize),
+	}
+	merger.ctx, merger.cancelFn = context.WithCancel(ctx)
+	for tag, source := range sources {
+		merger.sources[tag] = source
+		merger.wg.Add(1)
+		go func(tag string, source Emitter) {
+			defer merger.wg.Done()
+			merger.forward(tag, source)
+		}(tag, source)
+	}
+	go func() {
+		merger.wg.Wait()
+		close(merger.eventChan)
+		close(merger.errChan)
+	}()
+	return merger, nil
+}
+
+func (merger *Merger) forward(tag string, source Emitter) {
+	inChan := source.C()
+	errChan := source.Errors()
+	for {
+		select {
+		case ev, ok := <-inChan:
+			if !ok {
+				// deliver the errors which are left
+				for {
+					select {
+					case err, ok := <-errChan:
+						if !ok {
+							return
+						}
+						merger.sendError(tag, err)
+					default:
+						return
+					}
+				}
+			}
+			if ev.Source == "" {
+				ev.Source = tag
+			} else {
+				ev.Source = tag + "/" + ev.Source
+			}
+			select {
+			case merger.eventChan <- ev:
+			case <-merger.ctx.Done():
+				return
+			}
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			merger.sendError(tag, err)
+		case <-merger.ctx.Done():
+			return
+		}
+	}
+}
+
+func (merger *Merger) sendError(tag string, err error) {
+	select {
+	case merger.errChan <- ErrSource{Source: tag, Err: err}:
+	default:
+	}
+}
+
+// Source returns the source Emitter with the tag (or nil).
+func (merger *Merger) Source(tag string) Emitter {
+	return merger.sources[tag]
+}
+
+// Tags returns the sorted tags of the sources.
+func (merger *Merger) Tags() []string {
+	tags := make([]string, 0, len(merger.sources))
+	for tag := range merger.sources {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (merger *Merger) C() <-chan Event {
+	return merger.eventChan
+}
+
+// Errors returns the errors of the sources wrapped into ErrSource.
+func (merger *Merger) Errors() <-chan error {
+	return merger.errChan
+}
+
+// Close closes all the sources. The first error is returned.
+func (merger *Merger) Close() error {
+	merger.cancelFn()
+	var result error
+	for _, tag := range merger.Tags() {
+		if err := merger.sources[tag].Close(); err != nil && result == nil {
+			result = fmt.Errorf("unable to close source '%s': %w", tag, err)
+		}
+	}
+	merger.wg.Wait()
+	return result
+}
+
+// Watch is not implemented, because the paths of different sources
+// are not comparable. Use Watch of the source instead (see Source).
+func (merger *Merger) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...WatchOption,
+) error {
+	return file.ErrNotImplemented{}
+}
+
+// Unwatch is not implemented for the same reason as Watch.
+func (merger *Merger) Unwatch(path file.Path, recursive bool) error {
+	return file.ErrNotImplemented{}
+}
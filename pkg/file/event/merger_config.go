@@ -0,0 +1,36 @@
+package event
+
+var (
+	DefaultMergerConfig = MergerConfig{
+		QueueSize: 1 << 16,
+	}
+)
+
+type MergerConfig struct {
+	// QueueSize is the capacity of the channel of a Merger.
+	QueueSize uint
+}
+
+func NewMergerConfig(opts ...MergerOption) *MergerConfig {
+	cfg := DefaultMergerConfig
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	return &cfg
+}
+
+func (cfg MergerConfig) Validate() error {
+	return nil
+}
+
+type MergerOption interface {
+	apply(*MergerConfig)
+}
+
+type OptionMergerQueueSize struct {
+	Size uint
+}
+
+func (opt OptionMergerQueueSize) apply(cfg *MergerConfig) {
+	cfg.QueueSize = opt.Size
+}
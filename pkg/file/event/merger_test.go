@@ -0,0 +1,76 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerger(t *testing.T) {
+	sourceA := make(chanEmitter, 1)
+	sourceB := make(chanEmitter, 1)
+	merger, err := NewMerger(context.Background(), map[string]Emitter{
+		"a": sourceA,
+		"b": sourceB,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, merger.Tags())
+	require.Equal(t, Emitter(sourceB), merger.Source("b"))
+
+	nextEvent := func() Event {
+		select {
+		case ev := <-merger.C():
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+		panic("unreachable")
+	}
+
+	sourceA <- Event{Path: file.Path{"x"}, TypeMask: TypeCreate}
+	require.Equal(t, Event{Path: file.Path{"x"}, TypeMask: TypeCreate, Source: "a"}, nextEvent())
+	sourceB <- Event{Path: file.Path{"y"}, TypeMask: TypeDelete, Source: "c"}
+	require.Equal(t, Event{Path: file.Path{"y"}, TypeMask: TypeDelete, Source: "b/c"}, nextEvent())
+
+	require.NoError(t, merger.Close())
+	_, ok := <-merger.C()
+	require.False(t, ok)
+}
+
+type errEmitter struct {
+	chanEmitter
+	errChan chan error
+}
+
+func (emitter errEmitter) Errors() <-chan error {
+	return emitter.errChan
+}
+
+func TestMergerErrors(t *testing.T) {
+	source := errEmitter{
+		chanEmitter: make(chanEmitter),
+		errChan:     make(chan error, 1),
+	}
+	merger, err := NewMerger(context.Background(), map[string]Emitter{
+		"a": source,
+	})
+	require.NoError(t, err)
+
+	errTest := errors.New("test")
+	source.errChan <- errTest
+	select {
+	case err := <-merger.Errors():
+		require.Equal(t, ErrSource{Source: "a", Err: errTest}, err)
+		require.True(t, errors.Is(err, errTest))
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	require.NoError(t, merger.Close())
+	_, ok := <-merger.Errors()
+	require.False(t, ok)
+}
@@ -0,0 +1,50 @@
+package polling
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	DefaultConfig = Config{
+		Interval:       10 * time.Second,
+		IntervalMax:    5 * time.Minute,
+		EventQueueSize: 1 << 16,
+	}
+)
+
+type Config struct {
+	// Interval is the period of scanning of the watched directories.
+	Interval time.Duration
+
+	// IntervalMax is the maximal period of scanning of a directory.
+	// The period of a directory is doubled each time its scan finds
+	// no changes (up to IntervalMax) and is reset to Interval on
+	// the first change. Thus cold subtrees are scanned rarely.
+	//
+	// The change of a subdirectory noticed while scanning its parent
+	// (for example, its mtime changed) resets the period as well.
+	IntervalMax time.Duration
+
+	// EventQueueSize is the capacity of the channel of an Emitter.
+	EventQueueSize uint
+}
+
+func NewConfig(opts ...Option) *Config {
+	cfg := DefaultConfig
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	return &cfg
+}
+
+func (cfg Config) Validate() error {
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("cfg.Interval (%v) <= 0", cfg.Interval)
+	}
+	if cfg.IntervalMax < cfg.Interval {
+		return fmt.Errorf("cfg.IntervalMax (%v) < cfg.Interval (%v)",
+			cfg.IntervalMax, cfg.Interval)
+	}
+	return nil
+}
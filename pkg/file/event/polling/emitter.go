@@ -0,0 +1,518 @@
+package polling
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+var _ event.Emitter = &Emitter{}
+
+// Emitter emits events found by periodic scans of the watched subtrees.
+type Emitter struct {
+	ctx       context.Context
+	cancelFn  context.CancelFunc
+	storage   file.Storage
+	config    Config
+	wg        sync.WaitGroup
+	eventChan chan event.Event
+	errChan   chan error
+
+	// locker protects the fields below; it is held during a whole scan,
+	// but not while the found events are sent
+	locker        sync.Mutex
+	watchRequests []watchRequest
+	dirs          map[string]*dirState
+
+	// unwatched are the directories excluded by Unwatch from
+	// the watched subtrees
+	unwatched map[string]unwatchedDir
+}
+
+type unwatchedDir struct {
+	Path      file.Path
+	Recursive bool
+}
+
+type watchRequest struct {
+	Path            file.Path
+	ShouldWatchFunc event.ShouldWatchFunc
+	ShouldWalkFunc  file.ShouldWalkFunc
+	ErrorHandler    file.ErrorHandlerFunc
+	Config          event.WatchConfig
+}
+
+// entrySnapshot is the state of a directory entry remembered
+// between scans.
+type entrySnapshot struct {
+	Mode    os.FileMode
+	Size    int64
+	ModTime time.Time
+	ObjID   file.ObjectID
+}
+
+func newEntrySnapshot(info os.FileInfo) entrySnapshot {
+	return entrySnapshot{
+		Mode:    info.Mode(),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		ObjID:   objectIDOf(info),
+	}
+}
+
+// diff returns the types of events which describe the change
+// from `old` to `snapshot`, or zero if there is no change.
+func (snapshot entrySnapshot) diff(old entrySnapshot) event.TypeMask {
+	if snapshot.Mode.Type() != old.Mode.Type() || snapshot.ObjID != old.ObjID {
+		// the object was replaced
+		return event.TypeDelete | event.TypeCreate
+	}
+	var result event.TypeMask
+	if snapshot.Mode.Perm() != old.Mode.Perm() {
+		result |= event.TypeAttrib
+	}
+	if !snapshot.Mode.IsDir() && (snapshot.Size != old.Size || !snapshot.ModTime.Equal(old.ModTime)) {
+		result |= event.TypeWrite | event.TypeCloseWrite
+	}
+	return result
+}
+
+// dirState is the state of a scanned directory.
+type dirState struct {
+	Path       file.Path
+	Entries    map[string]entrySnapshot
+	IsWatched  bool
+	Interval   time.Duration
+	NextScanTS time.Time
+}
+
+func newEmitter(ctx context.Context, storage file.Storage, cfg Config) *Emitter {
+	emitter := &Emitter{
+		storage:   storage,
+		config:    cfg,
+		eventChan: make(chan event.Event, cfg.EventQueueSize),
+		errChan:   make(chan error, event.ErrorQueueSize),
+		dirs:      map[string]*dirState{},
+		unwatched: map[string]unwatchedDir{},
+	}
+	emitter.ctx, emitter.cancelFn = context.WithCancel(ctx)
+	emitter.initScanner()
+	return emitter
+}
+
+func (emitter *Emitter) initScanner() {
+	emitter.wg.Add(1)
+	go func() {
+		defer func() {
+			close(emitter.eventChan)
+			close(emitter.errChan)
+			emitter.wg.Done()
+		}()
+		emitter.scannerLoop()
+	}()
+}
+
+func (emitter *Emitter) scannerLoop() {
+	ticker := time.NewTicker(emitter.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			emitter.scanAll()
+		case <-emitter.ctx.Done():
+			return
+		}
+	}
+}
+
+func (emitter *Emitter) scanAll() {
+	events := emitter.collectEvents()
+	for _, ev := range events {
+		// the lock is not held here, so Watch and Unwatch are not
+		// blocked by a consumer which does not read the events
+		select {
+		case emitter.eventChan <- ev:
+		case <-emitter.ctx.Done():
+			return
+		}
+	}
+}
+
+// collectEvents scans all the watched subtrees and returns the events
+// found.
+func (emitter *Emitter) collectEvents() []event.Event {
+	emitter.locker.Lock()
+	defer emitter.locker.Unlock()
+
+	var events []event.Event
+	var gone []file.Path
+	for _, req := range emitter.watchRequests {
+		if _, err := emitter.storage.Stat(emitter.ctx, nil, req.Path, true); file.IsNotExist(err) {
+			// the watched directory is deleted
+			gone = append(gone, req.Path)
+			continue
+		}
+		reqEvents, err := emitter.scan(req, true)
+		if emitter.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			emitter.sendError(err)
+		}
+		events = append(events, reqEvents...)
+	}
+	for _, path := range gone {
+		emitter.unwatch(path, true)
+	}
+	return events
+}
+
+func (emitter *Emitter) sendError(err error) {
+	select {
+	case emitter.errChan <- err:
+	default:
+	}
+}
+
+func (emitter *Emitter) C() <-chan event.Event {
+	return emitter.eventChan
+}
+
+// Errors returns the errors of the periodic scans (the ones which are
+// not handled by the ErrorHandlerFunc of the Watch call).
+func (emitter *Emitter) Errors() <-chan error {
+	return emitter.errChan
+}
+
+func (emitter *Emitter) Close() error {
+	emitter.cancelFn()
+	emitter.wg.Wait()
+	return nil
+}
+
+// Watch scans the subtree to remember its current state. The changes are
+// reported starting with the next scan.
+func (emitter *Emitter) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc event.ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
+) error {
+	if errorHandler == nil {
+		errorHandler = dummyErrorHandler
+	}
+
+	if dirAt != nil {
+		return file.ErrNotImplemented{}
+	}
+
+	req := watchRequest{
+		Path:            path,
+		ShouldWatchFunc: shouldWatchFunc,
+		ShouldWalkFunc:  shouldWalkFunc,
+		ErrorHandler:    errorHandler,
+		Config:          *event.NewWatchConfig(opts...),
+	}
+
+	emitter.locker.Lock()
+	defer emitter.locker.Unlock()
+
+	for key, unwatched := range emitter.unwatched {
+		if unwatched.Path.HasPrefix(path) {
+			delete(emitter.unwatched, key)
+		}
+	}
+
+	_, err := emitter.scan(req, false)
+	if err != nil {
+		return &file.ErrWatch{
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	emitter.watchRequests = append(emitter.watchRequests, req)
+	return nil
+}
+
+// Unwatch stops watching the directory. If recursive is true then
+// the remembered state of the subtree is forgotten and it is not
+// scanned anymore.
+func (emitter *Emitter) Unwatch(path file.Path, recursive bool) error {
+	emitter.locker.Lock()
+	defer emitter.locker.Unlock()
+	emitter.unwatch(path, recursive)
+	return nil
+}
+
+func (emitter *Emitter) unwatch(path file.Path, recursive bool) {
+	watchRequests := emitter.watchRequests[:0]
+	for _, req := range emitter.watchRequests {
+		if recursive && req.Path.HasPrefix(path) {
+			continue
+		}
+		watchRequests = append(watchRequests, req)
+	}
+	emitter.watchRequests = watchRequests
+
+	if !recursive {
+		if _, ok := emitter.watchRequestFor(path); ok {
+			emitter.unwatched[path.Key()] = unwatchedDir{Path: path.Append()}
+		}
+		return
+	}
+	for key, unwatched := range emitter.unwatched {
+		if unwatched.Path.HasPrefix(path) {
+			delete(emitter.unwatched, key)
+		}
+	}
+	emitter.forgetSubtree(path)
+	if _, ok := emitter.watchRequestFor(path); ok {
+		// still inside of a watched subtree
+		emitter.unwatched[path.Key()] = unwatchedDir{Path: path.Append(), Recursive: true}
+	}
+}
+
+// watchRequestFor returns a request which covers the path.
+func (emitter *Emitter) watchRequestFor(path file.Path) (watchRequest, bool) {
+	for _, req := range emitter.watchRequests {
+		if path.HasPrefix(req.Path) {
+			return req, true
+		}
+	}
+	return watchRequest{}, false
+}
+
+// isUnwatched returns true if the directory is excluded by Unwatch,
+// and if the whole subtree is excluded.
+func (emitter *Emitter) isUnwatched(dir file.Path) (isUnwatched bool, isRecursive bool) {
+	if unwatched, ok := emitter.unwatched[dir.Key()]; ok {
+		return true, unwatched.Recursive
+	}
+	for _, unwatched := range emitter.unwatched {
+		if unwatched.Recursive && dir.HasPrefix(unwatched.Path) {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// scan walks through the subtree of the request (skipping directories
+// which are not due to be scanned, yet) and compares the found entries
+// with the previous scan. It returns the events about the difference;
+// if emitEvents is false then the state is just remembered.
+func (emitter *Emitter) scan(req watchRequest, emitEvents bool) ([]event.Event, error) {
+	now := time.Now()
+
+	// scanned contains the entries of the directories walked
+	// through by this scan
+	scanned := map[string]map[string]entrySnapshot{}
+	startScan := func(dir file.Path, isWatched bool) {
+		if isUnwatched, _ := emitter.isUnwatched(dir); isUnwatched {
+			isWatched = false
+		}
+		state := emitter.dirStateFor(dir)
+		state.IsWatched = isWatched
+		scanned[dir.Key()] = map[string]entrySnapshot{}
+	}
+
+	err := file.Walk(
+		emitter.ctx,
+		emitter.storage,
+		nil,
+		req.Path,
+		func(dir file.Directory, info os.FileInfo) error {
+			if info.Name() == "." {
+				// the root of the subtree
+				startScan(dir.Path(), req.ShouldWatchFunc == nil || req.ShouldWatchFunc(dir, info))
+				return nil
+			}
+			snapshot := newEntrySnapshot(info)
+			scanned[dir.Path().Key()][info.Name()] = snapshot
+			if !info.IsDir() {
+				return nil
+			}
+
+			childPath := dir.Path().Append(info.Name())
+			childState := emitter.dirs[childPath.Key()]
+			if childState == nil {
+				return nil
+			}
+			childState.IsWatched = req.ShouldWatchFunc == nil || req.ShouldWatchFunc(dir, info)
+			if isUnwatched, _ := emitter.isUnwatched(childPath); isUnwatched {
+				childState.IsWatched = false
+			}
+			if old, ok := emitter.dirs[dir.Path().Key()].Entries[info.Name()]; !ok || snapshot.diff(old) != 0 || !snapshot.ModTime.Equal(old.ModTime) {
+				// the subdirectory has changed, so it should be scanned right now
+				childState.Interval = emitter.config.Interval
+				childState.NextScanTS = now
+			}
+			return nil
+		},
+		func(dir file.Directory, info os.FileInfo) bool {
+			if req.ShouldWalkFunc != nil && !req.ShouldWalkFunc(dir, info) {
+				return false
+			}
+			childPath := dir.Path().Append(info.Name())
+			if _, isRecursive := emitter.isUnwatched(childPath); isRecursive {
+				return false
+			}
+			if childState := emitter.dirs[childPath.Key()]; childState != nil && now.Before(childState.NextScanTS) {
+				return false
+			}
+			startScan(childPath, req.ShouldWatchFunc == nil || req.ShouldWatchFunc(dir, info))
+			return true
+		},
+		func(err error) error {
+			// the directory was not scanned, so its (empty) entries
+			// should not be considered
+			switch err := err.(type) {
+			case file.ErrWalkOpen:
+				if err.Dir != nil && err.Child != nil {
+					delete(scanned, err.Dir.Path().Append(err.Child.Name()).Key())
+				}
+			case file.ErrGetChildrenInfo:
+				delete(scanned, err.Dir.Path().Key())
+			}
+			return req.ErrorHandler(err)
+		},
+	)
+
+	if err != nil {
+		// the scan was interrupted, so the scanned entries may be
+		// incomplete (which would look like removals)
+		return nil, err
+	}
+
+	// parents first, so events are emitted in a natural order
+	keys := make([]string, 0, len(scanned))
+	for key := range scanned {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pathI, pathJ := emitter.dirs[keys[i]].Path, emitter.dirs[keys[j]].Path
+		if len(pathI) != len(pathJ) {
+			return len(pathI) < len(pathJ)
+		}
+		return pathI.LocalPath() < pathJ.LocalPath()
+	})
+
+	var events []event.Event
+	for _, key := range keys {
+		state := emitter.dirs[key]
+		if state == nil {
+			// the directory was replaced and is forgotten, it will be
+			// scanned from scratch next time
+			continue
+		}
+		events = emitter.applyScan(events, state, scanned[key], now, emitEvents, req.Config)
+	}
+	return events, nil
+}
+
+// applyScan replaces the remembered entries of the directory with
+// the scanned ones and appends the events about the difference (which
+// pass the filters of watchCfg) to `events`.
+func (emitter *Emitter) applyScan(
+	events []event.Event,
+	state *dirState,
+	entries map[string]entrySnapshot,
+	now time.Time,
+	emitEvents bool,
+	watchCfg event.WatchConfig,
+) []event.Event {
+	type change struct {
+		Name     string
+		TypeMask event.TypeMask
+		ObjID    file.ObjectID
+	}
+	var changes []change
+	for name, snapshot := range entries {
+		old, ok := state.Entries[name]
+		if !ok {
+			changes = append(changes, change{Name: name, TypeMask: event.TypeCreate, ObjID: snapshot.ObjID})
+			continue
+		}
+		if typeMask := snapshot.diff(old); typeMask != 0 {
+			changes = append(changes, change{Name: name, TypeMask: typeMask, ObjID: snapshot.ObjID})
+			if typeMask.Has(event.TypeDelete) && old.Mode.IsDir() {
+				emitter.forgetSubtree(state.Path.Append(name))
+			}
+		}
+	}
+	for name, old := range state.Entries {
+		if _, ok := entries[name]; ok {
+			continue
+		}
+		changes = append(changes, change{Name: name, TypeMask: event.TypeDelete, ObjID: old.ObjID})
+		if old.Mode.IsDir() {
+			emitter.forgetSubtree(state.Path.Append(name))
+		}
+	}
+
+	state.Entries = entries
+	if len(changes) > 0 {
+		state.Interval = emitter.config.Interval
+	} else {
+		state.Interval *= 2
+		if state.Interval > emitter.config.IntervalMax {
+			state.Interval = emitter.config.IntervalMax
+		}
+	}
+	state.NextScanTS = now.Add(state.Interval)
+
+	if !emitEvents || !state.IsWatched {
+		return events
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	for _, change := range changes {
+		ev, ok := watchCfg.Filter(event.Event{
+			ObjID:     change.ObjID,
+			Path:      state.Path.Append(change.Name),
+			TypeMask:  change.TypeMask,
+			Timestamp: now,
+		})
+		if !ok {
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func (emitter *Emitter) dirStateFor(path file.Path) *dirState {
+	key := path.Key()
+	state := emitter.dirs[key]
+	if state == nil {
+		state = &dirState{
+			Path:     path.Append(),
+			Interval: emitter.config.Interval,
+		}
+		emitter.dirs[key] = state
+	}
+	return state
+}
+
+// forgetSubtree removes the states of the directory and its
+// subdirectories.
+func (emitter *Emitter) forgetSubtree(dir file.Path) {
+	for key, state := range emitter.dirs {
+		if state.Path.HasPrefix(dir) {
+			delete(emitter.dirs, key)
+		}
+	}
+}
+
+func dummyErrorHandler(err error) error {
+	return err
+}
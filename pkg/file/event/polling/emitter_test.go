@@ -0,0 +1,133 @@
+// +build test_integration
+
+package polling
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/my-network/fsutil/pkg/file/storage/localfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_event_polling")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "dir"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "dir", "a"), []byte("a"), 0600))
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	stor := localfs.NewStorage(tmpDir)
+
+	watcher, err := NewWatcher(ctx, stor,
+		OptionInterval{Value: 10 * time.Millisecond},
+		OptionIntervalMax{Value: 40 * time.Millisecond},
+	)
+	require.NoError(t, err)
+	emitter, err := watcher.Watch(nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, emitter.Close()) }()
+
+	nextEvent := func() event.Event {
+		select {
+		case ev := <-emitter.C():
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+		panic("unreachable")
+	}
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "dir", "a"), []byte("abc"), 0600))
+	ev := nextEvent()
+	require.Equal(t, file.Path{"dir", "a"}, ev.Path)
+	require.Equal(t, event.TypeWrite|event.TypeCloseWrite, ev.TypeMask)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "dir", "sub"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "dir", "sub", "b"), nil, 0600))
+	ev = nextEvent()
+	require.Equal(t, file.Path{"dir", "sub"}, ev.Path)
+	require.Equal(t, event.TypeCreate, ev.TypeMask)
+	ev = nextEvent()
+	require.Equal(t, file.Path{"dir", "sub", "b"}, ev.Path)
+	require.Equal(t, event.TypeCreate, ev.TypeMask)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "dir", "sub")))
+	ev = nextEvent()
+	require.Equal(t, file.Path{"dir", "sub"}, ev.Path)
+	require.Equal(t, event.TypeDelete, ev.TypeMask)
+
+	select {
+	case ev := <-emitter.C():
+		t.Fatalf("unexpected event: %v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "dir", "unwatched"), 0700))
+	ev = nextEvent()
+	require.Equal(t, file.Path{"dir", "unwatched"}, ev.Path)
+	require.NoError(t, emitter.Unwatch(file.Path{"dir", "unwatched"}, true))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "dir", "unwatched", "c"), nil, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "dir", "d"), nil, 0600))
+	ev = nextEvent()
+	require.Equal(t, file.Path{"dir", "d"}, ev.Path)
+	require.Equal(t, event.TypeCreate, ev.TypeMask)
+
+	select {
+	case ev := <-emitter.C():
+		t.Fatalf("unexpected event: %v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEmitterFullQueue(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_event_polling")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "dir"), 0700))
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+	stor := localfs.NewStorage(tmpDir)
+
+	watcher, err := NewWatcher(ctx, stor,
+		OptionInterval{Value: 10 * time.Millisecond},
+		OptionIntervalMax{Value: 40 * time.Millisecond},
+		OptionEventQueueSize{Size: 1},
+	)
+	require.NoError(t, err)
+	emitter, err := watcher.Watch(nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, emitter.Close()) }()
+
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "dir", name), nil, 0600))
+	}
+	select {
+	case <-emitter.C():
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	// let the scanner block on sending the rest of the events
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- emitter.Unwatch(file.Path{"dir"}, true)
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Unwatch is blocked by the full queue")
+	}
+}
@@ -0,0 +1,13 @@
+// +build !linux,!freebsd,!darwin
+
+package polling
+
+import (
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+func objectIDOf(info os.FileInfo) file.ObjectID {
+	return file.ObjectID{}
+}
@@ -0,0 +1,21 @@
+// +build linux freebsd darwin
+
+package polling
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+func objectIDOf(info os.FileInfo) file.ObjectID {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return file.ObjectID{}
+	}
+	return file.ObjectID{
+		Dev: uint64(stat.Dev),
+		Ino: uint64(stat.Ino),
+	}
+}
@@ -0,0 +1,33 @@
+package polling
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionInterval struct {
+	Value time.Duration
+}
+
+func (opt OptionInterval) apply(cfg *Config) {
+	cfg.Interval = opt.Value
+}
+
+type OptionIntervalMax struct {
+	Value time.Duration
+}
+
+func (opt OptionIntervalMax) apply(cfg *Config) {
+	cfg.IntervalMax = opt.Value
+}
+
+type OptionEventQueueSize struct {
+	Size uint
+}
+
+func (opt OptionEventQueueSize) apply(cfg *Config) {
+	cfg.EventQueueSize = opt.Size
+}
@@ -0,0 +1,50 @@
+package polling
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+var _ event.Watcher = &Watcher{}
+
+// Watcher is an event.Watcher which periodically scans a file.Storage
+// and compares the result with the previous scan. It is supposed to be
+// used where the OS does not report filesystem events (NFS, CIFS,
+// FUSE, some overlay mounts and non-local storages).
+type Watcher struct {
+	ctx     context.Context
+	storage file.Storage
+	config  Config
+}
+
+func NewWatcher(ctx context.Context, storage file.Storage, opts ...Option) (*Watcher, error) {
+	cfg := NewConfig(opts...)
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &Watcher{
+		ctx:     ctx,
+		storage: storage,
+		config:  *cfg,
+	}, nil
+}
+
+func (watcher *Watcher) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc event.ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
+) (event.Emitter, error) {
+	emitter := newEmitter(watcher.ctx, watcher.storage, watcher.config)
+	err := emitter.Watch(dirAt, path, shouldWatchFunc, shouldWalkFunc, errorHandler, opts...)
+	if err != nil {
+		_ = emitter.Close()
+		return nil, err
+	}
+	return emitter, nil
+}
@@ -0,0 +1,70 @@
+//go:generate msgp
+
+package event
+
+import (
+	"time"
+
+	pkgbytes "github.com/my-network/fsutil/pkg/bytes"
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// Record is the serializable form of an Event (see Recorder and Replayer).
+type Record struct {
+	Path      file.Path `msg:"path" json:"path"`
+	TypeMask  TypeMask  `msg:"type" json:"type"`
+	Timestamp time.Time `msg:"ts" json:"ts"`
+	MovedTo   file.Path `msg:"moved_to" json:"moved_to,omitempty"`
+	Source    string    `msg:"source" json:"source,omitempty"`
+
+	ObjDev    uint64 `msg:"obj_dev" json:"obj_dev,omitempty"`
+	ObjIno    uint64 `msg:"obj_ino" json:"obj_ino,omitempty"`
+	ObjHandle []byte `msg:"obj_handle" json:"obj_handle,omitempty"`
+
+	HasRange    bool   `msg:"has_range" json:"has_range,omitempty"`
+	RangeOffset uint64 `msg:"range_offset" json:"range_offset,omitempty"`
+	RangeLength uint64 `msg:"range_length" json:"range_length,omitempty"`
+}
+
+func NewRecord(ev Event) Record {
+	record := Record{
+		Path:      ev.Path,
+		TypeMask:  ev.TypeMask,
+		Timestamp: ev.Timestamp,
+		MovedTo:   ev.MovedTo,
+		Source:    ev.Source,
+		ObjDev:    ev.ObjID.Dev,
+		ObjIno:    ev.ObjID.Ino,
+	}
+	if ev.ObjID.Handle != "" {
+		record.ObjHandle = []byte(ev.ObjID.Handle)
+	}
+	if ev.Range != nil {
+		record.HasRange = true
+		record.RangeOffset = ev.Range.Offset
+		record.RangeLength = ev.Range.Length
+	}
+	return record
+}
+
+func (record Record) Event() Event {
+	ev := Event{
+		Path:      record.Path,
+		TypeMask:  record.TypeMask,
+		Timestamp: record.Timestamp,
+		MovedTo:   record.MovedTo,
+		Source:    record.Source,
+		ObjID: file.ObjectID{
+			Dev:    record.ObjDev,
+			Ino:    record.ObjIno,
+			Handle: string(record.ObjHandle),
+		},
+	}
+	if record.HasRange {
+		ev.Range = &pkgbytes.Range{
+			Offset: record.RangeOffset,
+			Length: record.RangeLength,
+		}
+	}
+	return ev
+}
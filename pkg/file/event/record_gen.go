@@ -0,0 +1,372 @@
+package event
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *Record) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "type":
+			err = z.TypeMask.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "TypeMask")
+				return
+			}
+		case "ts":
+			z.Timestamp, err = dc.ReadTime()
+			if err != nil {
+				err = msgp.WrapError(err, "Timestamp")
+				return
+			}
+		case "moved_to":
+			err = z.MovedTo.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "MovedTo")
+				return
+			}
+		case "source":
+			z.Source, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Source")
+				return
+			}
+		case "obj_dev":
+			z.ObjDev, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "ObjDev")
+				return
+			}
+		case "obj_ino":
+			z.ObjIno, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "ObjIno")
+				return
+			}
+		case "obj_handle":
+			z.ObjHandle, err = dc.ReadBytes(z.ObjHandle)
+			if err != nil {
+				err = msgp.WrapError(err, "ObjHandle")
+				return
+			}
+		case "has_range":
+			z.HasRange, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "HasRange")
+				return
+			}
+		case "range_offset":
+			z.RangeOffset, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "RangeOffset")
+				return
+			}
+		case "range_length":
+			z.RangeLength, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "RangeLength")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Record) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 11
+	// write "path"
+	err = en.Append(0x8b, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "type"
+	err = en.Append(0xa4, 0x74, 0x79, 0x70, 0x65)
+	if err != nil {
+		return
+	}
+	err = z.TypeMask.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "TypeMask")
+		return
+	}
+	// write "ts"
+	err = en.Append(0xa2, 0x74, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteTime(z.Timestamp)
+	if err != nil {
+		err = msgp.WrapError(err, "Timestamp")
+		return
+	}
+	// write "moved_to"
+	err = en.Append(0xa8, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x5f, 0x74, 0x6f)
+	if err != nil {
+		return
+	}
+	err = z.MovedTo.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "MovedTo")
+		return
+	}
+	// write "source"
+	err = en.Append(0xa6, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Source)
+	if err != nil {
+		err = msgp.WrapError(err, "Source")
+		return
+	}
+	// write "obj_dev"
+	err = en.Append(0xa7, 0x6f, 0x62, 0x6a, 0x5f, 0x64, 0x65, 0x76)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ObjDev)
+	if err != nil {
+		err = msgp.WrapError(err, "ObjDev")
+		return
+	}
+	// write "obj_ino"
+	err = en.Append(0xa7, 0x6f, 0x62, 0x6a, 0x5f, 0x69, 0x6e, 0x6f)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ObjIno)
+	if err != nil {
+		err = msgp.WrapError(err, "ObjIno")
+		return
+	}
+	// write "obj_handle"
+	err = en.Append(0xaa, 0x6f, 0x62, 0x6a, 0x5f, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.ObjHandle)
+	if err != nil {
+		err = msgp.WrapError(err, "ObjHandle")
+		return
+	}
+	// write "has_range"
+	err = en.Append(0xa9, 0x68, 0x61, 0x73, 0x5f, 0x72, 0x61, 0x6e, 0x67, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.HasRange)
+	if err != nil {
+		err = msgp.WrapError(err, "HasRange")
+		return
+	}
+	// write "range_offset"
+	err = en.Append(0xac, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.RangeOffset)
+	if err != nil {
+		err = msgp.WrapError(err, "RangeOffset")
+		return
+	}
+	// write "range_length"
+	err = en.Append(0xac, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.RangeLength)
+	if err != nil {
+		err = msgp.WrapError(err, "RangeLength")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Record) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 11
+	// string "path"
+	o = append(o, 0x8b, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "type"
+	o = append(o, 0xa4, 0x74, 0x79, 0x70, 0x65)
+	o, err = z.TypeMask.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "TypeMask")
+		return
+	}
+	// string "ts"
+	o = append(o, 0xa2, 0x74, 0x73)
+	o = msgp.AppendTime(o, z.Timestamp)
+	// string "moved_to"
+	o = append(o, 0xa8, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x5f, 0x74, 0x6f)
+	o, err = z.MovedTo.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "MovedTo")
+		return
+	}
+	// string "source"
+	o = append(o, 0xa6, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65)
+	o = msgp.AppendString(o, z.Source)
+	// string "obj_dev"
+	o = append(o, 0xa7, 0x6f, 0x62, 0x6a, 0x5f, 0x64, 0x65, 0x76)
+	o = msgp.AppendUint64(o, z.ObjDev)
+	// string "obj_ino"
+	o = append(o, 0xa7, 0x6f, 0x62, 0x6a, 0x5f, 0x69, 0x6e, 0x6f)
+	o = msgp.AppendUint64(o, z.ObjIno)
+	// string "obj_handle"
+	o = append(o, 0xaa, 0x6f, 0x62, 0x6a, 0x5f, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendBytes(o, z.ObjHandle)
+	// string "has_range"
+	o = append(o, 0xa9, 0x68, 0x61, 0x73, 0x5f, 0x72, 0x61, 0x6e, 0x67, 0x65)
+	o = msgp.AppendBool(o, z.HasRange)
+	// string "range_offset"
+	o = append(o, 0xac, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	o = msgp.AppendUint64(o, z.RangeOffset)
+	// string "range_length"
+	o = append(o, 0xac, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68)
+	o = msgp.AppendUint64(o, z.RangeLength)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Record) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "type":
+			bts, err = z.TypeMask.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TypeMask")
+				return
+			}
+		case "ts":
+			z.Timestamp, bts, err = msgp.ReadTimeBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Timestamp")
+				return
+			}
+		case "moved_to":
+			bts, err = z.MovedTo.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "MovedTo")
+				return
+			}
+		case "source":
+			z.Source, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Source")
+				return
+			}
+		case "obj_dev":
+			z.ObjDev, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ObjDev")
+				return
+			}
+		case "obj_ino":
+			z.ObjIno, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ObjIno")
+				return
+			}
+		case "obj_handle":
+			z.ObjHandle, bts, err = msgp.ReadBytesBytes(bts, z.ObjHandle)
+			if err != nil {
+				err = msgp.WrapError(err, "ObjHandle")
+				return
+			}
+		case "has_range":
+			z.HasRange, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "HasRange")
+				return
+			}
+		case "range_offset":
+			z.RangeOffset, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RangeOffset")
+				return
+			}
+		case "range_length":
+			z.RangeLength, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "RangeLength")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Record) Msgsize() (s int) {
+	s = 1 + 5 + z.Path.Msgsize() + 5 + z.TypeMask.Msgsize() + 3 + msgp.TimeSize + 9 + z.MovedTo.Msgsize() + 7 + msgp.StringPrefixSize + len(z.Source) + 8 + msgp.Uint64Size + 8 + msgp.Uint64Size + 11 + msgp.BytesPrefixSize + len(z.ObjHandle) + 10 + msgp.BoolSize + 13 + msgp.Uint64Size + 13 + msgp.Uint64Size
+	return
+}
@@ -0,0 +1,198 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// RecordFormat is the format of a log of events (see Recorder).
+type RecordFormat uint8
+
+const (
+	// RecordFormatMsgp is a stream of msgp-encoded Record-s.
+	RecordFormatMsgp = RecordFormat(iota)
+
+	// RecordFormatJSONLines is a JSON-encoded Record per line.
+	RecordFormatJSONLines
+)
+
+func (format RecordFormat) String() string {
+	switch format {
+	case RecordFormatMsgp:
+		return "msgp"
+	case RecordFormatJSONLines:
+		return "jsonl"
+	}
+	return fmt.Sprintf("unknown_%d", uint8(format))
+}
+
+// ParseRecordFormat parses the output of RecordFormat.String.
+func ParseRecordFormat(s string) (RecordFormat, error) {
+	for format := RecordFormatMsgp; format <= RecordFormatJSONLines; format++ {
+		if format.String() == s {
+			return format, nil
+		}
+	}
+	return RecordFormatMsgp, fmt.Errorf("unknown record format: '%s'", s)
+}
+
+// recordEncoder writes Record-s in a RecordFormat.
+type recordEncoder interface {
+	Encode(Record) error
+}
+
+type msgpRecordEncoder struct {
+	writer *msgp.Writer
+}
+
+func (encoder msgpRecordEncoder) Encode(record Record) error {
+	if err := record.EncodeMsg(encoder.writer); err != nil {
+		return err
+	}
+	// each record is flushed, so the log is useful even after a crash
+	return encoder.writer.Flush()
+}
+
+type jsonRecordEncoder struct {
+	encoder *json.Encoder
+}
+
+func (encoder jsonRecordEncoder) Encode(record Record) error {
+	return encoder.encoder.Encode(record)
+}
+
+func newRecordEncoder(w io.Writer, format RecordFormat) (recordEncoder, error) {
+	switch format {
+	case RecordFormatMsgp:
+		return msgpRecordEncoder{writer: msgp.NewWriter(w)}, nil
+	case RecordFormatJSONLines:
+		return jsonRecordEncoder{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown record format: %v", format)
+}
+
+var _ Emitter = &Recorder{}
+
+// Recorder is an Emitter which passes through the events of another
+// Emitter and writes them to a log (which may be played back
+// by a Replayer).
+type Recorder struct {
+	ctx       context.Context
+	cancelFn  context.CancelFunc
+	emitter   Emitter
+	encoder   recordEncoder
+	wg        sync.WaitGroup
+	eventChan chan Event
+	errChan   chan error
+	err       error
+}
+
+// NewRecorder returns a Recorder of events of `emitter`. The Recorder
+// takes the ownership of `emitter` (it is closed by Recorder.Close).
+func NewRecorder(ctx context.Context, emitter Emitter, w io.Writer, format RecordFormat) (*Recorder, error) {
+	encoder, err := newRecordEncoder(w, format)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := &Recorder{
+		emitter:   emitter,
+		encoder:   encoder,
+		eventChan: make(chan Event),
+		errChan:   make(chan error, ErrorQueueSize),
+	}
+	recorder.ctx, recorder.cancelFn = context.WithCancel(ctx)
+	recorder.wg.Add(1)
+	go func() {
+		defer func() {
+			close(recorder.eventChan)
+			close(recorder.errChan)
+			recorder.wg.Done()
+		}()
+		recorder.loop()
+	}()
+	return recorder, nil
+}
+
+func (recorder *Recorder) loop() {
+	inChan := recorder.emitter.C()
+	errChan := recorder.emitter.Errors()
+	for {
+		select {
+		case ev, ok := <-inChan:
+			if !ok {
+				return
+			}
+			if recorder.err == nil {
+				if err := recorder.encoder.Encode(NewRecord(ev)); err != nil {
+					// the events are still passed through
+					recorder.err = fmt.Errorf("unable to record an event: %w", err)
+					recorder.sendError(recorder.err)
+				}
+			}
+			select {
+			case recorder.eventChan <- ev:
+			case <-recorder.ctx.Done():
+				return
+			}
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			recorder.sendError(err)
+		case <-recorder.ctx.Done():
+			return
+		}
+	}
+}
+
+func (recorder *Recorder) sendError(err error) {
+	select {
+	case recorder.errChan <- err:
+	default:
+	}
+}
+
+func (recorder *Recorder) C() <-chan Event {
+	return recorder.eventChan
+}
+
+// Errors returns the errors of the source Emitter and the first
+// error of writing to the log.
+func (recorder *Recorder) Errors() <-chan error {
+	return recorder.errChan
+}
+
+// Close closes the source Emitter and returns the first error of
+// writing to the log (if any).
+func (recorder *Recorder) Close() error {
+	recorder.cancelFn()
+	err := recorder.emitter.Close()
+	recorder.wg.Wait()
+	if recorder.err != nil {
+		return recorder.err
+	}
+	return err
+}
+
+func (recorder *Recorder) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...WatchOption,
+) error {
+	return recorder.emitter.Watch(dirAt, path, shouldWatchFunc, shouldWalkFunc, errorHandler, opts...)
+}
+
+func (recorder *Recorder) Unwatch(path file.Path, recursive bool) error {
+	return recorder.emitter.Unwatch(path, recursive)
+}
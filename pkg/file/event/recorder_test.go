@@ -0,0 +1,58 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	pkgbytes "github.com/my-network/fsutil/pkg/bytes"
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderReplayer(t *testing.T) {
+	ts := time.Unix(1600000000, 0)
+	events := []Event{
+		{Path: file.Path{"a"}, TypeMask: TypeCreate, Timestamp: ts, Source: "src"},
+		{Path: file.Path{"a"}, TypeMask: TypeWrite, Timestamp: ts.Add(30 * time.Millisecond),
+			Range: &pkgbytes.Range{Offset: 1, Length: 2}},
+		{Path: file.Path{"a"}, MovedTo: file.Path{"b", "c"}, TypeMask: TypeMove, Timestamp: ts.Add(40 * time.Millisecond)},
+	}
+
+	for _, format := range []RecordFormat{RecordFormatMsgp, RecordFormatJSONLines} {
+		t.Run(format.String(), func(t *testing.T) {
+			var log bytes.Buffer
+			source := make(chanEmitter, len(events))
+			recorder, err := NewRecorder(context.Background(), source, &log, format)
+			require.NoError(t, err)
+			for _, ev := range events {
+				source <- ev
+				require.Equal(t, ev, <-recorder.C())
+			}
+			require.NoError(t, recorder.Close())
+
+			for _, realTime := range []bool{false, true} {
+				replayer, err := NewReplayer(context.Background(), bytes.NewReader(log.Bytes()), format,
+					OptionReplayRealTime{Enable: realTime})
+				require.NoError(t, err)
+
+				startTS := time.Now()
+				var replayed []Event
+				for ev := range replayer.C() {
+					replayed = append(replayed, ev)
+				}
+				require.NoError(t, replayer.Close())
+				require.Len(t, replayed, len(events))
+				for idx := range events {
+					require.True(t, events[idx].Timestamp.Equal(replayed[idx].Timestamp))
+					replayed[idx].Timestamp = events[idx].Timestamp
+				}
+				require.Equal(t, events, replayed)
+				if realTime {
+					require.GreaterOrEqual(t, int64(time.Since(startTS)), int64(40*time.Millisecond))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// recordDecoder reads Record-s in a RecordFormat. It returns io.EOF
+// at the end of the log.
+type recordDecoder interface {
+	Decode(*Record) error
+}
+
+type msgpRecordDecoder struct {
+	reader *msgp.Reader
+}
+
+func (decoder msgpRecordDecoder) Decode(record *Record) error {
+	if _, err := decoder.reader.R.Peek(1); err != nil {
+		return err
+	}
+	return record.DecodeMsg(decoder.reader)
+}
+
+type jsonRecordDecoder struct {
+	decoder *json.Decoder
+}
+
+func (decoder jsonRecordDecoder) Decode(record *Record) error {
+	return decoder.decoder.Decode(record)
+}
+
+func newRecordDecoder(r io.Reader, format RecordFormat) (recordDecoder, error) {
+	switch format {
+	case RecordFormatMsgp:
+		return msgpRecordDecoder{reader: msgp.NewReader(r)}, nil
+	case RecordFormatJSONLines:
+		return jsonRecordDecoder{decoder: json.NewDecoder(r)}, nil
+	}
+	return nil, fmt.Errorf("unknown record format: %v", format)
+}
+
+type ReplayerConfig struct {
+	// RealTime enables reproducing the intervals between the events
+	// (by their timestamps). Otherwise the events are emitted as fast
+	// as they are consumed.
+	RealTime bool
+}
+
+type ReplayerOption interface {
+	apply(*ReplayerConfig)
+}
+
+type OptionReplayRealTime struct {
+	Enable bool
+}
+
+func (opt OptionReplayRealTime) apply(cfg *ReplayerConfig) {
+	cfg.RealTime = opt.Enable
+}
+
+var _ Emitter = &Replayer{}
+
+// Replayer is an Emitter which plays back a log written by a Recorder.
+//
+// The channel is closed at the end of the log.
+type Replayer struct {
+	ctx       context.Context
+	cancelFn  context.CancelFunc
+	decoder   recordDecoder
+	config    ReplayerConfig
+	wg        sync.WaitGroup
+	eventChan chan Event
+	errChan   chan error
+	err       error
+}
+
+func NewReplayer(ctx context.Context, r io.Reader, format RecordFormat, opts ...ReplayerOption) (*Replayer, error) {
+	decoder, err := newRecordDecoder(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	replayer := &Replayer{
+		decoder:   decoder,
+		eventChan: make(chan Event),
+		errChan:   make(chan error, 1),
+	}
+	for _, opt := range opts {
+		opt.apply(&replayer.config)
+	}
+	replayer.ctx, replayer.cancelFn = context.WithCancel(ctx)
+	replayer.wg.Add(1)
+	go func() {
+		defer func() {
+			close(replayer.eventChan)
+			close(replayer.errChan)
+			replayer.wg.Done()
+		}()
+		replayer.err = replayer.loop()
+		if replayer.err != nil {
+			replayer.errChan <- replayer.err
+		}
+	}()
+	return replayer, nil
+}
+
+func (replayer *Replayer) loop() error {
+	var (
+		firstEventTS time.Time
+		startTS      time.Time
+	)
+	for {
+		var record Record
+		err := replayer.decoder.Decode(&record)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("unable to read an event record: %w", err)
+		}
+
+		if replayer.config.RealTime {
+			if startTS.IsZero() {
+				firstEventTS, startTS = record.Timestamp, time.Now()
+			}
+			delay := time.Until(startTS.Add(record.Timestamp.Sub(firstEventTS)))
+			if delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-replayer.ctx.Done():
+					timer.Stop()
+					return nil
+				}
+			}
+		}
+
+		select {
+		case replayer.eventChan <- record.Event():
+		case <-replayer.ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (replayer *Replayer) C() <-chan Event {
+	return replayer.eventChan
+}
+
+// Errors returns the error of reading the log (if any).
+func (replayer *Replayer) Errors() <-chan error {
+	return replayer.errChan
+}
+
+// Close stops the playback and returns the error of reading
+// the log (if any).
+func (replayer *Replayer) Close() error {
+	replayer.cancelFn()
+	replayer.wg.Wait()
+	return replayer.err
+}
+
+// Watch does nothing: the events are prerecorded (and are not filtered).
+func (replayer *Replayer) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...WatchOption,
+) error {
+	return nil
+}
+
+// Unwatch does nothing: the replayed events are already filtered.
+func (replayer *Replayer) Unwatch(path file.Path, recursive bool) error {
+	return nil
+}
@@ -0,0 +1,146 @@
+//go:generate msgp
+
+package event
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TypeMask is a set of kinds of events (similar to inotify's mask).
+type TypeMask uint32
+
+const (
+	TypeAccess = TypeMask(1 << iota)
+	TypeAttrib
+	TypeWrite
+	TypeOpen
+	TypeOpenWrite
+	TypeCloseWrite
+	TypeCloseNoWrite
+	TypeCreate
+	TypeDelete
+	TypeDeleteSelf
+	TypeMoveFrom
+	TypeMoveTo
+	TypeMoveSelf
+	TypeUnmount
+
+	// TypeOverflow means some events inside of Event.Path were lost,
+	// so the whole subtree should be rescanned.
+	TypeOverflow
+
+	typeEnd
+)
+
+const (
+	TypeClose = TypeCloseWrite | TypeCloseNoWrite
+	TypeMove  = TypeMoveFrom | TypeMoveTo
+	TypeAll   = typeEnd - 1
+)
+
+var typeNames = map[TypeMask]string{
+	TypeAccess:       "access",
+	TypeAttrib:       "attrib",
+	TypeWrite:        "write",
+	TypeOpen:         "open",
+	TypeOpenWrite:    "open_write",
+	TypeCloseWrite:   "close_write",
+	TypeCloseNoWrite: "close_nowrite",
+	TypeCreate:       "create",
+	TypeDelete:       "delete",
+	TypeDeleteSelf:   "delete_self",
+	TypeMoveFrom:     "move_from",
+	TypeMoveTo:       "move_to",
+	TypeMoveSelf:     "move_self",
+	TypeUnmount:      "unmount",
+	TypeOverflow:     "overflow",
+}
+
+// typeAliases are names of combined masks, accepted by ParseTypeMask.
+var typeAliases = map[string]TypeMask{
+	"none":  0,
+	"close": TypeClose,
+	"move":  TypeMove,
+	"all":   TypeAll,
+}
+
+// Has returns true if all the types of `other` are set in `mask`.
+func (mask TypeMask) Has(other TypeMask) bool {
+	return mask&other == other
+}
+
+// Any returns true if at least one of the types of `other` is set in `mask`.
+func (mask TypeMask) Any(other TypeMask) bool {
+	return mask&other != 0
+}
+
+// String returns the names of the types separated by "|",
+// for example: "create|close_write".
+func (mask TypeMask) String() string {
+	if mask == 0 {
+		return "none"
+	}
+
+	var names []string
+	for t := TypeMask(1); t < typeEnd; t <<= 1 {
+		if mask.Has(t) {
+			names = append(names, typeNames[t])
+		}
+	}
+	if unknown := mask &^ TypeAll; unknown != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint32(unknown)))
+	}
+	return strings.Join(names, "|")
+}
+
+// ParseTypeMask parses the output of TypeMask.String. Names may be
+// separated by "|" or ",", and also "close", "move", "all" and "none"
+// are accepted.
+func ParseTypeMask(s string) (TypeMask, error) {
+	var mask TypeMask
+	for _, name := range strings.FieldsFunc(s, func(r rune) bool { return r == '|' || r == ',' }) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		t, err := parseTypeName(name)
+		if err != nil {
+			return 0, err
+		}
+		mask |= t
+	}
+	return mask, nil
+}
+
+func parseTypeName(name string) (TypeMask, error) {
+	if t, ok := typeAliases[name]; ok {
+		return t, nil
+	}
+	for t, typeName := range typeNames {
+		if typeName == name {
+			return t, nil
+		}
+	}
+	if strings.HasPrefix(name, "0x") {
+		value, err := strconv.ParseUint(name[2:], 16, 32)
+		if err == nil {
+			return TypeMask(value), nil
+		}
+	}
+	return 0, ErrUnknownType{Name: name}
+}
+
+func (mask TypeMask) MarshalText() ([]byte, error) {
+	return []byte(mask.String()), nil
+}
+
+func (mask *TypeMask) UnmarshalText(text []byte) error {
+	parsed, err := ParseTypeMask(string(text))
+	if err != nil {
+		return err
+	}
+	*mask = parsed
+	return nil
+}
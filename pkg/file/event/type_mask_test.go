@@ -0,0 +1,40 @@
+package event
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeMask(t *testing.T) {
+	mask := TypeCreate | TypeCloseWrite
+
+	require.True(t, mask.Has(TypeCreate))
+	require.False(t, mask.Has(TypeCreate|TypeDelete))
+	require.True(t, mask.Any(TypeCreate|TypeDelete))
+	require.False(t, mask.Any(TypeMove))
+
+	require.Equal(t, "close_write|create", mask.String())
+	require.Equal(t, "none", TypeMask(0).String())
+
+	for _, testMask := range []TypeMask{0, mask, TypeAll, TypeOverflow | 1<<31} {
+		parsed, err := ParseTypeMask(testMask.String())
+		require.NoError(t, err)
+		require.Equal(t, testMask, parsed)
+	}
+
+	parsed, err := ParseTypeMask("Move, close_write")
+	require.NoError(t, err)
+	require.Equal(t, TypeMoveFrom|TypeMoveTo|TypeCloseWrite, parsed)
+
+	_, err = ParseTypeMask("create|unknown")
+	require.Equal(t, ErrUnknownType{Name: "unknown"}, err)
+
+	b, err := json.Marshal(mask)
+	require.NoError(t, err)
+	require.Equal(t, `"close_write|create"`, string(b))
+	var unmarshaled TypeMask
+	require.NoError(t, json.Unmarshal(b, &unmarshaled))
+	require.Equal(t, mask, unmarshaled)
+}
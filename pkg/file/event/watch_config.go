@@ -0,0 +1,76 @@
+package event
+
+import (
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// DefaultWatchTypeMask is the set of types of events reported if
+// OptionTypeMask is not used. Read-only accesses are excluded as they
+// are very frequent and are rarely useful.
+const DefaultWatchTypeMask = TypeAll &^ (TypeAccess | TypeOpen | TypeCloseNoWrite)
+
+// PathFilterFunc returns false for paths which events are not wanted.
+type PathFilterFunc func(path file.Path) bool
+
+// WatchConfig is the configuration of a Watch call (see Watcher
+// and Emitter).
+type WatchConfig struct {
+	// TypeMask is the set of types of events the consumer is interested
+	// in. It is passed down to the source of events (if possible), so
+	// other events are not even generated. TypeOverflow is always
+	// reported.
+	TypeMask TypeMask
+
+	// PathFilter (if not nil) filters the events by paths. An event with
+	// MovedTo is reported if any of the paths passes the filter.
+	PathFilter PathFilterFunc
+}
+
+func NewWatchConfig(opts ...WatchOption) *WatchConfig {
+	cfg := &WatchConfig{
+		TypeMask: DefaultWatchTypeMask,
+	}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
+
+// Filter returns the event with only the requested types left,
+// or false if the event should not be reported at all.
+func (cfg WatchConfig) Filter(ev Event) (Event, bool) {
+	ev.TypeMask &= cfg.TypeMask | TypeOverflow
+	if ev.TypeMask == 0 {
+		return ev, false
+	}
+	if ev.TypeMask.Has(TypeOverflow) || cfg.PathFilter == nil {
+		return ev, true
+	}
+	if cfg.PathFilter(ev.Path) {
+		return ev, true
+	}
+	if ev.MovedTo != nil && cfg.PathFilter(ev.MovedTo) {
+		return ev, true
+	}
+	return ev, false
+}
+
+type WatchOption interface {
+	apply(*WatchConfig)
+}
+
+type OptionTypeMask struct {
+	Mask TypeMask
+}
+
+func (opt OptionTypeMask) apply(cfg *WatchConfig) {
+	cfg.TypeMask = opt.Mask
+}
+
+type OptionPathFilter struct {
+	Func PathFilterFunc
+}
+
+func (opt OptionPathFilter) apply(cfg *WatchConfig) {
+	cfg.PathFilter = opt.Func
+}
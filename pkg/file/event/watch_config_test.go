@@ -0,0 +1,33 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchConfigFilter(t *testing.T) {
+	cfg := NewWatchConfig(
+		OptionTypeMask{Mask: TypeCloseWrite | TypeMove},
+		OptionPathFilter{Func: func(path file.Path) bool {
+			return len(path) > 0 && path[0] == "data"
+		}},
+	)
+
+	ev, ok := cfg.Filter(Event{Path: file.Path{"data", "a"}, TypeMask: TypeCreate | TypeCloseWrite})
+	require.True(t, ok)
+	require.Equal(t, TypeCloseWrite, ev.TypeMask)
+
+	_, ok = cfg.Filter(Event{Path: file.Path{"data", "a"}, TypeMask: TypeCreate})
+	require.False(t, ok)
+
+	_, ok = cfg.Filter(Event{Path: file.Path{"tmp", "a"}, TypeMask: TypeCloseWrite})
+	require.False(t, ok)
+
+	_, ok = cfg.Filter(Event{Path: file.Path{"tmp", "a"}, MovedTo: file.Path{"data", "a"}, TypeMask: TypeMove})
+	require.True(t, ok)
+
+	_, ok = cfg.Filter(Event{Path: file.Path{"tmp"}, TypeMask: TypeOverflow})
+	require.True(t, ok)
+}
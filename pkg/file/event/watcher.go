@@ -9,5 +9,5 @@ import (
 type ShouldWatchFunc func(file.Directory, os.FileInfo) bool
 
 type Watcher interface {
-	Watch(file.Directory, file.Path, ShouldWatchFunc, file.ShouldWalkFunc, file.ErrorHandlerFunc) (Emitter, error)
+	Watch(file.Directory, file.Path, ShouldWatchFunc, file.ShouldWalkFunc, file.ErrorHandlerFunc, ...WatchOption) (Emitter, error)
 }
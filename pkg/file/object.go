@@ -8,7 +8,7 @@ import (
 )
 
 type Object interface {
-	ID() interface{}
+	ID() ObjectID
 
 	Path() Path
 	Name() string
@@ -0,0 +1,47 @@
+package file
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// ObjectID identifies an object within a storage regardless of its
+// path, so it stays the same when the object is renamed. ObjectID is
+// comparable, so it may be used as a map key.
+//
+// The zero value means the identity is unknown.
+type ObjectID struct {
+	// Dev is the ID of the device (filesystem) containing the object.
+	Dev uint64
+
+	// Ino is the number of the object within the device.
+	Ino uint64
+
+	// Handle is an opaque handle of the object (for example, the one
+	// returned by name_to_handle_at(2)). Unlike Ino it is not reused
+	// after the object is deleted. It is empty if not supported.
+	Handle string
+}
+
+func (id ObjectID) IsZero() bool {
+	return id == ObjectID{}
+}
+
+// SameObject returns true if both IDs are known and refer to the same
+// object. Unlike "==" it tolerates a missing Handle on either side.
+func (id ObjectID) SameObject(other ObjectID) bool {
+	if id.IsZero() || other.IsZero() {
+		return false
+	}
+	if id.Dev != other.Dev || id.Ino != other.Ino {
+		return false
+	}
+	return id.Handle == "" || other.Handle == "" || id.Handle == other.Handle
+}
+
+func (id ObjectID) String() string {
+	if id.Handle == "" {
+		return fmt.Sprintf("%d:%d", id.Dev, id.Ino)
+	}
+	return fmt.Sprintf("%d:%d:%s", id.Dev, id.Ino, hex.EncodeToString([]byte(id.Handle)))
+}
@@ -46,9 +46,40 @@ func (p Path) Key() string {
 	return string(buf.Bytes())
 }
 
+// Append returns a new path with `appendie` appended to `p`.
+//
+// The result never shares the underlying array with `p`.
 func (p Path) Append(appendie ...string) Path {
-	p = append(p, appendie...)
-	return p
+	result := make(Path, 0, len(p)+len(appendie))
+	result = append(result, p...)
+	result = append(result, appendie...)
+	return result
+}
+
+// HasPrefix returns true if `p` is `prefix` or is inside of `prefix`.
+func (p Path) HasPrefix(prefix Path) bool {
+	if len(p) < len(prefix) {
+		return false
+	}
+	for idx := range prefix {
+		if p[idx] != prefix[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// CommonPrefix returns the longest path which both `p` and `other`
+// have as a prefix.
+func (p Path) CommonPrefix(other Path) Path {
+	minLen := mathutils.Min(len(p), len(other))
+	var idx int
+	for idx = 0; idx < minLen; idx++ {
+		if p[idx] != other[idx] {
+			break
+		}
+	}
+	return p[:idx:idx]
 }
 
 func (p Path) Up() Path {
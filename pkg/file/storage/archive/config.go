@@ -0,0 +1,43 @@
+package archive
+
+import (
+	"time"
+)
+
+type Config struct {
+	// Whiteouts makes a writable Storage append a whiteout entry (an empty
+	// file named ".wh.<name>", as in the layers of OCI images) for each
+	// removed or renamed object, and makes a Storage apply them
+	// (remove the objects and skip the whiteout entries). Tar has no
+	// other way to express removals in an appended archive.
+	Whiteouts bool
+
+	// Gzip makes a writable Storage compress the archive. The content of
+	// the appended files cannot be read back then (see NewTarStorage).
+	Gzip bool
+
+	// FlushDelay is the time after which the pending entries of
+	// a writable Storage are appended even if no other object is modified.
+	// Zero means they are appended only on the modification of another
+	// object, on Flush and on Close.
+	FlushDelay time.Duration
+
+	// NowFunc returns the current time; it is used for timestamps of
+	// the objects created in a writable Storage. Nil means time.Now.
+	NowFunc func() time.Time
+}
+
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
+
+func (cfg Config) now() time.Time {
+	if cfg.NowFunc == nil {
+		return time.Now()
+	}
+	return cfg.NowFunc()
+}
@@ -0,0 +1,140 @@
+package archive
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// blockSize is the size of the blocks of tar archives.
+const blockSize = 512
+
+// stream is a sequential reader of a content which cannot be read at
+// random offsets (a decompressed archive or a deflated entry of a zip
+// archive). Reading forward continues the current reader; reading
+// backward restarts it from the beginning.
+type stream struct {
+	locker sync.Mutex
+	open   func() (io.ReadCloser, error)
+	reader io.ReadCloser
+	offset int64
+}
+
+func newStream(open func() (io.ReadCloser, error)) *stream {
+	return &stream{open: open}
+}
+
+// readAt has the semantics of io.ReaderAt.
+func (s *stream) readAt(b []byte, offset int64) (int, error) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if s.reader == nil || s.offset > offset {
+		if s.reader != nil {
+			_ = s.reader.Close()
+			s.reader = nil
+		}
+		reader, err := s.open()
+		if err != nil {
+			return 0, err
+		}
+		s.reader, s.offset = reader, 0
+	}
+	if s.offset < offset {
+		n, err := io.CopyN(ioutil.Discard, s.reader, offset-s.offset)
+		s.offset += n
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := io.ReadFull(s.reader, b)
+	s.offset += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (s *stream) Close() error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if s.reader == nil {
+		return nil
+	}
+	err := s.reader.Close()
+	s.reader = nil
+	return err
+}
+
+var _ io.ReaderAt = &streamContent{}
+
+// streamContent is the content of an entry at the offset of a stream.
+type streamContent struct {
+	stream *stream
+	offset int64
+	size   int64
+}
+
+func (content *streamContent) ReadAt(b []byte, offset int64) (int, error) {
+	if offset >= content.size {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	isClipped := false
+	if remaining := content.size - offset; int64(len(b)) > remaining {
+		b = b[:remaining]
+		isClipped = true
+	}
+	n, err := content.stream.readAt(b, content.offset+offset)
+	if err == nil && isClipped {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// offsetReader tracks the offset of a reader of a tar archive while it
+// is indexed. It is an io.Seeker (forward only, if the reader is not
+// one), so archive/tar skips the content of the entries instead of
+// reading it.
+type offsetReader struct {
+	reader io.Reader
+	offset int64
+
+	// blockOffset is the offset of the first block read since it was
+	// reset to -1 (the first header of an entry or the end of the archive)
+	blockOffset int64
+}
+
+func (r *offsetReader) Read(b []byte) (int, error) {
+	if r.blockOffset < 0 && len(b) == blockSize {
+		r.blockOffset = r.offset
+	}
+	n, err := r.reader.Read(b)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *offsetReader) Seek(offset int64, whence int) (int64, error) {
+	if seeker, ok := r.reader.(io.Seeker); ok {
+		newOffset, err := seeker.Seek(offset, whence)
+		if err == nil {
+			r.offset = newOffset
+		}
+		return newOffset, err
+	}
+	if whence != io.SeekCurrent || offset < 0 {
+		return r.offset, errNotSeekable{}
+	}
+	n, err := io.CopyN(ioutil.Discard, r.reader, offset)
+	r.offset += n
+	return r.offset, err
+}
+
+// errNotSeekable is returned by offsetReader.Seek for the backward
+// seeks of a stream.
+type errNotSeekable struct{}
+
+func (errNotSeekable) Error() string {
+	return "the stream is not seekable backward"
+}
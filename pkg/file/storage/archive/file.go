@@ -0,0 +1,153 @@
+package archive
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.File = &File{}
+
+type File struct {
+	Object
+	offset int64
+}
+
+func (f *File) Read(b []byte) (int, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	n, err := f.readAt(b, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *File) ReadAt(b []byte, offset int64) (int, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	return f.readAt(b, offset)
+}
+
+func (f *File) readAt(b []byte, offset int64) (int, error) {
+	switch {
+	case f.isClosed:
+		return 0, f.pathError("read", os.ErrClosed)
+	case f.flags&file.FlagRead == 0:
+		return 0, f.pathError("read", syscall.EBADF)
+	case offset < 0:
+		return 0, f.pathError("read", syscall.EINVAL)
+	}
+	size := f.node.size()
+	if offset >= size {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+
+	var content io.ReaderAt
+	switch {
+	case f.node.spool != nil:
+		content = f.node.spool
+	case f.node.content != nil:
+		content = f.node.content
+	default:
+		// the file was appended to a compressed archive
+		return 0, f.pathError("read", file.ErrNotImplemented{})
+	}
+	n, err := io.NewSectionReader(content, 0, size).ReadAt(b, offset)
+	if err != nil && err != io.EOF {
+		return n, f.pathError("read", err)
+	}
+	return n, err
+}
+
+func (f *File) Write(b []byte) (int, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	offset := f.offset
+	if f.flags.HasAppend() {
+		offset = f.node.size()
+	}
+	n, err := f.writeAt(b, offset)
+	f.offset = offset + int64(n)
+	return n, err
+}
+
+func (f *File) WriteAt(b []byte, offset int64) (int, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if f.flags.HasAppend() {
+		// the same as os.File.WriteAt
+		return 0, f.pathError("writeat", syscall.EINVAL)
+	}
+	return f.writeAt(b, offset)
+}
+
+func (f *File) writeAt(b []byte, offset int64) (int, error) {
+	switch {
+	case f.isClosed:
+		return 0, f.pathError("write", os.ErrClosed)
+	case !isWritable(f.flags):
+		return 0, f.pathError("write", syscall.EBADF)
+	case offset < 0:
+		return 0, f.pathError("write", syscall.EINVAL)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	n, err := f.StorageValue.write(f.LastPath, f.node, b, offset)
+	if err != nil {
+		return n, f.pathError("write", err)
+	}
+	return n, nil
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if f.isClosed {
+		return 0, f.pathError("seek", os.ErrClosed)
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.node.size()
+	default:
+		return 0, f.pathError("seek", syscall.EINVAL)
+	}
+	if offset < 0 {
+		return 0, f.pathError("seek", syscall.EINVAL)
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Sync does nothing: the file is appended to the archive when it is
+// closed and another object is modified (or on Storage.Flush).
+func (f *File) Sync() error {
+	return nil
+}
+
+// SetDeadline is not supported (the same as for regular files of the OS).
+func (f *File) SetDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetReadDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetWriteDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
@@ -0,0 +1,399 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// Format is the format of an archive.
+type Format int
+
+const (
+	FormatTar = Format(iota)
+	FormatTarGzip
+	FormatTarBzip2
+	FormatZip
+)
+
+func (format Format) String() string {
+	switch format {
+	case FormatTar:
+		return "tar"
+	case FormatTarGzip:
+		return "tar.gz"
+	case FormatTarBzip2:
+		return "tar.bz2"
+	case FormatZip:
+		return "zip"
+	}
+	return fmt.Sprintf("Format(%d)", int(format))
+}
+
+// DetectFormat returns the format of the archive by its magic bytes
+// (anything unknown is considered tar).
+func DetectFormat(r io.ReaderAt) (Format, error) {
+	block := make([]byte, blockSize)
+	n, err := r.ReadAt(block, 0)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	if isTarHeader(block[:n]) {
+		// the name of the first entry could look like a magic
+		return FormatTar, nil
+	}
+	magic := block[:n]
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return FormatZip, nil
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return FormatTarGzip, nil
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return FormatTarBzip2, nil
+	}
+	return FormatTar, nil
+}
+
+// isTarHeader returns true if the block is a tar header with a valid
+// checksum.
+func isTarHeader(block []byte) bool {
+	if len(block) < blockSize {
+		return false
+	}
+	checksum, err := strconv.ParseInt(strings.Trim(string(block[148:156]), " \x00"), 8, 64)
+	if err != nil {
+		return false
+	}
+	var sum int64
+	for idx, b := range block {
+		if idx >= 148 && idx < 156 {
+			b = ' '
+		}
+		sum += int64(b)
+	}
+	return sum == checksum
+}
+
+// whiteoutPrefix and whiteoutOpaque are the names of the whiteout
+// entries (see Config.Whiteouts).
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// apply adds the entry to the index (replacing the existing object
+// with the same path, as the later entries do on extraction). The
+// content is the content of a regular file.
+func (stor *Storage) apply(hdr *tar.Header, content io.ReaderAt) {
+	path := pathOf(hdr.Name)
+	if len(path) == 0 {
+		// the root itself ("./")
+		if hdr.Typeflag == tar.TypeDir {
+			children := stor.root.children
+			stor.root.hdr = *hdr
+			stor.root.hdr.Name = ""
+			stor.root.children = children
+		}
+		return
+	}
+	name := path[len(path)-1]
+	if stor.Whiteouts && strings.HasPrefix(name, whiteoutPrefix) {
+		var depth int
+		dir, err := stor.resolve(stor.root, path[:len(path)-1], true, &depth)
+		if err != nil || !dir.isDir() {
+			return
+		}
+		if name == whiteoutOpaque {
+			for _, childName := range sortedNames(dir) {
+				stor.unlink(dir, childName)
+			}
+		} else {
+			stor.unlink(dir, strings.TrimPrefix(name, whiteoutPrefix))
+		}
+		return
+	}
+
+	dir := stor.mkdirAll(path[:len(path)-1])
+	switch {
+	case hdr.Typeflag == tar.TypeLink:
+		var depth int
+		target, err := stor.resolve(stor.root, pathOf(hdr.Linkname), true, &depth)
+		if err != nil || target.isDir() || target == dir.children[name] {
+			// a broken hardlink
+			return
+		}
+		stor.link(dir, name, target)
+	case hdr.Typeflag == tar.TypeDir:
+		existing := dir.children[name]
+		if existing != nil && existing.isDir() {
+			existing.hdr = *hdr
+			existing.hdr.Name = ""
+			return
+		}
+		stor.link(dir, name, newNode(hdr))
+	case isRegularType(hdr.Typeflag):
+		n := newNode(hdr)
+		n.hdr.Typeflag = tar.TypeReg
+		n.content = content
+		stor.link(dir, name, n)
+	case hdr.Typeflag == tar.TypeSymlink, hdr.Typeflag == tar.TypeChar,
+		hdr.Typeflag == tar.TypeBlock, hdr.Typeflag == tar.TypeFifo:
+		stor.link(dir, name, newNode(hdr))
+	}
+}
+
+// mkdirAll returns the directory of the path, creating the missing
+// ones (the archives may have no entries for the directories) and
+// replacing the other objects.
+func (stor *Storage) mkdirAll(path file.Path) *node {
+	cur := stor.root
+	for _, name := range path {
+		child := cur.children[name]
+		if child == nil || !child.isDir() {
+			child = newNode(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Mode:     0755,
+			})
+			stor.link(cur, name, child)
+		}
+		cur = child
+	}
+	return cur
+}
+
+// isSparse returns true if the content of the entry is stored sparse
+// (so it is not contiguous in the archive).
+func isSparse(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// indexTar indexes the tar archive read by `open` (from the beginning)
+// and returns the offset of its end (of the first of the zero blocks
+// or of a truncated entry). If `r` is not nil, it is the uncompressed
+// archive, and the content is read from it at random offsets.
+func (stor *Storage) indexTar(open func() (io.ReadCloser, error), r io.ReaderAt) (int64, error) {
+	reader, err := open()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	var shared *stream
+	if r == nil {
+		shared = newStream(open)
+		stor.streams = append(stor.streams, shared)
+	}
+	tracker := &offsetReader{reader: reader}
+	tr := tar.NewReader(tracker)
+	for {
+		tracker.blockOffset = -1
+		hdr, err := tr.Next()
+		headerOffset := tracker.blockOffset
+		if headerOffset < 0 {
+			headerOffset = tracker.offset
+		}
+		switch {
+		case err == io.EOF:
+			return headerOffset, nil
+		case err != nil:
+			return 0, fmt.Errorf("unable to read the header at %d: %w", headerOffset, err)
+		}
+
+		var content io.ReaderAt
+		if isRegularType(hdr.Typeflag) {
+			dataOffset := tracker.offset
+			switch {
+			case isSparse(hdr):
+				entryStream := newStream(func() (io.ReadCloser, error) {
+					return openEntry(open, headerOffset)
+				})
+				stor.streams = append(stor.streams, entryStream)
+				content = &streamContent{stream: entryStream, size: hdr.Size}
+			case r != nil:
+				content = io.NewSectionReader(r, dataOffset, hdr.Size)
+			default:
+				content = &streamContent{stream: shared, offset: dataOffset, size: hdr.Size}
+			}
+		}
+		stor.apply(hdr, content)
+	}
+}
+
+// openEntry returns the reader of the content of the entry whose header
+// starts at the offset (it is used for sparse files, which are expanded
+// by archive/tar).
+func openEntry(open func() (io.ReadCloser, error), headerOffset int64) (io.ReadCloser, error) {
+	reader, err := open()
+	if err != nil {
+		return nil, err
+	}
+	if seeker, ok := reader.(io.Seeker); ok {
+		_, err = seeker.Seek(headerOffset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(ioutil.Discard, reader, headerOffset)
+	}
+	if err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	tr := tar.NewReader(reader)
+	if _, err := tr.Next(); err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+	return readCloser{Reader: tr, Closer: reader}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// indexZip indexes the zip archive. The content of the stored entries
+// is read at random offsets, the deflated ones are decompressed
+// sequentially.
+func (stor *Storage) indexZip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		hdr, err := headerOfZip(f)
+		if err != nil {
+			return fmt.Errorf("unable to read the entry '%s': %w", f.Name, err)
+		}
+		var content io.ReaderAt
+		if hdr.Typeflag == tar.TypeReg {
+			offset, err := f.DataOffset()
+			switch {
+			case err == nil && f.Method == zip.Store:
+				content = io.NewSectionReader(r, offset, hdr.Size)
+			default:
+				entryStream := newStream(f.Open)
+				stor.streams = append(stor.streams, entryStream)
+				content = &streamContent{stream: entryStream, size: hdr.Size}
+			}
+		}
+		stor.apply(hdr, content)
+	}
+	return nil
+}
+
+// zipExtraUnix is the ID of the extra field of Info-ZIP with the owner
+// ("ux").
+const zipExtraUnix = 0x7875
+
+// headerOfZip returns the tar header of the entry of a zip archive. The
+// target of a symlink is its content.
+func headerOfZip(f *zip.File) (*tar.Header, error) {
+	mode := f.Mode()
+	hdr := &tar.Header{
+		Name:     f.Name,
+		Mode:     tarModeOf(mode),
+		ModTime:  f.Modified,
+		Typeflag: tar.TypeReg,
+		Size:     int64(f.UncompressedSize64),
+	}
+	if hdr.ModTime.IsZero() {
+		hdr.ModTime = f.ModTime()
+	}
+	switch {
+	case mode.IsDir() || strings.HasSuffix(f.Name, "/"):
+		hdr.Typeflag = tar.TypeDir
+		hdr.Size = 0
+	case mode&os.ModeSymlink != 0:
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		target, err := ioutil.ReadAll(io.LimitReader(rc, 1<<16))
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		hdr.Typeflag = tar.TypeSymlink
+		hdr.Linkname = string(target)
+		hdr.Size = 0
+	}
+	hdr.Uid, hdr.Gid = ownerOfZipExtra(f.Extra)
+	return hdr, nil
+}
+
+// ownerOfZipExtra returns the owner stored in the extra field of
+// Info-ZIP (zero if there is no such field).
+func ownerOfZipExtra(extra []byte) (uid, gid int) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			break
+		}
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+		// version (1), UIDSize, UID, GIDSize, GID
+		if id != zipExtraUnix || len(field) < 2 || field[0] != 1 {
+			continue
+		}
+		uidSize := int(field[1])
+		if len(field) < 2+uidSize+1 {
+			continue
+		}
+		gidSize := int(field[2+uidSize])
+		if len(field) < 3+uidSize+gidSize {
+			continue
+		}
+		return int(littleEndianUint(field[2 : 2+uidSize])), int(littleEndianUint(field[3+uidSize : 3+uidSize+gidSize]))
+	}
+	return 0, 0
+}
+
+func littleEndianUint(b []byte) uint64 {
+	var result uint64
+	for idx := len(b) - 1; idx >= 0; idx-- {
+		result = result<<8 | uint64(b[idx])
+	}
+	return result
+}
+
+// openDecompressed returns the opener of the decompressed archive.
+func openDecompressed(r io.ReaderAt, size int64, format Format) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		compressed := io.NewSectionReader(r, 0, size)
+		switch format {
+		case FormatTarGzip:
+			return gzip.NewReader(compressed)
+		case FormatTarBzip2:
+			return ioutil.NopCloser(bzip2.NewReader(compressed)), nil
+		}
+		return nopSeekCloser{SectionReader: compressed}, nil
+	}
+}
+
+// nopSeekCloser is an uncompressed archive (it is an io.Seeker, unlike
+// the result of ioutil.NopCloser).
+type nopSeekCloser struct {
+	*io.SectionReader
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
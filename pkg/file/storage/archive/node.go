@@ -0,0 +1,426 @@
+package archive
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+const (
+	// maxSymlinkDepth is the maximal amount of symlinks followed while
+	// resolving a path (the same as MAXSYMLINKS of Linux).
+	maxSymlinkDepth = 40
+
+	// modeMask is the part of os.FileMode which may be changed by Chmod.
+	modeMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+	// xattrPrefix is the prefix of the PAX records with the extended
+	// attributes (as written by GNU tar and star).
+	xattrPrefix = "SCHILY.xattr."
+)
+
+// node is an object of the archive (similar to an inode). Directory
+// entries refer to nodes, so a node with several entries is
+// a hardlinked file.
+//
+// All the fields are protected by Storage.locker.
+type node struct {
+	// hdr keeps the attributes of the object; its Name is not used and
+	// the Size of a regular file is the size of its content.
+	hdr   tar.Header
+	ino   uint64
+	nlink uint64
+
+	// content is the content of a regular file (nil if it cannot be
+	// read, see NewTarStorage)
+	content io.ReaderAt
+
+	// spool is the content of a regular file being written to
+	// a writable Storage (until the file is appended to the archive);
+	// writers is the amount of the objects opened for writing.
+	spool   *os.File
+	writers int
+
+	// children, parent and name are used by directories only (they
+	// cannot be hardlinked, so they have a single parent). The parent
+	// of the root is the root itself; the parent of a removed directory
+	// is nil.
+	children map[string]*node
+	parent   *node
+	name     string
+}
+
+// newNode returns a node with the attributes of the header.
+func newNode(hdr *tar.Header) *node {
+	n := &node{hdr: *hdr}
+	n.hdr.Name = ""
+	// the extended attributes are kept in PAXRecords (archive/tar fills
+	// both)
+	n.hdr.Xattrs = nil
+	if n.isDir() {
+		n.children = map[string]*node{}
+	}
+	return n
+}
+
+// newRoot returns the root directory of an archive without an entry
+// for it.
+func newRoot() *node {
+	root := newNode(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Mode:     0755,
+	})
+	root.parent = root
+	root.nlink = 1
+	return root
+}
+
+func (n *node) mode() os.FileMode {
+	return n.hdr.FileInfo().Mode()
+}
+
+func (n *node) isDir() bool {
+	return n.hdr.Typeflag == tar.TypeDir
+}
+
+func (n *node) isSymlink() bool {
+	return n.hdr.Typeflag == tar.TypeSymlink
+}
+
+func (n *node) isRegular() bool {
+	return isRegularType(n.hdr.Typeflag)
+}
+
+func isRegularType(typeflag byte) bool {
+	switch typeflag {
+	case tar.TypeReg, tar.TypeRegA, tar.TypeGNUSparse, tar.TypeCont:
+		return true
+	}
+	return false
+}
+
+// target returns the destination of a symlink.
+func (n *node) target() file.Path {
+	return file.Path(strings.Split(n.hdr.Linkname, "/"))
+}
+
+func (n *node) size() int64 {
+	switch {
+	case n.isSymlink():
+		return int64(len(n.hdr.Linkname))
+	case n.isRegular():
+		return n.hdr.Size
+	}
+	return 0
+}
+
+// path returns the path of the directory.
+func (n *node) path() file.Path {
+	var names []string
+	for cur := n; cur.parent != nil && cur.parent != cur; cur = cur.parent {
+		names = append(names, cur.name)
+	}
+	result := make(file.Path, len(names))
+	for idx, name := range names {
+		result[len(names)-1-idx] = name
+	}
+	return result
+}
+
+// isInside returns true if the directory is `dir` or is inside of it.
+func (n *node) isInside(dir *node) bool {
+	for cur := n; cur != nil; cur = cur.parent {
+		if cur == dir {
+			return true
+		}
+		if cur.parent == cur {
+			break
+		}
+	}
+	return false
+}
+
+func (n *node) chmod(mode os.FileMode) {
+	n.hdr.Mode = n.hdr.Mode&^07777 | tarModeOf(mode)
+}
+
+// chown changes the owner; a negative ID means "do not change". The
+// names of the owner are reset, so the IDs are used on extraction.
+func (n *node) chown(uid, gid int) {
+	if uid >= 0 {
+		n.hdr.Uid = uid
+		n.hdr.Uname = ""
+	}
+	if gid >= 0 {
+		n.hdr.Gid = gid
+		n.hdr.Gname = ""
+	}
+}
+
+// tarModeOf returns the permission bits of the mode as stored in tar
+// headers (c_ISUID and others).
+func tarModeOf(mode os.FileMode) int64 {
+	result := int64(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		result |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		result |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		result |= 01000
+	}
+	return result
+}
+
+// sortedNames returns the names of the entries of the directory in
+// the lexical order, so the results are deterministic.
+func sortedNames(dir *node) []string {
+	names := make([]string, 0, len(dir.children))
+	for name := range dir.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// entry is a directory entry found by Storage.resolveEntry.
+type entry struct {
+	// dir is the directory containing the entry (nil for the root)
+	dir  *node
+	name string
+
+	// node is nil if there is no such entry
+	node *node
+}
+
+func (e entry) path() file.Path {
+	if e.dir == nil {
+		return file.Path{}
+	}
+	return e.dir.path().Append(e.name)
+}
+
+// pathOf returns the path of the name of an entry of an archive. The
+// names are relative to the root of the archive ("/a", "./a" and "a" are
+// the same), and ".." cannot go above the root.
+func pathOf(name string) file.Path {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return file.Path{}
+	}
+	return file.Path(strings.Split(name, "/"))
+}
+
+// nameOf returns the name of the entry of the path in an archive (with
+// the trailing "/" for directories).
+func nameOf(path file.Path, isDir bool) string {
+	if len(path) == 0 {
+		return "./"
+	}
+	name := strings.Join(path, "/")
+	if isDir {
+		name += "/"
+	}
+	return name
+}
+
+func pathError(op string, path file.Path, err error) error {
+	return &os.PathError{
+		Op:   op,
+		Path: path.LocalPath(),
+		Err:  err,
+	}
+}
+
+var _ os.FileInfo = &fileInfo{}
+
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+	hdr   tar.Header
+}
+
+func infoOf(name string, n *node) *fileInfo {
+	if name == "" {
+		name = "/"
+	}
+	info := &fileInfo{
+		name:  name,
+		size:  n.size(),
+		mode:  n.mode(),
+		mtime: n.hdr.ModTime,
+		hdr:   n.hdr,
+	}
+	info.hdr.Name = name
+	info.hdr.Size = n.size()
+	info.hdr.PAXRecords = cloneRecords(n.hdr.PAXRecords)
+	if n.isRegular() {
+		info.hdr.Typeflag = tar.TypeReg
+	}
+	return info
+}
+
+// cloneRecords returns a copy of the PAX records, so the header may be
+// used without Storage.locker.
+func cloneRecords(records map[string]string) map[string]string {
+	if records == nil {
+		return nil
+	}
+	result := make(map[string]string, len(records))
+	for key, value := range records {
+		result[key] = value
+	}
+	return result
+}
+
+func (info *fileInfo) Name() string {
+	return info.name
+}
+
+func (info *fileInfo) Size() int64 {
+	return info.size
+}
+
+func (info *fileInfo) Mode() os.FileMode {
+	return info.mode
+}
+
+func (info *fileInfo) ModTime() time.Time {
+	return info.mtime
+}
+
+func (info *fileInfo) IsDir() bool {
+	return info.mode.IsDir()
+}
+
+// Sys returns *tar.Header with the attributes of the object (the owner,
+// the device numbers, the extended attributes in PAXRecords and
+// others). The objects of zip archives have headers too.
+func (info *fileInfo) Sys() interface{} {
+	return &info.hdr
+}
+
+// resolve returns the node of the path relative to the directory `dir`.
+// Symlinks are followed, except the last component if noFollow is true.
+func (stor *Storage) resolve(dir *node, path file.Path, noFollow bool, depth *int) (*node, error) {
+	cur := dir
+	for idx, name := range path {
+		if idx == 0 && name == "" {
+			// an absolute path
+			cur = stor.root
+			continue
+		}
+		if !cur.isDir() {
+			return nil, syscall.ENOTDIR
+		}
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			if cur.parent != nil {
+				cur = cur.parent
+			}
+			continue
+		}
+		child := cur.children[name]
+		if child == nil {
+			return nil, syscall.ENOENT
+		}
+		if child.isSymlink() && !(noFollow && idx == len(path)-1) {
+			*depth++
+			if *depth > maxSymlinkDepth {
+				return nil, syscall.ELOOP
+			}
+			var err error
+			child, err = stor.resolve(cur, child.target(), false, depth)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cur = child
+	}
+	return cur, nil
+}
+
+// resolveEntry returns the directory entry of the path relative to
+// the directory `dir`. The node of the entry is nil if the entry does
+// not exist (but its directory does). A symlink in the last component
+// is followed unless noFollow is true.
+func (stor *Storage) resolveEntry(dir *node, path file.Path, noFollow bool, depth *int) (entry, error) {
+	if len(path) == 0 {
+		return stor.entryOf(dir), nil
+	}
+	name := path[len(path)-1]
+	switch name {
+	case "", ".", "..":
+		n, err := stor.resolve(dir, path, false, depth)
+		if err != nil {
+			return entry{}, err
+		}
+		return stor.entryOf(n), nil
+	}
+
+	parent, err := stor.resolve(dir, path[:len(path)-1], false, depth)
+	if err != nil {
+		return entry{}, err
+	}
+	if !parent.isDir() {
+		return entry{}, syscall.ENOTDIR
+	}
+	child := parent.children[name]
+	if child != nil && child.isSymlink() && !noFollow {
+		*depth++
+		if *depth > maxSymlinkDepth {
+			return entry{}, syscall.ELOOP
+		}
+		return stor.resolveEntry(parent, child.target(), false, depth)
+	}
+	return entry{dir: parent, name: name, node: child}, nil
+}
+
+// entryOf returns the entry of the directory in its parent.
+func (stor *Storage) entryOf(dir *node) entry {
+	if dir == stor.root || dir.parent == nil {
+		return entry{node: dir}
+	}
+	return entry{dir: dir.parent, name: dir.name, node: dir}
+}
+
+// link adds the entry of the node to the directory (replacing
+// the existing one).
+func (stor *Storage) link(dir *node, name string, n *node) {
+	stor.unlink(dir, name)
+	if n.ino == 0 {
+		stor.lastIno++
+		n.ino = stor.lastIno
+	}
+	dir.children[name] = n
+	n.nlink++
+	if n.isDir() {
+		n.parent = dir
+		n.name = name
+	}
+}
+
+// unlink removes the entry from the directory.
+func (stor *Storage) unlink(dir *node, name string) {
+	n := dir.children[name]
+	if n == nil {
+		return
+	}
+	delete(dir.children, name)
+	n.nlink--
+	if n.isDir() {
+		n.parent = nil
+	}
+}
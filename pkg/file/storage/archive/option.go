@@ -0,0 +1,41 @@
+package archive
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionWhiteouts struct {
+	Enable bool
+}
+
+func (opt OptionWhiteouts) apply(cfg *Config) {
+	cfg.Whiteouts = opt.Enable
+}
+
+type OptionGzip struct {
+	Enable bool
+}
+
+func (opt OptionGzip) apply(cfg *Config) {
+	cfg.Gzip = opt.Enable
+}
+
+type OptionFlushDelay struct {
+	Delay time.Duration
+}
+
+func (opt OptionFlushDelay) apply(cfg *Config) {
+	cfg.FlushDelay = opt.Delay
+}
+
+type OptionNowFunc struct {
+	Func func() time.Time
+}
+
+func (opt OptionNowFunc) apply(cfg *Config) {
+	cfg.NowFunc = opt.Func
+}
@@ -0,0 +1,24 @@
+package archive
+
+import (
+	"context"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.PathDescriptor = &PathDescriptor{}
+
+// PathDescriptor is a regular file opened with file.FlagPath, or
+// a device or a named pipe (they have no content in archives).
+type PathDescriptor struct {
+	Object
+}
+
+func (pathDesc *PathDescriptor) Open(
+	ctx context.Context,
+	mask file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return pathDesc.StorageValue.Open(ctx, nil, pathDesc.Path(), mask&^file.FlagPath, defaultPerm)
+}
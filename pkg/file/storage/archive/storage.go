@@ -0,0 +1,330 @@
+package archive
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Storage = &Storage{}
+
+// lastDev is the last device number assigned to a Storage, so objects
+// of different storages have different IDs.
+var lastDev uint64
+
+// Storage is a file.Storage of an archive.
+//
+// A Storage returned by NewStorage or OpenStorage is read-only: it
+// indexes a tar (optionally compressed by gzip or bzip2) or zip archive
+// and reads the content of the files from it. The content of
+// uncompressed tar archives and of stored zip entries is read at random
+// offsets; compressed content is decompressed sequentially (reading
+// backward restarts the decompression).
+//
+// A Storage returned by NewTarStorage or OpenTarStorage is writable: it
+// appends entries to a tar archive as the objects are modified (see
+// NewTarStorage).
+type Storage struct {
+	Config
+	dev uint64
+
+	// locker protects the fields below and all the nodes
+	locker  sync.Mutex
+	root    *node
+	lastIno uint64
+	streams []*stream
+	closer  io.Closer
+
+	// writer is nil if the storage is read-only
+	writer *writer
+}
+
+func newStorage(opts []Option) *Storage {
+	stor := &Storage{
+		dev:  atomic.AddUint64(&lastDev, 1),
+		root: newRoot(),
+	}
+	for _, opt := range opts {
+		opt.apply(&stor.Config)
+	}
+	stor.lastIno++
+	stor.root.ino = stor.lastIno
+	return stor
+}
+
+// NewStorage returns a read-only Storage of the archive of the size.
+// The format is detected by DetectFormat.
+func NewStorage(r io.ReaderAt, size int64, opts ...Option) (*Storage, error) {
+	stor := newStorage(opts)
+	if err := stor.index(r, size); err != nil {
+		_ = stor.Close()
+		return nil, err
+	}
+	return stor, nil
+}
+
+// OpenStorage returns a read-only Storage of the archive file. The file
+// is closed by Close.
+func OpenStorage(path string, opts ...Option) (*Storage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	stor, err := NewStorage(f, info.Size(), opts...)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("unable to index '%s': %w", path, err)
+	}
+	stor.closer = f
+	return stor, nil
+}
+
+// index adds the entries of the archive to the index.
+func (stor *Storage) index(r io.ReaderAt, size int64) error {
+	format, err := DetectFormat(r)
+	if err != nil {
+		return err
+	}
+	if format == FormatZip {
+		return stor.indexZip(r, size)
+	}
+	ra := r
+	if format != FormatTar {
+		ra = nil
+	}
+	_, err = stor.indexTar(openDecompressed(r, size, format), ra)
+	return err
+}
+
+// Close appends the pending entries and finishes the archive (if
+// the storage is writable). Then the archive is closed if it was opened
+// by the storage.
+func (stor *Storage) Close() error {
+	var result error
+	if stor.writer != nil {
+		result = stor.closeWriter()
+	}
+
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	for _, s := range stor.streams {
+		if err := s.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+	stor.streams = nil
+	if stor.closer != nil {
+		if err := stor.closer.Close(); err != nil && result == nil {
+			result = err
+		}
+		stor.closer = nil
+	}
+	return result
+}
+
+// ToAbsPath returns the path relative to the root of the archive.
+func (stor *Storage) ToAbsPath(pathRel file.Path) file.Path {
+	return file.Path{""}.Append(pathRel...)
+}
+
+// ToLocalPath returns the path as if the archive was extracted to "/".
+func (stor *Storage) ToLocalPath(path file.Path) string {
+	return stor.ToAbsPath(path).LocalPath()
+}
+
+// baseNode returns the directory the paths are relative to.
+func (stor *Storage) baseNode(dirAt file.Object) (*node, error) {
+	if dirAt == nil {
+		return stor.root, nil
+	}
+	dir, ok := dirAt.(*Directory)
+	if !ok || dir.StorageValue != stor {
+		return nil, file.ErrNotImplemented{}
+	}
+	return dir.node, nil
+}
+
+func (stor *Storage) objectID(n *node) file.ObjectID {
+	return file.ObjectID{
+		Dev: stor.dev,
+		Ino: n.ino,
+	}
+}
+
+func (stor *Storage) Open(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	select {
+	case <-ctx.Done():
+		return nil, file.ErrAborted{}
+	default:
+	}
+
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := stor.open(base, path, flags, defaultPerm)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open '%s': %w",
+			path.LocalPath(), pathError("open", path, err))
+	}
+
+	obj := Object{
+		StorageValue: stor,
+		LastInfo:     infoOf(e.name, e.node),
+		LastPath:     e.path(),
+		node:         e.node,
+		flags:        flags,
+	}
+	switch {
+	case e.node.isDir():
+		return &Directory{Object: obj}, nil
+	case e.node.isSymlink():
+		return &Symlink{Object: obj}, nil
+	case flags.HasPath() || !e.node.isRegular():
+		return &PathDescriptor{Object: obj}, nil
+	}
+	return &File{Object: obj}, nil
+}
+
+func (stor *Storage) open(
+	base *node,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (entry, error) {
+	if isWritable(flags) || flags.HasCreate() || flags.HasTrunc() {
+		if err := stor.checkWritable(); err != nil {
+			return entry{}, err
+		}
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, flags.HasNoFollow(), &depth)
+	if err != nil {
+		return entry{}, err
+	}
+
+	isCreated := false
+	switch {
+	case e.node == nil && !flags.HasCreate():
+		return entry{}, syscall.ENOENT
+	case e.node == nil:
+		e.node = newNode(stor.newHeader(tar.TypeReg, defaultPerm))
+		stor.link(e.dir, e.name, e.node)
+		isCreated = true
+	case flags.HasCreate() && flags.HasExcl():
+		return entry{}, syscall.EEXIST
+	}
+
+	switch {
+	case flags.HasPath():
+		return e, nil
+	case e.node.isSymlink():
+		// the same as O_NOFOLLOW without O_PATH
+		return entry{}, syscall.ELOOP
+	case e.node.isDir() && isWritable(flags):
+		return entry{}, syscall.EISDIR
+	case !e.node.isRegular() && isWritable(flags):
+		// devices and named pipes are not emulated
+		return entry{}, file.ErrNotImplemented{}
+	}
+
+	if isWritable(flags) {
+		if err := stor.openForWrite(e, flags.HasTrunc() || isCreated); err != nil {
+			if isCreated {
+				stor.unlink(e.dir, e.name)
+			}
+			return entry{}, err
+		}
+	}
+	return e, nil
+}
+
+// isWritable returns true if the object is opened for writing.
+func isWritable(flags file.OpenFlag) bool {
+	return flags&(file.FlagWrite|file.FlagAppend) != 0
+}
+
+func (stor *Storage) Stat(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	noFollow bool,
+) (os.FileInfo, error) {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, noFollow, &depth)
+	if err == nil && e.node == nil {
+		err = syscall.ENOENT
+	}
+	if err != nil {
+		return nil, pathError("stat", path, err)
+	}
+	return infoOf(e.name, e.node), nil
+}
+
+func (stor *Storage) Readlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+) (file.Path, error) {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, true, &depth)
+	switch {
+	case err != nil:
+	case e.node == nil:
+		err = syscall.ENOENT
+	case !e.node.isSymlink():
+		err = syscall.EINVAL
+	}
+	if err != nil {
+		return nil, pathError("readlink", path, err)
+	}
+	return e.node.target(), nil
+}
+
+// checkWritable returns EROFS for a read-only storage and the error of
+// the last write to the archive (after which it cannot be continued).
+func (stor *Storage) checkWritable() error {
+	if stor.writer == nil {
+		return syscall.EROFS
+	}
+	return stor.writer.err
+}
@@ -0,0 +1,215 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// tarOf returns the tar archive of the entries (the content of regular
+// files is their Linkname).
+func tarOf(t *testing.T, hdrs ...*tar.Header) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range hdrs {
+		content := ""
+		if hdr.Typeflag == tar.TypeReg {
+			content, hdr.Linkname = hdr.Linkname, ""
+			hdr.Size = int64(len(content))
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func testTar(t *testing.T) []byte {
+	mtime := time.Unix(1000, 0)
+	return tarOf(t,
+		&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0700, ModTime: mtime},
+		&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0750, Uid: 1, Gid: 2, ModTime: mtime},
+		&tar.Header{Name: "dir/file", Typeflag: tar.TypeReg, Mode: 0640, ModTime: mtime, Linkname: "hello",
+			PAXRecords: map[string]string{xattrPrefix + "user.key": "value"}},
+		&tar.Header{Name: "dir/gone", Typeflag: tar.TypeReg, Mode: 0640, Linkname: "gone"},
+		&tar.Header{Name: "dir/.wh.gone", Typeflag: tar.TypeReg},
+		&tar.Header{Name: "symlink", Typeflag: tar.TypeSymlink, Linkname: "dir/file", Mode: 0777},
+		&tar.Header{Name: "hardlink", Typeflag: tar.TypeLink, Linkname: "dir/file"},
+		&tar.Header{Name: "implicit/sub/file", Typeflag: tar.TypeReg, Mode: 0600, Linkname: "world"},
+		&tar.Header{Name: "dev/null", Typeflag: tar.TypeChar, Mode: 0666, Devmajor: 1, Devminor: 3},
+	)
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	archive := testTar(t)
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err := gw.Write(archive)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	for name, content := range map[string][]byte{"tar": archive, "tar.gz": gzipped.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			stor, err := NewStorage(bytes.NewReader(content), int64(len(content)), OptionWhiteouts{Enable: true})
+			require.NoError(t, err)
+			defer func() { require.NoError(t, stor.Close()) }()
+
+			t.Run("file", func(t *testing.T) {
+				require.Equal(t, "hello", storagetest.ReadFile(t, stor, file.Path{"dir", "file"}))
+				require.Equal(t, "world", storagetest.ReadFile(t, stor, file.Path{"implicit", "sub", "file"}))
+				// backward (restarting the decompression)
+				require.Equal(t, "hello", storagetest.ReadFile(t, stor, file.Path{"dir", "file"}))
+
+				info, err := stor.Stat(ctx, nil, file.Path{"dir", "file"}, false)
+				require.NoError(t, err)
+				require.Equal(t, int64(5), info.Size())
+				require.Equal(t, os.FileMode(0640), info.Mode())
+				require.Equal(t, "value", info.Sys().(*tar.Header).PAXRecords[xattrPrefix+"user.key"])
+			})
+			t.Run("dir", func(t *testing.T) {
+				info, err := stor.Stat(ctx, nil, file.Path{}, false)
+				require.NoError(t, err)
+				require.Equal(t, os.ModeDir|0700, info.Mode())
+
+				obj, err := stor.Open(ctx, nil, file.Path{"dir"}, file.FlagRead, 0000)
+				require.NoError(t, err)
+				infos, err := obj.(*Directory).Readdir(-1)
+				require.NoError(t, err)
+				require.Len(t, infos, 1)
+				require.Equal(t, "file", infos[0].Name())
+				hdr := obj.LastStat().Sys().(*tar.Header)
+				require.Equal(t, 1, hdr.Uid)
+				require.Equal(t, 2, hdr.Gid)
+				require.NoError(t, obj.Close())
+
+				info, err = stor.Stat(ctx, nil, file.Path{"implicit"}, false)
+				require.NoError(t, err)
+				require.True(t, info.IsDir())
+			})
+			t.Run("links", func(t *testing.T) {
+				target, err := stor.Readlink(ctx, nil, file.Path{"symlink"})
+				require.NoError(t, err)
+				require.Equal(t, file.Path{"dir", "file"}, target)
+				require.Equal(t, "hello", storagetest.ReadFile(t, stor, file.Path{"symlink"}))
+				require.Equal(t, "hello", storagetest.ReadFile(t, stor, file.Path{"hardlink"}))
+
+				fileObj, err := stor.Open(ctx, nil, file.Path{"dir", "file"}, file.FlagRead, 0000)
+				require.NoError(t, err)
+				linkObj, err := stor.Open(ctx, nil, file.Path{"hardlink"}, file.FlagRead, 0000)
+				require.NoError(t, err)
+				require.True(t, fileObj.ID().SameObject(linkObj.ID()))
+				require.NoError(t, fileObj.Close())
+				require.NoError(t, linkObj.Close())
+			})
+			t.Run("device", func(t *testing.T) {
+				info, err := stor.Stat(ctx, nil, file.Path{"dev", "null"}, false)
+				require.NoError(t, err)
+				require.Equal(t, os.ModeDevice|os.ModeCharDevice|0666, info.Mode())
+				require.Equal(t, int64(3), info.Sys().(*tar.Header).Devminor)
+			})
+			t.Run("read_only", func(t *testing.T) {
+				_, err := stor.Open(ctx, nil, file.Path{"dir", "file"}, file.FlagReadWrite, 0000)
+				require.True(t, errors.Is(err, syscall.EROFS), err)
+				err = stor.Mkdir(ctx, nil, file.Path{"new"}, 0755, false)
+				require.True(t, errors.Is(err, syscall.EROFS), err)
+				err = stor.Remove(ctx, nil, file.Path{"dir"}, true)
+				require.True(t, errors.Is(err, syscall.EROFS), err)
+				err = stor.Rename(ctx, nil, file.Path{"dir"}, file.Path{"new"})
+				require.True(t, errors.Is(err, syscall.EROFS), err)
+				require.Error(t, stor.Flush())
+			})
+		})
+	}
+}
+
+func TestStorageZip(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "dir/", Method: zip.Store})
+	require.NoError(t, err)
+	_, err = w.Write(nil)
+	require.NoError(t, err)
+	w, err = zw.CreateHeader(&zip.FileHeader{Name: "dir/stored", Method: zip.Store})
+	require.NoError(t, err)
+	_, err = w.Write([]byte("stored"))
+	require.NoError(t, err)
+	w, err = zw.CreateHeader(&zip.FileHeader{Name: "deflated", Method: zip.Deflate})
+	require.NoError(t, err)
+	_, err = w.Write(bytes.Repeat([]byte("deflated"), 100))
+	require.NoError(t, err)
+	hdr := &zip.FileHeader{Name: "symlink", Method: zip.Store}
+	hdr.SetMode(os.ModeSymlink | 0777)
+	w, err = zw.CreateHeader(hdr)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("dir/stored"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	format, err := DetectFormat(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, FormatZip, format)
+
+	stor, err := NewStorage(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, stor.Close()) }()
+
+	require.Equal(t, "stored", storagetest.ReadFile(t, stor, file.Path{"dir", "stored"}))
+	require.Equal(t, "stored", storagetest.ReadFile(t, stor, file.Path{"symlink"}))
+	require.Equal(t, string(bytes.Repeat([]byte("deflated"), 100)), storagetest.ReadFile(t, stor, file.Path{"deflated"}))
+
+	obj, err := stor.Open(ctx, nil, file.Path{"deflated"}, file.FlagRead, 0000)
+	require.NoError(t, err)
+	b := make([]byte, 8)
+	_, err = obj.(*File).ReadAt(b, 16)
+	require.NoError(t, err)
+	_, err = obj.(*File).ReadAt(b, 8)
+	require.NoError(t, err)
+	require.Equal(t, "deflated", string(b))
+	require.NoError(t, obj.Close())
+}
+
+func TestDetectFormat(t *testing.T) {
+	// a tar archive with the first entry named as the magic of bzip2
+	archive := tarOf(t, &tar.Header{Name: "BZh9", Typeflag: tar.TypeReg, Linkname: "content"})
+	format, err := DetectFormat(bytes.NewReader(archive))
+	require.NoError(t, err)
+	require.Equal(t, FormatTar, format)
+
+	format, err = DetectFormat(bytes.NewReader([]byte{0x1f, 0x8b, 8}))
+	require.NoError(t, err)
+	require.Equal(t, FormatTarGzip, format)
+}
+
+func TestPathOf(t *testing.T) {
+	require.Equal(t, file.Path{}, pathOf("./"))
+	require.Equal(t, file.Path{}, pathOf("/"))
+	require.Equal(t, file.Path{"a", "b"}, pathOf("./a/b/"))
+	require.Equal(t, file.Path{"b"}, pathOf("../a/../b"))
+	require.Equal(t, "./", nameOf(file.Path{}, true))
+	require.Equal(t, "a/b/", nameOf(file.Path{"a", "b"}, true))
+}
+
+func TestOwnerOfZipExtra(t *testing.T) {
+	// "ux", size 11, version 1, 4-byte UID 1000, 4-byte GID 100
+	extra := []byte{0x75, 0x78, 11, 0, 1, 4, 0xe8, 0x03, 0, 0, 4, 100, 0, 0, 0}
+	uid, gid := ownerOfZipExtra(extra)
+	require.Equal(t, 1000, uid)
+	require.Equal(t, 100, gid)
+	uid, gid = ownerOfZipExtra([]byte{1, 2, 3})
+	require.Zero(t, uid)
+	require.Zero(t, gid)
+}
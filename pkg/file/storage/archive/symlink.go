@@ -0,0 +1,25 @@
+package archive
+
+import (
+	"context"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.SymLink = &Symlink{}
+
+type Symlink struct {
+	Object
+}
+
+func (symlink *Symlink) Destination() (file.Path, error) {
+	stor := symlink.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	return symlink.node.target(), nil
+}
+
+func (symlink *Symlink) Open(ctx context.Context, flags file.OpenFlag, defaultPerms os.FileMode) (file.Object, error) {
+	return symlink.StorageValue.Open(ctx, nil, symlink.Path(), flags&^(file.FlagNoFollow|file.FlagPath), defaultPerms)
+}
@@ -0,0 +1,516 @@
+package archive
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// NewTarStorage returns a writable Storage which appends the entries to
+// the tar archive written to `w` (from its beginning).
+//
+// Tar archives cannot be modified in place, so each modified object is
+// appended again (the later entries replace the earlier ones on
+// extraction). The entry is appended when another object is modified
+// (so consecutive modifications of the same object produce a single
+// entry), after Config.FlushDelay, on Flush and on Close. A file opened
+// for writing is appended after it is closed. Removed and renamed
+// objects are expressed by whiteout entries if Config.Whiteouts is
+// enabled (otherwise the old paths remain in the archive).
+//
+// Symlinks, hardlinks, devices, named pipes, modes, owners, timestamps
+// and extended attributes (PAX records "SCHILY.xattr.*", see SetXattr)
+// are preserved.
+//
+// The content of the files being written is kept in temporary files.
+// After a file is appended, its content is read back from the archive
+// if `w` is an io.ReaderAt (such as *os.File) and Config.Gzip is
+// disabled; otherwise the file cannot be read or modified any more (it
+// may only be replaced), and file.ErrNotImplemented is returned.
+func NewTarStorage(w io.Writer, opts ...Option) *Storage {
+	stor := newStorage(opts)
+	readerAt, _ := w.(io.ReaderAt)
+	stor.writer = newWriter(w, 0, readerAt, stor.Gzip)
+	return stor
+}
+
+// OpenTarStorage returns a writable Storage of the archive file (see
+// NewTarStorage). An existing uncompressed archive is indexed and
+// continued: its end is overwritten by the new entries. The file is
+// closed by Close.
+func OpenTarStorage(path string, opts ...Option) (*Storage, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	stor := newStorage(opts)
+	offset, err := stor.resume(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("unable to continue the archive '%s': %w", path, err)
+	}
+	stor.writer = newWriter(f, offset, f, stor.Gzip)
+	stor.closer = f
+	return stor, nil
+}
+
+// resume indexes the existing archive and returns the offset to
+// continue it from.
+func (stor *Storage) resume(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() == 0 {
+		return 0, nil
+	}
+	format, err := DetectFormat(f)
+	if err != nil {
+		return 0, err
+	}
+	if format != FormatTar || stor.Gzip {
+		return 0, fmt.Errorf("appending to a compressed archive (%s) is not supported", format)
+	}
+	offset, err := stor.indexTar(openDecompressed(f, info.Size(), format), f)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.Truncate(offset); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+func (stor *Storage) Symlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	destination file.Path,
+) error {
+	hdr := stor.newHeader(tar.TypeSymlink, os.ModePerm)
+	hdr.Linkname = strings.Join(destination, "/")
+	return stor.create("symlink", dirAt, path, hdr)
+}
+
+// Mknod creates a device or a named pipe (the type is defined by
+// the bits os.ModeDevice, os.ModeCharDevice and os.ModeNamedPipe of
+// the mode).
+func (stor *Storage) Mknod(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	mode os.FileMode,
+	major, minor int64,
+) error {
+	var typeflag byte
+	switch {
+	case mode&os.ModeCharDevice != 0:
+		typeflag = tar.TypeChar
+	case mode&os.ModeDevice != 0:
+		typeflag = tar.TypeBlock
+	case mode&os.ModeNamedPipe != 0:
+		typeflag = tar.TypeFifo
+	default:
+		return pathError("mknod", path, syscall.EINVAL)
+	}
+	hdr := stor.newHeader(typeflag, mode)
+	if typeflag != tar.TypeFifo {
+		hdr.Devmajor, hdr.Devminor = major, minor
+	}
+	return stor.create("mknod", dirAt, path, hdr)
+}
+
+// create adds the new object (a symlink, a device or a named pipe).
+func (stor *Storage) create(op string, dirAt file.Object, path file.Path, hdr *tar.Header) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	if err := stor.checkWritable(); err != nil {
+		return pathError(op, path, err)
+	}
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, true, &depth)
+	if err == nil && e.node != nil {
+		err = syscall.EEXIST
+	}
+	if err != nil {
+		return pathError(op, path, err)
+	}
+
+	n := newNode(hdr)
+	stor.link(e.dir, e.name, n)
+	stor.markDirty(e.path(), n)
+	return nil
+}
+
+func (stor *Storage) Mkdir(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	perms os.FileMode,
+	recursive bool,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	if err := stor.checkWritable(); err != nil {
+		return pathError("mkdir", path, err)
+	}
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	if !recursive {
+		if err := stor.mkdir(base, path, perms, false); err != nil {
+			return pathError("mkdir", path, err)
+		}
+		return nil
+	}
+
+	for idx := range path {
+		if err := stor.mkdir(base, path[:idx+1], perms, true); err != nil {
+			return pathError("mkdir", path, err)
+		}
+	}
+	return nil
+}
+
+// mkdir creates the directory. If mayExist is true then an existing
+// directory is not an error.
+func (stor *Storage) mkdir(base *node, path file.Path, perms os.FileMode, mayExist bool) error {
+	var depth int
+	e, err := stor.resolveEntry(base, path, !mayExist, &depth)
+	switch {
+	case err != nil:
+		return err
+	case e.node == nil:
+	case mayExist && e.node.isDir():
+		return nil
+	case mayExist:
+		return syscall.ENOTDIR
+	default:
+		return syscall.EEXIST
+	}
+
+	n := newNode(stor.newHeader(tar.TypeDir, perms))
+	stor.link(e.dir, e.name, n)
+	stor.markDirty(e.path(), n)
+	return nil
+}
+
+func (stor *Storage) Remove(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	isRecursive bool,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	if err := stor.checkWritable(); err != nil {
+		return pathError("remove", path, err)
+	}
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, true, &depth)
+	switch {
+	case isRecursive && (file.IsNotExist(err) || err == nil && e.node == nil):
+		return nil
+	case err != nil:
+	case e.node == nil:
+		err = syscall.ENOENT
+	case e.dir == nil:
+		err = syscall.EBUSY
+	case !isRecursive && e.node.isDir() && len(e.node.children) > 0:
+		err = syscall.ENOTEMPTY
+	}
+	if err != nil {
+		return pathError("remove", path, err)
+	}
+
+	removedPath := e.path()
+	stor.removeTree(e)
+	if err := stor.appendWhiteout(removedPath); err != nil {
+		stor.writer.err = err
+		return pathError("remove", path, err)
+	}
+	return nil
+}
+
+// removeTree removes the entry from the index (the pending entries of
+// the removed objects are dropped by appendNode).
+func (stor *Storage) removeTree(e entry) {
+	if e.node.isDir() {
+		for _, name := range sortedNames(e.node) {
+			stor.removeTree(entry{dir: e.node, name: name, node: e.node.children[name]})
+		}
+	}
+	stor.unlink(e.dir, e.name)
+}
+
+func (stor *Storage) Rename(
+	ctx context.Context,
+	dirAt file.Object,
+	path, newPath file.Path,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	err := stor.checkWritable()
+	if err == nil {
+		var base *node
+		base, err = stor.baseNode(dirAt)
+		if err != nil {
+			return err
+		}
+		err = stor.rename(base, path, newPath)
+	}
+	if err != nil {
+		return &os.LinkError{
+			Op:  "rename",
+			Old: path.LocalPath(),
+			New: newPath.LocalPath(),
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// rename moves the object in the index and appends it by the new path:
+// the directories, symlinks and others are appended again, the regular
+// files are appended as hardlinks to their old paths. Then the old path
+// is whiteouted.
+func (stor *Storage) rename(base *node, path, newPath file.Path) error {
+	var depth int
+	src, err := stor.resolveEntry(base, path, true, &depth)
+	if err != nil {
+		return err
+	}
+	dst, err := stor.resolveEntry(base, newPath, true, &depth)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case src.node == nil:
+		return syscall.ENOENT
+	case src.dir == nil || dst.dir == nil:
+		return syscall.EBUSY
+	case src.node == dst.node:
+		// the same entry or hardlinks of the same file
+		return nil
+	case src.node.isDir() && dst.dir.isInside(src.node):
+		return syscall.EINVAL
+	case dst.node == nil:
+	case src.node.isDir() && !dst.node.isDir():
+		return syscall.ENOTDIR
+	case src.node.isDir() && len(dst.node.children) > 0:
+		return syscall.ENOTEMPTY
+	case !src.node.isDir() && dst.node.isDir():
+		return syscall.EISDIR
+	}
+
+	// the old paths in the archive should be the current ones
+	stor.appendPending(nil, false)
+	if err := stor.writer.err; err != nil {
+		return err
+	}
+
+	srcPath := src.path()
+	isReplacing := dst.node != nil
+	if isReplacing {
+		stor.removeTree(dst)
+	}
+	stor.unlink(src.dir, src.name)
+	stor.link(dst.dir, dst.name, src.node)
+	dstPath := dst.path()
+
+	err = nil
+	if isReplacing {
+		err = stor.appendWhiteout(dstPath)
+	}
+	if err == nil {
+		err = stor.appendTree(dstPath, srcPath, src.node)
+	}
+	if err == nil {
+		err = stor.appendWhiteout(srcPath)
+	}
+	if err != nil {
+		stor.writer.err = err
+	}
+	return err
+}
+
+func (stor *Storage) Link(
+	ctx context.Context,
+	dirAt file.Object,
+	path, destination file.Path,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	err := stor.checkWritable()
+	if err == nil {
+		var base *node
+		base, err = stor.baseNode(dirAt)
+		if err != nil {
+			return err
+		}
+		err = stor.hardlink(base, path, destination)
+	}
+	if err != nil {
+		return &os.LinkError{
+			Op:  "link",
+			Old: path.LocalPath(),
+			New: destination.LocalPath(),
+			Err: err,
+		}
+	}
+	return nil
+}
+
+// hardlink adds the entry `destination` of the object at `path`.
+func (stor *Storage) hardlink(base *node, path, destination file.Path) error {
+	var depth int
+	src, err := stor.resolveEntry(base, path, true, &depth)
+	if err != nil {
+		return err
+	}
+	dst, err := stor.resolveEntry(base, destination, true, &depth)
+	switch {
+	case err != nil:
+		return err
+	case src.node == nil:
+		return syscall.ENOENT
+	case src.node.isDir():
+		return syscall.EPERM
+	case dst.node != nil:
+		return syscall.EEXIST
+	}
+
+	stor.appendPending(nil, false)
+	if err := stor.writer.err; err != nil {
+		return err
+	}
+	stor.link(dst.dir, dst.name, src.node)
+	if stor.isPending(src.node) {
+		// all the paths are appended with the file
+		return nil
+	}
+	_, err = stor.writeEntry(linkHeaderOf(dst.path(), src.path(), src.node), nil)
+	if err != nil {
+		stor.writer.err = err
+	}
+	return err
+}
+
+// modify resolves the path and calls `fn` for the found node.
+func (stor *Storage) modify(
+	op string,
+	dirAt file.Object,
+	path file.Path,
+	noFollow bool,
+	fn func(n *node),
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	if err := stor.checkWritable(); err != nil {
+		return pathError(op, path, err)
+	}
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, noFollow, &depth)
+	if err == nil && e.node == nil {
+		err = syscall.ENOENT
+	}
+	if err == nil {
+		err = stor.modifyNode(e.path(), e.node, fn)
+	}
+	if err != nil {
+		return pathError(op, path, err)
+	}
+	return nil
+}
+
+func (stor *Storage) Chmod(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	mode os.FileMode,
+) error {
+	return stor.modify("chmod", dirAt, path, false, func(n *node) {
+		n.chmod(mode)
+	})
+}
+
+func (stor *Storage) Chown(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	uid, gid int,
+	noFollow bool,
+) error {
+	return stor.modify("chown", dirAt, path, noFollow, func(n *node) {
+		n.chown(uid, gid)
+	})
+}
+
+func (stor *Storage) Chtimes(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	atime, mtime time.Time,
+) error {
+	return stor.modify("chtimes", dirAt, path, false, func(n *node) {
+		n.hdr.AccessTime, n.hdr.ModTime = atime, mtime
+	})
+}
+
+// SetXattr sets the extended attribute of the object (it is stored as
+// the PAX record "SCHILY.xattr.<name>", the same as by GNU tar). A nil
+// value removes the attribute. The attributes are returned in
+// the PAXRecords of the *tar.Header returned by os.FileInfo.Sys.
+func (stor *Storage) SetXattr(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	name string,
+	value []byte,
+	noFollow bool,
+) error {
+	return stor.modify("setxattr", dirAt, path, noFollow, func(n *node) {
+		if value == nil {
+			delete(n.hdr.PAXRecords, xattrPrefix+name)
+			return
+		}
+		if n.hdr.PAXRecords == nil {
+			n.hdr.PAXRecords = map[string]string{}
+		}
+		n.hdr.PAXRecords[xattrPrefix+name] = string(value)
+	})
+}
@@ -0,0 +1,219 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/memfs"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// entriesOf returns the names of the entries of the tar archive.
+func entriesOf(t *testing.T, r io.Reader) []string {
+	var result []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return result
+		}
+		require.NoError(t, err)
+		result = append(result, hdr.Name)
+	}
+}
+
+func entriesOfFile(t *testing.T, path string) []string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	return entriesOf(t, f)
+}
+
+func TestTarStorage(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "archive-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archivePath := filepath.Join(dir, "archive.tar")
+	mtime := time.Unix(1000, 0)
+
+	stor, err := OpenTarStorage(archivePath, OptionWhiteouts{Enable: true})
+	require.NoError(t, err)
+
+	t.Run("create", func(t *testing.T) {
+		require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0750, false))
+		storagetest.WriteFile(t, stor, file.Path{"dir", "file"}, "hello")
+		require.NoError(t, stor.Chmod(ctx, nil, file.Path{"dir", "file"}, 0640))
+		require.NoError(t, stor.Chown(ctx, nil, file.Path{"dir", "file"}, 1, 2, false))
+		require.NoError(t, stor.Chtimes(ctx, nil, file.Path{"dir", "file"}, mtime, mtime))
+		require.NoError(t, stor.SetXattr(ctx, nil, file.Path{"dir", "file"}, "user.key", []byte("value"), false))
+		require.NoError(t, stor.Symlink(ctx, nil, file.Path{"symlink"}, file.Path{"dir", "file"}))
+		require.NoError(t, stor.Mknod(ctx, nil, file.Path{"null"}, os.ModeDevice|os.ModeCharDevice|0666, 1, 3))
+		require.NoError(t, stor.Flush())
+
+		// the metadata changes of the file are coalesced
+		require.Equal(t, []string{"dir/", "dir/file", "symlink", "null"}, entriesOfFile(t, archivePath))
+
+		// the content is read back from the archive
+		require.Equal(t, "hello", storagetest.ReadFile(t, stor, file.Path{"symlink"}))
+	})
+
+	t.Run("open_for_write", func(t *testing.T) {
+		obj, err := stor.Open(ctx, nil, file.Path{"dir", "file"}, file.FlagReadWrite, 0000)
+		require.NoError(t, err)
+		_, err = obj.(*File).WriteAt([]byte("J"), 0)
+		require.NoError(t, err)
+		require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"other"}, 0755, false))
+		require.NoError(t, stor.Flush())
+		// not appended until closed
+		require.NotContains(t, entriesOfFile(t, archivePath)[4:], "dir/file")
+		require.Equal(t, "Jello", storagetest.ReadFile(t, stor, file.Path{"dir", "file"}))
+		require.NoError(t, obj.Close())
+		require.NoError(t, stor.Flush())
+		require.Contains(t, entriesOfFile(t, archivePath)[4:], "dir/file")
+	})
+
+	t.Run("link_rename_remove", func(t *testing.T) {
+		require.NoError(t, stor.Link(ctx, nil, file.Path{"dir", "file"}, file.Path{"hardlink"}))
+		require.NoError(t, stor.Rename(ctx, nil, file.Path{"dir"}, file.Path{"renamed"}))
+		require.NoError(t, stor.Remove(ctx, nil, file.Path{"other"}, false))
+		require.NoError(t, stor.Close())
+	})
+
+	t.Run("read", func(t *testing.T) {
+		stor, err := OpenStorage(archivePath, OptionWhiteouts{Enable: true})
+		require.NoError(t, err)
+		defer func() { require.NoError(t, stor.Close()) }()
+
+		require.Equal(t, "Jello", storagetest.ReadFile(t, stor, file.Path{"renamed", "file"}))
+		require.Equal(t, "Jello", storagetest.ReadFile(t, stor, file.Path{"hardlink"}))
+		_, err = stor.Stat(ctx, nil, file.Path{"dir"}, false)
+		require.True(t, file.IsNotExist(err), err)
+		_, err = stor.Stat(ctx, nil, file.Path{"other"}, false)
+		require.True(t, file.IsNotExist(err), err)
+
+		info, err := stor.Stat(ctx, nil, file.Path{"renamed", "file"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0640), info.Mode())
+		hdr := info.Sys().(*tar.Header)
+		require.Equal(t, 1, hdr.Uid)
+		require.Equal(t, 2, hdr.Gid)
+		require.Equal(t, "value", hdr.PAXRecords[xattrPrefix+"user.key"])
+
+		info, err = stor.Stat(ctx, nil, file.Path{"renamed"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.ModeDir|0750, info.Mode())
+
+		info, err = stor.Stat(ctx, nil, file.Path{"null"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.ModeDevice|os.ModeCharDevice|0666, info.Mode())
+		require.Equal(t, int64(3), info.Sys().(*tar.Header).Devminor)
+
+		// the symlink is dangling after the rename
+		target, err := stor.Readlink(ctx, nil, file.Path{"symlink"})
+		require.NoError(t, err)
+		require.Equal(t, file.Path{"dir", "file"}, target)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		stor, err := OpenTarStorage(archivePath, OptionWhiteouts{Enable: true})
+		require.NoError(t, err)
+		require.Equal(t, "Jello", storagetest.ReadFile(t, stor, file.Path{"hardlink"}))
+		storagetest.WriteFile(t, stor, file.Path{"renamed", "new"}, "new")
+		require.NoError(t, stor.Close())
+
+		stor, err = OpenStorage(archivePath, OptionWhiteouts{Enable: true})
+		require.NoError(t, err)
+		defer func() { require.NoError(t, stor.Close()) }()
+		require.Equal(t, "new", storagetest.ReadFile(t, stor, file.Path{"renamed", "new"}))
+		require.Equal(t, "Jello", storagetest.ReadFile(t, stor, file.Path{"renamed", "file"}))
+	})
+}
+
+func TestTarStorageConformance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	stor, err := OpenTarStorage(filepath.Join(dir, "archive.tar"), OptionWhiteouts{Enable: true})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, stor.Close()) }()
+	storagetest.Run(t, stor)
+}
+
+func TestTarStorageGzip(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	stor := NewTarStorage(&buf, OptionGzip{Enable: true})
+	storagetest.WriteFile(t, stor, file.Path{"file"}, "hello")
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0755, false))
+
+	// the file is appended, and it cannot be read back
+	_, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagRead, 0000)
+	require.NoError(t, err)
+	err = stor.Chmod(ctx, nil, file.Path{"file"}, 0644)
+	require.True(t, errors.As(err, &file.ErrNotImplemented{}), err)
+	require.NoError(t, stor.Close())
+
+	format, err := DetectFormat(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, FormatTarGzip, format)
+	readStor, err := NewStorage(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, readStor.Close()) }()
+	require.Equal(t, "hello", storagetest.ReadFile(t, readStor, file.Path{"file"}))
+}
+
+func TestTarStorageFlushDelay(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "archive-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	archivePath := filepath.Join(dir, "archive.tar")
+
+	stor, err := OpenTarStorage(archivePath, OptionFlushDelay{Delay: time.Millisecond})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, stor.Close()) }()
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0755, false))
+	require.Eventually(t, func() bool {
+		info, err := os.Stat(archivePath)
+		return err == nil && info.Size() > 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestTarStorageAddTree(t *testing.T) {
+	ctx := context.Background()
+	src := memfs.NewStorage()
+	require.NoError(t, src.Mkdir(ctx, nil, file.Path{"src", "dir"}, 0700, true))
+	obj, err := src.Open(ctx, nil, file.Path{"src", "dir", "file"}, file.FlagWrite|file.FlagCreate, 0640)
+	require.NoError(t, err)
+	_, err = obj.(*memfs.File).Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, obj.Close())
+	require.NoError(t, src.Symlink(ctx, nil, file.Path{"src", "symlink"}, file.Path{"dir", "file"}))
+
+	var buf bytes.Buffer
+	stor := NewTarStorage(&buf)
+	require.NoError(t, stor.AddTree(ctx, src, file.Path{"src"}, file.Path{"dst", "tree"}))
+	require.NoError(t, stor.Close())
+
+	readStor, err := NewStorage(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, readStor.Close()) }()
+	require.Equal(t, "hello", storagetest.ReadFile(t, readStor, file.Path{"dst", "tree", "symlink"}))
+	info, err := readStor.Stat(ctx, nil, file.Path{"dst", "tree", "dir"}, false)
+	require.NoError(t, err)
+	require.Equal(t, os.ModeDir|0700, info.Mode())
+	info, err = readStor.Stat(ctx, nil, file.Path{"dst", "tree", "dir", "file"}, false)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0640), info.Mode())
+}
@@ -0,0 +1,215 @@
+package archive
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// AddTree appends the objects of the storage `src` inside of
+// the directory `srcPath` to the directory `dstPath` of the writable
+// Storage. The owners, the device numbers, the hardlinks and
+// the extended attributes are copied if `src` provides them (see
+// tar.FileInfoHeader, hardlinkIDOf and xattrsOf). Sockets are skipped.
+func (stor *Storage) AddTree(ctx context.Context, src file.Storage, srcPath, dstPath file.Path) error {
+	if len(dstPath) > 1 {
+		if err := stor.Mkdir(ctx, nil, dstPath.Up(), 0755, true); err != nil {
+			return err
+		}
+	}
+
+	// hardlinks are the paths of the first entries of the hardlinked
+	// files (by the IDs in src)
+	hardlinks := map[file.ObjectID]file.Path{}
+	return file.Walk(
+		ctx,
+		src,
+		nil,
+		srcPath,
+		func(dir file.Directory, info os.FileInfo) error {
+			objPath := dir.Path()
+			if info.Name() != "." {
+				objPath = objPath.Append(info.Name())
+			}
+			path := dstPath.Append(objPath.RelativeTo(srcPath)...)
+			if err := stor.addObject(ctx, src, objPath, path, info, hardlinks); err != nil {
+				return fmt.Errorf("unable to add '%s': %w", objPath.LocalPath(), err)
+			}
+			return nil
+		},
+		nil,
+		nil,
+	)
+}
+
+// addObject appends the object `srcPath` of `src` as `path`.
+func (stor *Storage) addObject(
+	ctx context.Context,
+	src file.Storage,
+	srcPath, path file.Path,
+	info os.FileInfo,
+	hardlinks map[file.ObjectID]file.Path,
+) error {
+	mode := info.Mode()
+	if mode&os.ModeSocket != 0 {
+		return nil
+	}
+
+	if mode.IsRegular() {
+		if id := hardlinkIDOf(info); !id.IsZero() {
+			if firstPath, ok := hardlinks[id]; ok {
+				return stor.addHardlink(path, firstPath)
+			}
+			hardlinks[id] = path
+		}
+	}
+
+	var linkname string
+	if mode&os.ModeSymlink != 0 {
+		target, err := src.Readlink(ctx, nil, srcPath)
+		if err != nil {
+			return err
+		}
+		linkname = strings.Join(target, "/")
+	}
+	hdr, err := tar.FileInfoHeader(info, linkname)
+	if err != nil {
+		return err
+	}
+	xattrs, err := xattrsOf(src, srcPath, info)
+	if err != nil {
+		return err
+	}
+	for name, value := range xattrs {
+		if hdr.PAXRecords == nil {
+			hdr.PAXRecords = map[string]string{}
+		}
+		hdr.PAXRecords[xattrPrefix+name] = value
+	}
+
+	var spool *os.File
+	if mode.IsRegular() {
+		spool, err = spoolOf(ctx, src, srcPath)
+		if err != nil {
+			return err
+		}
+		size, err := spool.Seek(0, io.SeekEnd)
+		if err != nil {
+			_ = spool.Close()
+			_ = os.Remove(spool.Name())
+			return err
+		}
+		hdr.Size = size
+	}
+	return stor.addEntry(path, hdr, spool)
+}
+
+// spoolOf copies the content of the regular file to a temporary file.
+func spoolOf(ctx context.Context, src file.Storage, path file.Path) (*os.File, error) {
+	obj, err := src.Open(ctx, nil, path, file.FlagRead|file.FlagNoFollow|file.FlagNoATime, 0000)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = obj.Close() }()
+	f, ok := obj.(file.File)
+	if !ok {
+		return nil, syscall.EINVAL
+	}
+
+	spool, err := ioutil.TempFile("", "fsutil-archive-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(spool, f); err != nil {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+		return nil, err
+	}
+	return spool, nil
+}
+
+// addEntry adds the object (replacing the existing one). The spool is
+// the content of a regular file.
+func (stor *Storage) addEntry(path file.Path, hdr *tar.Header, spool *os.File) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	err := stor.checkWritable()
+	var e entry
+	if err == nil {
+		var depth int
+		e, err = stor.resolveEntry(stor.root, path, true, &depth)
+	}
+	if err != nil {
+		if spool != nil {
+			_ = spool.Close()
+			_ = os.Remove(spool.Name())
+		}
+		return pathError("add", path, err)
+	}
+
+	if e.node != nil && e.node.isDir() && hdr.Typeflag == tar.TypeDir {
+		// keep the content of the directory
+		children := e.node.children
+		e.node.hdr = newNode(hdr).hdr
+		e.node.children = children
+		stor.markDirty(e.path(), e.node)
+		return nil
+	}
+	if e.node != nil {
+		isWhiteoutRequired := e.node.isDir()
+		stor.removeTree(e)
+		if isWhiteoutRequired {
+			// a directory is not replaced by a later entry on extraction
+			if err := stor.appendWhiteout(e.path()); err != nil {
+				stor.writer.err = err
+				return pathError("add", path, err)
+			}
+		}
+	}
+
+	n := newNode(hdr)
+	if spool != nil {
+		n.spool = spool
+		stor.writer.spooled[n] = struct{}{}
+	}
+	stor.link(e.dir, e.name, n)
+	stor.markDirty(e.path(), n)
+	return nil
+}
+
+// addHardlink adds the hardlink `path` to the regular file at
+// `targetPath` (replacing the existing object).
+func (stor *Storage) addHardlink(path, targetPath file.Path) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	err := stor.checkWritable()
+	if err == nil {
+		var depth int
+		var e entry
+		e, err = stor.resolveEntry(stor.root, path, true, &depth)
+		if err == nil && e.node != nil {
+			stor.removeTree(e)
+		}
+	}
+	if err == nil {
+		err = stor.hardlink(stor.root, targetPath, path)
+	}
+	if err != nil {
+		return &os.LinkError{
+			Op:  "link",
+			Old: targetPath.LocalPath(),
+			New: path.LocalPath(),
+			Err: err,
+		}
+	}
+	return nil
+}
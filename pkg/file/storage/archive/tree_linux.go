@@ -0,0 +1,84 @@
+// +build linux
+
+package archive
+
+import (
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"golang.org/x/sys/unix"
+)
+
+// hardlinkIDOf returns the ID of the object if it has several hardlinks
+// (if the Sys of the info is *syscall.Stat_t), otherwise the zero ID.
+func hardlinkIDOf(info os.FileInfo) file.ObjectID {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink <= 1 {
+		return file.ObjectID{}
+	}
+	return file.ObjectID{
+		Dev: uint64(stat.Dev),
+		Ino: uint64(stat.Ino),
+	}
+}
+
+// xattrsOf returns the extended attributes of the object if it is
+// a local one (if the Sys of the info is *syscall.Stat_t).
+func xattrsOf(src file.Storage, path file.Path, info os.FileInfo) (map[string]string, error) {
+	if _, ok := info.Sys().(*syscall.Stat_t); !ok {
+		return nil, nil
+	}
+	localPath := src.ToLocalPath(path)
+
+	size, err := unix.Llistxattr(localPath, nil)
+	if err != nil || size == 0 {
+		return nil, ignoreXattrError(err)
+	}
+	list := make([]byte, size)
+	size, err = unix.Llistxattr(localPath, list)
+	if err != nil {
+		return nil, ignoreXattrError(err)
+	}
+
+	result := map[string]string{}
+	for _, name := range strings.Split(string(list[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+		value, err := lgetxattr(localPath, name)
+		if err != nil {
+			if err := ignoreXattrError(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		result[name] = string(value)
+	}
+	return result, nil
+}
+
+func lgetxattr(path, name string) ([]byte, error) {
+	size, err := unix.Lgetxattr(path, name, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	value := make([]byte, size)
+	size, err = unix.Lgetxattr(path, name, value)
+	if err != nil {
+		return nil, err
+	}
+	return value[:size], nil
+}
+
+// ignoreXattrError returns nil for the errors meaning there are no
+// attributes (the filesystem does not support them, the attribute was
+// removed or the storage is not a local one).
+func ignoreXattrError(err error) error {
+	switch err {
+	case unix.ENOTSUP, unix.ENODATA, unix.ENOENT:
+		return nil
+	}
+	return err
+}
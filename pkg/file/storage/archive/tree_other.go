@@ -0,0 +1,20 @@
+// +build !linux
+
+package archive
+
+import (
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// hardlinkIDOf returns the zero ID: hardlinks are detected only on
+// Linux.
+func hardlinkIDOf(info os.FileInfo) file.ObjectID {
+	return file.ObjectID{}
+}
+
+// xattrsOf returns no attributes: they are supported only on Linux.
+func xattrsOf(src file.Storage, path file.Path, info os.FileInfo) (map[string]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,484 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// writer appends the entries of a writable Storage to the archive.
+//
+// All the fields are protected by Storage.locker.
+type writer struct {
+	counter    countingWriter
+	gzipWriter *gzip.Writer
+	tarWriter  *tar.Writer
+
+	// readerAt is the archive being written (nil if the content of
+	// the appended files cannot be read back)
+	readerAt io.ReaderAt
+
+	// pending are the modified objects not appended yet, in the order
+	// of their first modification
+	pending []pendingEntry
+
+	// spooled are the nodes with a spool file
+	spooled map[*node]struct{}
+
+	timer    *time.Timer
+	isClosed bool
+
+	// err is the error of the last write to the archive (the archive
+	// cannot be continued after it)
+	err error
+}
+
+type pendingEntry struct {
+	path file.Path
+	node *node
+}
+
+// countingWriter tracks the offset in the (uncompressed) archive.
+type countingWriter struct {
+	writer io.Writer
+	offset int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.writer.Write(b)
+	w.offset += int64(n)
+	return n, err
+}
+
+// newWriter returns the writer of the archive continued at the offset.
+func newWriter(w io.Writer, offset int64, readerAt io.ReaderAt, isGzip bool) *writer {
+	result := &writer{
+		readerAt: readerAt,
+		spooled:  map[*node]struct{}{},
+	}
+	if isGzip {
+		result.gzipWriter = gzip.NewWriter(w)
+		result.readerAt = nil
+		w = result.gzipWriter
+	}
+	result.counter = countingWriter{writer: w, offset: offset}
+	result.tarWriter = tar.NewWriter(&result.counter)
+	return result
+}
+
+// newHeader returns the header of a new object owned by the current
+// user.
+func (stor *Storage) newHeader(typeflag byte, perm os.FileMode) *tar.Header {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid < 0 {
+		uid = 0
+	}
+	if gid < 0 {
+		gid = 0
+	}
+	return &tar.Header{
+		Typeflag: typeflag,
+		Mode:     tarModeOf(perm & modeMask),
+		Uid:      uid,
+		Gid:      gid,
+		ModTime:  stor.now(),
+	}
+}
+
+// openForWrite prepares the regular file for writing: its content is
+// copied to a spool file (unless it is truncated).
+func (stor *Storage) openForWrite(e entry, isTrunc bool) error {
+	n := e.node
+	if n.spool == nil {
+		if !isTrunc && n.size() > 0 && n.content == nil {
+			// the file was appended to a compressed archive
+			return file.ErrNotImplemented{}
+		}
+		spool, err := ioutil.TempFile("", "fsutil-archive-")
+		if err != nil {
+			return err
+		}
+		if !isTrunc && n.size() > 0 {
+			if _, err := io.Copy(spool, io.NewSectionReader(n.content, 0, n.size())); err != nil {
+				_ = spool.Close()
+				_ = os.Remove(spool.Name())
+				return err
+			}
+		}
+		n.spool = spool
+		stor.writer.spooled[n] = struct{}{}
+	}
+	n.writers++
+
+	if isTrunc {
+		if err := n.spool.Truncate(0); err != nil {
+			n.writers--
+			return err
+		}
+		n.hdr.Size = 0
+		n.hdr.ModTime = stor.now()
+		stor.markDirty(e.path(), n)
+	}
+	return nil
+}
+
+// closeForWrite is called on closing an object of the regular file
+// opened for writing.
+func (stor *Storage) closeForWrite(n *node) {
+	n.writers--
+	if n.writers == 0 && !stor.isPending(n) {
+		stor.dropSpool(n)
+	}
+}
+
+// write writes to the spool file of the regular file.
+func (stor *Storage) write(path file.Path, n *node, b []byte, offset int64) (int, error) {
+	if err := stor.checkWritable(); err != nil {
+		return 0, err
+	}
+	if n.spool == nil {
+		return 0, os.ErrClosed
+	}
+	written, err := n.spool.WriteAt(b, offset)
+	if end := offset + int64(written); end > n.hdr.Size {
+		n.hdr.Size = end
+	}
+	n.hdr.ModTime = stor.now()
+	stor.markDirty(path, n)
+	return written, err
+}
+
+// modifyNode calls `fn` to change the attributes of the node. The node
+// is appended to the archive again.
+func (stor *Storage) modifyNode(path file.Path, n *node, fn func(n *node)) error {
+	if err := stor.checkWritable(); err != nil {
+		return err
+	}
+	if n.isRegular() && n.spool == nil && n.content == nil && n.size() > 0 {
+		// the content is required to append the file again
+		return file.ErrNotImplemented{}
+	}
+	fn(n)
+	stor.markDirty(path, n)
+	return nil
+}
+
+func (stor *Storage) dropSpool(n *node) {
+	if n.spool == nil {
+		return
+	}
+	_ = n.spool.Close()
+	_ = os.Remove(n.spool.Name())
+	n.spool = nil
+	delete(stor.writer.spooled, n)
+}
+
+func (stor *Storage) isPending(n *node) bool {
+	for _, p := range stor.writer.pending {
+		if p.node == n {
+			return true
+		}
+	}
+	return false
+}
+
+// markDirty adds the node to the pending entries. The other pending
+// entries are appended to the archive (except the files opened for
+// writing), so consecutive modifications of the same object produce
+// a single entry.
+func (stor *Storage) markDirty(path file.Path, n *node) {
+	w := stor.writer
+	stor.appendPending(n, false)
+	stor.resetTimer()
+	for idx := range w.pending {
+		if w.pending[idx].node == n {
+			w.pending[idx].path = path
+			return
+		}
+	}
+	w.pending = append(w.pending, pendingEntry{path: path, node: n})
+}
+
+func (stor *Storage) resetTimer() {
+	w := stor.writer
+	if stor.FlushDelay <= 0 {
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(stor.FlushDelay, stor.flushByTimer)
+		return
+	}
+	w.timer.Reset(stor.FlushDelay)
+}
+
+func (stor *Storage) flushByTimer() {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if stor.writer.isClosed {
+		return
+	}
+	stor.appendPending(nil, false)
+}
+
+// appendPending appends the pending entries except the node `except`
+// and (unless isAll is true) the files opened for writing.
+func (stor *Storage) appendPending(except *node, isAll bool) {
+	w := stor.writer
+	pending := w.pending
+	w.pending = nil
+	for _, p := range pending {
+		if w.err != nil || p.node == except || (!isAll && p.node.writers > 0) {
+			w.pending = append(w.pending, p)
+			continue
+		}
+		if err := stor.appendNode(p.path, p.node); err != nil {
+			w.err = err
+			w.pending = append(w.pending, p)
+		}
+	}
+}
+
+// appendNode appends the entry of the node. The path is the last known
+// path of the node; it is checked, since the node could be renamed,
+// hardlinked or removed since then.
+func (stor *Storage) appendNode(path file.Path, n *node) error {
+	w := stor.writer
+	paths := stor.pathsOf(path, n)
+	if len(paths) == 0 {
+		// removed
+		if n.writers == 0 {
+			stor.dropSpool(n)
+		}
+		return nil
+	}
+
+	hdr := headerOf(paths[0], n)
+	if !n.isRegular() {
+		_, err := stor.writeEntry(hdr, nil)
+		return err
+	}
+
+	size := n.size()
+	var content io.Reader
+	switch {
+	case n.spool != nil:
+		content = io.NewSectionReader(n.spool, 0, size)
+	case n.content != nil:
+		content = io.NewSectionReader(n.content, 0, size)
+	case size > 0:
+		return file.ErrNotImplemented{}
+	}
+	offset, err := stor.writeEntry(hdr, content)
+	if err != nil {
+		return err
+	}
+	n.content = nil
+	if w.readerAt != nil {
+		n.content = io.NewSectionReader(w.readerAt, offset, size)
+	}
+	if n.writers == 0 {
+		stor.dropSpool(n)
+	}
+
+	// the other paths of a hardlinked file would refer to the previous
+	// content on extraction
+	for _, otherPath := range paths[1:] {
+		if _, err := stor.writeEntry(linkHeaderOf(otherPath, paths[0], n), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathsOf returns the paths of the node (the given one first, if it is
+// still valid).
+func (stor *Storage) pathsOf(path file.Path, n *node) []file.Path {
+	if n.isDir() {
+		if n != stor.root && n.parent == nil {
+			return nil
+		}
+		return []file.Path{n.path()}
+	}
+	if n.nlink == 0 {
+		return nil
+	}
+	var depth int
+	e, err := stor.resolveEntry(stor.root, path, true, &depth)
+	isValid := err == nil && e.node == n
+	if isValid && n.nlink == 1 {
+		return []file.Path{e.path()}
+	}
+
+	var result []file.Path
+	if isValid {
+		result = append(result, e.path())
+	}
+	stor.walkNodes(stor.root, file.Path{}, func(childPath file.Path, child *node) {
+		if child == n && !(isValid && len(childPath) == len(result[0]) && childPath.HasPrefix(result[0])) {
+			result = append(result, childPath)
+		}
+	})
+	return result
+}
+
+// walkNodes calls `fn` for each object inside of the directory.
+func (stor *Storage) walkNodes(dir *node, dirPath file.Path, fn func(path file.Path, n *node)) {
+	for _, name := range sortedNames(dir) {
+		child := dir.children[name]
+		childPath := dirPath.Append(name)
+		fn(childPath, child)
+		if child.isDir() {
+			stor.walkNodes(child, childPath, fn)
+		}
+	}
+}
+
+// writeEntry writes the entry and returns the offset of its content.
+func (stor *Storage) writeEntry(hdr *tar.Header, content io.Reader) (int64, error) {
+	w := stor.writer
+	if err := w.tarWriter.WriteHeader(hdr); err != nil {
+		return 0, err
+	}
+	offset := w.counter.offset
+	if content != nil {
+		if _, err := io.Copy(w.tarWriter, content); err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// appendWhiteout appends the whiteout entry of the path (if
+// Config.Whiteouts is enabled).
+func (stor *Storage) appendWhiteout(path file.Path) error {
+	if !stor.Whiteouts || len(path) == 0 {
+		return nil
+	}
+	whiteoutPath := path.Up().Append(whiteoutPrefix + path[len(path)-1])
+	_, err := stor.writeEntry(&tar.Header{
+		Name:     nameOf(whiteoutPath, false),
+		Typeflag: tar.TypeReg,
+		ModTime:  stor.now(),
+	}, nil)
+	return err
+}
+
+// appendTree appends the entries of the object moved from the path
+// `oldPath` to `path`. The content of regular files is not copied:
+// they are appended as hardlinks to the old paths.
+func (stor *Storage) appendTree(path, oldPath file.Path, n *node) error {
+	switch {
+	case stor.isPending(n):
+		// it will be appended by the new path
+		return nil
+	case n.isRegular():
+		_, err := stor.writeEntry(linkHeaderOf(path, oldPath, n), nil)
+		return err
+	}
+	if _, err := stor.writeEntry(headerOf(path, n), nil); err != nil {
+		return err
+	}
+	if !n.isDir() {
+		return nil
+	}
+	for _, name := range sortedNames(n) {
+		if err := stor.appendTree(path.Append(name), oldPath.Append(name), n.children[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// headerOf returns the header of the entry of the node.
+func headerOf(path file.Path, n *node) *tar.Header {
+	hdr := n.hdr
+	hdr.Name = nameOf(path, n.isDir())
+	hdr.PAXRecords = cloneRecords(n.hdr.PAXRecords)
+	// the extended attributes are kept in PAXRecords
+	hdr.Xattrs = nil
+	// PAX keeps the sub-second timestamps and long names
+	hdr.Format = tar.FormatPAX
+	hdr.ChangeTime = time.Time{}
+	switch {
+	case n.isRegular():
+		hdr.Typeflag = tar.TypeReg
+		hdr.Linkname = ""
+	case n.isSymlink():
+		hdr.Size = 0
+	default:
+		hdr.Size = 0
+		hdr.Linkname = ""
+	}
+	return &hdr
+}
+
+// linkHeaderOf returns the header of the hardlink from `path` to
+// the regular file at `targetPath`.
+func linkHeaderOf(path, targetPath file.Path, n *node) *tar.Header {
+	hdr := headerOf(path, n)
+	hdr.Typeflag = tar.TypeLink
+	hdr.Linkname = nameOf(targetPath, false)
+	hdr.Size = 0
+	return hdr
+}
+
+// Flush appends the pending entries to the archive, except the files
+// opened for writing (they are appended after they are closed). The end
+// of the archive is written by Close only, but the readers of tar
+// archives accept archives without it.
+func (stor *Storage) Flush() error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if err := stor.checkWritable(); err != nil {
+		return err
+	}
+	w := stor.writer
+	stor.appendPending(nil, false)
+	if w.err != nil {
+		return w.err
+	}
+	if w.gzipWriter != nil {
+		if err := w.gzipWriter.Flush(); err != nil {
+			w.err = err
+		}
+	}
+	return w.err
+}
+
+// closeWriter appends all the pending entries (including the files
+// still opened for writing) and finishes the archive.
+func (stor *Storage) closeWriter() error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	w := stor.writer
+	if w.isClosed {
+		return nil
+	}
+	w.isClosed = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+
+	stor.appendPending(nil, true)
+	err := w.err
+	if err == nil {
+		err = w.tarWriter.Close()
+	}
+	if w.gzipWriter != nil {
+		if gzipErr := w.gzipWriter.Close(); err == nil {
+			err = gzipErr
+		}
+	}
+	for n := range w.spooled {
+		stor.dropSpool(n)
+	}
+	if w.err == nil {
+		w.err = os.ErrClosed
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package fsdgrpc
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// Access is the level of access of a client to a subtree of the storage.
+type Access uint8
+
+const (
+	AccessNone = Access(iota)
+	AccessRead
+	AccessReadWrite
+)
+
+func (access Access) String() string {
+	switch access {
+	case AccessNone:
+		return "none"
+	case AccessRead:
+		return "ro"
+	case AccessReadWrite:
+		return "rw"
+	}
+	return fmt.Sprintf("unknown_access_%d", uint8(access))
+}
+
+func (access Access) MarshalText() ([]byte, error) {
+	return []byte(access.String()), nil
+}
+
+func (access *Access) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "none":
+		*access = AccessNone
+	case "ro":
+		*access = AccessRead
+	case "rw":
+		*access = AccessReadWrite
+	default:
+		return fmt.Errorf("unknown access '%s' (expected: none, ro or rw)", text)
+	}
+	return nil
+}
+
+// Grant allows a client to access the subtree Root.
+type Grant struct {
+	Root   file.Path `json:"root"`
+	Access Access    `json:"access"`
+}
+
+// ACL defines which clients may access the storage of a Server and
+// what they may access.
+//
+// A client is identified either by a bearer token (see BearerToken) or
+// by the CommonName of its TLS client certificate (mTLS; the certificate
+// is verified by the credentials of the grpc.Server).
+type ACL struct {
+	// Tokens maps the bearer tokens to the identities of the clients.
+	Tokens map[string]string `json:"tokens"`
+
+	// Grants are the subtrees allowed to each identity. If the roots of
+	// several grants contain a path then the longest one decides.
+	Grants map[string][]Grant `json:"grants"`
+}
+
+// identityOfToken returns the identity the token belongs to.
+func (acl *ACL) identityOfToken(token string) (string, bool) {
+	var identity string
+	isFound := false
+	for knownToken, knownIdentity := range acl.Tokens {
+		// the time does not depend on the matched prefix
+		if subtle.ConstantTimeCompare([]byte(knownToken), []byte(token)) == 1 {
+			identity, isFound = knownIdentity, true
+		}
+	}
+	return identity, isFound
+}
+
+// accessOf returns the access of the identity to the path.
+func (acl *ACL) accessOf(identity string, path file.Path) Access {
+	access := AccessNone
+	matchLen := -1
+	for _, grant := range acl.Grants[identity] {
+		if len(grant.Root) > matchLen && path.HasPrefix(grant.Root) {
+			access, matchLen = grant.Access, len(grant.Root)
+		}
+	}
+	return access
+}
+
+// subtreeAccessOf returns the lowest access of the identity to the path
+// and to everything inside of it.
+func (acl *ACL) subtreeAccessOf(identity string, path file.Path) Access {
+	access := acl.accessOf(identity, path)
+	for _, grant := range acl.Grants[identity] {
+		if len(grant.Root) > len(path) && grant.Root.HasPrefix(path) && grant.Access < access {
+			access = grant.Access
+		}
+	}
+	return access
+}
@@ -0,0 +1,346 @@
+package fsdgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// authorizationKey is the metadata key of the bearer token (see
+// BearerToken).
+const authorizationKey = "authorization"
+
+const bearerPrefix = "Bearer "
+
+// maxSymlinkDepth is the maximal amount of symlinks resolved within
+// a path (the same as in Linux).
+const maxSymlinkDepth = 40
+
+// errSymlinkEscape is returned by resolvePath if a symlink leads outside
+// of the storage.
+var errSymlinkEscape = errors.New("the symlink leads outside of the storage")
+
+// BearerToken is the credentials.PerRPCCredentials of a client identified
+// by a token (see ACL.Tokens):
+//
+//	grpc.Dial(addr, grpc.WithTransportCredentials(creds),
+//	    grpc.WithPerRPCCredentials(fsdgrpc.BearerToken{Token: token}))
+type BearerToken struct {
+	Token string
+
+	// AllowInsecure allows to send the token over a connection without
+	// TLS (for example, within a trusted network).
+	AllowInsecure bool
+}
+
+var _ credentials.PerRPCCredentials = BearerToken{}
+
+func (token BearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		authorizationKey: bearerPrefix + token.Token,
+	}, nil
+}
+
+func (token BearerToken) RequireTransportSecurity() bool {
+	return !token.AllowInsecure
+}
+
+// AuditRecord describes a request denied by a Server.
+type AuditRecord struct {
+	Time time.Time
+
+	// Peer is the address of the client.
+	Peer string
+
+	// Identity is the identity of the client (empty if the client is not
+	// authenticated).
+	Identity string
+
+	Method string
+	Path   file.Path
+	Reason string
+}
+
+func (record AuditRecord) String() string {
+	return fmt.Sprintf("denied %s of '%s' to '%s' (%s): %s",
+		record.Method, record.Path.LocalPath(), record.Identity, record.Peer, record.Reason)
+}
+
+// AuditFunc receives the denied requests (see Config.AuditFunc).
+type AuditFunc func(record AuditRecord)
+
+// identityOf authenticates the client of the call. The identity is
+// always empty if there is no ACL.
+func (srv *Server) identityOf(ctx context.Context, method string) (string, error) {
+	if srv.ACL == nil {
+		return "", nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(authorizationKey); len(values) > 0 {
+		if strings.HasPrefix(values[0], bearerPrefix) {
+			identity, ok := srv.ACL.identityOfToken(strings.TrimPrefix(values[0], bearerPrefix))
+			if ok {
+				return identity, nil
+			}
+		}
+		srv.deny(ctx, "", method, nil, "invalid bearer token")
+		return "", status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+
+	if identity := tlsIdentityOf(ctx); identity != "" {
+		return identity, nil
+	}
+	srv.deny(ctx, "", method, nil, "no credentials")
+	return "", status.Error(codes.Unauthenticated, "no credentials")
+}
+
+// tlsIdentityOf returns the CommonName of the verified client
+// certificate (if any).
+func tlsIdentityOf(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	for _, chain := range tlsInfo.State.VerifiedChains {
+		if len(chain) > 0 && chain[0].Subject.CommonName != "" {
+			return chain[0].Subject.CommonName
+		}
+	}
+	return ""
+}
+
+func (srv *Server) deny(ctx context.Context, identity, method string, path file.Path, reason string) {
+	record := AuditRecord{
+		Time:     time.Now(),
+		Identity: identity,
+		Method:   method,
+		Path:     path,
+		Reason:   reason,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		record.Peer = p.Addr.String()
+	}
+	srv.audit(record)
+}
+
+// authorize checks the access of the client to the path (relative to
+// dirAt) and returns the access granted to it. The path is checked both
+// as it is and with the symlinks resolved (the last one is followed only
+// if `follow` is true), so a symlink cannot lead outside of the granted
+// subtrees.
+func (srv *Server) authorize(
+	ctx context.Context,
+	identity string,
+	method string,
+	dirAt file.Object,
+	path file.Path,
+	access Access,
+	follow bool,
+) (Access, error) {
+	if srv.ACL == nil {
+		return AccessReadWrite, nil
+	}
+
+	fullPath := path
+	if dirAt != nil {
+		fullPath = dirAt.Path().Append(path...)
+	}
+
+	var reason string
+	granted := srv.ACL.accessOf(identity, fullPath)
+	switch {
+	case !isPlainPath(path):
+		reason = "the path has special components"
+	case granted < access:
+		reason = fmt.Sprintf("no %s access", access)
+	default:
+		resolvedPath, err := srv.resolvePath(ctx, fullPath, follow)
+		if err == errSymlinkEscape {
+			reason = err.Error()
+			break
+		}
+		if err != nil {
+			return AccessNone, err
+		}
+		if resolvedAccess := srv.ACL.accessOf(identity, resolvedPath); resolvedAccess < granted {
+			granted = resolvedAccess
+		}
+		if granted < access {
+			reason = fmt.Sprintf("no %s access to the symlink destination '%s'",
+				access, resolvedPath.LocalPath())
+		}
+	}
+	if reason == "" {
+		return granted, nil
+	}
+
+	srv.deny(ctx, identity, method, fullPath, reason)
+	return AccessNone, &os.PathError{Op: method, Path: fullPath.LocalPath(), Err: syscall.EACCES}
+}
+
+// authorizeDestination checks the destination of a new symlink
+// at the path: the client should have at least the same access to the
+// whole subtree it leads to as to the symlink itself.
+//
+// Otherwise the client could replace a symlink between the check of
+// a call (see authorize) and the call itself (which follows symlinks
+// again), and so gain the access of the symlink to its destination.
+func (srv *Server) authorizeDestination(
+	ctx context.Context,
+	identity string,
+	method string,
+	dirAt file.Object,
+	path file.Path,
+	destination file.Path,
+) error {
+	if srv.ACL == nil {
+		return nil
+	}
+
+	fullPath := path
+	if dirAt != nil {
+		fullPath = dirAt.Path().Append(path...)
+	}
+	target, ok := joinPath(fullPath.Up(), destination)
+	if ok && srv.ACL.subtreeAccessOf(identity, target) >= srv.ACL.accessOf(identity, fullPath) {
+		return nil
+	}
+
+	srv.deny(ctx, identity, method, fullPath,
+		fmt.Sprintf("the destination '%s' is not accessible", destination.LocalPath()))
+	return &os.PathError{Op: method, Path: fullPath.LocalPath(), Err: syscall.EACCES}
+}
+
+// authorizeMove checks the object at the path if it is a symlink which
+// is going to appear at the new path: its destination is checked the same
+// as for a new symlink (see authorizeDestination).
+func (srv *Server) authorizeMove(
+	ctx context.Context,
+	identity string,
+	method string,
+	dirAt file.Object,
+	path file.Path,
+	newPath file.Path,
+) error {
+	if srv.ACL == nil {
+		return nil
+	}
+
+	info, err := srv.storage.Stat(ctx, dirAt, path, true)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		// the call itself will fail if needed
+		return nil
+	}
+	destination, err := srv.storage.Readlink(ctx, dirAt, path)
+	if err != nil {
+		return err
+	}
+	return srv.authorizeDestination(ctx, identity, method, dirAt, newPath, destination)
+}
+
+// isPlainPath returns false if the path has components which could lead
+// outside of the requested directory ("..", "." and names with "/").
+func isPlainPath(path file.Path) bool {
+	for _, name := range path {
+		switch {
+		case name == "", name == ".", name == "..":
+			return false
+		case strings.ContainsAny(name, "/\\\x00"):
+			return false
+		}
+	}
+	return true
+}
+
+// joinPath returns the destination of a symlink in the directory dir.
+// It returns false if the destination is absolute or leads outside of
+// the storage.
+func joinPath(dir file.Path, destination file.Path) (file.Path, bool) {
+	if len(destination) > 0 && destination[0] == "" {
+		return nil, false
+	}
+	result := dir.Append()
+	for _, name := range destination {
+		switch name {
+		case "", ".":
+		case "..":
+			if len(result) == 0 {
+				return nil, false
+			}
+			result = result.Up()
+		default:
+			result = append(result, name)
+		}
+	}
+	return result, true
+}
+
+// resolvePath returns the path with the symlinks replaced by their
+// destinations. The components which do not exist are kept as they are.
+func (srv *Server) resolvePath(ctx context.Context, path file.Path, followLast bool) (file.Path, error) {
+	resolved := file.Path{}
+	pending := path.Append()
+	symlinkCount := 0
+	isMissing := false
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return nil, errSymlinkEscape
+			}
+			resolved = resolved.Up()
+			continue
+		}
+
+		curPath := resolved.Append(name)
+		if isMissing || (len(pending) == 0 && !followLast) {
+			resolved = curPath
+			continue
+		}
+		info, err := srv.storage.Stat(ctx, nil, curPath, true)
+		if err != nil {
+			// nothing to resolve, the call itself will fail if needed
+			isMissing = true
+			resolved = curPath
+			continue
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = curPath
+			continue
+		}
+
+		symlinkCount++
+		if symlinkCount > maxSymlinkDepth {
+			return nil, &os.PathError{Op: "resolve", Path: path.LocalPath(), Err: syscall.ELOOP}
+		}
+		destination, err := srv.storage.Readlink(ctx, nil, curPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(destination) > 0 && destination[0] == "" {
+			return nil, errSymlinkEscape
+		}
+		pending = destination.Append(pending...)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,182 @@
+package fsdgrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/my-network/fsutil/pkg/file/storage/memfs"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestACL(t *testing.T) {
+	backend := memfs.NewStorage()
+	ctx := context.Background()
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"shared", "rw"}, 0755, true))
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"shared", "hidden"}, 0755, true))
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"private"}, 0755, true))
+	require.NoError(t, backend.Symlink(ctx, nil, file.Path{"shared", "rw", "escape"}, file.Path{"..", "..", "private"}))
+	require.NoError(t, backend.Symlink(ctx, nil, file.Path{"shared", "rw", "up"}, file.Path{".."}))
+
+	acl := &ACL{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"tokens": {"secret": "alice"},
+		"grants": {"alice": [
+			{"root": ["shared"], "access": "ro"},
+			{"root": ["shared", "rw"], "access": "rw"},
+			{"root": ["shared", "rw", "locked"], "access": "ro"},
+			{"root": ["shared", "hidden"], "access": "none"}
+		]}
+	}`), acl))
+
+	var auditLocker sync.Mutex
+	var audit []AuditRecord
+	lastAudit := func() AuditRecord {
+		auditLocker.Lock()
+		defer auditLocker.Unlock()
+		require.NotEmpty(t, audit)
+		return audit[len(audit)-1]
+	}
+	_, addr := newTestServer(t, backend, OptionACL{ACL: acl}, OptionAuditFunc{Func: func(record AuditRecord) {
+		auditLocker.Lock()
+		defer auditLocker.Unlock()
+		audit = append(audit, record)
+	}})
+
+	t.Run("authentication", func(t *testing.T) {
+		_, err := NewStorage(dialTestServer(t, addr))
+		require.Error(t, err)
+		require.Equal(t, "no credentials", lastAudit().Reason)
+
+		_, err = NewStorage(dialTestServer(t, addr,
+			grpc.WithPerRPCCredentials(BearerToken{Token: "wrong", AllowInsecure: true})))
+		require.Error(t, err)
+		require.Equal(t, "invalid bearer token", lastAudit().Reason)
+	})
+
+	stor, err := NewStorage(dialTestServer(t, addr,
+		grpc.WithPerRPCCredentials(BearerToken{Token: "secret", AllowInsecure: true})))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, stor.Close()) }()
+
+	requireDenied := func(t *testing.T, err error, path file.Path) {
+		require.True(t, errors.Is(err, os.ErrPermission), err)
+		record := lastAudit()
+		require.Equal(t, "alice", record.Identity)
+		require.Equal(t, path, record.Path)
+	}
+
+	t.Run("paths", func(t *testing.T) {
+		_, err := stor.Stat(ctx, nil, file.Path{"shared"}, false)
+		require.NoError(t, err)
+		_, err = stor.Stat(ctx, nil, file.Path{"private"}, false)
+		requireDenied(t, err, file.Path{"private"})
+		_, err = stor.Stat(ctx, nil, file.Path{"shared", "..", "private"}, false)
+		requireDenied(t, err, file.Path{"shared", "..", "private"})
+
+		require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"shared", "rw", "dir"}, 0755, false))
+		err = stor.Mkdir(ctx, nil, file.Path{"shared", "dir"}, 0755, false)
+		requireDenied(t, err, file.Path{"shared", "dir"})
+
+		// relative to an opened directory
+		dir, err := stor.Open(ctx, nil, file.Path{"shared"}, file.FlagWalkDefaults, 0000)
+		require.NoError(t, err)
+		storagetest.WriteFileAt(t, stor, dir, file.Path{"rw", "file"}, "content")
+		_, err = stor.Open(ctx, dir, file.Path{"file"}, file.FlagWrite|file.FlagCreate, 0600)
+		requireDenied(t, err, file.Path{"shared", "file"})
+		require.NoError(t, dir.Close())
+	})
+
+	t.Run("symlinks", func(t *testing.T) {
+		// the symlink itself is accessible, but not its destination
+		_, err := stor.Stat(ctx, nil, file.Path{"shared", "rw", "escape"}, true)
+		require.NoError(t, err)
+		_, err = stor.Stat(ctx, nil, file.Path{"shared", "rw", "escape"}, false)
+		requireDenied(t, err, file.Path{"shared", "rw", "escape"})
+		_, err = stor.Open(ctx, nil, file.Path{"shared", "rw", "escape", "x"}, file.FlagRead, 0000)
+		requireDenied(t, err, file.Path{"shared", "rw", "escape", "x"})
+
+		// the destination is read-only
+		_, err = stor.Stat(ctx, nil, file.Path{"shared", "rw", "up"}, false)
+		require.NoError(t, err)
+		err = stor.Mkdir(ctx, nil, file.Path{"shared", "rw", "up", "dir"}, 0755, false)
+		requireDenied(t, err, file.Path{"shared", "rw", "up", "dir"})
+
+		err = stor.Symlink(ctx, nil, file.Path{"shared", "rw", "new"}, file.Path{"..", "..", "private"})
+		requireDenied(t, err, file.Path{"shared", "rw", "new"})
+		err = stor.Symlink(ctx, nil, file.Path{"shared", "rw", "new"}, file.Path{"", "etc"})
+		requireDenied(t, err, file.Path{"shared", "rw", "new"})
+		require.NoError(t, stor.Symlink(ctx, nil, file.Path{"shared", "rw", "new"}, file.Path{"dir"}))
+
+		// a writable symlink should not lead to anything read-only, otherwise
+		// it could be replaced while a call to a writable destination is
+		// being authorized
+		err = stor.Symlink(ctx, nil, file.Path{"shared", "rw", "ro"}, file.Path{".."})
+		requireDenied(t, err, file.Path{"shared", "rw", "ro"})
+		err = stor.Symlink(ctx, nil, file.Path{"shared", "rw", "ro"}, file.Path{"."})
+		requireDenied(t, err, file.Path{"shared", "rw", "ro"})
+
+		// the same for existing symlinks which are moved
+		err = stor.Rename(ctx, nil, file.Path{"shared", "rw", "up"}, file.Path{"shared", "rw", "moved"})
+		requireDenied(t, err, file.Path{"shared", "rw", "moved"})
+		err = stor.Link(ctx, nil, file.Path{"shared", "rw", "escape"}, file.Path{"shared", "rw", "moved"})
+		requireDenied(t, err, file.Path{"shared", "rw", "moved"})
+		require.NoError(t, stor.Rename(ctx, nil, file.Path{"shared", "rw", "new"}, file.Path{"shared", "rw", "moved"}))
+	})
+
+	t.Run("handles", func(t *testing.T) {
+		obj, err := stor.Open(ctx, nil, file.Path{"shared", "rw", "file"}, file.FlagRead, 0000)
+		require.NoError(t, err)
+		require.NoError(t, obj.(*File).Chmod(0640))
+		require.NoError(t, obj.Close())
+
+		// the handle keeps the access granted on opening
+		obj, err = stor.Open(ctx, nil, file.Path{"shared"}, file.FlagWalkDefaults, 0000)
+		require.NoError(t, err)
+		err = obj.Chown(1, 1)
+		requireDenied(t, err, file.Path{"shared"})
+		require.NoError(t, obj.Close())
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		evEmitter, err := stor.Subscribe(WatchRequest{Path: file.Path{"shared"}}, nil,
+			event.OptionTypeMask{Mask: event.TypeCreate})
+		require.NoError(t, err)
+		defer func() { require.NoError(t, evEmitter.Close()) }()
+
+		// the events inside of the subtree without access are not sent
+		require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"shared", "hidden", "secret"}, 0755, false))
+		require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"shared", "rw", "visible"}, 0755, false))
+		expectEvent(t, evEmitter, event.TypeCreate, file.Path{"shared", "rw", "visible"})
+	})
+}
+
+func TestTLSIdentity(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "bob"}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}},
+	})
+	require.Equal(t, "bob", tlsIdentityOf(ctx))
+
+	// the certificates which are not verified are ignored
+	ctx = peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+		}},
+	})
+	require.Empty(t, tlsIdentityOf(ctx))
+}
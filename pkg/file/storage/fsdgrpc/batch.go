@@ -0,0 +1,326 @@
+package fsdgrpc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// batchHandleFlag marks the handles which refer to the objects opened
+// within the same batch (see BatchHandle).
+const batchHandleFlag = Handle(1 << 63)
+
+// BatchHandle returns the reference to the object opened by the call
+// with the index `callIdx` of the same BatchRequest. It is resolved by
+// the server; if the call failed then the reference is an invalid handle.
+func BatchHandle(callIdx int) Handle {
+	return batchHandleFlag | Handle(callIdx)
+}
+
+// ObjectRef is either an object of the Storage or the result of
+// Batch.Open (an object opened by an earlier call of the same batch).
+type ObjectRef interface {
+	batchHandle(batch *Batch) (Handle, error)
+}
+
+var _ ObjectRef = &Object{}
+
+func (obj *Object) batchHandle(batch *Batch) (Handle, error) {
+	return batch.storage.handleOf(obj)
+}
+
+// Batch collects calls to be done by one round trip to the server,
+// for example:
+//
+//	batch := stor.NewBatch()
+//	opened := batch.Open(nil, path, file.FlagWrite|file.FlagCreate, 0600)
+//	chmod := batch.ObjectChmod(opened, 0640)
+//	batch.Close(opened)
+//	err := batch.Do(ctx)
+//
+// The calls are done by the server in order, a failed call does not stop
+// the next ones. The results are available after Do.
+//
+// A Batch is not safe for concurrent use and may be done only once.
+type Batch struct {
+	storage  *Storage
+	calls    []BatchCall
+	decoders []func(data msgp.Raw) error
+
+	// err is the first error of adding a call
+	err error
+}
+
+// NewBatch returns an empty batch of calls of the storage.
+func (stor *Storage) NewBatch() *Batch {
+	return &Batch{
+		storage: stor,
+	}
+}
+
+// BatchResult is the result of a batched call which returns only
+// an error.
+type BatchResult struct {
+	Err error
+}
+
+// BatchOpen is the result of Batch.Open. It may be passed as an ObjectRef
+// to the next calls of the same batch.
+type BatchOpen struct {
+	Object file.Object
+	Err    error
+
+	batch   *Batch
+	callIdx int
+}
+
+var _ ObjectRef = &BatchOpen{}
+
+func (open *BatchOpen) batchHandle(batch *Batch) (Handle, error) {
+	if open.batch != batch {
+		return 0, fmt.Errorf("the object is opened by another batch")
+	}
+	return BatchHandle(open.callIdx), nil
+}
+
+// BatchStat is the result of Batch.Stat.
+type BatchStat struct {
+	Info os.FileInfo
+	Err  error
+}
+
+// Len returns the amount of the calls in the batch.
+func (batch *Batch) Len() int {
+	return len(batch.calls)
+}
+
+// Do sends the calls to the server and fills their results. The returned
+// error is a failure of the batch itself (for example, the connection is
+// lost); the errors of the storage are in the results of the calls.
+func (batch *Batch) Do(ctx context.Context) error {
+	if batch.err != nil {
+		return batch.err
+	}
+	if len(batch.calls) == 0 {
+		return nil
+	}
+
+	var resp BatchResponse
+	if err := batch.storage.invoke(ctx, methodBatch, &BatchRequest{Calls: batch.calls}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Responses) != len(batch.calls) {
+		return ErrCall{
+			Method: methodBatch,
+			Err:    fmt.Errorf("expected %d responses, received %d", len(batch.calls), len(resp.Responses)),
+		}
+	}
+	for idx, decode := range batch.decoders {
+		if err := decode(resp.Responses[idx]); err != nil {
+			return ErrCall{
+				Method: batch.calls[idx].Method,
+				Err:    err,
+			}
+		}
+	}
+	return nil
+}
+
+// handleOf returns the handle of the object reference (zero for nil).
+func (batch *Batch) handleOf(ref ObjectRef) Handle {
+	if ref == nil {
+		return 0
+	}
+	handle, err := ref.batchHandle(batch)
+	if err != nil && batch.err == nil {
+		batch.err = err
+	}
+	return handle
+}
+
+// add appends the call; `resp` is decoded from the response and passed
+// to `fill`.
+func (batch *Batch) add(method string, req msgp.Marshaler, resp msgp.Unmarshaler, fill func()) {
+	data, err := req.MarshalMsg(nil)
+	if err != nil {
+		if batch.err == nil {
+			batch.err = err
+		}
+		return
+	}
+	batch.calls = append(batch.calls, BatchCall{
+		Method:  method,
+		Request: data,
+	})
+	batch.decoders = append(batch.decoders, func(data msgp.Raw) error {
+		if _, err := resp.UnmarshalMsg(data); err != nil {
+			return err
+		}
+		fill()
+		return nil
+	})
+}
+
+// addNoResult appends a call which returns only an error.
+func (batch *Batch) addNoResult(method string, req msgp.Marshaler) *BatchResult {
+	result := &BatchResult{}
+	var resp ErrorResponse
+	batch.add(method, req, &resp, func() {
+		result.Err = resp.Error.Err()
+	})
+	return result
+}
+
+func (batch *Batch) Open(
+	dirAt ObjectRef,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) *BatchOpen {
+	result := &BatchOpen{
+		batch:   batch,
+		callIdx: len(batch.calls),
+	}
+	var resp OpenResponse
+	batch.add(methodOpen, &OpenRequest{
+		DirAt: batch.handleOf(dirAt),
+		Path:  path,
+		Flags: uint16(flags),
+		Perm:  uint32(defaultPerm),
+	}, &resp, func() {
+		if result.Err = resp.Error.Err(); result.Err == nil {
+			result.Object = batch.storage.newObject(&resp)
+		}
+	})
+	return result
+}
+
+func (batch *Batch) Stat(
+	dirAt ObjectRef,
+	path file.Path,
+	noFollow bool,
+) *BatchStat {
+	result := &BatchStat{}
+	var resp StatResponse
+	batch.add(methodStat, &StatRequest{
+		DirAt:    batch.handleOf(dirAt),
+		Path:     path,
+		NoFollow: noFollow,
+	}, &resp, func() {
+		if result.Err = resp.Error.Err(); result.Err == nil {
+			result.Info = resp.Info.OSFileInfo()
+		}
+	})
+	return result
+}
+
+func (batch *Batch) Symlink(dirAt ObjectRef, path file.Path, destination file.Path) *BatchResult {
+	return batch.addNoResult(methodSymlink, &SymlinkRequest{
+		DirAt:       batch.handleOf(dirAt),
+		Path:        path,
+		Destination: destination,
+	})
+}
+
+func (batch *Batch) Mkdir(dirAt ObjectRef, path file.Path, perms os.FileMode, isRecursive bool) *BatchResult {
+	return batch.addNoResult(methodMkdir, &MkdirRequest{
+		DirAt:       batch.handleOf(dirAt),
+		Path:        path,
+		Perm:        uint32(perms),
+		IsRecursive: isRecursive,
+	})
+}
+
+func (batch *Batch) Remove(dirAt ObjectRef, path file.Path, isRecursive bool) *BatchResult {
+	return batch.addNoResult(methodRemove, &RemoveRequest{
+		DirAt:       batch.handleOf(dirAt),
+		Path:        path,
+		IsRecursive: isRecursive,
+	})
+}
+
+func (batch *Batch) Rename(dirAt ObjectRef, path, newPath file.Path) *BatchResult {
+	return batch.addNoResult(methodRename, &RenameRequest{
+		DirAt:   batch.handleOf(dirAt),
+		Path:    path,
+		NewPath: newPath,
+	})
+}
+
+func (batch *Batch) Link(dirAt ObjectRef, path, destination file.Path) *BatchResult {
+	return batch.addNoResult(methodLink, &LinkRequest{
+		DirAt:       batch.handleOf(dirAt),
+		Path:        path,
+		Destination: destination,
+	})
+}
+
+func (batch *Batch) Chmod(dirAt ObjectRef, path file.Path, mode os.FileMode) *BatchResult {
+	return batch.addNoResult(methodChmod, &ChmodRequest{
+		DirAt: batch.handleOf(dirAt),
+		Path:  path,
+		Mode:  uint32(mode),
+	})
+}
+
+func (batch *Batch) Chown(dirAt ObjectRef, path file.Path, uid, gid int, noFollow bool) *BatchResult {
+	return batch.addNoResult(methodChown, &ChownRequest{
+		DirAt:    batch.handleOf(dirAt),
+		Path:     path,
+		UID:      uid,
+		GID:      gid,
+		NoFollow: noFollow,
+	})
+}
+
+func (batch *Batch) Chtimes(dirAt ObjectRef, path file.Path, atime, mtime time.Time) *BatchResult {
+	return batch.addNoResult(methodChtimes, &ChtimesRequest{
+		DirAt: batch.handleOf(dirAt),
+		Path:  path,
+		Atime: atime,
+		Mtime: mtime,
+	})
+}
+
+// ObjectChmod changes the mode of the opened object (see File.Chmod).
+func (batch *Batch) ObjectChmod(obj ObjectRef, mode os.FileMode) *BatchResult {
+	return batch.addNoResult(methodObjectChmod, &ObjectChmodRequest{
+		Handle: batch.objectHandleOf(obj),
+		Mode:   uint32(mode),
+	})
+}
+
+// ObjectChown changes the owner of the opened object (see Object.Chown).
+func (batch *Batch) ObjectChown(obj ObjectRef, uid, gid int) *BatchResult {
+	return batch.addNoResult(methodObjectChown, &ObjectChownRequest{
+		Handle: batch.objectHandleOf(obj),
+		UID:    uid,
+		GID:    gid,
+	})
+}
+
+func (batch *Batch) Sync(obj ObjectRef) *BatchResult {
+	return batch.addNoResult(methodSync, &HandleRequest{
+		Handle: batch.objectHandleOf(obj),
+	})
+}
+
+// Close closes the opened object. The client side of the object (if it
+// is the result of Batch.Open) becomes invalid after Do.
+func (batch *Batch) Close(obj ObjectRef) *BatchResult {
+	return batch.addNoResult(methodClose, &HandleRequest{
+		Handle: batch.objectHandleOf(obj),
+	})
+}
+
+// objectHandleOf is handleOf for the calls which require an object.
+func (batch *Batch) objectHandleOf(obj ObjectRef) Handle {
+	if obj == nil && batch.err == nil {
+		batch.err = ErrInvalidHandle{}
+	}
+	return batch.handleOf(obj)
+}
@@ -0,0 +1,108 @@
+package fsdgrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/memfs"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestBatch(t *testing.T) {
+	backend := memfs.NewStorage()
+	stor, _ := newTestStorage(t, backend)
+	ctx := context.Background()
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"dir"}, 0755, false))
+
+	batch := stor.NewBatch()
+	dir := batch.Open(nil, file.Path{"dir"}, file.FlagWalkDefaults, 0000)
+	opened := batch.Open(dir, file.Path{"file"}, file.FlagWrite|file.FlagCreate, 0600)
+	chmod := batch.ObjectChmod(opened, 0640)
+	closing := batch.Close(opened)
+	mkdir := batch.Mkdir(dir, file.Path{"subdir"}, 0755, false)
+	stat := batch.Stat(nil, file.Path{"dir", "file"}, false)
+
+	// the calls on a failed open refer to an invalid handle
+	missing := batch.Open(nil, file.Path{"missing"}, file.FlagRead, 0000)
+	missingChmod := batch.ObjectChmod(missing, 0600)
+	missingStat := batch.Stat(missing, file.Path{"x"}, false)
+	require.Equal(t, 9, batch.Len())
+
+	require.NoError(t, batch.Do(ctx))
+	require.NoError(t, dir.Err)
+	require.IsType(t, &Directory{}, dir.Object)
+	require.NoError(t, opened.Err)
+	require.Equal(t, file.Path{"dir", "file"}, opened.Object.Path())
+	require.NoError(t, chmod.Err)
+	require.NoError(t, closing.Err)
+	require.NoError(t, mkdir.Err)
+	require.NoError(t, stat.Err)
+	require.Equal(t, os.FileMode(0640), stat.Info.Mode().Perm())
+
+	require.True(t, errors.Is(missing.Err, os.ErrNotExist), missing.Err)
+	require.Nil(t, missing.Object)
+	var invalidHandle ErrInvalidHandle
+	require.True(t, errors.As(missingChmod.Err, &invalidHandle), missingChmod.Err)
+	require.True(t, errors.As(missingStat.Err, &invalidHandle), missingStat.Err)
+
+	// the objects opened by a batch are the usual ones
+	_, err := dir.Object.(*Directory).Readdir(-1)
+	require.NoError(t, err)
+	batch = stor.NewBatch()
+	closing = batch.Close(dir.Object.(*Directory))
+	require.NoError(t, batch.Do(ctx))
+	require.NoError(t, closing.Err)
+
+	// the references to the objects of another batch are rejected
+	batch = stor.NewBatch()
+	batch.ObjectChmod(opened, 0600)
+	require.Error(t, batch.Do(ctx))
+}
+
+func TestCompression(t *testing.T) {
+	backend := memfs.NewStorage()
+
+	// the server does not allow compression
+	_, addr := newTestServer(t, backend)
+	stor, err := NewStorage(dialTestServer(t, addr), OptionCompressors{Names: []string{CompressorZstd}})
+	require.NoError(t, err)
+	require.Empty(t, stor.compressor)
+	require.NoError(t, stor.Close())
+
+	stor, _ = newTestStorage(t, backend,
+		OptionChunkSize{Size: 1 << 10},
+		OptionCompressors{Names: []string{"unknown", CompressorZstd}})
+	require.Equal(t, CompressorZstd, stor.compressor)
+
+	content := make([]byte, 0, 1<<16)
+	for len(content) < cap(content) {
+		content = append(content, "compressible content "...)
+	}
+	storagetest.WriteFile(t, stor, file.Path{"file"}, string(content))
+	require.Equal(t, string(content), storagetest.ReadFile(t, stor, file.Path{"file"}))
+
+	// the pooled encoders and decoders are reused
+	compressor := encoding.GetCompressor(CompressorZstd)
+	for i := 0; i < 3; i++ {
+		var buf bytes.Buffer
+		w, err := compressor.Compress(&buf)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.Less(t, buf.Len(), len(content))
+
+		r, err := compressor.Decompress(&buf)
+		require.NoError(t, err)
+		decompressed, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, content, decompressed)
+	}
+}
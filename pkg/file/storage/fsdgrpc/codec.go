@@ -0,0 +1,40 @@
+package fsdgrpc
+
+import (
+	"fmt"
+
+	"github.com/tinylib/msgp/msgp"
+	"google.golang.org/grpc/encoding"
+)
+
+// codecName is the content subtype of the protocol: the messages are
+// encoded with MessagePack instead of protobuf.
+const codecName = "msgp"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is the grpc codec of the messages defined in messages.go.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(msgp.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement msgp.Marshaler", v)
+	}
+	return msg.MarshalMsg(nil)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(msgp.Unmarshaler)
+	if !ok {
+		return fmt.Errorf("%T does not implement msgp.Unmarshaler", v)
+	}
+	_, err := msg.UnmarshalMsg(data)
+	return err
+}
+
+func (codec) Name() string {
+	return codecName
+}
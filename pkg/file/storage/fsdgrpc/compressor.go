@@ -0,0 +1,87 @@
+package fsdgrpc
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+)
+
+// CompressorZstd is the name of the grpc compressor of the Read and Write
+// streams with zstd (see Config.Compressors).
+const CompressorZstd = "zstd"
+
+func init() {
+	encoding.RegisterCompressor(zstdCompressor{})
+}
+
+var (
+	zstdEncoderPool sync.Pool
+	zstdDecoderPool sync.Pool
+)
+
+// zstdCompressor is the encoding.Compressor of zstd. The encoders and
+// decoders are reused, since they are expensive to create.
+type zstdCompressor struct{}
+
+func (zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	encoder, ok := zstdEncoderPool.Get().(*zstd.Encoder)
+	if !ok {
+		var err error
+		encoder, err = zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		encoder.Reset(w)
+	}
+	return &zstdWriter{Encoder: encoder}, nil
+}
+
+func (zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	decoder, ok := zstdDecoderPool.Get().(*zstd.Decoder)
+	if !ok {
+		var err error
+		decoder, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	} else if err := decoder.Reset(r); err != nil {
+		zstdDecoderPool.Put(decoder)
+		return nil, err
+	}
+	return &zstdReader{decoder: decoder}, nil
+}
+
+func (zstdCompressor) Name() string {
+	return CompressorZstd
+}
+
+// zstdWriter returns the encoder to the pool on Close.
+type zstdWriter struct {
+	*zstd.Encoder
+}
+
+func (w *zstdWriter) Close() error {
+	err := w.Encoder.Close()
+	zstdEncoderPool.Put(w.Encoder)
+	return err
+}
+
+// zstdReader returns the decoder to the pool at the end of the data.
+type zstdReader struct {
+	decoder *zstd.Decoder
+}
+
+func (r *zstdReader) Read(b []byte) (int, error) {
+	if r.decoder == nil {
+		return 0, io.EOF
+	}
+	n, err := r.decoder.Read(b)
+	if err == io.EOF {
+		zstdDecoderPool.Put(r.decoder)
+		r.decoder = nil
+	}
+	return n, err
+}
@@ -0,0 +1,102 @@
+package fsdgrpc
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	DefaultChunkSize          = 1 << 18
+	DefaultEventQueueSize     = 1 << 16
+	DefaultWatchJournalSize   = 1 << 16
+	DefaultWatchRetention     = time.Minute
+	DefaultWatchRetryInterval = time.Second
+)
+
+type Config struct {
+	// ChunkSize is the maximal size of the data in one message of
+	// the Read and Write streams. Zero means DefaultChunkSize.
+	ChunkSize uint
+
+	// EventQueueSize is the capacity of the channel of an EventEmitter.
+	// Zero means DefaultEventQueueSize.
+	EventQueueSize uint
+
+	// WatchJournalSize is the amount of the last events of a watch kept
+	// by the server to be resent to a reconnecting client. Zero means
+	// DefaultWatchJournalSize.
+	WatchJournalSize uint
+
+	// WatchRetention is how long the server keeps a watch without
+	// a connected client. Zero means DefaultWatchRetention.
+	WatchRetention time.Duration
+
+	// WatchRetryInterval is the delay between the attempts of
+	// an EventEmitter to reconnect. Zero means DefaultWatchRetryInterval.
+	WatchRetryInterval time.Duration
+
+	// ACL (if not nil) restricts the clients of a Server. Without it
+	// any client has the full access.
+	ACL *ACL
+
+	// AuditFunc is called on each denied request of a Server. Nil means
+	// logging by the standard logger.
+	AuditFunc AuditFunc
+
+	// Compressors are the names of the grpc compressors of the Read and
+	// Write streams (for example, CompressorZstd). A Storage proposes them
+	// in the order of preference, and a Server chooses the first one it
+	// also has. Nil means no compression.
+	Compressors []string
+}
+
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
+
+func (cfg Config) chunkSize() int {
+	if cfg.ChunkSize == 0 {
+		return DefaultChunkSize
+	}
+	return int(cfg.ChunkSize)
+}
+
+func (cfg Config) eventQueueSize() int {
+	if cfg.EventQueueSize == 0 {
+		return DefaultEventQueueSize
+	}
+	return int(cfg.EventQueueSize)
+}
+
+func (cfg Config) watchJournalSize() int {
+	if cfg.WatchJournalSize == 0 {
+		return DefaultWatchJournalSize
+	}
+	return int(cfg.WatchJournalSize)
+}
+
+func (cfg Config) watchRetention() time.Duration {
+	if cfg.WatchRetention == 0 {
+		return DefaultWatchRetention
+	}
+	return cfg.WatchRetention
+}
+
+func (cfg Config) watchRetryInterval() time.Duration {
+	if cfg.WatchRetryInterval == 0 {
+		return DefaultWatchRetryInterval
+	}
+	return cfg.WatchRetryInterval
+}
+
+func (cfg Config) audit(record AuditRecord) {
+	if cfg.AuditFunc == nil {
+		log.Printf("fsd: %s", record)
+		return
+	}
+	cfg.AuditFunc(record)
+}
@@ -0,0 +1,42 @@
+package fsdgrpc
+
+import (
+	"context"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Directory = &Directory{}
+
+type Directory struct {
+	Object
+}
+
+// Readdir has the same semantics as os.File.Readdir.
+func (dir *Directory) Readdir(n int) ([]os.FileInfo, error) {
+	stor := dir.StorageValue
+	var resp ReaddirResponse
+	err := stor.invoke(stor.ctx, methodReaddir, &ReaddirRequest{
+		Handle: dir.handle,
+		N:      n,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []os.FileInfo
+	for idx := range resp.Infos {
+		result = append(result, resp.Infos[idx].OSFileInfo())
+	}
+	return result, resp.Error.Err()
+}
+
+func (dir *Directory) Open(
+	ctx context.Context,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return dir.StorageValue.Open(ctx, dir, path, flags, defaultPerm)
+}
@@ -0,0 +1,155 @@
+package fsdgrpc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// ErrRemote is an error returned by the storage on the server side.
+// Message is the original text of the error, and Err is its
+// reconstruction (so errors.Is(err, os.ErrNotExist) and similar work).
+type ErrRemote struct {
+	Message string
+	Err     error
+}
+
+func (err ErrRemote) Error() string {
+	return err.Message
+}
+
+func (err ErrRemote) Unwrap() error {
+	return err.Err
+}
+
+type ErrInvalidHandle struct {
+	Handle Handle
+}
+
+func (err ErrInvalidHandle) Error() string {
+	return fmt.Sprintf("invalid handle %d", err.Handle)
+}
+
+type ErrUnknownSession struct {
+	SessionID string
+}
+
+func (err ErrUnknownSession) Error() string {
+	return fmt.Sprintf("unknown session '%s'", err.SessionID)
+}
+
+// ErrCall is a failure of a call to the server (not an error of
+// the storage itself); for example, the connection is lost.
+type ErrCall struct {
+	Method string
+	Err    error
+}
+
+func (err ErrCall) Error() string {
+	return fmt.Sprintf("unable to call '%s': %v", err.Method, err.Err)
+}
+
+func (err ErrCall) Unwrap() error {
+	return err.Err
+}
+
+// ErrNotSerializable is returned if functions (like ShouldWatchFunc)
+// are passed to a remote watch; use Rules instead.
+type ErrNotSerializable struct{}
+
+func (err ErrNotSerializable) Error() string {
+	return "functions cannot be sent to the server, use Rules instead"
+}
+
+// newError converts an error of the storage to its serializable form.
+func newError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	result := &Error{
+		Message: err.Error(),
+	}
+
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	switch {
+	case errors.As(err, &pathErr):
+		result.Op, result.Path = pathErr.Op, pathErr.Path
+	case errors.As(err, &linkErr):
+		result.Op, result.Path, result.NewPath = linkErr.Op, linkErr.Old, linkErr.New
+	}
+
+	var errno syscall.Errno
+	var invalidHandle ErrInvalidHandle
+	switch {
+	case err == io.EOF:
+		result.Kind = ErrorKindEOF
+	case errors.As(err, &errno):
+		result.Kind = ErrorKindErrno
+		result.Errno = uint32(errno)
+	case errors.As(err, &file.ErrNotImplemented{}):
+		result.Kind = ErrorKindNotImplemented
+	case errors.As(err, &file.ErrAborted{}):
+		result.Kind = ErrorKindAborted
+	case errors.As(err, &invalidHandle):
+		result.Kind = ErrorKindInvalidHandle
+		result.Errno = uint32(invalidHandle.Handle)
+	case errors.Is(err, os.ErrClosed):
+		result.Kind = ErrorKindClosed
+	case errors.Is(err, os.ErrNotExist):
+		result.Kind = ErrorKindNotExist
+	case errors.Is(err, os.ErrExist):
+		result.Kind = ErrorKindExist
+	case errors.Is(err, os.ErrPermission):
+		result.Kind = ErrorKindPermission
+	}
+	return result
+}
+
+// Err returns the error reconstructed on the client side. It is nil
+// if `e` is nil.
+func (e *Error) Err() error {
+	if e == nil {
+		return nil
+	}
+
+	var cause error
+	switch e.Kind {
+	case ErrorKindEOF:
+		// io.EOF is compared directly by the callers, so it is never wrapped
+		return io.EOF
+	case ErrorKindErrno:
+		cause = syscall.Errno(e.Errno)
+	case ErrorKindNotImplemented:
+		cause = file.ErrNotImplemented{}
+	case ErrorKindAborted:
+		cause = file.ErrAborted{}
+	case ErrorKindInvalidHandle:
+		cause = ErrInvalidHandle{Handle: Handle(e.Errno)}
+	case ErrorKindClosed:
+		cause = os.ErrClosed
+	case ErrorKindNotExist:
+		cause = os.ErrNotExist
+	case ErrorKindExist:
+		cause = os.ErrExist
+	case ErrorKindPermission:
+		cause = os.ErrPermission
+	default:
+		return ErrRemote{Message: e.Message}
+	}
+
+	switch {
+	case e.NewPath != "":
+		cause = &os.LinkError{Op: e.Op, Old: e.Path, New: e.NewPath, Err: cause}
+	case e.Op != "":
+		cause = &os.PathError{Op: e.Op, Path: e.Path, Err: cause}
+	}
+	if cause.Error() == e.Message {
+		return cause
+	}
+	return ErrRemote{Message: e.Message, Err: cause}
+}
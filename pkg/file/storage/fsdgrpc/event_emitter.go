@@ -0,0 +1,368 @@
+package fsdgrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"google.golang.org/grpc"
+)
+
+var _ event.Emitter = &EventEmitter{}
+
+// EventEmitter reports the events of a watch on the server (see
+// Storage.Subscribe).
+//
+// If the connection is broken, the emitter reconnects with its
+// ResumeToken and receives the events it missed. If the events are lost
+// (for example, the server was restarted) then an event of TypeOverflow
+// on the watched path is reported, so the consumer should rescan it.
+type EventEmitter struct {
+	ctx          context.Context
+	cancelFn     context.CancelFunc
+	storage      *Storage
+	wg           sync.WaitGroup
+	config       event.WatchConfig
+	errorHandler file.ErrorHandlerFunc
+	eventChan    chan event.Event
+	errChan      chan error
+
+	// locker protects the fields below
+	locker sync.Mutex
+
+	// request is the request to (re)connect, its Token is updated on
+	// each received message
+	request WatchRequest
+
+	// commands are the Watch and Unwatch calls, they are repeated if
+	// the server starts a new watch
+	commands []watchCommand
+}
+
+type watchCommand struct {
+	Add     *WatchAddRequest
+	Unwatch *UnwatchRequest
+}
+
+// Subscribe starts a watch on the server. The types of events are
+// defined by the options (req.TypeMask is ignored), and the PathFilter
+// (if any) is applied on the client.
+//
+// The errors of the watch on the server are passed to errorHandler;
+// the errors it returns are reported through Errors.
+//
+// If req.Token is set then the watch is resumed from it (see
+// EventEmitter.ResumeToken).
+func (stor *Storage) Subscribe(
+	req WatchRequest,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
+) (*EventEmitter, error) {
+	evEmitter := &EventEmitter{
+		storage:      stor,
+		config:       *event.NewWatchConfig(opts...),
+		errorHandler: errorHandler,
+		eventChan:    make(chan event.Event, stor.eventQueueSize()),
+		errChan:      make(chan error, event.ErrorQueueSize),
+		request:      req,
+	}
+	evEmitter.request.TypeMask = evEmitter.config.TypeMask
+	evEmitter.ctx, evEmitter.cancelFn = context.WithCancel(stor.ctx)
+
+	stream, isLost, err := evEmitter.connect()
+	if err != nil {
+		evEmitter.cancelFn()
+		return nil, &file.ErrWatch{
+			Path: req.Path,
+			Err:  err,
+		}
+	}
+
+	evEmitter.wg.Add(1)
+	go func() {
+		defer func() {
+			close(evEmitter.eventChan)
+			close(evEmitter.errChan)
+			evEmitter.wg.Done()
+		}()
+		evEmitter.loop(stream, isLost)
+	}()
+	return evEmitter, nil
+}
+
+// connect starts (or resumes) the watch on the server.
+//
+// isLost is true if the events since the last received one are lost.
+func (evEmitter *EventEmitter) connect() (stream grpc.ClientStream, isLost bool, err error) {
+	evEmitter.locker.Lock()
+	req := evEmitter.request
+	evEmitter.locker.Unlock()
+
+	stream, err = evEmitter.storage.newStream(evEmitter.ctx, &watchStreamDesc)
+	if err != nil {
+		return nil, false, err
+	}
+	err = stream.SendMsg(&req)
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	var msg WatchMessage
+	if err == nil {
+		err = stream.RecvMsg(&msg)
+	}
+	if err != nil {
+		return nil, false, ErrCall{Method: methodWatch, Err: err}
+	}
+	hello := msg.Hello
+	if hello == nil {
+		return nil, false, ErrCall{Method: methodWatch, Err: fmt.Errorf("no hello message")}
+	}
+	if hello.Error != nil {
+		return nil, false, hello.Error.Err()
+	}
+
+	evEmitter.locker.Lock()
+	defer evEmitter.locker.Unlock()
+	token := &evEmitter.request.Token
+	isNewWatch := token.WatchID != hello.WatchID
+	if isNewWatch {
+		*token = ResumeToken{
+			ServerID: hello.ServerID,
+			WatchID:  hello.WatchID,
+		}
+		if len(evEmitter.commands) > 0 {
+			evEmitter.replayCommands()
+		}
+	}
+	return stream, hello.Lost, nil
+}
+
+// replayCommands repeats the Watch and Unwatch calls on a new watch
+// of the server. The errors are reported through Errors.
+//
+// It is called with the locker held.
+func (evEmitter *EventEmitter) replayCommands() {
+	watchID := evEmitter.request.Token.WatchID
+	for _, cmd := range evEmitter.commands {
+		var err error
+		switch {
+		case cmd.Add != nil:
+			req := *cmd.Add
+			req.WatchID = watchID
+			err = evEmitter.invoke(methodWatchAdd, &req)
+		case cmd.Unwatch != nil:
+			req := *cmd.Unwatch
+			req.WatchID = watchID
+			err = evEmitter.invoke(methodUnwatch, &req)
+		}
+		if err != nil {
+			evEmitter.sendError(err)
+		}
+	}
+}
+
+func (evEmitter *EventEmitter) invoke(method string, req interface{}) error {
+	var resp ErrorResponse
+	if err := evEmitter.storage.invoke(evEmitter.ctx, method, req, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error.Err()
+	}
+	return nil
+}
+
+func (evEmitter *EventEmitter) loop(stream grpc.ClientStream, isLost bool) {
+	for {
+		if isLost && !evEmitter.emitOverflow() {
+			return
+		}
+
+		err := evEmitter.receiveLoop(stream)
+		if evEmitter.ctx.Err() != nil {
+			return
+		}
+		evEmitter.sendError(ErrCall{Method: methodWatch, Err: err})
+
+		for {
+			select {
+			case <-evEmitter.ctx.Done():
+				return
+			case <-time.After(evEmitter.storage.watchRetryInterval()):
+			}
+			stream, isLost, err = evEmitter.connect()
+			if err == nil {
+				break
+			}
+			if evEmitter.ctx.Err() != nil {
+				return
+			}
+			evEmitter.sendError(err)
+		}
+	}
+}
+
+// receiveLoop reports the messages of the stream until it is broken.
+func (evEmitter *EventEmitter) receiveLoop(stream grpc.ClientStream) error {
+	for {
+		var msg WatchMessage
+		if err := stream.RecvMsg(&msg); err != nil {
+			return err
+		}
+
+		switch {
+		case msg.Event != nil:
+			if ev, ok := evEmitter.config.Filter(msg.Event.Event()); ok && !evEmitter.emit(ev) {
+				return file.ErrAborted{}
+			}
+		case msg.Error != nil:
+			err := msg.Error.Err()
+			if evEmitter.errorHandler != nil {
+				err = evEmitter.errorHandler(err)
+			}
+			if err != nil {
+				evEmitter.sendError(err)
+			}
+		}
+
+		evEmitter.locker.Lock()
+		evEmitter.request.Token.Seq = msg.Seq
+		evEmitter.locker.Unlock()
+	}
+}
+
+// emitOverflow reports that the events of the watched path are lost.
+func (evEmitter *EventEmitter) emitOverflow() bool {
+	evEmitter.locker.Lock()
+	path := evEmitter.request.Path
+	evEmitter.locker.Unlock()
+	return evEmitter.emit(event.Event{
+		Path:      path,
+		TypeMask:  event.TypeOverflow,
+		Timestamp: time.Now(),
+	})
+}
+
+func (evEmitter *EventEmitter) emit(ev event.Event) bool {
+	select {
+	case evEmitter.eventChan <- ev:
+		return true
+	case <-evEmitter.ctx.Done():
+		return false
+	}
+}
+
+func (evEmitter *EventEmitter) sendError(err error) {
+	select {
+	case evEmitter.errChan <- err:
+	default:
+	}
+}
+
+// ResumeToken returns the position of the last received event. It may
+// be passed to Storage.Subscribe to continue watching (for example,
+// after a restart of the client) without losing events.
+func (evEmitter *EventEmitter) ResumeToken() ResumeToken {
+	evEmitter.locker.Lock()
+	defer evEmitter.locker.Unlock()
+	return evEmitter.request.Token
+}
+
+func (evEmitter *EventEmitter) C() <-chan event.Event {
+	return evEmitter.eventChan
+}
+
+// Errors returns the errors of the watch on the server which are not
+// handled by the ErrorHandlerFunc, and the failures of the connection.
+func (evEmitter *EventEmitter) Errors() <-chan error {
+	return evEmitter.errChan
+}
+
+// Close stops the watch on the server.
+func (evEmitter *EventEmitter) Close() error {
+	evEmitter.locker.Lock()
+	watchID := evEmitter.request.Token.WatchID
+	evEmitter.locker.Unlock()
+
+	evEmitter.cancelFn()
+	evEmitter.wg.Wait()
+
+	// the watch is closed by the server anyway if the storage is closed
+	var resp ErrorResponse
+	err := evEmitter.storage.invoke(evEmitter.storage.ctx, methodWatchClose, &WatchCloseRequest{WatchID: watchID}, &resp)
+	if _, ok := err.(file.ErrAborted); ok {
+		return nil
+	}
+	return err
+}
+
+// Watch watches one more directory by the watch on the server. The
+// functions cannot be sent to the server, so shouldWatchFunc and
+// shouldWalkFunc should be nil (use WatchWithRules instead). The
+// errors are handled by the ErrorHandlerFunc of Storage.Subscribe,
+// so errorHandler is ignored.
+func (evEmitter *EventEmitter) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc event.ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
+) error {
+	if shouldWatchFunc != nil || shouldWalkFunc != nil {
+		return ErrNotSerializable{}
+	}
+	path, err := evEmitter.storage.pathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	return evEmitter.WatchWithRules(path, nil, nil, opts...)
+}
+
+// WatchWithRules is Watch with the serializable analogs of
+// ShouldWatchFunc and ShouldWalkFunc.
+func (evEmitter *EventEmitter) WatchWithRules(
+	path file.Path,
+	watchRules Rules,
+	walkRules Rules,
+	opts ...event.WatchOption,
+) error {
+	evEmitter.locker.Lock()
+	defer evEmitter.locker.Unlock()
+	req := WatchAddRequest{
+		WatchID:    evEmitter.request.Token.WatchID,
+		Path:       path,
+		WatchRules: watchRules,
+		WalkRules:  walkRules,
+		TypeMask:   event.NewWatchConfig(opts...).TypeMask,
+	}
+	if err := evEmitter.invoke(methodWatchAdd, &req); err != nil {
+		return &file.ErrWatch{
+			Path: path,
+			Err:  err,
+		}
+	}
+	evEmitter.commands = append(evEmitter.commands, watchCommand{Add: &req})
+	return nil
+}
+
+func (evEmitter *EventEmitter) Unwatch(path file.Path, recursive bool) error {
+	evEmitter.locker.Lock()
+	defer evEmitter.locker.Unlock()
+	req := UnwatchRequest{
+		WatchID:     evEmitter.request.Token.WatchID,
+		Path:        path,
+		IsRecursive: recursive,
+	}
+	if err := evEmitter.invoke(methodUnwatch, &req); err != nil {
+		return &file.ErrUnwatch{
+			Path: path,
+			Err:  err,
+		}
+	}
+	evEmitter.commands = append(evEmitter.commands, watchCommand{Unwatch: &req})
+	return nil
+}
@@ -0,0 +1,107 @@
+package fsdgrpc
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/my-network/fsutil/pkg/file/storage/memfs"
+	"github.com/stretchr/testify/require"
+)
+
+func nextEvent(t *testing.T, evEmitter event.Emitter) event.Event {
+	select {
+	case ev := <-evEmitter.C():
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	panic("unreachable")
+}
+
+func expectEvent(t *testing.T, evEmitter event.Emitter, typeMask event.TypeMask, path file.Path) {
+	ev := nextEvent(t, evEmitter)
+	require.Equal(t, typeMask, ev.TypeMask, ev.Path)
+	require.Equal(t, path, ev.Path)
+}
+
+func TestEventEmitter(t *testing.T) {
+	backend := memfs.NewStorage()
+	stor, _ := newTestStorage(t, backend)
+	ctx := context.Background()
+
+	evEmitter, err := stor.Subscribe(WatchRequest{
+		WatchRules: Rules{{Name: "ignored", Exclude: true}},
+	}, nil, event.OptionTypeMask{Mask: event.TypeCreate | event.TypeDelete})
+	require.NoError(t, err)
+	require.False(t, evEmitter.ResumeToken().IsZero())
+
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"a"}, 0755, false))
+	expectEvent(t, evEmitter, event.TypeCreate, file.Path{"a"})
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"a", "ignored"}, 0755, false))
+	expectEvent(t, evEmitter, event.TypeCreate, file.Path{"a", "ignored"})
+
+	// the excluded directory is not watched
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"a", "ignored", "b"}, 0755, false))
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"a", "c"}, 0755, false))
+	require.NoError(t, backend.Remove(ctx, nil, file.Path{"a", "c"}, false))
+	expectEvent(t, evEmitter, event.TypeCreate, file.Path{"a", "c"})
+	expectEvent(t, evEmitter, event.TypeDelete, file.Path{"a", "c"})
+
+	// functions cannot be sent to the server
+	_, err = stor.Watch(nil, nil, nil, func(file.Directory, os.FileInfo) bool { return true }, nil)
+	require.Equal(t, ErrNotSerializable{}, err)
+
+	require.NoError(t, evEmitter.Close())
+	_, ok := <-evEmitter.C()
+	require.False(t, ok)
+}
+
+func TestEventEmitterResume(t *testing.T) {
+	backend := memfs.NewStorage()
+	stor, srv := newTestStorage(t, backend,
+		OptionWatchJournalSize{Size: 2},
+		OptionWatchRetryInterval{Value: time.Millisecond})
+	ctx := context.Background()
+
+	evEmitter, err := stor.Subscribe(WatchRequest{}, nil, event.OptionTypeMask{Mask: event.TypeCreate})
+	require.NoError(t, err)
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"a"}, 0755, false))
+	expectEvent(t, evEmitter, event.TypeCreate, file.Path{"a"})
+	token := evEmitter.ResumeToken()
+	require.Equal(t, uint64(1), token.Seq)
+
+	// the events after the token are received again
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"b"}, 0755, false))
+	expectEvent(t, evEmitter, event.TypeCreate, file.Path{"b"})
+	resumed, err := stor.Subscribe(WatchRequest{Token: token}, nil, event.OptionTypeMask{Mask: event.TypeCreate})
+	require.NoError(t, err)
+	expectEvent(t, resumed, event.TypeCreate, file.Path{"b"})
+	require.Equal(t, token.WatchID, resumed.ResumeToken().WatchID)
+
+	// the events dropped from the journal are lost
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"c"}, 0755, false))
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"d"}, 0755, false))
+	expectEvent(t, evEmitter, event.TypeCreate, file.Path{"c"})
+	expectEvent(t, evEmitter, event.TypeCreate, file.Path{"d"})
+	lost, err := stor.Subscribe(WatchRequest{Token: token}, nil, event.OptionTypeMask{Mask: event.TypeCreate})
+	require.NoError(t, err)
+	expectEvent(t, lost, event.TypeOverflow, nil)
+	expectEvent(t, lost, event.TypeCreate, file.Path{"c"})
+	expectEvent(t, lost, event.TypeCreate, file.Path{"d"})
+	require.NoError(t, lost.Close())
+
+	// the watch is closed on the server, so the emitter reconnects to
+	// a new watch and reports that the events are lost
+	expectEvent(t, evEmitter, event.TypeOverflow, nil)
+	require.NotEqual(t, token.WatchID, evEmitter.ResumeToken().WatchID)
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"e"}, 0755, false))
+	expectEvent(t, evEmitter, event.TypeCreate, file.Path{"e"})
+
+	require.NoError(t, evEmitter.Close())
+	require.NoError(t, resumed.Close())
+	require.Nil(t, srv.watchByID(token.WatchID))
+}
@@ -0,0 +1,139 @@
+package fsdgrpc
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.File = &File{}
+
+type File struct {
+	Object
+}
+
+func (f *File) Read(b []byte) (int, error) {
+	return f.read(b, 0, false)
+}
+
+func (f *File) ReadAt(b []byte, offset int64) (int, error) {
+	return f.read(b, offset, true)
+}
+
+// read reads the data streamed by the server (see Server.read).
+func (f *File) read(b []byte, offset int64, useOffset bool) (int, error) {
+	stor := f.StorageValue
+	stream, err := stor.newStream(stor.ctx, &readStreamDesc, stor.dataCallOptions()...)
+	if err != nil {
+		return 0, err
+	}
+	err = stream.SendMsg(&ReadRequest{
+		Handle:    f.handle,
+		Size:      uint32(len(b)),
+		Offset:    offset,
+		UseOffset: useOffset,
+	})
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	if err != nil {
+		return 0, ErrCall{Method: methodRead, Err: err}
+	}
+
+	var n int
+	for {
+		var chunk ReadChunk
+		err := stream.RecvMsg(&chunk)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, ErrCall{Method: methodRead, Err: err}
+		}
+		n += copy(b[n:], chunk.Data)
+		if chunk.Error != nil {
+			return n, chunk.Error.Err()
+		}
+	}
+}
+
+func (f *File) Write(b []byte) (int, error) {
+	return f.write(b, 0, false)
+}
+
+func (f *File) WriteAt(b []byte, offset int64) (int, error) {
+	return f.write(b, offset, true)
+}
+
+// write streams the data to the server by chunks of ChunkSize
+// (see Server.write).
+func (f *File) write(b []byte, offset int64, useOffset bool) (int, error) {
+	stor := f.StorageValue
+	stream, err := stor.newStream(stor.ctx, &writeStreamDesc, stor.dataCallOptions()...)
+	if err != nil {
+		return 0, err
+	}
+
+	chunkSize := stor.chunkSize()
+	for isFirst := true; isFirst || len(b) > 0; isFirst = false {
+		size := len(b)
+		if size > chunkSize {
+			size = chunkSize
+		}
+		err := stream.SendMsg(&WriteChunk{
+			Handle:    f.handle,
+			Offset:    offset,
+			UseOffset: useOffset,
+			Data:      b[:size],
+		})
+		if err != nil {
+			// the server stopped reading (for example, on an error);
+			// the reason is in the response
+			break
+		}
+		b = b[size:]
+	}
+	if err := stream.CloseSend(); err != nil {
+		return 0, ErrCall{Method: methodWrite, Err: err}
+	}
+
+	var resp WriteResponse
+	if err := stream.RecvMsg(&resp); err != nil {
+		return 0, ErrCall{Method: methodWrite, Err: err}
+	}
+	return int(resp.N), resp.Error.Err()
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	stor := f.StorageValue
+	var resp SeekResponse
+	err := stor.invoke(stor.ctx, methodSeek, &SeekRequest{
+		Handle: f.handle,
+		Offset: offset,
+		Whence: whence,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Offset, resp.Error.Err()
+}
+
+func (f *File) Sync() error {
+	return f.invokeNoResult(methodSync, &HandleRequest{Handle: f.handle})
+}
+
+// SetDeadline is not supported: the calls are limited only by
+// the connection.
+func (f *File) SetDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetReadDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetWriteDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
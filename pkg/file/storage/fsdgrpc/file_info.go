@@ -0,0 +1,65 @@
+package fsdgrpc
+
+import (
+	"os"
+	"time"
+)
+
+// newFileInfo returns the serializable form of the os.FileInfo.
+func newFileInfo(info os.FileInfo) *FileInfo {
+	if info == nil {
+		return nil
+	}
+	if remoteInfo, ok := info.Sys().(*FileInfo); ok {
+		// a storage of another fsd server
+		result := *remoteInfo
+		return &result
+	}
+	result := &FileInfo{
+		Name:    info.Name(),
+		Size:    info.Size(),
+		Mode:    uint32(info.Mode()),
+		ModTime: info.ModTime(),
+	}
+	fillStat(result, info.Sys())
+	return result
+}
+
+// OSFileInfo returns the os.FileInfo represented by `info`.
+func (info *FileInfo) OSFileInfo() os.FileInfo {
+	if info == nil {
+		return nil
+	}
+	return &fileInfo{info: info}
+}
+
+var _ os.FileInfo = &fileInfo{}
+
+type fileInfo struct {
+	info *FileInfo
+}
+
+func (info *fileInfo) Name() string {
+	return info.info.Name
+}
+
+func (info *fileInfo) Size() int64 {
+	return info.info.Size
+}
+
+func (info *fileInfo) Mode() os.FileMode {
+	return os.FileMode(info.info.Mode)
+}
+
+func (info *fileInfo) ModTime() time.Time {
+	return info.info.ModTime
+}
+
+func (info *fileInfo) IsDir() bool {
+	return info.Mode().IsDir()
+}
+
+// Sys returns *FileInfo.
+func (info *fileInfo) Sys() interface{} {
+	return info.info
+}
@@ -0,0 +1,24 @@
+// +build linux
+
+package fsdgrpc
+
+import (
+	"syscall"
+	"time"
+)
+
+// fillStat copies the OS-specific attributes of the object (if `sys`
+// is *syscall.Stat_t).
+func fillStat(info *FileInfo, sys interface{}) {
+	stat, ok := sys.(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	info.Dev = uint64(stat.Dev)
+	info.Ino = uint64(stat.Ino)
+	info.Nlink = uint64(stat.Nlink)
+	info.UID = int(stat.Uid)
+	info.GID = int(stat.Gid)
+	info.Atime = time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
+	info.Ctime = time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec))
+}
@@ -0,0 +1,7 @@
+// +build !linux
+
+package fsdgrpc
+
+// fillStat does nothing: the OS-specific attributes are supported only
+// on Linux.
+func fillStat(info *FileInfo, sys interface{}) {}
@@ -0,0 +1,356 @@
+//go:generate msgp
+
+package fsdgrpc
+
+import (
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Handle identifies an object opened on the server within a session.
+// The zero handle means "no object" (for example, `dirAt` is nil).
+type Handle uint64
+
+// ObjectType is the type of an opened object, it defines which
+// file.Object interface the client side implements.
+type ObjectType uint8
+
+const (
+	ObjectTypeUntyped = ObjectType(iota)
+	ObjectTypeDirectory
+	ObjectTypeFile
+	ObjectTypeSymlink
+	ObjectTypePathDescriptor
+)
+
+// ErrorKind is the class of an error, it defines which error value is
+// reconstructed on the client side (see Error.Err).
+type ErrorKind uint8
+
+const (
+	ErrorKindOther = ErrorKind(iota)
+	ErrorKindErrno
+	ErrorKindNotExist
+	ErrorKindExist
+	ErrorKindPermission
+	ErrorKindClosed
+	ErrorKindEOF
+	ErrorKindNotImplemented
+	ErrorKindAborted
+	ErrorKindInvalidHandle
+)
+
+// Error is the serializable form of an error returned by the storage
+// on the server side.
+type Error struct {
+	Kind  ErrorKind `msg:"kind"`
+	Errno uint32    `msg:"errno"`
+
+	// Op, Path and NewPath are the fields of *os.PathError and
+	// *os.LinkError (if the error is one of them).
+	Op      string `msg:"op"`
+	Path    string `msg:"path"`
+	NewPath string `msg:"new_path"`
+
+	// Message is the text of the original error.
+	Message string `msg:"message"`
+}
+
+// FileInfo is the serializable form of os.FileInfo. The os.FileInfo
+// returned by the client has Sys() of type *FileInfo.
+type FileInfo struct {
+	Name    string    `msg:"name"`
+	Size    int64     `msg:"size"`
+	Mode    uint32    `msg:"mode"`
+	ModTime time.Time `msg:"mtime"`
+
+	// The fields below are filled only if the server storage provides
+	// them (for example, if Sys() is *syscall.Stat_t).
+	Dev   uint64    `msg:"dev"`
+	Ino   uint64    `msg:"ino"`
+	Nlink uint64    `msg:"nlink"`
+	UID   int       `msg:"uid"`
+	GID   int       `msg:"gid"`
+	Atime time.Time `msg:"atime"`
+	Ctime time.Time `msg:"ctime"`
+}
+
+type ObjectID struct {
+	Dev    uint64 `msg:"dev"`
+	Ino    uint64 `msg:"ino"`
+	Handle []byte `msg:"handle"`
+}
+
+// SessionRequest starts a session. Compressors are the names of
+// the grpc compressors the client may use for the Read and Write streams,
+// in the order of preference.
+type SessionRequest struct {
+	Compressors []string `msg:"compressors"`
+}
+
+type SessionResponse struct {
+	SessionID string `msg:"session_id"`
+
+	// Compressor is the compressor chosen by the server from
+	// SessionRequest.Compressors (empty means no compression).
+	Compressor string `msg:"compressor"`
+}
+
+// ErrorResponse is the response of the requests which return only
+// an error.
+type ErrorResponse struct {
+	Error *Error `msg:"error"`
+}
+
+type OpenRequest struct {
+	DirAt Handle    `msg:"dir_at"`
+	Path  file.Path `msg:"path"`
+	Flags uint16    `msg:"flags"`
+	Perm  uint32    `msg:"perm"`
+}
+
+type OpenResponse struct {
+	Handle Handle     `msg:"handle"`
+	Type   ObjectType `msg:"type"`
+	ID     ObjectID   `msg:"id"`
+	Info   *FileInfo  `msg:"info"`
+	Path   file.Path  `msg:"path"`
+	Error  *Error     `msg:"error"`
+}
+
+type StatRequest struct {
+	DirAt    Handle    `msg:"dir_at"`
+	Path     file.Path `msg:"path"`
+	NoFollow bool      `msg:"no_follow"`
+}
+
+type StatResponse struct {
+	Info  *FileInfo `msg:"info"`
+	Error *Error    `msg:"error"`
+}
+
+type SymlinkRequest struct {
+	DirAt       Handle    `msg:"dir_at"`
+	Path        file.Path `msg:"path"`
+	Destination file.Path `msg:"destination"`
+}
+
+type ReadlinkRequest struct {
+	DirAt Handle    `msg:"dir_at"`
+	Path  file.Path `msg:"path"`
+}
+
+type ReadlinkResponse struct {
+	Destination file.Path `msg:"destination"`
+	Error       *Error    `msg:"error"`
+}
+
+type MkdirRequest struct {
+	DirAt       Handle    `msg:"dir_at"`
+	Path        file.Path `msg:"path"`
+	Perm        uint32    `msg:"perm"`
+	IsRecursive bool      `msg:"recursive"`
+}
+
+type RemoveRequest struct {
+	DirAt       Handle    `msg:"dir_at"`
+	Path        file.Path `msg:"path"`
+	IsRecursive bool      `msg:"recursive"`
+}
+
+type RenameRequest struct {
+	DirAt   Handle    `msg:"dir_at"`
+	Path    file.Path `msg:"path"`
+	NewPath file.Path `msg:"new_path"`
+}
+
+type LinkRequest struct {
+	DirAt       Handle    `msg:"dir_at"`
+	Path        file.Path `msg:"path"`
+	Destination file.Path `msg:"destination"`
+}
+
+type ChmodRequest struct {
+	DirAt Handle    `msg:"dir_at"`
+	Path  file.Path `msg:"path"`
+	Mode  uint32    `msg:"mode"`
+}
+
+type ChownRequest struct {
+	DirAt    Handle    `msg:"dir_at"`
+	Path     file.Path `msg:"path"`
+	UID      int       `msg:"uid"`
+	GID      int       `msg:"gid"`
+	NoFollow bool      `msg:"no_follow"`
+}
+
+type ChtimesRequest struct {
+	DirAt Handle    `msg:"dir_at"`
+	Path  file.Path `msg:"path"`
+	Atime time.Time `msg:"atime"`
+	Mtime time.Time `msg:"mtime"`
+}
+
+// HandleRequest is the request of the operations on an opened object
+// which have no arguments (Close, Stat, Sync and Destination).
+type HandleRequest struct {
+	Handle Handle `msg:"handle"`
+}
+
+type ObjectOpenRequest struct {
+	Handle Handle `msg:"handle"`
+	Flags  uint16 `msg:"flags"`
+	Perm   uint32 `msg:"perm"`
+}
+
+type ObjectChmodRequest struct {
+	Handle Handle `msg:"handle"`
+	Mode   uint32 `msg:"mode"`
+}
+
+type ObjectChownRequest struct {
+	Handle Handle `msg:"handle"`
+	UID    int    `msg:"uid"`
+	GID    int    `msg:"gid"`
+}
+
+type ReaddirRequest struct {
+	Handle Handle `msg:"handle"`
+	N      int    `msg:"n"`
+}
+
+type ReaddirResponse struct {
+	Infos []FileInfo `msg:"infos"`
+	Error *Error     `msg:"error"`
+}
+
+type SeekRequest struct {
+	Handle Handle `msg:"handle"`
+	Offset int64  `msg:"offset"`
+	Whence int    `msg:"whence"`
+}
+
+type SeekResponse struct {
+	Offset int64  `msg:"offset"`
+	Error  *Error `msg:"error"`
+}
+
+// ReadRequest requests to read up to Size bytes. The data is streamed
+// back as ReadChunk-s; the error (if any) is in the last chunk.
+type ReadRequest struct {
+	Handle Handle `msg:"handle"`
+	Size   uint32 `msg:"size"`
+
+	// Offset is used only if UseOffset is true (ReadAt), otherwise
+	// the current offset of the file is used (Read).
+	Offset    int64 `msg:"offset"`
+	UseOffset bool  `msg:"use_offset"`
+}
+
+type ReadChunk struct {
+	Data  []byte `msg:"data"`
+	Error *Error `msg:"error"`
+}
+
+// WriteChunk is a part of the data streamed by the client to write.
+// Handle, Offset and UseOffset are taken from the first chunk.
+type WriteChunk struct {
+	Handle    Handle `msg:"handle"`
+	Offset    int64  `msg:"offset"`
+	UseOffset bool   `msg:"use_offset"`
+	Data      []byte `msg:"data"`
+}
+
+type WriteResponse struct {
+	N     int64  `msg:"n"`
+	Error *Error `msg:"error"`
+}
+
+// ResumeToken is the position in the stream of events of a watch on
+// the server. A client reconnecting with the token receives the events
+// it missed, or learns that they are lost (see WatchHello).
+type ResumeToken struct {
+	// ServerID is the ID of the Server instance (it changes on restart).
+	ServerID string `msg:"server_id"`
+	WatchID  string `msg:"watch_id"`
+
+	// Seq is the sequence number of the last received WatchMessage.
+	Seq uint64 `msg:"seq"`
+}
+
+func (token ResumeToken) IsZero() bool {
+	return token == ResumeToken{}
+}
+
+// WatchRequest starts a watch on the server (or resumes it if Token is
+// set). The arguments are the same as of event.Watcher.Watch.
+type WatchRequest struct {
+	Path       file.Path      `msg:"path"`
+	WatchRules Rules          `msg:"watch_rules"`
+	WalkRules  Rules          `msg:"walk_rules"`
+	TypeMask   event.TypeMask `msg:"type_mask"`
+	Token      ResumeToken    `msg:"token"`
+}
+
+// WatchMessage is a message of the stream of a watch. The first message
+// has only Hello set, any other one has either Event or Error.
+type WatchMessage struct {
+	Seq   uint64        `msg:"seq"`
+	Event *event.Record `msg:"event"`
+	Error *Error        `msg:"error"`
+	Hello *WatchHello   `msg:"hello"`
+}
+
+type WatchHello struct {
+	ServerID string `msg:"server_id"`
+	WatchID  string `msg:"watch_id"`
+
+	// Lost is true if the events since the resume token are lost (for
+	// example, the server was restarted), so the tree should be rescanned.
+	Lost bool `msg:"lost"`
+
+	// Error is the error of starting the watch; the stream ends after it.
+	Error *Error `msg:"error"`
+}
+
+// WatchAddRequest requests to watch one more directory by the watch
+// (see EventEmitter.Watch).
+type WatchAddRequest struct {
+	WatchID    string         `msg:"watch_id"`
+	Path       file.Path      `msg:"path"`
+	WatchRules Rules          `msg:"watch_rules"`
+	WalkRules  Rules          `msg:"walk_rules"`
+	TypeMask   event.TypeMask `msg:"type_mask"`
+}
+
+type UnwatchRequest struct {
+	WatchID     string    `msg:"watch_id"`
+	Path        file.Path `msg:"path"`
+	IsRecursive bool      `msg:"recursive"`
+}
+
+type WatchCloseRequest struct {
+	WatchID string `msg:"watch_id"`
+}
+
+// BatchRequest is a sequence of unary calls done by one round trip (see
+// Batch). The calls are done in order; the handles in a request may refer
+// the objects opened by the previous calls (see BatchHandle).
+type BatchRequest struct {
+	Calls []BatchCall `msg:"calls"`
+}
+
+type BatchCall struct {
+	Method  string   `msg:"method"`
+	Request msgp.Raw `msg:"request"`
+}
+
+// BatchResponse has the encoded responses of the calls of a BatchRequest
+// (in the same order). The errors of the storage are in the responses
+// themselves.
+type BatchResponse struct {
+	Responses []msgp.Raw `msg:"responses"`
+}
@@ -0,0 +1,8035 @@
+package fsdgrpc
+
+// Code generated by github.com/tinylib/msgp DO NOT EDIT.
+
+import (
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// DecodeMsg implements msgp.Decodable
+func (z *BatchCall) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "method":
+			z.Method, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Method")
+				return
+			}
+		case "request":
+			err = z.Request.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Request")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *BatchCall) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "method"
+	err = en.Append(0x82, 0xa6, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Method)
+	if err != nil {
+		err = msgp.WrapError(err, "Method")
+		return
+	}
+	// write "request"
+	err = en.Append(0xa7, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74)
+	if err != nil {
+		return
+	}
+	err = z.Request.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Request")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *BatchCall) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "method"
+	o = append(o, 0x82, 0xa6, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64)
+	o = msgp.AppendString(o, z.Method)
+	// string "request"
+	o = append(o, 0xa7, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74)
+	o, err = z.Request.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Request")
+		return
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *BatchCall) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "method":
+			z.Method, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Method")
+				return
+			}
+		case "request":
+			bts, err = z.Request.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Request")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *BatchCall) Msgsize() (s int) {
+	s = 1 + 7 + msgp.StringPrefixSize + len(z.Method) + 8 + z.Request.Msgsize()
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *BatchRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "calls":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Calls")
+				return
+			}
+			if cap(z.Calls) >= int(zb0002) {
+				z.Calls = (z.Calls)[:zb0002]
+			} else {
+				z.Calls = make([]BatchCall, zb0002)
+			}
+			for za0001 := range z.Calls {
+				var zb0003 uint32
+				zb0003, err = dc.ReadMapHeader()
+				if err != nil {
+					err = msgp.WrapError(err, "Calls", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						err = msgp.WrapError(err, "Calls", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "method":
+						z.Calls[za0001].Method, err = dc.ReadString()
+						if err != nil {
+							err = msgp.WrapError(err, "Calls", za0001, "Method")
+							return
+						}
+					case "request":
+						err = z.Calls[za0001].Request.DecodeMsg(dc)
+						if err != nil {
+							err = msgp.WrapError(err, "Calls", za0001, "Request")
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							err = msgp.WrapError(err, "Calls", za0001)
+							return
+						}
+					}
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *BatchRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "calls"
+	err = en.Append(0x81, 0xa5, 0x63, 0x61, 0x6c, 0x6c, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Calls)))
+	if err != nil {
+		err = msgp.WrapError(err, "Calls")
+		return
+	}
+	for za0001 := range z.Calls {
+		// map header, size 2
+		// write "method"
+		err = en.Append(0x82, 0xa6, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64)
+		if err != nil {
+			return
+		}
+		err = en.WriteString(z.Calls[za0001].Method)
+		if err != nil {
+			err = msgp.WrapError(err, "Calls", za0001, "Method")
+			return
+		}
+		// write "request"
+		err = en.Append(0xa7, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74)
+		if err != nil {
+			return
+		}
+		err = z.Calls[za0001].Request.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Calls", za0001, "Request")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *BatchRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "calls"
+	o = append(o, 0x81, 0xa5, 0x63, 0x61, 0x6c, 0x6c, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Calls)))
+	for za0001 := range z.Calls {
+		// map header, size 2
+		// string "method"
+		o = append(o, 0x82, 0xa6, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64)
+		o = msgp.AppendString(o, z.Calls[za0001].Method)
+		// string "request"
+		o = append(o, 0xa7, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74)
+		o, err = z.Calls[za0001].Request.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Calls", za0001, "Request")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *BatchRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "calls":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Calls")
+				return
+			}
+			if cap(z.Calls) >= int(zb0002) {
+				z.Calls = (z.Calls)[:zb0002]
+			} else {
+				z.Calls = make([]BatchCall, zb0002)
+			}
+			for za0001 := range z.Calls {
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Calls", za0001)
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Calls", za0001)
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "method":
+						z.Calls[za0001].Method, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Calls", za0001, "Method")
+							return
+						}
+					case "request":
+						bts, err = z.Calls[za0001].Request.UnmarshalMsg(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Calls", za0001, "Request")
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							err = msgp.WrapError(err, "Calls", za0001)
+							return
+						}
+					}
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *BatchRequest) Msgsize() (s int) {
+	s = 1 + 6 + msgp.ArrayHeaderSize
+	for za0001 := range z.Calls {
+		s += 1 + 7 + msgp.StringPrefixSize + len(z.Calls[za0001].Method) + 8 + z.Calls[za0001].Request.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *BatchResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "responses":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Responses")
+				return
+			}
+			if cap(z.Responses) >= int(zb0002) {
+				z.Responses = (z.Responses)[:zb0002]
+			} else {
+				z.Responses = make([]msgp.Raw, zb0002)
+			}
+			for za0001 := range z.Responses {
+				err = z.Responses[za0001].DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Responses", za0001)
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *BatchResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "responses"
+	err = en.Append(0x81, 0xa9, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Responses)))
+	if err != nil {
+		err = msgp.WrapError(err, "Responses")
+		return
+	}
+	for za0001 := range z.Responses {
+		err = z.Responses[za0001].EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Responses", za0001)
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *BatchResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "responses"
+	o = append(o, 0x81, 0xa9, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Responses)))
+	for za0001 := range z.Responses {
+		o, err = z.Responses[za0001].MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Responses", za0001)
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *BatchResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "responses":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Responses")
+				return
+			}
+			if cap(z.Responses) >= int(zb0002) {
+				z.Responses = (z.Responses)[:zb0002]
+			} else {
+				z.Responses = make([]msgp.Raw, zb0002)
+			}
+			for za0001 := range z.Responses {
+				bts, err = z.Responses[za0001].UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Responses", za0001)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *BatchResponse) Msgsize() (s int) {
+	s = 1 + 10 + msgp.ArrayHeaderSize
+	for za0001 := range z.Responses {
+		s += z.Responses[za0001].Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ChmodRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "mode":
+			z.Mode, err = dc.ReadUint32()
+			if err != nil {
+				err = msgp.WrapError(err, "Mode")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ChmodRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "dir_at"
+	err = en.Append(0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "mode"
+	err = en.Append(0xa4, 0x6d, 0x6f, 0x64, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint32(z.Mode)
+	if err != nil {
+		err = msgp.WrapError(err, "Mode")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ChmodRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "dir_at"
+	o = append(o, 0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "mode"
+	o = append(o, 0xa4, 0x6d, 0x6f, 0x64, 0x65)
+	o = msgp.AppendUint32(o, z.Mode)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ChmodRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "mode":
+			z.Mode, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Mode")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ChmodRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 5 + msgp.Uint32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ChownRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "uid":
+			z.UID, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "UID")
+				return
+			}
+		case "gid":
+			z.GID, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "GID")
+				return
+			}
+		case "no_follow":
+			z.NoFollow, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "NoFollow")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ChownRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 5
+	// write "dir_at"
+	err = en.Append(0x85, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "uid"
+	err = en.Append(0xa3, 0x75, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.UID)
+	if err != nil {
+		err = msgp.WrapError(err, "UID")
+		return
+	}
+	// write "gid"
+	err = en.Append(0xa3, 0x67, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.GID)
+	if err != nil {
+		err = msgp.WrapError(err, "GID")
+		return
+	}
+	// write "no_follow"
+	err = en.Append(0xa9, 0x6e, 0x6f, 0x5f, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.NoFollow)
+	if err != nil {
+		err = msgp.WrapError(err, "NoFollow")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ChownRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 5
+	// string "dir_at"
+	o = append(o, 0x85, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "uid"
+	o = append(o, 0xa3, 0x75, 0x69, 0x64)
+	o = msgp.AppendInt(o, z.UID)
+	// string "gid"
+	o = append(o, 0xa3, 0x67, 0x69, 0x64)
+	o = msgp.AppendInt(o, z.GID)
+	// string "no_follow"
+	o = append(o, 0xa9, 0x6e, 0x6f, 0x5f, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77)
+	o = msgp.AppendBool(o, z.NoFollow)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ChownRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "uid":
+			z.UID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UID")
+				return
+			}
+		case "gid":
+			z.GID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "GID")
+				return
+			}
+		case "no_follow":
+			z.NoFollow, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "NoFollow")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ChownRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 4 + msgp.IntSize + 4 + msgp.IntSize + 10 + msgp.BoolSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ChtimesRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "atime":
+			z.Atime, err = dc.ReadTime()
+			if err != nil {
+				err = msgp.WrapError(err, "Atime")
+				return
+			}
+		case "mtime":
+			z.Mtime, err = dc.ReadTime()
+			if err != nil {
+				err = msgp.WrapError(err, "Mtime")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ChtimesRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "dir_at"
+	err = en.Append(0x84, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "atime"
+	err = en.Append(0xa5, 0x61, 0x74, 0x69, 0x6d, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteTime(z.Atime)
+	if err != nil {
+		err = msgp.WrapError(err, "Atime")
+		return
+	}
+	// write "mtime"
+	err = en.Append(0xa5, 0x6d, 0x74, 0x69, 0x6d, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteTime(z.Mtime)
+	if err != nil {
+		err = msgp.WrapError(err, "Mtime")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ChtimesRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "dir_at"
+	o = append(o, 0x84, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "atime"
+	o = append(o, 0xa5, 0x61, 0x74, 0x69, 0x6d, 0x65)
+	o = msgp.AppendTime(o, z.Atime)
+	// string "mtime"
+	o = append(o, 0xa5, 0x6d, 0x74, 0x69, 0x6d, 0x65)
+	o = msgp.AppendTime(o, z.Mtime)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ChtimesRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "atime":
+			z.Atime, bts, err = msgp.ReadTimeBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Atime")
+				return
+			}
+		case "mtime":
+			z.Mtime, bts, err = msgp.ReadTimeBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Mtime")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ChtimesRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 6 + msgp.TimeSize + 6 + msgp.TimeSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Error) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "kind":
+			{
+				var zb0002 uint8
+				zb0002, err = dc.ReadUint8()
+				if err != nil {
+					err = msgp.WrapError(err, "Kind")
+					return
+				}
+				z.Kind = ErrorKind(zb0002)
+			}
+		case "errno":
+			z.Errno, err = dc.ReadUint32()
+			if err != nil {
+				err = msgp.WrapError(err, "Errno")
+				return
+			}
+		case "op":
+			z.Op, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Op")
+				return
+			}
+		case "path":
+			z.Path, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "new_path":
+			z.NewPath, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "NewPath")
+				return
+			}
+		case "message":
+			z.Message, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Message")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *Error) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 6
+	// write "kind"
+	err = en.Append(0x86, 0xa4, 0x6b, 0x69, 0x6e, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint8(uint8(z.Kind))
+	if err != nil {
+		err = msgp.WrapError(err, "Kind")
+		return
+	}
+	// write "errno"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6e, 0x6f)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint32(z.Errno)
+	if err != nil {
+		err = msgp.WrapError(err, "Errno")
+		return
+	}
+	// write "op"
+	err = en.Append(0xa2, 0x6f, 0x70)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Op)
+	if err != nil {
+		err = msgp.WrapError(err, "Op")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Path)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "new_path"
+	err = en.Append(0xa8, 0x6e, 0x65, 0x77, 0x5f, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.NewPath)
+	if err != nil {
+		err = msgp.WrapError(err, "NewPath")
+		return
+	}
+	// write "message"
+	err = en.Append(0xa7, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Message)
+	if err != nil {
+		err = msgp.WrapError(err, "Message")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *Error) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 6
+	// string "kind"
+	o = append(o, 0x86, 0xa4, 0x6b, 0x69, 0x6e, 0x64)
+	o = msgp.AppendUint8(o, uint8(z.Kind))
+	// string "errno"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6e, 0x6f)
+	o = msgp.AppendUint32(o, z.Errno)
+	// string "op"
+	o = append(o, 0xa2, 0x6f, 0x70)
+	o = msgp.AppendString(o, z.Op)
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o = msgp.AppendString(o, z.Path)
+	// string "new_path"
+	o = append(o, 0xa8, 0x6e, 0x65, 0x77, 0x5f, 0x70, 0x61, 0x74, 0x68)
+	o = msgp.AppendString(o, z.NewPath)
+	// string "message"
+	o = append(o, 0xa7, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65)
+	o = msgp.AppendString(o, z.Message)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Error) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "kind":
+			{
+				var zb0002 uint8
+				zb0002, bts, err = msgp.ReadUint8Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Kind")
+					return
+				}
+				z.Kind = ErrorKind(zb0002)
+			}
+		case "errno":
+			z.Errno, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Errno")
+				return
+			}
+		case "op":
+			z.Op, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Op")
+				return
+			}
+		case "path":
+			z.Path, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "new_path":
+			z.NewPath, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "NewPath")
+				return
+			}
+		case "message":
+			z.Message, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Message")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *Error) Msgsize() (s int) {
+	s = 1 + 5 + msgp.Uint8Size + 6 + msgp.Uint32Size + 3 + msgp.StringPrefixSize + len(z.Op) + 5 + msgp.StringPrefixSize + len(z.Path) + 9 + msgp.StringPrefixSize + len(z.NewPath) + 8 + msgp.StringPrefixSize + len(z.Message)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ErrorKind) DecodeMsg(dc *msgp.Reader) (err error) {
+	{
+		var zb0001 uint8
+		zb0001, err = dc.ReadUint8()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		(*z) = ErrorKind(zb0001)
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z ErrorKind) EncodeMsg(en *msgp.Writer) (err error) {
+	err = en.WriteUint8(uint8(z))
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z ErrorKind) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	o = msgp.AppendUint8(o, uint8(z))
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ErrorKind) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	{
+		var zb0001 uint8
+		zb0001, bts, err = msgp.ReadUint8Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		(*z) = ErrorKind(zb0001)
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z ErrorKind) Msgsize() (s int) {
+	s = msgp.Uint8Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ErrorResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ErrorResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "error"
+	err = en.Append(0x81, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ErrorResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "error"
+	o = append(o, 0x81, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ErrorResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ErrorResponse) Msgsize() (s int) {
+	s = 1 + 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *FileInfo) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "name":
+			z.Name, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Name")
+				return
+			}
+		case "size":
+			z.Size, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+		case "mode":
+			z.Mode, err = dc.ReadUint32()
+			if err != nil {
+				err = msgp.WrapError(err, "Mode")
+				return
+			}
+		case "mtime":
+			z.ModTime, err = dc.ReadTime()
+			if err != nil {
+				err = msgp.WrapError(err, "ModTime")
+				return
+			}
+		case "dev":
+			z.Dev, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Dev")
+				return
+			}
+		case "ino":
+			z.Ino, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Ino")
+				return
+			}
+		case "nlink":
+			z.Nlink, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Nlink")
+				return
+			}
+		case "uid":
+			z.UID, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "UID")
+				return
+			}
+		case "gid":
+			z.GID, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "GID")
+				return
+			}
+		case "atime":
+			z.Atime, err = dc.ReadTime()
+			if err != nil {
+				err = msgp.WrapError(err, "Atime")
+				return
+			}
+		case "ctime":
+			z.Ctime, err = dc.ReadTime()
+			if err != nil {
+				err = msgp.WrapError(err, "Ctime")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *FileInfo) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 11
+	// write "name"
+	err = en.Append(0x8b, 0xa4, 0x6e, 0x61, 0x6d, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Name)
+	if err != nil {
+		err = msgp.WrapError(err, "Name")
+		return
+	}
+	// write "size"
+	err = en.Append(0xa4, 0x73, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Size)
+	if err != nil {
+		err = msgp.WrapError(err, "Size")
+		return
+	}
+	// write "mode"
+	err = en.Append(0xa4, 0x6d, 0x6f, 0x64, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint32(z.Mode)
+	if err != nil {
+		err = msgp.WrapError(err, "Mode")
+		return
+	}
+	// write "mtime"
+	err = en.Append(0xa5, 0x6d, 0x74, 0x69, 0x6d, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteTime(z.ModTime)
+	if err != nil {
+		err = msgp.WrapError(err, "ModTime")
+		return
+	}
+	// write "dev"
+	err = en.Append(0xa3, 0x64, 0x65, 0x76)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Dev)
+	if err != nil {
+		err = msgp.WrapError(err, "Dev")
+		return
+	}
+	// write "ino"
+	err = en.Append(0xa3, 0x69, 0x6e, 0x6f)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Ino)
+	if err != nil {
+		err = msgp.WrapError(err, "Ino")
+		return
+	}
+	// write "nlink"
+	err = en.Append(0xa5, 0x6e, 0x6c, 0x69, 0x6e, 0x6b)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Nlink)
+	if err != nil {
+		err = msgp.WrapError(err, "Nlink")
+		return
+	}
+	// write "uid"
+	err = en.Append(0xa3, 0x75, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.UID)
+	if err != nil {
+		err = msgp.WrapError(err, "UID")
+		return
+	}
+	// write "gid"
+	err = en.Append(0xa3, 0x67, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.GID)
+	if err != nil {
+		err = msgp.WrapError(err, "GID")
+		return
+	}
+	// write "atime"
+	err = en.Append(0xa5, 0x61, 0x74, 0x69, 0x6d, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteTime(z.Atime)
+	if err != nil {
+		err = msgp.WrapError(err, "Atime")
+		return
+	}
+	// write "ctime"
+	err = en.Append(0xa5, 0x63, 0x74, 0x69, 0x6d, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteTime(z.Ctime)
+	if err != nil {
+		err = msgp.WrapError(err, "Ctime")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *FileInfo) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 11
+	// string "name"
+	o = append(o, 0x8b, 0xa4, 0x6e, 0x61, 0x6d, 0x65)
+	o = msgp.AppendString(o, z.Name)
+	// string "size"
+	o = append(o, 0xa4, 0x73, 0x69, 0x7a, 0x65)
+	o = msgp.AppendInt64(o, z.Size)
+	// string "mode"
+	o = append(o, 0xa4, 0x6d, 0x6f, 0x64, 0x65)
+	o = msgp.AppendUint32(o, z.Mode)
+	// string "mtime"
+	o = append(o, 0xa5, 0x6d, 0x74, 0x69, 0x6d, 0x65)
+	o = msgp.AppendTime(o, z.ModTime)
+	// string "dev"
+	o = append(o, 0xa3, 0x64, 0x65, 0x76)
+	o = msgp.AppendUint64(o, z.Dev)
+	// string "ino"
+	o = append(o, 0xa3, 0x69, 0x6e, 0x6f)
+	o = msgp.AppendUint64(o, z.Ino)
+	// string "nlink"
+	o = append(o, 0xa5, 0x6e, 0x6c, 0x69, 0x6e, 0x6b)
+	o = msgp.AppendUint64(o, z.Nlink)
+	// string "uid"
+	o = append(o, 0xa3, 0x75, 0x69, 0x64)
+	o = msgp.AppendInt(o, z.UID)
+	// string "gid"
+	o = append(o, 0xa3, 0x67, 0x69, 0x64)
+	o = msgp.AppendInt(o, z.GID)
+	// string "atime"
+	o = append(o, 0xa5, 0x61, 0x74, 0x69, 0x6d, 0x65)
+	o = msgp.AppendTime(o, z.Atime)
+	// string "ctime"
+	o = append(o, 0xa5, 0x63, 0x74, 0x69, 0x6d, 0x65)
+	o = msgp.AppendTime(o, z.Ctime)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *FileInfo) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "name":
+			z.Name, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Name")
+				return
+			}
+		case "size":
+			z.Size, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+		case "mode":
+			z.Mode, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Mode")
+				return
+			}
+		case "mtime":
+			z.ModTime, bts, err = msgp.ReadTimeBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ModTime")
+				return
+			}
+		case "dev":
+			z.Dev, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Dev")
+				return
+			}
+		case "ino":
+			z.Ino, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Ino")
+				return
+			}
+		case "nlink":
+			z.Nlink, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Nlink")
+				return
+			}
+		case "uid":
+			z.UID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UID")
+				return
+			}
+		case "gid":
+			z.GID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "GID")
+				return
+			}
+		case "atime":
+			z.Atime, bts, err = msgp.ReadTimeBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Atime")
+				return
+			}
+		case "ctime":
+			z.Ctime, bts, err = msgp.ReadTimeBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Ctime")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *FileInfo) Msgsize() (s int) {
+	s = 1 + 5 + msgp.StringPrefixSize + len(z.Name) + 5 + msgp.Int64Size + 5 + msgp.Uint32Size + 6 + msgp.TimeSize + 4 + msgp.Uint64Size + 4 + msgp.Uint64Size + 6 + msgp.Uint64Size + 4 + msgp.IntSize + 4 + msgp.IntSize + 6 + msgp.TimeSize + 6 + msgp.TimeSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *Handle) DecodeMsg(dc *msgp.Reader) (err error) {
+	{
+		var zb0001 uint64
+		zb0001, err = dc.ReadUint64()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		(*z) = Handle(zb0001)
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Handle) EncodeMsg(en *msgp.Writer) (err error) {
+	err = en.WriteUint64(uint64(z))
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Handle) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	o = msgp.AppendUint64(o, uint64(z))
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Handle) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	{
+		var zb0001 uint64
+		zb0001, bts, err = msgp.ReadUint64Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		(*z) = Handle(zb0001)
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Handle) Msgsize() (s int) {
+	s = msgp.Uint64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *HandleRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z HandleRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "handle"
+	err = en.Append(0x81, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z HandleRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "handle"
+	o = append(o, 0x81, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *HandleRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z HandleRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *LinkRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "destination":
+			err = z.Destination.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Destination")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *LinkRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "dir_at"
+	err = en.Append(0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "destination"
+	err = en.Append(0xab, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = z.Destination.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Destination")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *LinkRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "dir_at"
+	o = append(o, 0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "destination"
+	o = append(o, 0xab, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o, err = z.Destination.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Destination")
+		return
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *LinkRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "destination":
+			bts, err = z.Destination.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Destination")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *LinkRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 12 + z.Destination.Msgsize()
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *MkdirRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "perm":
+			z.Perm, err = dc.ReadUint32()
+			if err != nil {
+				err = msgp.WrapError(err, "Perm")
+				return
+			}
+		case "recursive":
+			z.IsRecursive, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "IsRecursive")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *MkdirRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "dir_at"
+	err = en.Append(0x84, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "perm"
+	err = en.Append(0xa4, 0x70, 0x65, 0x72, 0x6d)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint32(z.Perm)
+	if err != nil {
+		err = msgp.WrapError(err, "Perm")
+		return
+	}
+	// write "recursive"
+	err = en.Append(0xa9, 0x72, 0x65, 0x63, 0x75, 0x72, 0x73, 0x69, 0x76, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.IsRecursive)
+	if err != nil {
+		err = msgp.WrapError(err, "IsRecursive")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *MkdirRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "dir_at"
+	o = append(o, 0x84, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "perm"
+	o = append(o, 0xa4, 0x70, 0x65, 0x72, 0x6d)
+	o = msgp.AppendUint32(o, z.Perm)
+	// string "recursive"
+	o = append(o, 0xa9, 0x72, 0x65, 0x63, 0x75, 0x72, 0x73, 0x69, 0x76, 0x65)
+	o = msgp.AppendBool(o, z.IsRecursive)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *MkdirRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "perm":
+			z.Perm, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Perm")
+				return
+			}
+		case "recursive":
+			z.IsRecursive, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "IsRecursive")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *MkdirRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 5 + msgp.Uint32Size + 10 + msgp.BoolSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ObjectChmodRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "mode":
+			z.Mode, err = dc.ReadUint32()
+			if err != nil {
+				err = msgp.WrapError(err, "Mode")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z ObjectChmodRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "handle"
+	err = en.Append(0x82, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	// write "mode"
+	err = en.Append(0xa4, 0x6d, 0x6f, 0x64, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint32(z.Mode)
+	if err != nil {
+		err = msgp.WrapError(err, "Mode")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z ObjectChmodRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "handle"
+	o = append(o, 0x82, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	// string "mode"
+	o = append(o, 0xa4, 0x6d, 0x6f, 0x64, 0x65)
+	o = msgp.AppendUint32(o, z.Mode)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ObjectChmodRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "mode":
+			z.Mode, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Mode")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z ObjectChmodRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + msgp.Uint32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ObjectChownRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "uid":
+			z.UID, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "UID")
+				return
+			}
+		case "gid":
+			z.GID, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "GID")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z ObjectChownRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "handle"
+	err = en.Append(0x83, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	// write "uid"
+	err = en.Append(0xa3, 0x75, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.UID)
+	if err != nil {
+		err = msgp.WrapError(err, "UID")
+		return
+	}
+	// write "gid"
+	err = en.Append(0xa3, 0x67, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.GID)
+	if err != nil {
+		err = msgp.WrapError(err, "GID")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z ObjectChownRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "handle"
+	o = append(o, 0x83, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	// string "uid"
+	o = append(o, 0xa3, 0x75, 0x69, 0x64)
+	o = msgp.AppendInt(o, z.UID)
+	// string "gid"
+	o = append(o, 0xa3, 0x67, 0x69, 0x64)
+	o = msgp.AppendInt(o, z.GID)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ObjectChownRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "uid":
+			z.UID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UID")
+				return
+			}
+		case "gid":
+			z.GID, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "GID")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z ObjectChownRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 4 + msgp.IntSize + 4 + msgp.IntSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ObjectID) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dev":
+			z.Dev, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Dev")
+				return
+			}
+		case "ino":
+			z.Ino, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Ino")
+				return
+			}
+		case "handle":
+			z.Handle, err = dc.ReadBytes(z.Handle)
+			if err != nil {
+				err = msgp.WrapError(err, "Handle")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ObjectID) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "dev"
+	err = en.Append(0x83, 0xa3, 0x64, 0x65, 0x76)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Dev)
+	if err != nil {
+		err = msgp.WrapError(err, "Dev")
+		return
+	}
+	// write "ino"
+	err = en.Append(0xa3, 0x69, 0x6e, 0x6f)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Ino)
+	if err != nil {
+		err = msgp.WrapError(err, "Ino")
+		return
+	}
+	// write "handle"
+	err = en.Append(0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Handle)
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ObjectID) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "dev"
+	o = append(o, 0x83, 0xa3, 0x64, 0x65, 0x76)
+	o = msgp.AppendUint64(o, z.Dev)
+	// string "ino"
+	o = append(o, 0xa3, 0x69, 0x6e, 0x6f)
+	o = msgp.AppendUint64(o, z.Ino)
+	// string "handle"
+	o = append(o, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendBytes(o, z.Handle)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ObjectID) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dev":
+			z.Dev, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Dev")
+				return
+			}
+		case "ino":
+			z.Ino, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Ino")
+				return
+			}
+		case "handle":
+			z.Handle, bts, err = msgp.ReadBytesBytes(bts, z.Handle)
+			if err != nil {
+				err = msgp.WrapError(err, "Handle")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ObjectID) Msgsize() (s int) {
+	s = 1 + 4 + msgp.Uint64Size + 4 + msgp.Uint64Size + 7 + msgp.BytesPrefixSize + len(z.Handle)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ObjectOpenRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "flags":
+			z.Flags, err = dc.ReadUint16()
+			if err != nil {
+				err = msgp.WrapError(err, "Flags")
+				return
+			}
+		case "perm":
+			z.Perm, err = dc.ReadUint32()
+			if err != nil {
+				err = msgp.WrapError(err, "Perm")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z ObjectOpenRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "handle"
+	err = en.Append(0x83, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	// write "flags"
+	err = en.Append(0xa5, 0x66, 0x6c, 0x61, 0x67, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint16(z.Flags)
+	if err != nil {
+		err = msgp.WrapError(err, "Flags")
+		return
+	}
+	// write "perm"
+	err = en.Append(0xa4, 0x70, 0x65, 0x72, 0x6d)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint32(z.Perm)
+	if err != nil {
+		err = msgp.WrapError(err, "Perm")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z ObjectOpenRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "handle"
+	o = append(o, 0x83, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	// string "flags"
+	o = append(o, 0xa5, 0x66, 0x6c, 0x61, 0x67, 0x73)
+	o = msgp.AppendUint16(o, z.Flags)
+	// string "perm"
+	o = append(o, 0xa4, 0x70, 0x65, 0x72, 0x6d)
+	o = msgp.AppendUint32(o, z.Perm)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ObjectOpenRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "flags":
+			z.Flags, bts, err = msgp.ReadUint16Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Flags")
+				return
+			}
+		case "perm":
+			z.Perm, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Perm")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z ObjectOpenRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 6 + msgp.Uint16Size + 5 + msgp.Uint32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ObjectType) DecodeMsg(dc *msgp.Reader) (err error) {
+	{
+		var zb0001 uint8
+		zb0001, err = dc.ReadUint8()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		(*z) = ObjectType(zb0001)
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z ObjectType) EncodeMsg(en *msgp.Writer) (err error) {
+	err = en.WriteUint8(uint8(z))
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z ObjectType) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	o = msgp.AppendUint8(o, uint8(z))
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ObjectType) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	{
+		var zb0001 uint8
+		zb0001, bts, err = msgp.ReadUint8Bytes(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		(*z) = ObjectType(zb0001)
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z ObjectType) Msgsize() (s int) {
+	s = msgp.Uint8Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *OpenRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "flags":
+			z.Flags, err = dc.ReadUint16()
+			if err != nil {
+				err = msgp.WrapError(err, "Flags")
+				return
+			}
+		case "perm":
+			z.Perm, err = dc.ReadUint32()
+			if err != nil {
+				err = msgp.WrapError(err, "Perm")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *OpenRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "dir_at"
+	err = en.Append(0x84, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "flags"
+	err = en.Append(0xa5, 0x66, 0x6c, 0x61, 0x67, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint16(z.Flags)
+	if err != nil {
+		err = msgp.WrapError(err, "Flags")
+		return
+	}
+	// write "perm"
+	err = en.Append(0xa4, 0x70, 0x65, 0x72, 0x6d)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint32(z.Perm)
+	if err != nil {
+		err = msgp.WrapError(err, "Perm")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *OpenRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "dir_at"
+	o = append(o, 0x84, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "flags"
+	o = append(o, 0xa5, 0x66, 0x6c, 0x61, 0x67, 0x73)
+	o = msgp.AppendUint16(o, z.Flags)
+	// string "perm"
+	o = append(o, 0xa4, 0x70, 0x65, 0x72, 0x6d)
+	o = msgp.AppendUint32(o, z.Perm)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *OpenRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "flags":
+			z.Flags, bts, err = msgp.ReadUint16Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Flags")
+				return
+			}
+		case "perm":
+			z.Perm, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Perm")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *OpenRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 6 + msgp.Uint16Size + 5 + msgp.Uint32Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *OpenResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "type":
+			{
+				var zb0003 uint8
+				zb0003, err = dc.ReadUint8()
+				if err != nil {
+					err = msgp.WrapError(err, "Type")
+					return
+				}
+				z.Type = ObjectType(zb0003)
+			}
+		case "id":
+			var zb0004 uint32
+			zb0004, err = dc.ReadMapHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "ID")
+				return
+			}
+			for zb0004 > 0 {
+				zb0004--
+				field, err = dc.ReadMapKeyPtr()
+				if err != nil {
+					err = msgp.WrapError(err, "ID")
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "dev":
+					z.ID.Dev, err = dc.ReadUint64()
+					if err != nil {
+						err = msgp.WrapError(err, "ID", "Dev")
+						return
+					}
+				case "ino":
+					z.ID.Ino, err = dc.ReadUint64()
+					if err != nil {
+						err = msgp.WrapError(err, "ID", "Ino")
+						return
+					}
+				case "handle":
+					z.ID.Handle, err = dc.ReadBytes(z.ID.Handle)
+					if err != nil {
+						err = msgp.WrapError(err, "ID", "Handle")
+						return
+					}
+				default:
+					err = dc.Skip()
+					if err != nil {
+						err = msgp.WrapError(err, "ID")
+						return
+					}
+				}
+			}
+		case "info":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Info")
+					return
+				}
+				z.Info = nil
+			} else {
+				if z.Info == nil {
+					z.Info = new(FileInfo)
+				}
+				err = z.Info.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Info")
+					return
+				}
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *OpenResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 6
+	// write "handle"
+	err = en.Append(0x86, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	// write "type"
+	err = en.Append(0xa4, 0x74, 0x79, 0x70, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint8(uint8(z.Type))
+	if err != nil {
+		err = msgp.WrapError(err, "Type")
+		return
+	}
+	// write "id"
+	err = en.Append(0xa2, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	// map header, size 3
+	// write "dev"
+	err = en.Append(0x83, 0xa3, 0x64, 0x65, 0x76)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ID.Dev)
+	if err != nil {
+		err = msgp.WrapError(err, "ID", "Dev")
+		return
+	}
+	// write "ino"
+	err = en.Append(0xa3, 0x69, 0x6e, 0x6f)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.ID.Ino)
+	if err != nil {
+		err = msgp.WrapError(err, "ID", "Ino")
+		return
+	}
+	// write "handle"
+	err = en.Append(0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.ID.Handle)
+	if err != nil {
+		err = msgp.WrapError(err, "ID", "Handle")
+		return
+	}
+	// write "info"
+	err = en.Append(0xa4, 0x69, 0x6e, 0x66, 0x6f)
+	if err != nil {
+		return
+	}
+	if z.Info == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Info.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Info")
+			return
+		}
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *OpenResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 6
+	// string "handle"
+	o = append(o, 0x86, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	// string "type"
+	o = append(o, 0xa4, 0x74, 0x79, 0x70, 0x65)
+	o = msgp.AppendUint8(o, uint8(z.Type))
+	// string "id"
+	o = append(o, 0xa2, 0x69, 0x64)
+	// map header, size 3
+	// string "dev"
+	o = append(o, 0x83, 0xa3, 0x64, 0x65, 0x76)
+	o = msgp.AppendUint64(o, z.ID.Dev)
+	// string "ino"
+	o = append(o, 0xa3, 0x69, 0x6e, 0x6f)
+	o = msgp.AppendUint64(o, z.ID.Ino)
+	// string "handle"
+	o = append(o, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendBytes(o, z.ID.Handle)
+	// string "info"
+	o = append(o, 0xa4, 0x69, 0x6e, 0x66, 0x6f)
+	if z.Info == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Info.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Info")
+			return
+		}
+	}
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *OpenResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "type":
+			{
+				var zb0003 uint8
+				zb0003, bts, err = msgp.ReadUint8Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Type")
+					return
+				}
+				z.Type = ObjectType(zb0003)
+			}
+		case "id":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ID")
+				return
+			}
+			for zb0004 > 0 {
+				zb0004--
+				field, bts, err = msgp.ReadMapKeyZC(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "ID")
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "dev":
+					z.ID.Dev, bts, err = msgp.ReadUint64Bytes(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "ID", "Dev")
+						return
+					}
+				case "ino":
+					z.ID.Ino, bts, err = msgp.ReadUint64Bytes(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "ID", "Ino")
+						return
+					}
+				case "handle":
+					z.ID.Handle, bts, err = msgp.ReadBytesBytes(bts, z.ID.Handle)
+					if err != nil {
+						err = msgp.WrapError(err, "ID", "Handle")
+						return
+					}
+				default:
+					bts, err = msgp.Skip(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "ID")
+						return
+					}
+				}
+			}
+		case "info":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Info = nil
+			} else {
+				if z.Info == nil {
+					z.Info = new(FileInfo)
+				}
+				bts, err = z.Info.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Info")
+					return
+				}
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *OpenResponse) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + msgp.Uint8Size + 3 + 1 + 4 + msgp.Uint64Size + 4 + msgp.Uint64Size + 7 + msgp.BytesPrefixSize + len(z.ID.Handle) + 5
+	if z.Info == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Info.Msgsize()
+	}
+	s += 5 + z.Path.Msgsize() + 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ReadChunk) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "data":
+			z.Data, err = dc.ReadBytes(z.Data)
+			if err != nil {
+				err = msgp.WrapError(err, "Data")
+				return
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ReadChunk) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "data"
+	err = en.Append(0x82, 0xa4, 0x64, 0x61, 0x74, 0x61)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Data)
+	if err != nil {
+		err = msgp.WrapError(err, "Data")
+		return
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ReadChunk) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "data"
+	o = append(o, 0x82, 0xa4, 0x64, 0x61, 0x74, 0x61)
+	o = msgp.AppendBytes(o, z.Data)
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ReadChunk) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "data":
+			z.Data, bts, err = msgp.ReadBytesBytes(bts, z.Data)
+			if err != nil {
+				err = msgp.WrapError(err, "Data")
+				return
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ReadChunk) Msgsize() (s int) {
+	s = 1 + 5 + msgp.BytesPrefixSize + len(z.Data) + 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ReadRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "size":
+			z.Size, err = dc.ReadUint32()
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+		case "offset":
+			z.Offset, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "use_offset":
+			z.UseOffset, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "UseOffset")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ReadRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "handle"
+	err = en.Append(0x84, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	// write "size"
+	err = en.Append(0xa4, 0x73, 0x69, 0x7a, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint32(z.Size)
+	if err != nil {
+		err = msgp.WrapError(err, "Size")
+		return
+	}
+	// write "offset"
+	err = en.Append(0xa6, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Offset)
+	if err != nil {
+		err = msgp.WrapError(err, "Offset")
+		return
+	}
+	// write "use_offset"
+	err = en.Append(0xaa, 0x75, 0x73, 0x65, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.UseOffset)
+	if err != nil {
+		err = msgp.WrapError(err, "UseOffset")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ReadRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "handle"
+	o = append(o, 0x84, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	// string "size"
+	o = append(o, 0xa4, 0x73, 0x69, 0x7a, 0x65)
+	o = msgp.AppendUint32(o, z.Size)
+	// string "offset"
+	o = append(o, 0xa6, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	o = msgp.AppendInt64(o, z.Offset)
+	// string "use_offset"
+	o = append(o, 0xaa, 0x75, 0x73, 0x65, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	o = msgp.AppendBool(o, z.UseOffset)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ReadRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "size":
+			z.Size, bts, err = msgp.ReadUint32Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Size")
+				return
+			}
+		case "offset":
+			z.Offset, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "use_offset":
+			z.UseOffset, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UseOffset")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ReadRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + msgp.Uint32Size + 7 + msgp.Int64Size + 11 + msgp.BoolSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ReaddirRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "n":
+			z.N, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "N")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z ReaddirRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "handle"
+	err = en.Append(0x82, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	// write "n"
+	err = en.Append(0xa1, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.N)
+	if err != nil {
+		err = msgp.WrapError(err, "N")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z ReaddirRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "handle"
+	o = append(o, 0x82, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	// string "n"
+	o = append(o, 0xa1, 0x6e)
+	o = msgp.AppendInt(o, z.N)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ReaddirRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "n":
+			z.N, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "N")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z ReaddirRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 2 + msgp.IntSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ReaddirResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "infos":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Infos")
+				return
+			}
+			if cap(z.Infos) >= int(zb0002) {
+				z.Infos = (z.Infos)[:zb0002]
+			} else {
+				z.Infos = make([]FileInfo, zb0002)
+			}
+			for za0001 := range z.Infos {
+				err = z.Infos[za0001].DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Infos", za0001)
+					return
+				}
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ReaddirResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "infos"
+	err = en.Append(0x82, 0xa5, 0x69, 0x6e, 0x66, 0x6f, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Infos)))
+	if err != nil {
+		err = msgp.WrapError(err, "Infos")
+		return
+	}
+	for za0001 := range z.Infos {
+		err = z.Infos[za0001].EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Infos", za0001)
+			return
+		}
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ReaddirResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "infos"
+	o = append(o, 0x82, 0xa5, 0x69, 0x6e, 0x66, 0x6f, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Infos)))
+	for za0001 := range z.Infos {
+		o, err = z.Infos[za0001].MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Infos", za0001)
+			return
+		}
+	}
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ReaddirResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "infos":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Infos")
+				return
+			}
+			if cap(z.Infos) >= int(zb0002) {
+				z.Infos = (z.Infos)[:zb0002]
+			} else {
+				z.Infos = make([]FileInfo, zb0002)
+			}
+			for za0001 := range z.Infos {
+				bts, err = z.Infos[za0001].UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Infos", za0001)
+					return
+				}
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ReaddirResponse) Msgsize() (s int) {
+	s = 1 + 6 + msgp.ArrayHeaderSize
+	for za0001 := range z.Infos {
+		s += z.Infos[za0001].Msgsize()
+	}
+	s += 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ReadlinkRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ReadlinkRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "dir_at"
+	err = en.Append(0x82, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ReadlinkRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "dir_at"
+	o = append(o, 0x82, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ReadlinkRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ReadlinkRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize()
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ReadlinkResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "destination":
+			err = z.Destination.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Destination")
+				return
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *ReadlinkResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "destination"
+	err = en.Append(0x82, 0xab, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = z.Destination.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Destination")
+		return
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *ReadlinkResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "destination"
+	o = append(o, 0x82, 0xab, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o, err = z.Destination.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Destination")
+		return
+	}
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ReadlinkResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "destination":
+			bts, err = z.Destination.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Destination")
+				return
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *ReadlinkResponse) Msgsize() (s int) {
+	s = 1 + 12 + z.Destination.Msgsize() + 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *RemoveRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "recursive":
+			z.IsRecursive, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "IsRecursive")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *RemoveRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "dir_at"
+	err = en.Append(0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "recursive"
+	err = en.Append(0xa9, 0x72, 0x65, 0x63, 0x75, 0x72, 0x73, 0x69, 0x76, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.IsRecursive)
+	if err != nil {
+		err = msgp.WrapError(err, "IsRecursive")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *RemoveRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "dir_at"
+	o = append(o, 0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "recursive"
+	o = append(o, 0xa9, 0x72, 0x65, 0x63, 0x75, 0x72, 0x73, 0x69, 0x76, 0x65)
+	o = msgp.AppendBool(o, z.IsRecursive)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *RemoveRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "recursive":
+			z.IsRecursive, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "IsRecursive")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *RemoveRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 10 + msgp.BoolSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *RenameRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "new_path":
+			err = z.NewPath.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "NewPath")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *RenameRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "dir_at"
+	err = en.Append(0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "new_path"
+	err = en.Append(0xa8, 0x6e, 0x65, 0x77, 0x5f, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.NewPath.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "NewPath")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *RenameRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "dir_at"
+	o = append(o, 0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "new_path"
+	o = append(o, 0xa8, 0x6e, 0x65, 0x77, 0x5f, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.NewPath.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "NewPath")
+		return
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *RenameRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "new_path":
+			bts, err = z.NewPath.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "NewPath")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *RenameRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 9 + z.NewPath.Msgsize()
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *ResumeToken) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "server_id":
+			z.ServerID, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "ServerID")
+				return
+			}
+		case "watch_id":
+			z.WatchID, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		case "seq":
+			z.Seq, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Seq")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z ResumeToken) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "server_id"
+	err = en.Append(0x83, 0xa9, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.ServerID)
+	if err != nil {
+		err = msgp.WrapError(err, "ServerID")
+		return
+	}
+	// write "watch_id"
+	err = en.Append(0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.WatchID)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchID")
+		return
+	}
+	// write "seq"
+	err = en.Append(0xa3, 0x73, 0x65, 0x71)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Seq)
+	if err != nil {
+		err = msgp.WrapError(err, "Seq")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z ResumeToken) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "server_id"
+	o = append(o, 0x83, 0xa9, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.ServerID)
+	// string "watch_id"
+	o = append(o, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.WatchID)
+	// string "seq"
+	o = append(o, 0xa3, 0x73, 0x65, 0x71)
+	o = msgp.AppendUint64(o, z.Seq)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *ResumeToken) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "server_id":
+			z.ServerID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ServerID")
+				return
+			}
+		case "watch_id":
+			z.WatchID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		case "seq":
+			z.Seq, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Seq")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z ResumeToken) Msgsize() (s int) {
+	s = 1 + 10 + msgp.StringPrefixSize + len(z.ServerID) + 9 + msgp.StringPrefixSize + len(z.WatchID) + 4 + msgp.Uint64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *SeekRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "offset":
+			z.Offset, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "whence":
+			z.Whence, err = dc.ReadInt()
+			if err != nil {
+				err = msgp.WrapError(err, "Whence")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z SeekRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "handle"
+	err = en.Append(0x83, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	// write "offset"
+	err = en.Append(0xa6, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Offset)
+	if err != nil {
+		err = msgp.WrapError(err, "Offset")
+		return
+	}
+	// write "whence"
+	err = en.Append(0xa6, 0x77, 0x68, 0x65, 0x6e, 0x63, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt(z.Whence)
+	if err != nil {
+		err = msgp.WrapError(err, "Whence")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z SeekRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "handle"
+	o = append(o, 0x83, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	// string "offset"
+	o = append(o, 0xa6, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	o = msgp.AppendInt64(o, z.Offset)
+	// string "whence"
+	o = append(o, 0xa6, 0x77, 0x68, 0x65, 0x6e, 0x63, 0x65)
+	o = msgp.AppendInt(o, z.Whence)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *SeekRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "offset":
+			z.Offset, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "whence":
+			z.Whence, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Whence")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z SeekRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 7 + msgp.Int64Size + 7 + msgp.IntSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *SeekResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "offset":
+			z.Offset, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *SeekResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "offset"
+	err = en.Append(0x82, 0xa6, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Offset)
+	if err != nil {
+		err = msgp.WrapError(err, "Offset")
+		return
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *SeekResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "offset"
+	o = append(o, 0x82, 0xa6, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	o = msgp.AppendInt64(o, z.Offset)
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *SeekResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "offset":
+			z.Offset, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *SeekResponse) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Int64Size + 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *SessionRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "compressors":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Compressors")
+				return
+			}
+			if cap(z.Compressors) >= int(zb0002) {
+				z.Compressors = (z.Compressors)[:zb0002]
+			} else {
+				z.Compressors = make([]string, zb0002)
+			}
+			for za0001 := range z.Compressors {
+				z.Compressors[za0001], err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "Compressors", za0001)
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *SessionRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "compressors"
+	err = en.Append(0x81, 0xab, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Compressors)))
+	if err != nil {
+		err = msgp.WrapError(err, "Compressors")
+		return
+	}
+	for za0001 := range z.Compressors {
+		err = en.WriteString(z.Compressors[za0001])
+		if err != nil {
+			err = msgp.WrapError(err, "Compressors", za0001)
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *SessionRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "compressors"
+	o = append(o, 0x81, 0xab, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Compressors)))
+	for za0001 := range z.Compressors {
+		o = msgp.AppendString(o, z.Compressors[za0001])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *SessionRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "compressors":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Compressors")
+				return
+			}
+			if cap(z.Compressors) >= int(zb0002) {
+				z.Compressors = (z.Compressors)[:zb0002]
+			} else {
+				z.Compressors = make([]string, zb0002)
+			}
+			for za0001 := range z.Compressors {
+				z.Compressors[za0001], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Compressors", za0001)
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *SessionRequest) Msgsize() (s int) {
+	s = 1 + 12 + msgp.ArrayHeaderSize
+	for za0001 := range z.Compressors {
+		s += msgp.StringPrefixSize + len(z.Compressors[za0001])
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *SessionResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "session_id":
+			z.SessionID, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "SessionID")
+				return
+			}
+		case "compressor":
+			z.Compressor, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Compressor")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z SessionResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "session_id"
+	err = en.Append(0x82, 0xaa, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.SessionID)
+	if err != nil {
+		err = msgp.WrapError(err, "SessionID")
+		return
+	}
+	// write "compressor"
+	err = en.Append(0xaa, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Compressor)
+	if err != nil {
+		err = msgp.WrapError(err, "Compressor")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z SessionResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "session_id"
+	o = append(o, 0x82, 0xaa, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.SessionID)
+	// string "compressor"
+	o = append(o, 0xaa, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x6f, 0x72)
+	o = msgp.AppendString(o, z.Compressor)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *SessionResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "session_id":
+			z.SessionID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "SessionID")
+				return
+			}
+		case "compressor":
+			z.Compressor, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Compressor")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z SessionResponse) Msgsize() (s int) {
+	s = 1 + 11 + msgp.StringPrefixSize + len(z.SessionID) + 11 + msgp.StringPrefixSize + len(z.Compressor)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *StatRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "no_follow":
+			z.NoFollow, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "NoFollow")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *StatRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "dir_at"
+	err = en.Append(0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "no_follow"
+	err = en.Append(0xa9, 0x6e, 0x6f, 0x5f, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.NoFollow)
+	if err != nil {
+		err = msgp.WrapError(err, "NoFollow")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *StatRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "dir_at"
+	o = append(o, 0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "no_follow"
+	o = append(o, 0xa9, 0x6e, 0x6f, 0x5f, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77)
+	o = msgp.AppendBool(o, z.NoFollow)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *StatRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "no_follow":
+			z.NoFollow, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "NoFollow")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *StatRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 10 + msgp.BoolSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *StatResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "info":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Info")
+					return
+				}
+				z.Info = nil
+			} else {
+				if z.Info == nil {
+					z.Info = new(FileInfo)
+				}
+				err = z.Info.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Info")
+					return
+				}
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *StatResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "info"
+	err = en.Append(0x82, 0xa4, 0x69, 0x6e, 0x66, 0x6f)
+	if err != nil {
+		return
+	}
+	if z.Info == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Info.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Info")
+			return
+		}
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *StatResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "info"
+	o = append(o, 0x82, 0xa4, 0x69, 0x6e, 0x66, 0x6f)
+	if z.Info == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Info.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Info")
+			return
+		}
+	}
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *StatResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "info":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Info = nil
+			} else {
+				if z.Info == nil {
+					z.Info = new(FileInfo)
+				}
+				bts, err = z.Info.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Info")
+					return
+				}
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *StatResponse) Msgsize() (s int) {
+	s = 1 + 5
+	if z.Info == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Info.Msgsize()
+	}
+	s += 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *SymlinkRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "destination":
+			err = z.Destination.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Destination")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *SymlinkRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "dir_at"
+	err = en.Append(0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.DirAt))
+	if err != nil {
+		err = msgp.WrapError(err, "DirAt")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "destination"
+	err = en.Append(0xab, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	if err != nil {
+		return
+	}
+	err = z.Destination.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Destination")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *SymlinkRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "dir_at"
+	o = append(o, 0x83, 0xa6, 0x64, 0x69, 0x72, 0x5f, 0x61, 0x74)
+	o = msgp.AppendUint64(o, uint64(z.DirAt))
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "destination"
+	o = append(o, 0xab, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e)
+	o, err = z.Destination.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Destination")
+		return
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *SymlinkRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "dir_at":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "DirAt")
+					return
+				}
+				z.DirAt = Handle(zb0002)
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "destination":
+			bts, err = z.Destination.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Destination")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *SymlinkRequest) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 5 + z.Path.Msgsize() + 12 + z.Destination.Msgsize()
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *UnwatchRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "watch_id":
+			z.WatchID, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "recursive":
+			z.IsRecursive, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "IsRecursive")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *UnwatchRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 3
+	// write "watch_id"
+	err = en.Append(0x83, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.WatchID)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchID")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "recursive"
+	err = en.Append(0xa9, 0x72, 0x65, 0x63, 0x75, 0x72, 0x73, 0x69, 0x76, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.IsRecursive)
+	if err != nil {
+		err = msgp.WrapError(err, "IsRecursive")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *UnwatchRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 3
+	// string "watch_id"
+	o = append(o, 0x83, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.WatchID)
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "recursive"
+	o = append(o, 0xa9, 0x72, 0x65, 0x63, 0x75, 0x72, 0x73, 0x69, 0x76, 0x65)
+	o = msgp.AppendBool(o, z.IsRecursive)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *UnwatchRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "watch_id":
+			z.WatchID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "recursive":
+			z.IsRecursive, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "IsRecursive")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *UnwatchRequest) Msgsize() (s int) {
+	s = 1 + 9 + msgp.StringPrefixSize + len(z.WatchID) + 5 + z.Path.Msgsize() + 10 + msgp.BoolSize
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *WatchAddRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "watch_id":
+			z.WatchID, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "watch_rules":
+			err = z.WatchRules.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchRules")
+				return
+			}
+		case "walk_rules":
+			err = z.WalkRules.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "WalkRules")
+				return
+			}
+		case "type_mask":
+			err = z.TypeMask.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "TypeMask")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *WatchAddRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 5
+	// write "watch_id"
+	err = en.Append(0x85, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.WatchID)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchID")
+		return
+	}
+	// write "path"
+	err = en.Append(0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "watch_rules"
+	err = en.Append(0xab, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = z.WatchRules.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchRules")
+		return
+	}
+	// write "walk_rules"
+	err = en.Append(0xaa, 0x77, 0x61, 0x6c, 0x6b, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = z.WalkRules.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "WalkRules")
+		return
+	}
+	// write "type_mask"
+	err = en.Append(0xa9, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6d, 0x61, 0x73, 0x6b)
+	if err != nil {
+		return
+	}
+	err = z.TypeMask.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "TypeMask")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *WatchAddRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 5
+	// string "watch_id"
+	o = append(o, 0x85, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.WatchID)
+	// string "path"
+	o = append(o, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "watch_rules"
+	o = append(o, 0xab, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73)
+	o, err = z.WatchRules.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchRules")
+		return
+	}
+	// string "walk_rules"
+	o = append(o, 0xaa, 0x77, 0x61, 0x6c, 0x6b, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73)
+	o, err = z.WalkRules.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "WalkRules")
+		return
+	}
+	// string "type_mask"
+	o = append(o, 0xa9, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6d, 0x61, 0x73, 0x6b)
+	o, err = z.TypeMask.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "TypeMask")
+		return
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *WatchAddRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "watch_id":
+			z.WatchID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "watch_rules":
+			bts, err = z.WatchRules.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchRules")
+				return
+			}
+		case "walk_rules":
+			bts, err = z.WalkRules.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WalkRules")
+				return
+			}
+		case "type_mask":
+			bts, err = z.TypeMask.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TypeMask")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *WatchAddRequest) Msgsize() (s int) {
+	s = 1 + 9 + msgp.StringPrefixSize + len(z.WatchID) + 5 + z.Path.Msgsize() + 12 + z.WatchRules.Msgsize() + 11 + z.WalkRules.Msgsize() + 10 + z.TypeMask.Msgsize()
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *WatchCloseRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "watch_id":
+			z.WatchID, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z WatchCloseRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 1
+	// write "watch_id"
+	err = en.Append(0x81, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.WatchID)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchID")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z WatchCloseRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 1
+	// string "watch_id"
+	o = append(o, 0x81, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.WatchID)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *WatchCloseRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "watch_id":
+			z.WatchID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z WatchCloseRequest) Msgsize() (s int) {
+	s = 1 + 9 + msgp.StringPrefixSize + len(z.WatchID)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *WatchHello) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "server_id":
+			z.ServerID, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "ServerID")
+				return
+			}
+		case "watch_id":
+			z.WatchID, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		case "lost":
+			z.Lost, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "Lost")
+				return
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *WatchHello) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "server_id"
+	err = en.Append(0x84, 0xa9, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.ServerID)
+	if err != nil {
+		err = msgp.WrapError(err, "ServerID")
+		return
+	}
+	// write "watch_id"
+	err = en.Append(0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.WatchID)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchID")
+		return
+	}
+	// write "lost"
+	err = en.Append(0xa4, 0x6c, 0x6f, 0x73, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.Lost)
+	if err != nil {
+		err = msgp.WrapError(err, "Lost")
+		return
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *WatchHello) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "server_id"
+	o = append(o, 0x84, 0xa9, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.ServerID)
+	// string "watch_id"
+	o = append(o, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.WatchID)
+	// string "lost"
+	o = append(o, 0xa4, 0x6c, 0x6f, 0x73, 0x74)
+	o = msgp.AppendBool(o, z.Lost)
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *WatchHello) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "server_id":
+			z.ServerID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ServerID")
+				return
+			}
+		case "watch_id":
+			z.WatchID, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchID")
+				return
+			}
+		case "lost":
+			z.Lost, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Lost")
+				return
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *WatchHello) Msgsize() (s int) {
+	s = 1 + 10 + msgp.StringPrefixSize + len(z.ServerID) + 9 + msgp.StringPrefixSize + len(z.WatchID) + 5 + msgp.BoolSize + 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *WatchMessage) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "seq":
+			z.Seq, err = dc.ReadUint64()
+			if err != nil {
+				err = msgp.WrapError(err, "Seq")
+				return
+			}
+		case "event":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Event")
+					return
+				}
+				z.Event = nil
+			} else {
+				if z.Event == nil {
+					z.Event = new(event.Record)
+				}
+				err = z.Event.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Event")
+					return
+				}
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		case "hello":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Hello")
+					return
+				}
+				z.Hello = nil
+			} else {
+				if z.Hello == nil {
+					z.Hello = new(WatchHello)
+				}
+				err = z.Hello.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Hello")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *WatchMessage) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "seq"
+	err = en.Append(0x84, 0xa3, 0x73, 0x65, 0x71)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Seq)
+	if err != nil {
+		err = msgp.WrapError(err, "Seq")
+		return
+	}
+	// write "event"
+	err = en.Append(0xa5, 0x65, 0x76, 0x65, 0x6e, 0x74)
+	if err != nil {
+		return
+	}
+	if z.Event == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Event.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Event")
+			return
+		}
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	// write "hello"
+	err = en.Append(0xa5, 0x68, 0x65, 0x6c, 0x6c, 0x6f)
+	if err != nil {
+		return
+	}
+	if z.Hello == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Hello.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Hello")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *WatchMessage) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "seq"
+	o = append(o, 0x84, 0xa3, 0x73, 0x65, 0x71)
+	o = msgp.AppendUint64(o, z.Seq)
+	// string "event"
+	o = append(o, 0xa5, 0x65, 0x76, 0x65, 0x6e, 0x74)
+	if z.Event == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Event.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Event")
+			return
+		}
+	}
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	// string "hello"
+	o = append(o, 0xa5, 0x68, 0x65, 0x6c, 0x6c, 0x6f)
+	if z.Hello == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Hello.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Hello")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *WatchMessage) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "seq":
+			z.Seq, bts, err = msgp.ReadUint64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Seq")
+				return
+			}
+		case "event":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Event = nil
+			} else {
+				if z.Event == nil {
+					z.Event = new(event.Record)
+				}
+				bts, err = z.Event.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Event")
+					return
+				}
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		case "hello":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Hello = nil
+			} else {
+				if z.Hello == nil {
+					z.Hello = new(WatchHello)
+				}
+				bts, err = z.Hello.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Hello")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *WatchMessage) Msgsize() (s int) {
+	s = 1 + 4 + msgp.Uint64Size + 6
+	if z.Event == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Event.Msgsize()
+	}
+	s += 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	s += 6
+	if z.Hello == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Hello.Msgsize()
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *WatchRequest) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "path":
+			err = z.Path.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "watch_rules":
+			err = z.WatchRules.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchRules")
+				return
+			}
+		case "walk_rules":
+			err = z.WalkRules.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "WalkRules")
+				return
+			}
+		case "type_mask":
+			err = z.TypeMask.DecodeMsg(dc)
+			if err != nil {
+				err = msgp.WrapError(err, "TypeMask")
+				return
+			}
+		case "token":
+			var zb0002 uint32
+			zb0002, err = dc.ReadMapHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "Token")
+				return
+			}
+			for zb0002 > 0 {
+				zb0002--
+				field, err = dc.ReadMapKeyPtr()
+				if err != nil {
+					err = msgp.WrapError(err, "Token")
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "server_id":
+					z.Token.ServerID, err = dc.ReadString()
+					if err != nil {
+						err = msgp.WrapError(err, "Token", "ServerID")
+						return
+					}
+				case "watch_id":
+					z.Token.WatchID, err = dc.ReadString()
+					if err != nil {
+						err = msgp.WrapError(err, "Token", "WatchID")
+						return
+					}
+				case "seq":
+					z.Token.Seq, err = dc.ReadUint64()
+					if err != nil {
+						err = msgp.WrapError(err, "Token", "Seq")
+						return
+					}
+				default:
+					err = dc.Skip()
+					if err != nil {
+						err = msgp.WrapError(err, "Token")
+						return
+					}
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *WatchRequest) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 5
+	// write "path"
+	err = en.Append(0x85, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	if err != nil {
+		return
+	}
+	err = z.Path.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// write "watch_rules"
+	err = en.Append(0xab, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = z.WatchRules.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchRules")
+		return
+	}
+	// write "walk_rules"
+	err = en.Append(0xaa, 0x77, 0x61, 0x6c, 0x6b, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73)
+	if err != nil {
+		return
+	}
+	err = z.WalkRules.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "WalkRules")
+		return
+	}
+	// write "type_mask"
+	err = en.Append(0xa9, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6d, 0x61, 0x73, 0x6b)
+	if err != nil {
+		return
+	}
+	err = z.TypeMask.EncodeMsg(en)
+	if err != nil {
+		err = msgp.WrapError(err, "TypeMask")
+		return
+	}
+	// write "token"
+	err = en.Append(0xa5, 0x74, 0x6f, 0x6b, 0x65, 0x6e)
+	if err != nil {
+		return
+	}
+	// map header, size 3
+	// write "server_id"
+	err = en.Append(0x83, 0xa9, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Token.ServerID)
+	if err != nil {
+		err = msgp.WrapError(err, "Token", "ServerID")
+		return
+	}
+	// write "watch_id"
+	err = en.Append(0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Token.WatchID)
+	if err != nil {
+		err = msgp.WrapError(err, "Token", "WatchID")
+		return
+	}
+	// write "seq"
+	err = en.Append(0xa3, 0x73, 0x65, 0x71)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(z.Token.Seq)
+	if err != nil {
+		err = msgp.WrapError(err, "Token", "Seq")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *WatchRequest) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 5
+	// string "path"
+	o = append(o, 0x85, 0xa4, 0x70, 0x61, 0x74, 0x68)
+	o, err = z.Path.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "Path")
+		return
+	}
+	// string "watch_rules"
+	o = append(o, 0xab, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73)
+	o, err = z.WatchRules.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "WatchRules")
+		return
+	}
+	// string "walk_rules"
+	o = append(o, 0xaa, 0x77, 0x61, 0x6c, 0x6b, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73)
+	o, err = z.WalkRules.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "WalkRules")
+		return
+	}
+	// string "type_mask"
+	o = append(o, 0xa9, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x6d, 0x61, 0x73, 0x6b)
+	o, err = z.TypeMask.MarshalMsg(o)
+	if err != nil {
+		err = msgp.WrapError(err, "TypeMask")
+		return
+	}
+	// string "token"
+	o = append(o, 0xa5, 0x74, 0x6f, 0x6b, 0x65, 0x6e)
+	// map header, size 3
+	// string "server_id"
+	o = append(o, 0x83, 0xa9, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.Token.ServerID)
+	// string "watch_id"
+	o = append(o, 0xa8, 0x77, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64)
+	o = msgp.AppendString(o, z.Token.WatchID)
+	// string "seq"
+	o = append(o, 0xa3, 0x73, 0x65, 0x71)
+	o = msgp.AppendUint64(o, z.Token.Seq)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *WatchRequest) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "path":
+			bts, err = z.Path.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Path")
+				return
+			}
+		case "watch_rules":
+			bts, err = z.WatchRules.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WatchRules")
+				return
+			}
+		case "walk_rules":
+			bts, err = z.WalkRules.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "WalkRules")
+				return
+			}
+		case "type_mask":
+			bts, err = z.TypeMask.UnmarshalMsg(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "TypeMask")
+				return
+			}
+		case "token":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadMapHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Token")
+				return
+			}
+			for zb0002 > 0 {
+				zb0002--
+				field, bts, err = msgp.ReadMapKeyZC(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Token")
+					return
+				}
+				switch msgp.UnsafeString(field) {
+				case "server_id":
+					z.Token.ServerID, bts, err = msgp.ReadStringBytes(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Token", "ServerID")
+						return
+					}
+				case "watch_id":
+					z.Token.WatchID, bts, err = msgp.ReadStringBytes(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Token", "WatchID")
+						return
+					}
+				case "seq":
+					z.Token.Seq, bts, err = msgp.ReadUint64Bytes(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Token", "Seq")
+						return
+					}
+				default:
+					bts, err = msgp.Skip(bts)
+					if err != nil {
+						err = msgp.WrapError(err, "Token")
+						return
+					}
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *WatchRequest) Msgsize() (s int) {
+	s = 1 + 5 + z.Path.Msgsize() + 12 + z.WatchRules.Msgsize() + 11 + z.WalkRules.Msgsize() + 10 + z.TypeMask.Msgsize() + 6 + 1 + 10 + msgp.StringPrefixSize + len(z.Token.ServerID) + 9 + msgp.StringPrefixSize + len(z.Token.WatchID) + 4 + msgp.Uint64Size
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *WriteChunk) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, err = dc.ReadUint64()
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "offset":
+			z.Offset, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "use_offset":
+			z.UseOffset, err = dc.ReadBool()
+			if err != nil {
+				err = msgp.WrapError(err, "UseOffset")
+				return
+			}
+		case "data":
+			z.Data, err = dc.ReadBytes(z.Data)
+			if err != nil {
+				err = msgp.WrapError(err, "Data")
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *WriteChunk) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 4
+	// write "handle"
+	err = en.Append(0x84, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	if err != nil {
+		return
+	}
+	err = en.WriteUint64(uint64(z.Handle))
+	if err != nil {
+		err = msgp.WrapError(err, "Handle")
+		return
+	}
+	// write "offset"
+	err = en.Append(0xa6, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.Offset)
+	if err != nil {
+		err = msgp.WrapError(err, "Offset")
+		return
+	}
+	// write "use_offset"
+	err = en.Append(0xaa, 0x75, 0x73, 0x65, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	if err != nil {
+		return
+	}
+	err = en.WriteBool(z.UseOffset)
+	if err != nil {
+		err = msgp.WrapError(err, "UseOffset")
+		return
+	}
+	// write "data"
+	err = en.Append(0xa4, 0x64, 0x61, 0x74, 0x61)
+	if err != nil {
+		return
+	}
+	err = en.WriteBytes(z.Data)
+	if err != nil {
+		err = msgp.WrapError(err, "Data")
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *WriteChunk) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 4
+	// string "handle"
+	o = append(o, 0x84, 0xa6, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65)
+	o = msgp.AppendUint64(o, uint64(z.Handle))
+	// string "offset"
+	o = append(o, 0xa6, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	o = msgp.AppendInt64(o, z.Offset)
+	// string "use_offset"
+	o = append(o, 0xaa, 0x75, 0x73, 0x65, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	o = msgp.AppendBool(o, z.UseOffset)
+	// string "data"
+	o = append(o, 0xa4, 0x64, 0x61, 0x74, 0x61)
+	o = msgp.AppendBytes(o, z.Data)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *WriteChunk) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "handle":
+			{
+				var zb0002 uint64
+				zb0002, bts, err = msgp.ReadUint64Bytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Handle")
+					return
+				}
+				z.Handle = Handle(zb0002)
+			}
+		case "offset":
+			z.Offset, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Offset")
+				return
+			}
+		case "use_offset":
+			z.UseOffset, bts, err = msgp.ReadBoolBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "UseOffset")
+				return
+			}
+		case "data":
+			z.Data, bts, err = msgp.ReadBytesBytes(bts, z.Data)
+			if err != nil {
+				err = msgp.WrapError(err, "Data")
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *WriteChunk) Msgsize() (s int) {
+	s = 1 + 7 + msgp.Uint64Size + 7 + msgp.Int64Size + 11 + msgp.BoolSize + 5 + msgp.BytesPrefixSize + len(z.Data)
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *WriteResponse) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "n":
+			z.N, err = dc.ReadInt64()
+			if err != nil {
+				err = msgp.WrapError(err, "N")
+				return
+			}
+		case "error":
+			if dc.IsNil() {
+				err = dc.ReadNil()
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				err = z.Error.DecodeMsg(dc)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z *WriteResponse) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "n"
+	err = en.Append(0x82, 0xa1, 0x6e)
+	if err != nil {
+		return
+	}
+	err = en.WriteInt64(z.N)
+	if err != nil {
+		err = msgp.WrapError(err, "N")
+		return
+	}
+	// write "error"
+	err = en.Append(0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if err != nil {
+		return
+	}
+	if z.Error == nil {
+		err = en.WriteNil()
+		if err != nil {
+			return
+		}
+	} else {
+		err = z.Error.EncodeMsg(en)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z *WriteResponse) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "n"
+	o = append(o, 0x82, 0xa1, 0x6e)
+	o = msgp.AppendInt64(o, z.N)
+	// string "error"
+	o = append(o, 0xa5, 0x65, 0x72, 0x72, 0x6f, 0x72)
+	if z.Error == nil {
+		o = msgp.AppendNil(o)
+	} else {
+		o, err = z.Error.MarshalMsg(o)
+		if err != nil {
+			err = msgp.WrapError(err, "Error")
+			return
+		}
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *WriteResponse) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		err = msgp.WrapError(err)
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			err = msgp.WrapError(err)
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "n":
+			z.N, bts, err = msgp.ReadInt64Bytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "N")
+				return
+			}
+		case "error":
+			if msgp.IsNil(bts) {
+				bts, err = msgp.ReadNilBytes(bts)
+				if err != nil {
+					return
+				}
+				z.Error = nil
+			} else {
+				if z.Error == nil {
+					z.Error = new(Error)
+				}
+				bts, err = z.Error.UnmarshalMsg(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "Error")
+					return
+				}
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				err = msgp.WrapError(err)
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z *WriteResponse) Msgsize() (s int) {
+	s = 1 + 2 + msgp.Int64Size + 6
+	if z.Error == nil {
+		s += msgp.NilSize
+	} else {
+		s += z.Error.Msgsize()
+	}
+	return
+}
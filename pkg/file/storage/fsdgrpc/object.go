@@ -1 +1,92 @@
 package fsdgrpc
+
+import (
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Object = &Object{}
+
+// remoteObject is implemented by all the objects of a Storage.
+type remoteObject interface {
+	object() *Object
+}
+
+// Object is the client side of an object opened on the server.
+type Object struct {
+	StorageValue *Storage
+	LastInfo     os.FileInfo
+	LastPath     file.Path
+
+	handle Handle
+	id     file.ObjectID
+}
+
+func (obj *Object) object() *Object {
+	return obj
+}
+
+// ID returns the ID of the object on the server.
+func (obj *Object) ID() file.ObjectID {
+	return obj.id
+}
+
+func (obj *Object) Name() string {
+	return obj.LastInfo.Name()
+}
+
+func (obj *Object) Stat() (os.FileInfo, error) {
+	var resp StatResponse
+	err := obj.StorageValue.invoke(obj.StorageValue.ctx, methodObjectStat, &HandleRequest{Handle: obj.handle}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error.Err(); err != nil {
+		return nil, err
+	}
+	obj.LastInfo = resp.Info.OSFileInfo()
+	return obj.LastInfo, nil
+}
+
+func (obj *Object) LastStat() os.FileInfo {
+	return obj.LastInfo
+}
+
+// Path returns the path the object was opened by.
+func (obj *Object) Path() file.Path {
+	return obj.LastPath
+}
+
+func (obj *Object) Close() error {
+	return obj.invokeNoResult(methodClose, &HandleRequest{Handle: obj.handle})
+}
+
+func (obj *Object) Chmod(mode os.FileMode) error {
+	return obj.invokeNoResult(methodObjectChmod, &ObjectChmodRequest{
+		Handle: obj.handle,
+		Mode:   uint32(mode),
+	})
+}
+
+func (obj *Object) Chown(uid, gid int) error {
+	return obj.invokeNoResult(methodObjectChown, &ObjectChownRequest{
+		Handle: obj.handle,
+		UID:    uid,
+		GID:    gid,
+	})
+}
+
+func (obj *Object) Storage() file.Storage {
+	return obj.StorageValue
+}
+
+// FD returns an invalid file descriptor: the object is opened on
+// another machine.
+func (obj *Object) FD() uintptr {
+	return ^uintptr(0)
+}
+
+func (obj *Object) invokeNoResult(method string, req interface{}) error {
+	return obj.StorageValue.invokeNoResult(obj.StorageValue.ctx, method, req)
+}
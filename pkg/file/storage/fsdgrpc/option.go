@@ -0,0 +1,73 @@
+package fsdgrpc
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionChunkSize struct {
+	Size uint
+}
+
+func (opt OptionChunkSize) apply(cfg *Config) {
+	cfg.ChunkSize = opt.Size
+}
+
+type OptionEventQueueSize struct {
+	Size uint
+}
+
+func (opt OptionEventQueueSize) apply(cfg *Config) {
+	cfg.EventQueueSize = opt.Size
+}
+
+type OptionWatchJournalSize struct {
+	Size uint
+}
+
+func (opt OptionWatchJournalSize) apply(cfg *Config) {
+	cfg.WatchJournalSize = opt.Size
+}
+
+type OptionWatchRetention struct {
+	Value time.Duration
+}
+
+func (opt OptionWatchRetention) apply(cfg *Config) {
+	cfg.WatchRetention = opt.Value
+}
+
+type OptionWatchRetryInterval struct {
+	Value time.Duration
+}
+
+func (opt OptionWatchRetryInterval) apply(cfg *Config) {
+	cfg.WatchRetryInterval = opt.Value
+}
+
+type OptionACL struct {
+	ACL *ACL
+}
+
+func (opt OptionACL) apply(cfg *Config) {
+	cfg.ACL = opt.ACL
+}
+
+type OptionAuditFunc struct {
+	Func AuditFunc
+}
+
+func (opt OptionAuditFunc) apply(cfg *Config) {
+	cfg.AuditFunc = opt.Func
+}
+
+type OptionCompressors struct {
+	Names []string
+}
+
+func (opt OptionCompressors) apply(cfg *Config) {
+	cfg.Compressors = opt.Names
+}
@@ -0,0 +1,45 @@
+package fsdgrpc
+
+import (
+	"context"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.PathDescriptor = &PathDescriptor{}
+
+type PathDescriptor struct {
+	Object
+}
+
+func (pathDesc *PathDescriptor) Open(
+	ctx context.Context,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return pathDesc.open(ctx, flags, defaultPerm)
+}
+
+// open opens the object by its handle on the server (see
+// file.PathDescriptor).
+func (obj *Object) open(
+	ctx context.Context,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	stor := obj.StorageValue
+	var resp OpenResponse
+	err := stor.invoke(ctx, methodObjectOpen, &ObjectOpenRequest{
+		Handle: obj.handle,
+		Flags:  uint16(flags),
+		Perm:   uint32(defaultPerm),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error.Err(); err != nil {
+		return nil, err
+	}
+	return stor.newObject(&resp), nil
+}
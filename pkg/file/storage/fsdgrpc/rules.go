@@ -0,0 +1,86 @@
+//go:generate msgp
+
+package fsdgrpc
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+// Rule is a serializable condition on a directory. It is used instead
+// of event.ShouldWatchFunc and file.ShouldWalkFunc, since functions
+// cannot be sent to the server (see Rules).
+type Rule struct {
+	// Name is a pattern of filepath.Match the name of the directory
+	// should match. An empty pattern matches any name.
+	Name string `msg:"name"`
+
+	// Path (if not nil) is the path the directory should be inside of
+	// (or be equal to).
+	Path file.Path `msg:"path"`
+
+	// Exclude makes the rule to reject the matching directories instead
+	// of approving them.
+	Exclude bool `msg:"exclude"`
+}
+
+// Match returns true if the directory matches the conditions of the rule.
+func (rule Rule) Match(path file.Path) bool {
+	if rule.Path != nil && !path.HasPrefix(rule.Path) {
+		return false
+	}
+	if rule.Name == "" {
+		return true
+	}
+	if len(path) == 0 {
+		return false
+	}
+	isMatch, err := filepath.Match(rule.Name, path[len(path)-1])
+	return err == nil && isMatch
+}
+
+// Rules is a list of Rule-s similar to ".gitignore": the last matching
+// rule decides if a directory is approved. A directory which matches
+// no rule is approved, so empty Rules approve everything.
+type Rules []Rule
+
+// Approve returns true if the directory (at the path) is approved.
+func (rules Rules) Approve(path file.Path) bool {
+	for idx := len(rules) - 1; idx >= 0; idx-- {
+		if rules[idx].Match(path) {
+			return !rules[idx].Exclude
+		}
+	}
+	return true
+}
+
+// approve has the signature of event.ShouldWatchFunc
+// and file.ShouldWalkFunc.
+func (rules Rules) approve(dir file.Directory, info os.FileInfo) bool {
+	path := dir.Path()
+	if info.Name() != "." {
+		path = path.Append(info.Name())
+	}
+	return rules.Approve(path)
+}
+
+// ShouldWatchFunc returns the function with the same semantics as
+// the rules (nil if there are no rules).
+func (rules Rules) ShouldWatchFunc() event.ShouldWatchFunc {
+	if len(rules) == 0 {
+		return nil
+	}
+	return rules.approve
+}
+
+// ShouldWalkFunc returns the function with the same semantics as
+// the rules (nil if there are no rules).
+func (rules Rules) ShouldWalkFunc() file.ShouldWalkFunc {
+	if len(rules) == 0 {
+		return nil
+	}
+	return rules.approve
+}
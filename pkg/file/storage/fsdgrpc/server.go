@@ -0,0 +1,800 @@
+package fsdgrpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/tinylib/msgp/msgp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Server exposes a file.Storage over grpc (see Register). The client
+// side is Storage.
+//
+// The objects are opened on behalf of a session and are referred by
+// handles; all of them are closed when the session ends.
+//
+// If Config.ACL is set then each path received from a client is checked
+// against the subtrees granted to the client (see ACL).
+type Server struct {
+	Config
+	ctx      context.Context
+	cancelFn context.CancelFunc
+	storage  file.Storage
+
+	// id identifies the instance of the server (see ResumeToken)
+	id string
+
+	locker   sync.Mutex
+	sessions map[string]*session
+	watches  map[string]*watch
+}
+
+// NewServer returns a server of the storage. The events are supported
+// if the storage implements event.Watcher.
+func NewServer(storage file.Storage, opts ...Option) *Server {
+	srv := &Server{
+		storage:  storage,
+		id:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		sessions: map[string]*session{},
+		watches:  map[string]*watch{},
+	}
+	for _, opt := range opts {
+		opt.apply(&srv.Config)
+	}
+	srv.ctx, srv.cancelFn = context.WithCancel(context.Background())
+	return srv
+}
+
+// Register registers the service of the server in the grpc.Server.
+func (srv *Server) Register(grpcServer *grpc.Server) {
+	grpcServer.RegisterService(&serviceDesc, srv)
+}
+
+// Close ends all the sessions (and closes their objects) and
+// the watches.
+func (srv *Server) Close() error {
+	srv.cancelFn()
+
+	srv.locker.Lock()
+	sessions := srv.sessions
+	srv.sessions = map[string]*session{}
+	watches := srv.watches
+	srv.watches = map[string]*watch{}
+	srv.locker.Unlock()
+
+	for _, sess := range sessions {
+		sess.close()
+	}
+	for _, w := range watches {
+		w.close()
+	}
+	return nil
+}
+
+func (srv *Server) session(req *SessionRequest, stream grpc.ServerStream) error {
+	identity, err := srv.identityOf(stream.Context(), methodSession)
+	if err != nil {
+		return err
+	}
+	sessionID, err := newID()
+	if err != nil {
+		return status.Errorf(codes.Internal, "unable to generate a session ID: %v", err)
+	}
+	sess := newSession(identity)
+
+	srv.locker.Lock()
+	srv.sessions[sessionID] = sess
+	srv.locker.Unlock()
+
+	defer func() {
+		srv.locker.Lock()
+		delete(srv.sessions, sessionID)
+		srv.locker.Unlock()
+		sess.close()
+	}()
+
+	err = stream.SendMsg(&SessionResponse{
+		SessionID:  sessionID,
+		Compressor: srv.chooseCompressor(req.Compressors),
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-stream.Context().Done():
+	case <-srv.ctx.Done():
+	}
+	return nil
+}
+
+// chooseCompressor returns the first of the compressors proposed by
+// the client which is allowed by the server (empty if none).
+func (srv *Server) chooseCompressor(proposed []string) string {
+	for _, name := range proposed {
+		if encoding.GetCompressor(name) == nil {
+			continue
+		}
+		for _, allowed := range srv.Compressors {
+			if name == allowed {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// sessionOf returns the session the call belongs to. The caller
+// should be the client who started the session.
+func (srv *Server) sessionOf(ctx context.Context, method string) (*session, error) {
+	identity, err := srv.identityOf(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(sessionIDKey)
+	if len(values) != 1 {
+		return nil, status.Error(codes.FailedPrecondition, ErrUnknownSession{}.Error())
+	}
+
+	srv.locker.Lock()
+	sess := srv.sessions[values[0]]
+	srv.locker.Unlock()
+	if sess != nil && sess.identity != identity {
+		srv.deny(ctx, identity, method, nil, "the session belongs to another client")
+		sess = nil
+	}
+	if sess == nil {
+		return nil, status.Error(codes.FailedPrecondition, ErrUnknownSession{SessionID: values[0]}.Error())
+	}
+	return sess, nil
+}
+
+// callAt calls `fn` with the object of the handle `dirAt` (nil for
+// the zero handle). The first returned value is the error of the storage,
+// the second one is the error of the call itself.
+func (srv *Server) callAt(
+	ctx context.Context,
+	method string,
+	dirAt Handle,
+	fn func(sess *session, dirAt file.Object) error,
+) (*Error, error) {
+	sess, err := srv.sessionOf(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	var obj file.Object
+	if dirAt != 0 {
+		obj, _, err = sess.get(dirAt)
+		if err != nil {
+			return newError(err), nil
+		}
+	}
+	return newError(fn(sess, obj)), nil
+}
+
+// callObject is the same as callAt, but the handle is required and
+// the object should be opened with at least the access `access`.
+func (srv *Server) callObject(
+	ctx context.Context,
+	method string,
+	handle Handle,
+	access Access,
+	fn func(sess *session, obj file.Object) error,
+) (*Error, error) {
+	if handle == 0 {
+		return newError(ErrInvalidHandle{Handle: handle}), nil
+	}
+	sess, err := srv.sessionOf(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := srv.objectOf(ctx, sess, method, handle, access)
+	if err != nil {
+		return newError(err), nil
+	}
+	return newError(fn(sess, obj)), nil
+}
+
+// objectOf returns the object of the handle if it is opened with at least
+// the access `access`.
+func (srv *Server) objectOf(
+	ctx context.Context,
+	sess *session,
+	method string,
+	handle Handle,
+	access Access,
+) (file.Object, error) {
+	obj, granted, err := sess.get(handle)
+	if err != nil {
+		return nil, err
+	}
+	if granted < access {
+		srv.deny(ctx, sess.identity, method, obj.Path(), fmt.Sprintf("the object is opened without %s access", access))
+		return nil, &os.PathError{Op: method, Path: obj.Path().LocalPath(), Err: syscall.EACCES}
+	}
+	return obj, nil
+}
+
+// openAccess returns the access required to open with the flags.
+func openAccess(flags file.OpenFlag) Access {
+	if flags&(file.FlagWrite|file.FlagAppend|file.FlagCreate|file.FlagTrunc) != 0 {
+		return AccessReadWrite
+	}
+	return AccessRead
+}
+
+func (srv *Server) open(ctx context.Context, req *OpenRequest) (*OpenResponse, error) {
+	var resp *OpenResponse
+	flags := file.OpenFlag(req.Flags)
+	storErr, err := srv.callAt(ctx, methodOpen, req.DirAt, func(sess *session, dirAt file.Object) error {
+		access, err := srv.authorize(ctx, sess.identity, methodOpen, dirAt, req.Path, openAccess(flags), !flags.HasNoFollow())
+		if err != nil {
+			return err
+		}
+		obj, err := srv.storage.Open(ctx, dirAt, req.Path, flags, os.FileMode(req.Perm))
+		if err != nil {
+			return err
+		}
+		resp, err = sess.register(obj, access)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if storErr != nil {
+		return &OpenResponse{Error: storErr}, nil
+	}
+	return resp, nil
+}
+
+func (srv *Server) stat(ctx context.Context, req *StatRequest) (*StatResponse, error) {
+	var info os.FileInfo
+	storErr, err := srv.callAt(ctx, methodStat, req.DirAt, func(sess *session, dirAt file.Object) (err error) {
+		if _, err = srv.authorize(ctx, sess.identity, methodStat, dirAt, req.Path, AccessRead, !req.NoFollow); err != nil {
+			return
+		}
+		info, err = srv.storage.Stat(ctx, dirAt, req.Path, req.NoFollow)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &StatResponse{Info: newFileInfo(info), Error: storErr}, nil
+}
+
+func (srv *Server) symlink(ctx context.Context, req *SymlinkRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callAt(ctx, methodSymlink, req.DirAt, func(sess *session, dirAt file.Object) error {
+		if _, err := srv.authorize(ctx, sess.identity, methodSymlink, dirAt, req.Path, AccessReadWrite, false); err != nil {
+			return err
+		}
+		if err := srv.authorizeDestination(ctx, sess.identity, methodSymlink, dirAt, req.Path, req.Destination); err != nil {
+			return err
+		}
+		return srv.storage.Symlink(ctx, dirAt, req.Path, req.Destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) readlink(ctx context.Context, req *ReadlinkRequest) (*ReadlinkResponse, error) {
+	var destination file.Path
+	storErr, err := srv.callAt(ctx, methodReadlink, req.DirAt, func(sess *session, dirAt file.Object) (err error) {
+		if _, err = srv.authorize(ctx, sess.identity, methodReadlink, dirAt, req.Path, AccessRead, false); err != nil {
+			return
+		}
+		destination, err = srv.storage.Readlink(ctx, dirAt, req.Path)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ReadlinkResponse{Destination: destination, Error: storErr}, nil
+}
+
+func (srv *Server) mkdir(ctx context.Context, req *MkdirRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callAt(ctx, methodMkdir, req.DirAt, func(sess *session, dirAt file.Object) error {
+		if _, err := srv.authorize(ctx, sess.identity, methodMkdir, dirAt, req.Path, AccessReadWrite, false); err != nil {
+			return err
+		}
+		return srv.storage.Mkdir(ctx, dirAt, req.Path, os.FileMode(req.Perm), req.IsRecursive)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) remove(ctx context.Context, req *RemoveRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callAt(ctx, methodRemove, req.DirAt, func(sess *session, dirAt file.Object) error {
+		if _, err := srv.authorize(ctx, sess.identity, methodRemove, dirAt, req.Path, AccessReadWrite, false); err != nil {
+			return err
+		}
+		return srv.storage.Remove(ctx, dirAt, req.Path, req.IsRecursive)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) rename(ctx context.Context, req *RenameRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callAt(ctx, methodRename, req.DirAt, func(sess *session, dirAt file.Object) error {
+		if _, err := srv.authorize(ctx, sess.identity, methodRename, dirAt, req.Path, AccessReadWrite, false); err != nil {
+			return err
+		}
+		if _, err := srv.authorize(ctx, sess.identity, methodRename, dirAt, req.NewPath, AccessReadWrite, false); err != nil {
+			return err
+		}
+		if err := srv.authorizeMove(ctx, sess.identity, methodRename, dirAt, req.Path, req.NewPath); err != nil {
+			return err
+		}
+		return srv.storage.Rename(ctx, dirAt, req.Path, req.NewPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) link(ctx context.Context, req *LinkRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callAt(ctx, methodLink, req.DirAt, func(sess *session, dirAt file.Object) error {
+		// the link gives the same access as the original, so both
+		// should be writable
+		if _, err := srv.authorize(ctx, sess.identity, methodLink, dirAt, req.Path, AccessReadWrite, false); err != nil {
+			return err
+		}
+		if _, err := srv.authorize(ctx, sess.identity, methodLink, dirAt, req.Destination, AccessReadWrite, false); err != nil {
+			return err
+		}
+		if err := srv.authorizeMove(ctx, sess.identity, methodLink, dirAt, req.Path, req.Destination); err != nil {
+			return err
+		}
+		return srv.storage.Link(ctx, dirAt, req.Path, req.Destination)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) chmod(ctx context.Context, req *ChmodRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callAt(ctx, methodChmod, req.DirAt, func(sess *session, dirAt file.Object) error {
+		if _, err := srv.authorize(ctx, sess.identity, methodChmod, dirAt, req.Path, AccessReadWrite, true); err != nil {
+			return err
+		}
+		return srv.storage.Chmod(ctx, dirAt, req.Path, os.FileMode(req.Mode))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) chown(ctx context.Context, req *ChownRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callAt(ctx, methodChown, req.DirAt, func(sess *session, dirAt file.Object) error {
+		if _, err := srv.authorize(ctx, sess.identity, methodChown, dirAt, req.Path, AccessReadWrite, !req.NoFollow); err != nil {
+			return err
+		}
+		return srv.storage.Chown(ctx, dirAt, req.Path, req.UID, req.GID, req.NoFollow)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) chtimes(ctx context.Context, req *ChtimesRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callAt(ctx, methodChtimes, req.DirAt, func(sess *session, dirAt file.Object) error {
+		if _, err := srv.authorize(ctx, sess.identity, methodChtimes, dirAt, req.Path, AccessReadWrite, true); err != nil {
+			return err
+		}
+		return srv.storage.Chtimes(ctx, dirAt, req.Path, req.Atime, req.Mtime)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) close(ctx context.Context, req *HandleRequest) (*ErrorResponse, error) {
+	sess, err := srv.sessionOf(ctx, methodClose)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := sess.remove(req.Handle)
+	if err == nil {
+		err = obj.Close()
+	}
+	return &ErrorResponse{Error: newError(err)}, nil
+}
+
+func (srv *Server) objectStat(ctx context.Context, req *HandleRequest) (*StatResponse, error) {
+	var info os.FileInfo
+	storErr, err := srv.callObject(ctx, methodObjectStat, req.Handle, AccessRead, func(sess *session, obj file.Object) (err error) {
+		info, err = obj.Stat()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &StatResponse{Info: newFileInfo(info), Error: storErr}, nil
+}
+
+func (srv *Server) objectChmod(ctx context.Context, req *ObjectChmodRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callObject(ctx, methodObjectChmod, req.Handle, AccessReadWrite, func(sess *session, obj file.Object) error {
+		chmoder, ok := obj.(interface{ Chmod(os.FileMode) error })
+		if !ok {
+			return file.ErrNotImplemented{}
+		}
+		return chmoder.Chmod(os.FileMode(req.Mode))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) objectChown(ctx context.Context, req *ObjectChownRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callObject(ctx, methodObjectChown, req.Handle, AccessReadWrite, func(sess *session, obj file.Object) error {
+		return obj.Chown(req.UID, req.GID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+func (srv *Server) objectOpen(ctx context.Context, req *ObjectOpenRequest) (*OpenResponse, error) {
+	var resp *OpenResponse
+	flags := file.OpenFlag(req.Flags)
+	storErr, err := srv.callObject(ctx, methodObjectOpen, req.Handle, AccessRead, func(sess *session, obj file.Object) error {
+		pathDesc, ok := obj.(file.PathDescriptor)
+		if !ok {
+			return file.ErrNotImplemented{}
+		}
+		// opening a symlink follows it
+		access, err := srv.authorize(ctx, sess.identity, methodObjectOpen, nil, obj.Path(), openAccess(flags), true)
+		if err != nil {
+			return err
+		}
+		opened, err := pathDesc.Open(ctx, flags, os.FileMode(req.Perm))
+		if err != nil {
+			return err
+		}
+		resp, err = sess.register(opened, access)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if storErr != nil {
+		return &OpenResponse{Error: storErr}, nil
+	}
+	return resp, nil
+}
+
+func (srv *Server) destination(ctx context.Context, req *HandleRequest) (*ReadlinkResponse, error) {
+	var destination file.Path
+	storErr, err := srv.callObject(ctx, methodDestination, req.Handle, AccessRead, func(sess *session, obj file.Object) (err error) {
+		symlink, ok := obj.(file.SymLink)
+		if !ok {
+			return file.ErrNotImplemented{}
+		}
+		destination, err = symlink.Destination()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ReadlinkResponse{Destination: destination, Error: storErr}, nil
+}
+
+func (srv *Server) readdir(ctx context.Context, req *ReaddirRequest) (*ReaddirResponse, error) {
+	var infos []os.FileInfo
+	storErr, err := srv.callObject(ctx, methodReaddir, req.Handle, AccessRead, func(sess *session, obj file.Object) (err error) {
+		dir, ok := obj.(file.Directory)
+		if !ok {
+			return file.ErrNotImplemented{}
+		}
+		infos, err = dir.Readdir(req.N)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := &ReaddirResponse{
+		Infos: make([]FileInfo, 0, len(infos)),
+		Error: storErr,
+	}
+	for _, info := range infos {
+		resp.Infos = append(resp.Infos, *newFileInfo(info))
+	}
+	return resp, nil
+}
+
+func (srv *Server) seek(ctx context.Context, req *SeekRequest) (*SeekResponse, error) {
+	var offset int64
+	storErr, err := srv.callObject(ctx, methodSeek, req.Handle, AccessRead, func(sess *session, obj file.Object) (err error) {
+		seeker, ok := obj.(io.Seeker)
+		if !ok {
+			return file.ErrNotImplemented{}
+		}
+		offset, err = seeker.Seek(req.Offset, req.Whence)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SeekResponse{Offset: offset, Error: storErr}, nil
+}
+
+func (srv *Server) sync(ctx context.Context, req *HandleRequest) (*ErrorResponse, error) {
+	storErr, err := srv.callObject(ctx, methodSync, req.Handle, AccessRead, func(sess *session, obj file.Object) error {
+		syncer, ok := obj.(interface{ Sync() error })
+		if !ok {
+			return file.ErrNotImplemented{}
+		}
+		return syncer.Sync()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ErrorResponse{Error: storErr}, nil
+}
+
+// read streams up to req.Size bytes by chunks of ChunkSize. Without
+// UseOffset (Read) it stops on a short read, the same as os.File.Read.
+func (srv *Server) read(req *ReadRequest, stream grpc.ServerStream) error {
+	sess, err := srv.sessionOf(stream.Context(), methodRead)
+	if err != nil {
+		return err
+	}
+	obj, err := srv.objectOf(stream.Context(), sess, methodRead, req.Handle, AccessRead)
+	if err != nil {
+		return stream.SendMsg(&ReadChunk{Error: newError(err)})
+	}
+
+	bufSize := srv.chunkSize()
+	if int64(req.Size) < int64(bufSize) {
+		bufSize = int(req.Size)
+	}
+	buf := make([]byte, bufSize)
+	offset := req.Offset
+	remaining := int(req.Size)
+	for {
+		size := remaining
+		if size > len(buf) {
+			size = len(buf)
+		}
+		n, readErr := readChunk(obj, buf[:size], offset, req.UseOffset)
+		offset += int64(n)
+		remaining -= n
+
+		err := stream.SendMsg(&ReadChunk{Data: buf[:n], Error: newError(readErr)})
+		switch {
+		case err != nil:
+			return err
+		case readErr != nil, remaining == 0, n == 0:
+			return nil
+		case !req.UseOffset && n < size:
+			return nil
+		}
+	}
+}
+
+func readChunk(obj file.Object, b []byte, offset int64, useOffset bool) (int, error) {
+	if useOffset {
+		readerAt, ok := obj.(io.ReaderAt)
+		if !ok {
+			return 0, file.ErrNotImplemented{}
+		}
+		return readerAt.ReadAt(b, offset)
+	}
+	reader, ok := obj.(io.Reader)
+	if !ok {
+		return 0, file.ErrNotImplemented{}
+	}
+	return reader.Read(b)
+}
+
+// write writes the streamed chunks. The response is sent on the first
+// error (the rest of the chunks are not read).
+func (srv *Server) write(stream grpc.ServerStream) error {
+	sess, err := srv.sessionOf(stream.Context(), methodWrite)
+	if err != nil {
+		return err
+	}
+
+	var (
+		obj       file.Object
+		offset    int64
+		useOffset bool
+		total     int64
+		writeErr  error
+	)
+	for isFirst := true; writeErr == nil; isFirst = false {
+		var chunk WriteChunk
+		err := stream.RecvMsg(&chunk)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if isFirst {
+			offset, useOffset = chunk.Offset, chunk.UseOffset
+			obj, writeErr = srv.objectOf(stream.Context(), sess, methodWrite, chunk.Handle, AccessReadWrite)
+			if writeErr != nil {
+				break
+			}
+		}
+
+		var n int
+		n, writeErr = writeChunk(obj, chunk.Data, offset, useOffset)
+		offset += int64(n)
+		total += int64(n)
+	}
+
+	return stream.SendMsg(&WriteResponse{N: total, Error: newError(writeErr)})
+}
+
+func writeChunk(obj file.Object, b []byte, offset int64, useOffset bool) (int, error) {
+	if useOffset {
+		writerAt, ok := obj.(io.WriterAt)
+		if !ok {
+			return 0, file.ErrNotImplemented{}
+		}
+		return writerAt.WriteAt(b, offset)
+	}
+	writer, ok := obj.(io.Writer)
+	if !ok {
+		return 0, file.ErrNotImplemented{}
+	}
+	return writer.Write(b)
+}
+
+// batch does the calls of the batch in order (see Batch). A failure of
+// a call itself (for example, a malformed request) fails the whole batch.
+func (srv *Server) batch(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	if _, err := srv.sessionOf(ctx, methodBatch); err != nil {
+		return nil, err
+	}
+
+	resp := &BatchResponse{
+		Responses: make([]msgp.Raw, 0, len(req.Calls)),
+	}
+	// opened are the handles of the objects opened by the calls (by index)
+	opened := make([]Handle, len(req.Calls))
+	for idx, call := range req.Calls {
+		desc, ok := batchMethods[call.Method]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "the method '%s' cannot be batched", call.Method)
+		}
+		dec := func(req interface{}) error {
+			if _, err := req.(msgp.Unmarshaler).UnmarshalMsg(call.Request); err != nil {
+				return status.Errorf(codes.InvalidArgument, "unable to decode the request of '%s': %v", call.Method, err)
+			}
+			if refs, ok := req.(handleRefs); ok {
+				for _, handle := range refs.handleRefs() {
+					*handle = resolveBatchHandle(*handle, opened[:idx])
+				}
+			}
+			return nil
+		}
+		result, err := desc.Handler(srv, ctx, dec, nil)
+		if err != nil {
+			return nil, err
+		}
+		if openResp, ok := result.(*OpenResponse); ok {
+			opened[idx] = openResp.Handle
+		}
+		data, err := result.(msgp.Marshaler).MarshalMsg(nil)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "unable to encode the response of '%s': %v", call.Method, err)
+		}
+		resp.Responses = append(resp.Responses, data)
+	}
+	return resp, nil
+}
+
+// resolveBatchHandle replaces a reference to an object opened within
+// the batch by its handle. A reference to a call which opened nothing is
+// kept as it is, so it is reported as an invalid handle (it is never
+// confused with the zero handle, which means the root).
+func resolveBatchHandle(handle Handle, opened []Handle) Handle {
+	if handle&batchHandleFlag == 0 {
+		return handle
+	}
+	idx := uint64(handle &^ batchHandleFlag)
+	if idx >= uint64(len(opened)) || opened[idx] == 0 {
+		return handle
+	}
+	return opened[idx]
+}
+
+// handleRefs is implemented by the requests which refer opened objects.
+type handleRefs interface {
+	handleRefs() []*Handle
+}
+
+func (req *OpenRequest) handleRefs() []*Handle        { return []*Handle{&req.DirAt} }
+func (req *StatRequest) handleRefs() []*Handle        { return []*Handle{&req.DirAt} }
+func (req *SymlinkRequest) handleRefs() []*Handle     { return []*Handle{&req.DirAt} }
+func (req *ReadlinkRequest) handleRefs() []*Handle    { return []*Handle{&req.DirAt} }
+func (req *MkdirRequest) handleRefs() []*Handle       { return []*Handle{&req.DirAt} }
+func (req *RemoveRequest) handleRefs() []*Handle      { return []*Handle{&req.DirAt} }
+func (req *RenameRequest) handleRefs() []*Handle      { return []*Handle{&req.DirAt} }
+func (req *LinkRequest) handleRefs() []*Handle        { return []*Handle{&req.DirAt} }
+func (req *ChmodRequest) handleRefs() []*Handle       { return []*Handle{&req.DirAt} }
+func (req *ChownRequest) handleRefs() []*Handle       { return []*Handle{&req.DirAt} }
+func (req *ChtimesRequest) handleRefs() []*Handle     { return []*Handle{&req.DirAt} }
+func (req *HandleRequest) handleRefs() []*Handle      { return []*Handle{&req.Handle} }
+func (req *ObjectOpenRequest) handleRefs() []*Handle  { return []*Handle{&req.Handle} }
+func (req *ObjectChmodRequest) handleRefs() []*Handle { return []*Handle{&req.Handle} }
+func (req *ObjectChownRequest) handleRefs() []*Handle { return []*Handle{&req.Handle} }
+func (req *ReaddirRequest) handleRefs() []*Handle     { return []*Handle{&req.Handle} }
+func (req *SeekRequest) handleRefs() []*Handle        { return []*Handle{&req.Handle} }
+
+// register adds the opened object to the session and returns
+// the response to the client.
+func (sess *session) register(obj file.Object, access Access) (*OpenResponse, error) {
+	info := obj.LastStat()
+	if info == nil {
+		var err error
+		info, err = obj.Stat()
+		if err != nil {
+			_ = obj.Close()
+			return nil, err
+		}
+	}
+
+	handle, err := sess.add(obj, access)
+	if err != nil {
+		return nil, err
+	}
+
+	id := obj.ID()
+	return &OpenResponse{
+		Handle: handle,
+		Type:   objectTypeOf(obj),
+		ID: ObjectID{
+			Dev:    id.Dev,
+			Ino:    id.Ino,
+			Handle: []byte(id.Handle),
+		},
+		Info: newFileInfo(info),
+		Path: obj.Path(),
+	}, nil
+}
+
+func objectTypeOf(obj file.Object) ObjectType {
+	switch obj.(type) {
+	case file.Directory:
+		return ObjectTypeDirectory
+	case file.SymLink:
+		// checked before file.PathDescriptor, which it implements
+		return ObjectTypeSymlink
+	case file.PathDescriptor:
+		return ObjectTypePathDescriptor
+	case file.File:
+		return ObjectTypeFile
+	}
+	return ObjectTypeUntyped
+}
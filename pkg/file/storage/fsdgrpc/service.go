@@ -0,0 +1,262 @@
+package fsdgrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// serviceName is the name of the grpc service of the protocol. The service
+// is described by hand (there are no .proto files): the messages are
+// defined in messages.go and are encoded by `codec`.
+const serviceName = "fsd.Storage"
+
+// sessionIDKey is the metadata key of the ID of the session the call
+// belongs to (see methodSession).
+const sessionIDKey = "fsd-session-id"
+
+const (
+	// methodSession starts a session: the server returns its ID and keeps
+	// the stream open. All the objects opened within the session are closed
+	// when the stream ends (for example, the client disconnected).
+	methodSession = "Session"
+
+	methodOpen     = "Open"
+	methodStat     = "Stat"
+	methodSymlink  = "Symlink"
+	methodReadlink = "Readlink"
+	methodMkdir    = "Mkdir"
+	methodRemove   = "Remove"
+	methodRename   = "Rename"
+	methodLink     = "Link"
+	methodChmod    = "Chmod"
+	methodChown    = "Chown"
+	methodChtimes  = "Chtimes"
+
+	methodClose       = "Close"
+	methodObjectStat  = "ObjectStat"
+	methodObjectChmod = "ObjectChmod"
+	methodObjectChown = "ObjectChown"
+	methodObjectOpen  = "ObjectOpen"
+	methodDestination = "Destination"
+	methodReaddir     = "Readdir"
+	methodSeek        = "Seek"
+	methodSync        = "Sync"
+	methodRead        = "Read"
+	methodWrite       = "Write"
+
+	// methodWatch streams the events of a watch (see WatchRequest)
+	methodWatch      = "Watch"
+	methodWatchAdd   = "WatchAdd"
+	methodUnwatch    = "Unwatch"
+	methodWatchClose = "WatchClose"
+
+	// methodBatch does several unary calls at once (see BatchRequest)
+	methodBatch = "Batch"
+)
+
+func fullMethod(method string) string {
+	return "/" + serviceName + "/" + method
+}
+
+var (
+	sessionStreamDesc = grpc.StreamDesc{
+		StreamName:    methodSession,
+		ServerStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			var req SessionRequest
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			return srv.(*Server).session(&req, stream)
+		},
+	}
+	readStreamDesc = grpc.StreamDesc{
+		StreamName:    methodRead,
+		ServerStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			var req ReadRequest
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			return srv.(*Server).read(&req, stream)
+		},
+	}
+	watchStreamDesc = grpc.StreamDesc{
+		StreamName:    methodWatch,
+		ServerStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			var req WatchRequest
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			return srv.(*Server).watchStream(&req, stream)
+		},
+	}
+	writeStreamDesc = grpc.StreamDesc{
+		StreamName:    methodWrite,
+		ClientStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			return srv.(*Server).write(stream)
+		},
+	}
+)
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod(methodOpen, func() interface{} { return &OpenRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.open(ctx, req.(*OpenRequest))
+			}),
+		unaryMethod(methodStat, func() interface{} { return &StatRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.stat(ctx, req.(*StatRequest))
+			}),
+		unaryMethod(methodSymlink, func() interface{} { return &SymlinkRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.symlink(ctx, req.(*SymlinkRequest))
+			}),
+		unaryMethod(methodReadlink, func() interface{} { return &ReadlinkRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.readlink(ctx, req.(*ReadlinkRequest))
+			}),
+		unaryMethod(methodMkdir, func() interface{} { return &MkdirRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.mkdir(ctx, req.(*MkdirRequest))
+			}),
+		unaryMethod(methodRemove, func() interface{} { return &RemoveRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.remove(ctx, req.(*RemoveRequest))
+			}),
+		unaryMethod(methodRename, func() interface{} { return &RenameRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.rename(ctx, req.(*RenameRequest))
+			}),
+		unaryMethod(methodLink, func() interface{} { return &LinkRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.link(ctx, req.(*LinkRequest))
+			}),
+		unaryMethod(methodChmod, func() interface{} { return &ChmodRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.chmod(ctx, req.(*ChmodRequest))
+			}),
+		unaryMethod(methodChown, func() interface{} { return &ChownRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.chown(ctx, req.(*ChownRequest))
+			}),
+		unaryMethod(methodChtimes, func() interface{} { return &ChtimesRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.chtimes(ctx, req.(*ChtimesRequest))
+			}),
+		unaryMethod(methodClose, func() interface{} { return &HandleRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.close(ctx, req.(*HandleRequest))
+			}),
+		unaryMethod(methodObjectStat, func() interface{} { return &HandleRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.objectStat(ctx, req.(*HandleRequest))
+			}),
+		unaryMethod(methodObjectChmod, func() interface{} { return &ObjectChmodRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.objectChmod(ctx, req.(*ObjectChmodRequest))
+			}),
+		unaryMethod(methodObjectChown, func() interface{} { return &ObjectChownRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.objectChown(ctx, req.(*ObjectChownRequest))
+			}),
+		unaryMethod(methodObjectOpen, func() interface{} { return &ObjectOpenRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.objectOpen(ctx, req.(*ObjectOpenRequest))
+			}),
+		unaryMethod(methodDestination, func() interface{} { return &HandleRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.destination(ctx, req.(*HandleRequest))
+			}),
+		unaryMethod(methodReaddir, func() interface{} { return &ReaddirRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.readdir(ctx, req.(*ReaddirRequest))
+			}),
+		unaryMethod(methodSeek, func() interface{} { return &SeekRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.seek(ctx, req.(*SeekRequest))
+			}),
+		unaryMethod(methodSync, func() interface{} { return &HandleRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.sync(ctx, req.(*HandleRequest))
+			}),
+		unaryMethod(methodWatchAdd, func() interface{} { return &WatchAddRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.watchAdd(ctx, req.(*WatchAddRequest))
+			}),
+		unaryMethod(methodUnwatch, func() interface{} { return &UnwatchRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.unwatch(ctx, req.(*UnwatchRequest))
+			}),
+		unaryMethod(methodWatchClose, func() interface{} { return &WatchCloseRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.watchClose(ctx, req.(*WatchCloseRequest))
+			}),
+		unaryMethod(methodBatch, func() interface{} { return &BatchRequest{} },
+			func(srv *Server, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.batch(ctx, req.(*BatchRequest))
+			}),
+	},
+	Streams: []grpc.StreamDesc{
+		sessionStreamDesc,
+		readStreamDesc,
+		writeStreamDesc,
+		watchStreamDesc,
+	},
+}
+
+// batchMethods are the unary methods which may be called within
+// a batch (the watch methods are not session-bound, so they are excluded).
+var batchMethods = map[string]grpc.MethodDesc{}
+
+func init() {
+	// filled here, since serviceDesc refers to Server.batch which uses it
+	for _, desc := range serviceDesc.Methods {
+		switch desc.MethodName {
+		case methodBatch, methodWatchAdd, methodUnwatch, methodWatchClose:
+			continue
+		}
+		batchMethods[desc.MethodName] = desc
+	}
+}
+
+// unaryMethod returns the description of a unary method: the request
+// is created by newRequest, decoded and passed to `call` (through
+// the interceptors of the grpc.Server).
+func unaryMethod(
+	name string,
+	newRequest func() interface{},
+	call func(srv *Server, ctx context.Context, req interface{}) (interface{}, error),
+) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(
+			srv interface{},
+			ctx context.Context,
+			dec func(interface{}) error,
+			interceptor grpc.UnaryServerInterceptor,
+		) (interface{}, error) {
+			req := newRequest()
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(*Server), ctx, req)
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: fullMethod(name),
+			}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
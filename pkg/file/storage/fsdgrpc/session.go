@@ -0,0 +1,94 @@
+package fsdgrpc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// session is the state of a client on the server: the objects opened
+// by the client.
+type session struct {
+	// identity is the identity of the client (see ACL)
+	identity string
+
+	locker     sync.Mutex
+	objects    map[Handle]file.Object
+	access     map[Handle]Access
+	lastHandle Handle
+	isClosed   bool
+}
+
+func newSession(identity string) *session {
+	return &session{
+		identity: identity,
+		objects:  map[Handle]file.Object{},
+		access:   map[Handle]Access{},
+	}
+}
+
+// newID returns a random ID (of a session or a watch).
+func newID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// add registers the opened object and returns its handle. The object is
+// closed if the session is already closed.
+//
+// `access` is the access granted to the object when it was opened.
+func (sess *session) add(obj file.Object, access Access) (Handle, error) {
+	sess.locker.Lock()
+	defer sess.locker.Unlock()
+	if sess.isClosed {
+		_ = obj.Close()
+		return 0, file.ErrAborted{}
+	}
+	sess.lastHandle++
+	sess.objects[sess.lastHandle] = obj
+	sess.access[sess.lastHandle] = access
+	return sess.lastHandle, nil
+}
+
+// get returns the object of the handle and the access granted to it.
+func (sess *session) get(handle Handle) (file.Object, Access, error) {
+	sess.locker.Lock()
+	defer sess.locker.Unlock()
+	obj := sess.objects[handle]
+	if obj == nil {
+		return nil, AccessNone, ErrInvalidHandle{Handle: handle}
+	}
+	return obj, sess.access[handle], nil
+}
+
+// remove unregisters the object of the handle (without closing it).
+func (sess *session) remove(handle Handle) (file.Object, error) {
+	sess.locker.Lock()
+	defer sess.locker.Unlock()
+	obj := sess.objects[handle]
+	if obj == nil {
+		return nil, ErrInvalidHandle{Handle: handle}
+	}
+	delete(sess.objects, handle)
+	delete(sess.access, handle)
+	return obj, nil
+}
+
+// close closes all the objects of the session.
+func (sess *session) close() {
+	sess.locker.Lock()
+	objects := sess.objects
+	sess.objects = map[Handle]file.Object{}
+	sess.access = map[Handle]Access{}
+	sess.isClosed = true
+	sess.locker.Unlock()
+
+	for _, obj := range objects {
+		_ = obj.Close()
+	}
+}
@@ -1 +1,431 @@
 package fsdgrpc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ file.StorageWatchable = &Storage{}
+
+// Storage is a file.Storage of a remote fsd server (see Server).
+//
+// All the objects opened through the storage belong to its session on
+// the server, they become invalid after Close.
+//
+// The storage is safe for concurrent use, and the concurrent calls are
+// pipelined over the same connection. So on a link with a high latency
+// the independent operations should be done concurrently, and the
+// sequences of small ones should be batched (see Batch).
+type Storage struct {
+	Config
+	ctx       context.Context
+	cancelFn  context.CancelFunc
+	conn      grpc.ClientConnInterface
+	sessionID string
+
+	// compressor is the compressor of the Read and Write streams
+	// negotiated with the server (see Config.Compressors)
+	compressor string
+}
+
+// NewStorage starts a session on the server of the connection. The
+// connection should be closed by the caller after Storage.Close.
+func NewStorage(conn grpc.ClientConnInterface, opts ...Option) (*Storage, error) {
+	stor := &Storage{
+		conn: conn,
+	}
+	for _, opt := range opts {
+		opt.apply(&stor.Config)
+	}
+	stor.ctx, stor.cancelFn = context.WithCancel(context.Background())
+
+	// the session lasts as long as the stream
+	stream, err := stor.conn.NewStream(stor.ctx, &sessionStreamDesc, fullMethod(methodSession), stor.callOptions()...)
+	if err == nil {
+		err = stream.SendMsg(&SessionRequest{Compressors: stor.Compressors})
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	var resp SessionResponse
+	if err == nil {
+		err = stream.RecvMsg(&resp)
+	}
+	if err != nil {
+		stor.cancelFn()
+		return nil, fmt.Errorf("unable to start a session: %w", err)
+	}
+	stor.sessionID = resp.SessionID
+	stor.compressor = resp.Compressor
+	return stor, nil
+}
+
+// Close ends the session: all the objects opened through the storage
+// are closed on the server.
+func (stor *Storage) Close() error {
+	stor.cancelFn()
+	return nil
+}
+
+func (stor *Storage) callOptions() []grpc.CallOption {
+	return []grpc.CallOption{
+		grpc.CallContentSubtype(codecName),
+	}
+}
+
+// dataCallOptions returns the options of the streams of file data, which
+// are compressed if the server supports it.
+func (stor *Storage) dataCallOptions() []grpc.CallOption {
+	if stor.compressor == "" {
+		return nil
+	}
+	return []grpc.CallOption{
+		grpc.UseCompressor(stor.compressor),
+	}
+}
+
+// callContext returns the context of a call within the session.
+func (stor *Storage) callContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, sessionIDKey, stor.sessionID)
+}
+
+func (stor *Storage) invoke(ctx context.Context, method string, req, resp interface{}) error {
+	select {
+	case <-ctx.Done():
+		return file.ErrAborted{}
+	default:
+	}
+
+	err := stor.conn.Invoke(stor.callContext(ctx), fullMethod(method), req, resp, stor.callOptions()...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return file.ErrAborted{}
+		}
+		return ErrCall{Method: method, Err: err}
+	}
+	return nil
+}
+
+func (stor *Storage) newStream(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	opts = append(stor.callOptions(), opts...)
+	stream, err := stor.conn.NewStream(stor.callContext(ctx), desc, fullMethod(desc.StreamName), opts...)
+	if err != nil {
+		return nil, ErrCall{Method: desc.StreamName, Err: err}
+	}
+	return stream, nil
+}
+
+// handleOf returns the handle of the object (zero for nil). Only
+// the objects of the storage are supported.
+func (stor *Storage) handleOf(obj file.Object) (Handle, error) {
+	if obj == nil {
+		return 0, nil
+	}
+	remoteObj, ok := obj.(remoteObject)
+	if !ok || remoteObj.object().StorageValue != stor {
+		return 0, file.ErrNotImplemented{}
+	}
+	return remoteObj.object().handle, nil
+}
+
+// newObject returns the client side of the object opened on the server.
+func (stor *Storage) newObject(resp *OpenResponse) file.Object {
+	obj := Object{
+		StorageValue: stor,
+		LastInfo:     resp.Info.OSFileInfo(),
+		LastPath:     resp.Path,
+		handle:       resp.Handle,
+		id: file.ObjectID{
+			Dev:    resp.ID.Dev,
+			Ino:    resp.ID.Ino,
+			Handle: string(resp.ID.Handle),
+		},
+	}
+
+	switch resp.Type {
+	case ObjectTypeDirectory:
+		return &Directory{Object: obj}
+	case ObjectTypeFile:
+		return &File{Object: obj}
+	case ObjectTypeSymlink:
+		return &Symlink{Object: obj}
+	case ObjectTypePathDescriptor:
+		return &PathDescriptor{Object: obj}
+	}
+	return &Untyped{Object: obj}
+}
+
+// Watch starts watching the directory on the server (see Subscribe).
+// The functions cannot be sent to the server, so shouldWatchFunc and
+// shouldWalkFunc should be nil; use Subscribe with Rules instead.
+func (stor *Storage) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc event.ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandlerFunc file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
+) (event.Emitter, error) {
+	if shouldWatchFunc != nil || shouldWalkFunc != nil {
+		return nil, ErrNotSerializable{}
+	}
+	path, err := stor.pathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+	return stor.Subscribe(WatchRequest{Path: path}, errorHandlerFunc, opts...)
+}
+
+// pathOf returns the path relative to the root of the storage. Only
+// the directories of the storage are supported as `dirAt`.
+func (stor *Storage) pathOf(dirAt file.Directory, path file.Path) (file.Path, error) {
+	if dirAt == nil {
+		return path, nil
+	}
+	if _, err := stor.handleOf(dirAt); err != nil {
+		return nil, err
+	}
+	return dirAt.Path().Append(path...), nil
+}
+
+// ToLocalPath returns the path as if the remote storage was mounted
+// to "/".
+func (stor *Storage) ToLocalPath(path file.Path) string {
+	return file.Path{""}.Append(path...).LocalPath()
+}
+
+func (stor *Storage) Open(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return nil, err
+	}
+	var resp OpenResponse
+	err = stor.invoke(ctx, methodOpen, &OpenRequest{
+		DirAt: dirAtHandle,
+		Path:  path,
+		Flags: uint16(flags),
+		Perm:  uint32(defaultPerm),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error.Err(); err != nil {
+		return nil, err
+	}
+	return stor.newObject(&resp), nil
+}
+
+func (stor *Storage) Stat(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	noFollow bool,
+) (os.FileInfo, error) {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return nil, err
+	}
+	var resp StatResponse
+	err = stor.invoke(ctx, methodStat, &StatRequest{
+		DirAt:    dirAtHandle,
+		Path:     path,
+		NoFollow: noFollow,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error.Err(); err != nil {
+		return nil, err
+	}
+	return resp.Info.OSFileInfo(), nil
+}
+
+func (stor *Storage) Symlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	destination file.Path,
+) error {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return err
+	}
+	return stor.invokeNoResult(ctx, methodSymlink, &SymlinkRequest{
+		DirAt:       dirAtHandle,
+		Path:        path,
+		Destination: destination,
+	})
+}
+
+func (stor *Storage) Readlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+) (file.Path, error) {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return nil, err
+	}
+	var resp ReadlinkResponse
+	err = stor.invoke(ctx, methodReadlink, &ReadlinkRequest{
+		DirAt: dirAtHandle,
+		Path:  path,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error.Err(); err != nil {
+		return nil, err
+	}
+	return resp.Destination, nil
+}
+
+func (stor *Storage) Mkdir(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	perms os.FileMode,
+	isRecursive bool,
+) error {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return err
+	}
+	return stor.invokeNoResult(ctx, methodMkdir, &MkdirRequest{
+		DirAt:       dirAtHandle,
+		Path:        path,
+		Perm:        uint32(perms),
+		IsRecursive: isRecursive,
+	})
+}
+
+func (stor *Storage) Remove(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	isRecursive bool,
+) error {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return err
+	}
+	return stor.invokeNoResult(ctx, methodRemove, &RemoveRequest{
+		DirAt:       dirAtHandle,
+		Path:        path,
+		IsRecursive: isRecursive,
+	})
+}
+
+func (stor *Storage) Rename(
+	ctx context.Context,
+	dirAt file.Object,
+	path, newPath file.Path,
+) error {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return err
+	}
+	return stor.invokeNoResult(ctx, methodRename, &RenameRequest{
+		DirAt:   dirAtHandle,
+		Path:    path,
+		NewPath: newPath,
+	})
+}
+
+func (stor *Storage) Link(
+	ctx context.Context,
+	dirAt file.Object,
+	path, destination file.Path,
+) error {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return err
+	}
+	return stor.invokeNoResult(ctx, methodLink, &LinkRequest{
+		DirAt:       dirAtHandle,
+		Path:        path,
+		Destination: destination,
+	})
+}
+
+func (stor *Storage) Chmod(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	mode os.FileMode,
+) error {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return err
+	}
+	return stor.invokeNoResult(ctx, methodChmod, &ChmodRequest{
+		DirAt: dirAtHandle,
+		Path:  path,
+		Mode:  uint32(mode),
+	})
+}
+
+func (stor *Storage) Chown(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	uid, gid int,
+	noFollow bool,
+) error {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return err
+	}
+	return stor.invokeNoResult(ctx, methodChown, &ChownRequest{
+		DirAt:    dirAtHandle,
+		Path:     path,
+		UID:      uid,
+		GID:      gid,
+		NoFollow: noFollow,
+	})
+}
+
+func (stor *Storage) Chtimes(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	atime time.Time,
+	mtime time.Time,
+) error {
+	dirAtHandle, err := stor.handleOf(dirAt)
+	if err != nil {
+		return err
+	}
+	return stor.invokeNoResult(ctx, methodChtimes, &ChtimesRequest{
+		DirAt: dirAtHandle,
+		Path:  path,
+		Atime: atime,
+		Mtime: mtime,
+	})
+}
+
+// invokeNoResult calls a method which returns only an error.
+func (stor *Storage) invokeNoResult(ctx context.Context, method string, req interface{}) error {
+	var resp ErrorResponse
+	if err := stor.invoke(ctx, method, req, &resp); err != nil {
+		return err
+	}
+	return resp.Error.Err()
+}
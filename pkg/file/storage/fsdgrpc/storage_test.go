@@ -0,0 +1,172 @@
+package fsdgrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/memfs"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newTestStorage returns a client Storage of a Server exposing
+// the memfs storage.
+func newTestStorage(t *testing.T, backend file.Storage, opts ...Option) (*Storage, *Server) {
+	srv, addr := newTestServer(t, backend, opts...)
+	stor, err := NewStorage(dialTestServer(t, addr), opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, stor.Close()) })
+	return stor, srv
+}
+
+// newTestServer starts a Server of the storage and returns its address.
+func newTestServer(t *testing.T, backend file.Storage, opts ...Option) (*Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := NewServer(backend, opts...)
+	grpcServer := grpc.NewServer()
+	srv.Register(grpcServer)
+	go func() { _ = grpcServer.Serve(listener) }()
+
+	t.Cleanup(func() {
+		assert.NoError(t, srv.Close())
+		grpcServer.Stop()
+	})
+	return srv, listener.Addr().String()
+}
+
+func dialTestServer(t *testing.T, addr string, opts ...grpc.DialOption) *grpc.ClientConn {
+	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(addr, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, conn.Close()) })
+	return conn
+}
+
+func TestStorageConformance(t *testing.T) {
+	// a small chunk size to test the streaming
+	stor, _ := newTestStorage(t, memfs.NewStorage(), OptionChunkSize{Size: 3})
+	storagetest.Run(t, stor)
+}
+
+func TestStorage(t *testing.T) {
+	backend := memfs.NewStorage()
+	stor, srv := newTestStorage(t, backend, OptionChunkSize{Size: 3})
+	ctx := context.Background()
+
+	t.Run("file", func(t *testing.T) {
+		obj, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagReadWrite|file.FlagCreate|file.FlagExcl, 0600)
+		require.NoError(t, err)
+		require.IsType(t, &File{}, obj)
+		require.False(t, obj.ID().IsZero())
+		f := obj.(*File)
+
+		n, err := f.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Equal(t, 11, n)
+		info, err := f.Stat()
+		require.NoError(t, err)
+		require.Equal(t, int64(11), info.Size())
+		require.Equal(t, "file", info.Name())
+		require.NoError(t, f.Sync())
+		require.NoError(t, f.Close())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := stor.Stat(ctx, nil, file.Path{"nothing"}, false)
+		require.True(t, file.IsNotExist(err), err)
+		var pathErr *os.PathError
+		require.True(t, errors.As(err, &pathErr), err)
+		require.Equal(t, syscall.ENOENT, pathErr.Err)
+
+		_, err = stor.Open(ctx, nil, file.Path{"nothing"}, file.FlagRead, 0000)
+		require.True(t, file.IsNotExist(err), err)
+
+		canceledCtx, cancelFn := context.WithCancel(ctx)
+		cancelFn()
+		_, err = stor.Stat(canceledCtx, nil, file.Path{"file"}, false)
+		require.Equal(t, file.ErrAborted{}, err)
+
+		// objects of other storages cannot be used as `dirAt`
+		foreignDir, err := backend.Open(ctx, nil, nil, file.FlagWalkDefaults, 0000)
+		require.NoError(t, err)
+		_, err = stor.Stat(ctx, foreignDir, file.Path{"file"}, false)
+		require.Equal(t, file.ErrNotImplemented{}, err)
+		require.NoError(t, foreignDir.Close())
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir", "a"}, 0750, true))
+		obj, err := stor.Open(ctx, nil, file.Path{"dir"}, file.FlagWalkDefaults, 0000)
+		require.NoError(t, err)
+		dir := obj.(*Directory)
+
+		// relative to the directory
+		storagetest.WriteFileAt(t, stor, dir, file.Path{"a", "c"}, "c")
+		require.Equal(t, "c", storagetest.ReadFile(t, stor, file.Path{"dir", "a", "c"}))
+		require.NoError(t, stor.Rename(ctx, dir, file.Path{"a", "c"}, file.Path{"d"}))
+		require.NoError(t, stor.Remove(ctx, dir, file.Path{"a"}, false))
+		require.NoError(t, dir.Close())
+
+		_, err = dir.Readdir(1)
+		require.True(t, errors.As(err, &ErrInvalidHandle{}), err)
+		require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir"}, true))
+	})
+
+	t.Run("path", func(t *testing.T) {
+		obj, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagPath, 0000)
+		require.NoError(t, err)
+		require.IsType(t, &PathDescriptor{}, obj)
+		require.NoError(t, obj.Close())
+	})
+
+	t.Run("hardlink", func(t *testing.T) {
+		require.NoError(t, stor.Link(ctx, nil, file.Path{"file"}, file.Path{"link"}))
+		storagetest.WriteFile(t, stor, file.Path{"link"}, "linked")
+		require.Equal(t, "linked", storagetest.ReadFile(t, stor, file.Path{"file"}))
+		require.NoError(t, stor.Remove(ctx, nil, file.Path{"link"}, false))
+	})
+
+	t.Run("attributes", func(t *testing.T) {
+		require.NoError(t, stor.Chown(ctx, nil, file.Path{"file"}, 1, -1, false))
+		backendInfo, err := backend.Stat(ctx, nil, file.Path{"file"}, false)
+		require.NoError(t, err)
+		require.Equal(t, 1, backendInfo.Sys().(*memfs.Stat).UID)
+
+		obj, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagRead, 0000)
+		require.NoError(t, err)
+		require.NoError(t, obj.(*File).Chmod(0600))
+		require.NoError(t, obj.Chown(2, 2))
+		require.NoError(t, obj.Close())
+		backendInfo, err = backend.Stat(ctx, nil, file.Path{"file"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), backendInfo.Mode())
+		require.Equal(t, 2, backendInfo.Sys().(*memfs.Stat).GID)
+	})
+
+	t.Run("session", func(t *testing.T) {
+		obj, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagRead, 0000)
+		require.NoError(t, err)
+
+		require.NoError(t, stor.Close())
+		require.Eventually(t, func() bool {
+			srv.locker.Lock()
+			defer srv.locker.Unlock()
+			return len(srv.sessions) == 0
+		}, time.Second, time.Millisecond)
+
+		// the objects are closed by the server
+		_, err = obj.Stat()
+		require.Error(t, err)
+	})
+}
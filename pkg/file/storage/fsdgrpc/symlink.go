@@ -0,0 +1,31 @@
+package fsdgrpc
+
+import (
+	"context"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.SymLink = &Symlink{}
+
+type Symlink struct {
+	Object
+}
+
+func (symlink *Symlink) Destination() (file.Path, error) {
+	stor := symlink.StorageValue
+	var resp ReadlinkResponse
+	err := stor.invoke(stor.ctx, methodDestination, &HandleRequest{Handle: symlink.handle}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error.Err(); err != nil {
+		return nil, err
+	}
+	return resp.Destination, nil
+}
+
+func (symlink *Symlink) Open(ctx context.Context, flags file.OpenFlag, defaultPerms os.FileMode) (file.Object, error) {
+	return symlink.open(ctx, flags, defaultPerms)
+}
@@ -0,0 +1,429 @@
+package fsdgrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watch is an event.Emitter of the server storage together with
+// the journal of its last events. The watch outlives the stream of
+// the client, so a reconnecting client receives the events it missed
+// (see ResumeToken).
+type watch struct {
+	id       string
+	identity string
+	server   *Server
+	emitter  event.Emitter
+	wg       sync.WaitGroup
+	ctx      context.Context
+	cancelFn context.CancelFunc
+
+	// locker protects the fields below
+	locker sync.Mutex
+
+	// journal are the last messages; journal[0] has the sequence
+	// number firstSeq
+	journal  []WatchMessage
+	firstSeq uint64
+
+	// signal is closed (and replaced) on each new message
+	signal chan struct{}
+
+	// streamCount is the amount of connected clients, and
+	// retentionTimer closes the watch if there are none for too long
+	streamCount    int
+	retentionTimer *time.Timer
+}
+
+// lastSeq returns the sequence number of the last message of the journal
+// (firstSeq-1 if the journal is empty).
+//
+// It is called with the locker held.
+func (w *watch) lastSeq() uint64 {
+	return w.firstSeq + uint64(len(w.journal)) - 1
+}
+
+func (w *watch) collectLoop() {
+	defer w.wg.Done()
+	defer w.server.forgetWatch(w)
+	defer w.cancelFn()
+	errChan := w.emitter.Errors()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case ev, ok := <-w.emitter.C():
+			if !ok {
+				return
+			}
+			ev, ok = w.visibleEvent(ev)
+			if !ok {
+				continue
+			}
+			record := event.NewRecord(ev)
+			w.append(WatchMessage{Event: &record})
+		case err, ok := <-errChan:
+			if !ok {
+				// the events channel is closed together with it
+				errChan = nil
+				continue
+			}
+			if !w.isErrorVisible(err) {
+				continue
+			}
+			w.append(WatchMessage{Error: newError(err)})
+		}
+	}
+}
+
+// append adds the message to the journal and wakes up the streams.
+// The oldest messages are dropped if the journal is full.
+func (w *watch) append(msg WatchMessage) {
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	msg.Seq = w.lastSeq() + 1
+	w.journal = append(w.journal, msg)
+	if len(w.journal) > w.server.watchJournalSize() {
+		w.journal[0] = WatchMessage{}
+		w.journal = w.journal[1:]
+		w.firstSeq++
+	}
+	close(w.signal)
+	w.signal = make(chan struct{})
+}
+
+// messagesSince returns the messages after the sequence number `seq`
+// and the channel to wait for more messages. isLost is true if some of
+// the requested messages are dropped from the journal already.
+func (w *watch) messagesSince(seq uint64) (msgs []WatchMessage, signal <-chan struct{}, isLost bool) {
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	if seq+1 < w.firstSeq {
+		isLost = true
+		seq = w.firstSeq - 1
+	}
+	if seq < w.lastSeq() {
+		msgs = append(msgs, w.journal[seq+1-w.firstSeq:]...)
+	}
+	return msgs, w.signal, isLost
+}
+
+// attach is called when a client connects to the watch.
+func (w *watch) attach() {
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	w.streamCount++
+	if w.retentionTimer != nil {
+		w.retentionTimer.Stop()
+		w.retentionTimer = nil
+	}
+}
+
+// detach is called when a client disconnects. The watch is closed if
+// no client reconnects within WatchRetention.
+func (w *watch) detach() {
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	w.streamCount--
+	if w.streamCount > 0 {
+		return
+	}
+	w.retentionTimer = time.AfterFunc(w.server.watchRetention(), func() {
+		w.locker.Lock()
+		isIdle := w.streamCount == 0
+		w.locker.Unlock()
+		if isIdle {
+			w.server.closeWatch(w.id)
+		}
+	})
+}
+
+func (w *watch) close() {
+	w.locker.Lock()
+	if w.retentionTimer != nil {
+		w.retentionTimer.Stop()
+	}
+	w.locker.Unlock()
+	_ = w.emitter.Close()
+	w.cancelFn()
+	w.wg.Wait()
+}
+
+// newWatch starts a watch on the storage of the server.
+func (srv *Server) newWatch(ctx context.Context, identity string, req *WatchRequest) (*watch, error) {
+	watcher, ok := srv.storage.(event.Watcher)
+	if !ok {
+		return nil, file.ErrNotImplemented{}
+	}
+	if _, err := srv.authorize(ctx, identity, methodWatch, nil, req.Path, AccessRead, true); err != nil {
+		return nil, err
+	}
+	watchID, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watch{
+		id:       watchID,
+		identity: identity,
+		server:   srv,
+		firstSeq: 1,
+		signal:   make(chan struct{}),
+	}
+	w.ctx, w.cancelFn = context.WithCancel(srv.ctx)
+
+	// the errors of the storage are reported to the client instead of
+	// being handled on the server
+	w.emitter, err = watcher.Watch(nil, req.Path,
+		req.WatchRules.ShouldWatchFunc(), req.WalkRules.ShouldWalkFunc(), w.reportError,
+		event.OptionTypeMask{Mask: watchTypeMask(req.TypeMask)})
+	if err != nil {
+		w.cancelFn()
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.collectLoop()
+
+	srv.locker.Lock()
+	srv.watches[watchID] = w
+	srv.locker.Unlock()
+	return w, nil
+}
+
+// reportError is the file.ErrorHandlerFunc of the watches: the errors
+// are sent to the client, which applies its own handler.
+func (w *watch) reportError(err error) error {
+	if w.isErrorVisible(err) {
+		w.append(WatchMessage{Error: newError(err)})
+	}
+	return nil
+}
+
+// isReadable returns true if the client of the watch has read access
+// to the path. The watched subtree may contain paths the client has
+// no access to, so the events and errors about them are not sent.
+func (w *watch) isReadable(path file.Path) bool {
+	acl := w.server.ACL
+	return acl == nil || acl.accessOf(w.identity, path) >= AccessRead
+}
+
+// visibleEvent returns the event as it may be sent to the client, or
+// false if the client should not see it at all.
+func (w *watch) visibleEvent(ev event.Event) (event.Event, bool) {
+	if ev.TypeMask.Has(event.TypeOverflow) {
+		// the client should rescan anyway, but not learn the path
+		if !w.isReadable(ev.Path) {
+			ev.Path = nil
+		}
+		return ev, true
+	}
+	if !w.isReadable(ev.Path) {
+		return event.Event{}, false
+	}
+	if ev.MovedTo != nil && !w.isReadable(ev.MovedTo) {
+		return event.Event{}, false
+	}
+	return ev, true
+}
+
+// isErrorVisible returns false if the error is about a path the client
+// of the watch has no read access to.
+func (w *watch) isErrorVisible(err error) bool {
+	path, ok := errorPathOf(err)
+	return !ok || w.isReadable(path)
+}
+
+// errorPathOf returns the path of the object the error (returned by
+// the watch or the walk) is about.
+func errorPathOf(err error) (file.Path, bool) {
+	switch err := err.(type) {
+	case file.ErrWatch:
+		return err.Path, true
+	case *file.ErrWatch:
+		return err.Path, true
+	case file.ErrUnwatch:
+		return err.Path, true
+	case *file.ErrUnwatch:
+		return err.Path, true
+	case file.ErrWatchMark:
+		return err.Path, true
+	case file.ErrGetChildrenInfo:
+		return err.Dir.Path(), true
+	case file.ErrWalkCallback:
+		return err.Dir.Path().Append(err.Child.Name()), true
+	case file.ErrWalkOpen:
+		if err.Dir == nil {
+			break
+		}
+		return err.Dir.Path().Append(err.Child.Name()), true
+	case file.ErrWalkNotDir:
+		return err.Child.Path(), true
+	}
+	return nil, false
+}
+
+func watchTypeMask(typeMask event.TypeMask) event.TypeMask {
+	if typeMask == 0 {
+		return event.DefaultWatchTypeMask
+	}
+	return typeMask
+}
+
+func (srv *Server) watchByID(watchID string) *watch {
+	srv.locker.Lock()
+	defer srv.locker.Unlock()
+	return srv.watches[watchID]
+}
+
+// watchOf returns the watch of the client (nil if it is not found).
+func (srv *Server) watchOf(ctx context.Context, method string, watchID string) (*watch, error) {
+	identity, err := srv.identityOf(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	w := srv.watchByID(watchID)
+	if w != nil && w.identity != identity {
+		srv.deny(ctx, identity, method, nil, "the watch belongs to another client")
+		return nil, nil
+	}
+	return w, nil
+}
+
+// forgetWatch removes the closed watch, so it cannot be resumed.
+func (srv *Server) forgetWatch(w *watch) {
+	srv.locker.Lock()
+	defer srv.locker.Unlock()
+	if srv.watches[w.id] == w {
+		delete(srv.watches, w.id)
+	}
+}
+
+func (srv *Server) closeWatch(watchID string) bool {
+	srv.locker.Lock()
+	w := srv.watches[watchID]
+	delete(srv.watches, watchID)
+	srv.locker.Unlock()
+	if w == nil {
+		return false
+	}
+	w.close()
+	return true
+}
+
+// watchStream streams the events of a watch. If the request has
+// a resume token then the watch is resumed from it; if it is not
+// possible then a new watch is started and the client is told that
+// the events are lost.
+func (srv *Server) watchStream(req *WatchRequest, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	identity, err := srv.identityOf(ctx, methodWatch)
+	if err != nil {
+		return err
+	}
+
+	var w *watch
+	seq := req.Token.Seq
+	isLost := false
+	if !req.Token.IsZero() {
+		if req.Token.ServerID == srv.id {
+			if w, err = srv.watchOf(ctx, methodWatch, req.Token.WatchID); err != nil {
+				return err
+			}
+		}
+		isLost = w == nil
+	}
+	if w == nil {
+		w, err = srv.newWatch(ctx, identity, req)
+		if err != nil {
+			return stream.SendMsg(&WatchMessage{Hello: &WatchHello{
+				ServerID: srv.id,
+				Error:    newError(err),
+			}})
+		}
+		seq = 0
+	}
+
+	w.attach()
+	defer w.detach()
+
+	msgs, signal, isJournalLost := w.messagesSince(seq)
+	err = stream.SendMsg(&WatchMessage{Hello: &WatchHello{
+		ServerID: srv.id,
+		WatchID:  w.id,
+		Lost:     isLost || isJournalLost,
+	}})
+	if err != nil {
+		return err
+	}
+
+	for {
+		for idx := range msgs {
+			if err := stream.SendMsg(&msgs[idx]); err != nil {
+				return err
+			}
+			seq = msgs[idx].Seq
+		}
+
+		select {
+		case <-signal:
+		case <-w.ctx.Done():
+			return status.Error(codes.Unavailable, "the watch is closed")
+		case <-ctx.Done():
+			return nil
+		}
+
+		msgs, signal, isJournalLost = w.messagesSince(seq)
+		if isJournalLost {
+			// the client is too slow; it should reconnect and rescan
+			return status.Error(codes.ResourceExhausted, "the journal of the watch is overflowed")
+		}
+	}
+}
+
+func (srv *Server) watchAdd(ctx context.Context, req *WatchAddRequest) (*ErrorResponse, error) {
+	w, err := srv.watchOf(ctx, methodWatchAdd, req.WatchID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, status.Errorf(codes.NotFound, "unknown watch '%s'", req.WatchID)
+	}
+	if _, err := srv.authorize(ctx, w.identity, methodWatchAdd, nil, req.Path, AccessRead, true); err != nil {
+		return &ErrorResponse{Error: newError(err)}, nil
+	}
+	err = w.emitter.Watch(nil, req.Path,
+		req.WatchRules.ShouldWatchFunc(), req.WalkRules.ShouldWalkFunc(), w.reportError,
+		event.OptionTypeMask{Mask: watchTypeMask(req.TypeMask)})
+	return &ErrorResponse{Error: newError(err)}, nil
+}
+
+func (srv *Server) unwatch(ctx context.Context, req *UnwatchRequest) (*ErrorResponse, error) {
+	w, err := srv.watchOf(ctx, methodUnwatch, req.WatchID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, status.Errorf(codes.NotFound, "unknown watch '%s'", req.WatchID)
+	}
+	err = w.emitter.Unwatch(req.Path, req.IsRecursive)
+	return &ErrorResponse{Error: newError(err)}, nil
+}
+
+func (srv *Server) watchClose(ctx context.Context, req *WatchCloseRequest) (*ErrorResponse, error) {
+	w, err := srv.watchOf(ctx, methodWatchClose, req.WatchID)
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		srv.closeWatch(w.id)
+	}
+	return &ErrorResponse{}, nil
+}
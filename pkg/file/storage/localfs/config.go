@@ -0,0 +1,22 @@
+package localfs
+
+const (
+	DefaultEventQueueSize = 1 << 16
+)
+
+type Config struct {
+	// EventQueueSize is the capacity of the channel of an EventEmitter.
+	// Zero means DefaultEventQueueSize.
+	EventQueueSize uint
+
+	// WatcherBackend selects the source of filesystem events.
+	WatcherBackend WatcherBackend
+}
+
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
@@ -2,12 +2,10 @@ package localfs
 
 import (
 	"context"
-	"fmt"
 	"os"
 	"sync"
 	"time"
 
-	"github.com/howeyc/fsnotify"
 	"github.com/my-network/fsutil/pkg/file"
 	"github.com/my-network/fsutil/pkg/file/event"
 )
@@ -18,16 +16,47 @@ type EventEmitter struct {
 	ctx       context.Context
 	cancelFn  context.CancelFunc
 	storage   *Storage
-	watcher   *fsnotify.Watcher
+	backend   watcherBackend
 	wg        sync.WaitGroup
 	eventChan chan event.Event
+	errChan   chan error
+
+	watchRequestsLocker sync.Mutex
+	watchRequests       []watchRequest
+
+	// overflow is the pending TypeOverflow event (if overflowPending).
+	// It is accessed only by the pipeline goroutine.
+	overflow        event.Event
+	overflowPending bool
+}
+
+// watchRequest is the arguments of a Watch call. They are reused
+// to watch directories created inside of the watched subtree.
+type watchRequest struct {
+	Path            file.Path
+	ShouldWatchFunc event.ShouldWatchFunc
+	ShouldWalkFunc  file.ShouldWalkFunc
+	ErrorHandler    file.ErrorHandlerFunc
+	Config          event.WatchConfig
 }
 
-func newEventEmitter(ctx context.Context, storage *Storage, watcher *fsnotify.Watcher) *EventEmitter {
+// backendTypeMask returns the types of events to subscribe to in
+// the backend. Creates and moves are always required to watch new
+// directories and to pair moves.
+func (req watchRequest) backendTypeMask() event.TypeMask {
+	return req.Config.TypeMask | event.TypeCreate | event.TypeMove
+}
+
+func newEventEmitter(ctx context.Context, storage *Storage, backend watcherBackend) *EventEmitter {
+	queueSize := storage.EventQueueSize
+	if queueSize == 0 {
+		queueSize = DefaultEventQueueSize
+	}
 	evEmitter := &EventEmitter{
 		storage:   storage,
-		watcher:   watcher,
-		eventChan: make(chan event.Event, 1<<16),
+		backend:   backend,
+		eventChan: make(chan event.Event, queueSize),
+		errChan:   make(chan error, event.ErrorQueueSize),
 	}
 	evEmitter.ctx, evEmitter.cancelFn = context.WithCancel(ctx)
 	evEmitter.initPipeline()
@@ -39,6 +68,7 @@ func (evEmitter *EventEmitter) initPipeline() {
 	go func() {
 		defer func() {
 			close(evEmitter.eventChan)
+			close(evEmitter.errChan)
 			evEmitter.wg.Done()
 		}()
 		evEmitter.pipelineLoop()
@@ -46,45 +76,305 @@ func (evEmitter *EventEmitter) initPipeline() {
 }
 
 func (evEmitter *EventEmitter) pipelineLoop() {
+	defer func() { _ = evEmitter.backend.Close() }()
+	errChan := evEmitter.backend.Errors()
 	for {
-		select {
-		case ev := <-evEmitter.watcher.Event:
-			now := time.Now()
+		// the overflow event is delivered as soon as the consumer
+		// frees some space in the queue
+		var overflowChan chan event.Event
+		if evEmitter.overflowPending {
+			overflowChan = evEmitter.eventChan
+		}
 
-			var evTypeMask event.TypeMask
-			if ev.IsCreate() {
-				evTypeMask |= event.TypeCreate
-			}
-			if ev.IsModify() {
-				evTypeMask |= event.TypeWrite | event.TypeOpenWrite | event.TypeCloseWrite
+		select {
+		case ev, ok := <-evEmitter.backend.Events():
+			if !ok {
+				return
 			}
-			if ev.IsDelete() {
-				evTypeMask |= event.TypeDelete
+			convertedEvent := evEmitter.convertEvent(ev)
+			if filteredEvent, ok := evEmitter.filter(convertedEvent); ok && !evEmitter.emit(filteredEvent) {
+				return
 			}
-			if ev.IsRename() {
-				evTypeMask |= event.TypeMove
+			evEmitter.forgetDeleted(convertedEvent)
+			if !evEmitter.backend.IsRecursive() && !evEmitter.watchNewDirectory(convertedEvent) {
+				return
 			}
-			if ev.IsAttrib() {
-				evTypeMask |= event.TypeAttrib
+		case overflowChan <- evEmitter.overflow:
+			evEmitter.overflowPending = false
+			evEmitter.overflow = event.Event{}
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
 			}
+			evEmitter.sendError(ErrWatcherBackend{Err: err})
+		case <-evEmitter.ctx.Done():
+			return
+		}
+	}
+}
+
+// filter applies the WatchConfig of the Watch call which covers the path
+// of the event.
+func (evEmitter *EventEmitter) filter(ev event.Event) (event.Event, bool) {
+	req, ok := evEmitter.watchRequestFor(ev.Path)
+	if !ok && ev.MovedTo != nil {
+		req, ok = evEmitter.watchRequestFor(ev.MovedTo)
+	}
+	if !ok {
+		return ev, true
+	}
+	return req.Config.Filter(ev)
+}
+
+// emit sends the event to the consumer.
+//
+// It never blocks: if the queue is full (or there is an undelivered
+// TypeOverflow event already) then the event is dropped and folded into
+// the pending TypeOverflow event instead. The path of the overflow event
+// is the narrowest directory which contains all the dropped events.
+//
+// Returns false if the emitter is closed.
+func (evEmitter *EventEmitter) emit(ev event.Event) bool {
+	if evEmitter.ctx.Err() != nil {
+		return false
+	}
+
+	if ev.TypeMask.Has(event.TypeOverflow) {
+		evEmitter.addOverflow(ev.Path, ev.Timestamp)
+		return true
+	}
+
+	if !evEmitter.overflowPending {
+		select {
+		case evEmitter.eventChan <- ev:
+			return true
+		default:
+		}
+	}
+
+	// the parent directory has to be rescanned to find out what
+	// happened to the object
+	evEmitter.addOverflow(ev.Path.Up(), ev.Timestamp)
+	if ev.MovedTo != nil {
+		evEmitter.addOverflow(ev.MovedTo.Up(), ev.Timestamp)
+	}
+	return true
+}
+
+// emitFiltered is the same as emit, but the event is filtered by
+// the WatchConfig of the request first.
+func (evEmitter *EventEmitter) emitFiltered(req watchRequest, ev event.Event) bool {
+	ev, ok := req.Config.Filter(ev)
+	if !ok {
+		return true
+	}
+	return evEmitter.emit(ev)
+}
+
+// addOverflow extends the pending TypeOverflow event to cover `path`.
+func (evEmitter *EventEmitter) addOverflow(path file.Path, ts time.Time) {
+	if !evEmitter.overflowPending {
+		evEmitter.overflowPending = true
+		evEmitter.overflow = event.Event{
+			Path:      path,
+			TypeMask:  event.TypeOverflow,
+			Timestamp: ts,
+		}
+		return
+	}
+	evEmitter.overflow.Path = evEmitter.overflow.Path.CommonPrefix(path)
+}
+
+// watchedScope returns the narrowest path which contains all
+// the watched directories.
+func (evEmitter *EventEmitter) watchedScope() file.Path {
+	evEmitter.watchRequestsLocker.Lock()
+	defer evEmitter.watchRequestsLocker.Unlock()
+
+	if len(evEmitter.watchRequests) == 0 {
+		return file.Path{}
+	}
+	result := evEmitter.watchRequests[0].Path
+	for _, req := range evEmitter.watchRequests[1:] {
+		result = result.CommonPrefix(req.Path)
+	}
+	return result
+}
+
+// watchNewDirectory starts watching a directory created (or moved in)
+// by the event. The directory is scanned and synthetic TypeCreate events
+// are emitted for entries created before the watch was added.
+//
+// The functions of the Watch call which covers the directory are reused:
+// the directory is watched if ShouldWatchFunc approves it and is scanned
+// recursively if ShouldWalkFunc approves it.
+//
+// Returns false if the emitter is closed.
+func (evEmitter *EventEmitter) watchNewDirectory(ev event.Event) bool {
+	path := ev.Path
+	switch {
+	case ev.MovedTo != nil:
+		path = ev.MovedTo
+	case !ev.TypeMask.Any(event.TypeCreate | event.TypeMoveTo):
+		return true
+	}
+	if len(path) == 0 {
+		return true
+	}
+
+	req, ok := evEmitter.watchRequestFor(path)
+	if !ok {
+		return true
+	}
 
-			evEmitter.eventChan <- &event.Event{
-				ObjID:     nil,
-				Path:      localToPath(ev.Name).RelativeTo(evEmitter.storage.workDir),
-				TypeMask:  evTypeMask,
-				Timestamp: now,
-				Range:     nil,
-				MovedTo:   nil,
+	info, err := evEmitter.storage.Stat(evEmitter.ctx, nil, path, true)
+	if err != nil {
+		// already removed or is not accessible; there is nothing to watch
+		return true
+	}
+	if !info.IsDir() {
+		return true
+	}
+
+	parentObj, err := evEmitter.storage.Open(evEmitter.ctx, nil, path.Up(), file.FlagWalkDefaults, 0000)
+	if err != nil {
+		return true
+	}
+	defer func() { _ = parentObj.Close() }()
+	parent, ok := parentObj.(file.Directory)
+	if !ok {
+		return true
+	}
+
+	if req.ShouldWatchFunc != nil && !req.ShouldWatchFunc(parent, info) {
+		return true
+	}
+	if req.ShouldWalkFunc != nil && !req.ShouldWalkFunc(parent, info) {
+		if err := evEmitter.backend.Watch(evEmitter.storage.ToLocalPath(path), req.backendTypeMask()); err != nil {
+			if err := req.ErrorHandler(file.ErrWatchMark{Path: path, Err: err}); err != nil {
+				evEmitter.sendError(err)
 			}
-		case <-evEmitter.ctx.Done():
 		}
+		return true
+	}
+
+	err = evEmitter.watch(path, req, true)
+	if evEmitter.ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		evEmitter.sendError(err)
+	}
+	return true
+}
+
+// sendError delivers the error to the consumer (if there is room for it
+// in the queue). It is called only by the pipeline goroutine.
+func (evEmitter *EventEmitter) sendError(err error) {
+	select {
+	case evEmitter.errChan <- err:
+	default:
+	}
+}
+
+// watchRequestFor returns the request of the innermost watched subtree
+// which contains the path.
+func (evEmitter *EventEmitter) watchRequestFor(path file.Path) (watchRequest, bool) {
+	evEmitter.watchRequestsLocker.Lock()
+	defer evEmitter.watchRequestsLocker.Unlock()
+
+	var (
+		result watchRequest
+		found  bool
+	)
+	for _, req := range evEmitter.watchRequests {
+		if !path.HasPrefix(req.Path) {
+			continue
+		}
+		if found && len(req.Path) < len(result.Path) {
+			continue
+		}
+		result, found = req, true
+	}
+	return result, found
+}
+
+// forgetDeleted forgets the Watch calls on the directories deleted,
+// unmounted or moved away by the event (and inside of them), so
+// the backend does not keep watching them.
+func (evEmitter *EventEmitter) forgetDeleted(ev event.Event) {
+	if !ev.TypeMask.Any(event.TypeDelete|event.TypeDeleteSelf|event.TypeUnmount|event.TypeMoveFrom) ||
+		ev.TypeMask.Has(event.TypeOverflow) {
+		return
+	}
+	if evEmitter.forgetWatchRequests(ev.Path) {
+		_ = evEmitter.backend.Unwatch(evEmitter.storage.ToLocalPath(ev.Path), true)
 	}
 }
 
+// forgetWatchRequests removes the requests of the Watch calls on
+// the directory and inside of it.
+//
+// Returns true if any request is removed.
+func (evEmitter *EventEmitter) forgetWatchRequests(path file.Path) bool {
+	evEmitter.watchRequestsLocker.Lock()
+	defer evEmitter.watchRequestsLocker.Unlock()
+
+	watchRequests := evEmitter.watchRequests[:0]
+	for _, req := range evEmitter.watchRequests {
+		if req.Path.HasPrefix(path) {
+			continue
+		}
+		watchRequests = append(watchRequests, req)
+	}
+	isChanged := len(watchRequests) != len(evEmitter.watchRequests)
+	evEmitter.watchRequests = watchRequests
+	return isChanged
+}
+
+func (evEmitter *EventEmitter) convertEvent(ev backendEvent) event.Event {
+	result := event.Event{
+		TypeMask:  ev.TypeMask,
+		Timestamp: ev.Timestamp,
+		Range:     nil,
+	}
+	if ev.Path != "" {
+		result.Path = evEmitter.storage.fromLocalPath(ev.Path)
+	} else if ev.TypeMask.Has(event.TypeOverflow) {
+		// the OS does not know which events were lost
+		result.Path = evEmitter.watchedScope()
+	}
+	if ev.MovedTo != "" {
+		result.MovedTo = evEmitter.storage.fromLocalPath(ev.MovedTo)
+	}
+
+	// the object is looked up after the fact, so the ID is unknown if
+	// the object is gone already (or has been replaced, which is
+	// reported by a later event anyway)
+	objPath := ev.Path
+	if ev.MovedTo != "" {
+		objPath = ev.MovedTo
+	}
+	if objPath != "" && !ev.TypeMask.Any(event.TypeDelete|event.TypeDeleteSelf|event.TypeOverflow) {
+		if id, err := objectIDOfPath(objPath); err == nil {
+			result.ObjID = id
+		}
+	}
+	return result
+}
+
 func (evEmitter *EventEmitter) C() <-chan event.Event {
 	return evEmitter.eventChan
 }
 
+// Errors returns the errors of the watcher backend (ErrWatcherBackend)
+// and the errors of watching new directories (such as file.ErrWatchMark)
+// which are not handled by the ErrorHandlerFunc of the Watch call.
+func (evEmitter *EventEmitter) Errors() <-chan error {
+	return evEmitter.errChan
+}
+
 func (evEmitter *EventEmitter) Close() error {
 	evEmitter.cancelFn()
 	evEmitter.wg.Wait()
@@ -97,6 +387,7 @@ func (evEmitter *EventEmitter) Watch(
 	shouldWatchFunc event.ShouldWatchFunc,
 	shouldWalkFunc file.ShouldWalkFunc,
 	errorHandler file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
 ) error {
 	if errorHandler == nil {
 		errorHandler = dummyErrorHandler
@@ -106,40 +397,105 @@ func (evEmitter *EventEmitter) Watch(
 		return file.ErrNotImplemented{}
 	}
 
-	err := file.Walk(
+	req := watchRequest{
+		Path:            path,
+		ShouldWatchFunc: shouldWatchFunc,
+		ShouldWalkFunc:  shouldWalkFunc,
+		ErrorHandler:    errorHandler,
+		Config:          *event.NewWatchConfig(opts...),
+	}
+
+	var err error
+	if evEmitter.backend.IsRecursive() {
+		// a single mark covers the whole subtree, so shouldWatchFunc and
+		// shouldWalkFunc are not consulted
+		err = evEmitter.backend.Watch(evEmitter.storage.ToLocalPath(path), req.backendTypeMask())
+	} else {
+		err = evEmitter.watch(path, req, false)
+	}
+	if err != nil {
+		return &file.ErrWatch{
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	evEmitter.watchRequestsLocker.Lock()
+	evEmitter.watchRequests = append(evEmitter.watchRequests, req)
+	evEmitter.watchRequestsLocker.Unlock()
+	return nil
+}
+
+// Unwatch stops watching the directory. If recursive is true then
+// the Watch calls on the directory and inside of it are forgotten too,
+// so new subdirectories are not watched anymore.
+func (evEmitter *EventEmitter) Unwatch(path file.Path, recursive bool) error {
+	if recursive {
+		evEmitter.forgetWatchRequests(path)
+	}
+	if err := evEmitter.backend.Unwatch(evEmitter.storage.ToLocalPath(path), recursive); err != nil {
+		return &file.ErrUnwatch{
+			Path: path,
+			Err:  err,
+		}
+	}
+	return nil
+}
+
+// watch walks through the subtree and adds watches on directories.
+//
+// If emitCreates is true then a TypeCreate event is emitted for each
+// found entry (except the root of the subtree), see
+// syntheticCreateTypeMask.
+func (evEmitter *EventEmitter) watch(
+	path file.Path,
+	req watchRequest,
+	emitCreates bool,
+) error {
+	return file.Walk(
 		evEmitter.ctx,
 		evEmitter.storage,
 		nil,
 		path,
 		func(dir file.Directory, objectInfo os.FileInfo) error {
-			fmt.Printf("MARK? %v %v %v\n", dir.Path().LocalPath(), objectInfo.Name(), objectInfo.IsDir())
+			pathFull := dir.Path()
+			if objectInfo.Name() != "." {
+				pathFull = pathFull.Append(objectInfo.Name())
+				if emitCreates && !evEmitter.emitFiltered(req, event.Event{
+					ObjID:     objectIDAt(dir, objectInfo),
+					Path:      pathFull,
+					TypeMask:  syntheticCreateTypeMask(objectInfo),
+					Timestamp: time.Now(),
+				}) {
+					return file.ErrAborted{}
+				}
+			}
 			if !objectInfo.IsDir() {
 				return nil
 			}
-			if shouldWatchFunc != nil && !shouldWatchFunc(dir, objectInfo) {
+			if req.ShouldWatchFunc != nil && !req.ShouldWatchFunc(dir, objectInfo) {
 				return nil
 			}
-			pathFull := dir.Path().Append(objectInfo.Name())
 			pathFullLocal := dir.Storage().ToLocalPath(pathFull)
-			err := evEmitter.watcher.Watch(pathFullLocal)
-			fmt.Printf("MARK %v -> %v\n", pathFullLocal, err)
+			err := evEmitter.backend.Watch(pathFullLocal, req.backendTypeMask())
 			if err != nil {
-				if err := errorHandler(file.ErrWatchMark{Path: pathFull, Err: err}); err != nil {
+				if err := req.ErrorHandler(file.ErrWatchMark{Path: pathFull, Err: err}); err != nil {
 					return err
 				}
 			}
 			return nil
 		},
-		shouldWalkFunc,
-		errorHandler,
+		req.ShouldWalkFunc,
+		req.ErrorHandler,
 	)
+}
 
-	if err != nil {
-		return &file.ErrWatch{
-			Path: path,
-			Err:  err,
-		}
+// syntheticCreateTypeMask returns the types of the event emitted for
+// an entry found in a new directory. A regular file could be written
+// before the directory was watched, so it is reported as written too.
+func syntheticCreateTypeMask(info os.FileInfo) event.TypeMask {
+	if info.Mode().IsRegular() {
+		return event.TypeCreate | event.TypeCloseWrite
 	}
-
-	return nil
+	return event.TypeCreate
 }
@@ -0,0 +1,221 @@
+// +build test_integration,linux
+
+package localfs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventEmitterWatchNewDirectories(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_localfs")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+
+	stor := NewStorage(tmpDir, OptionWatcherBackend{Backend: WatcherBackendINotify})
+	defer func() { assert.NoError(t, stor.Close()) }()
+
+	shouldWatch := func(dir file.Directory, info os.FileInfo) bool {
+		return info.Name() != "ignored"
+	}
+	evEmitter, err := stor.Watch(nil, nil, shouldWatch, nil, nil)
+	require.NoError(t, err)
+
+	// waitFor returns false if there was no event on the path during the timeout
+	waitFor := func(path file.Path, timeout time.Duration) bool {
+		deadline := time.After(timeout)
+		for {
+			select {
+			case ev := <-evEmitter.C():
+				if ev.Path.LocalPath() == path.LocalPath() {
+					return true
+				}
+			case <-deadline:
+				return false
+			}
+		}
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "a", "b", "c"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "a", "b", "c", "f"), []byte("f"), 0600))
+	require.True(t, waitFor(file.Path{"a", "b", "c", "f"}, time.Second))
+
+	// "c" has to be watched already
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "a", "b", "c", "g"), []byte("g"), 0600))
+	require.True(t, waitFor(file.Path{"a", "b", "c", "g"}, time.Second))
+
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "ignored"), 0700))
+	require.True(t, waitFor(file.Path{"ignored"}, time.Second))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "ignored", "x"), []byte("x"), 0600))
+	require.False(t, waitFor(file.Path{"ignored", "x"}, 100*time.Millisecond))
+
+}
+
+func TestEventEmitterOverflow(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_localfs")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "a", "b"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "a", "c"), 0700))
+
+	stor := NewStorage(tmpDir,
+		OptionWatcherBackend{Backend: WatcherBackendINotify},
+		OptionEventQueueSize{Size: 1},
+	)
+	defer func() { assert.NoError(t, stor.Close()) }()
+
+	evEmitter, err := stor.Watch(nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	for _, path := range []file.Path{{"a", "b", "1"}, {"a", "b", "2"}, {"a", "c", "3"}} {
+		require.NoError(t, ioutil.WriteFile(stor.ToLocalPath(path), nil, 0600))
+	}
+	// let the emitter process the events while nobody reads them
+	time.Sleep(100 * time.Millisecond)
+
+	ev := <-evEmitter.C()
+	require.Equal(t, file.Path{"a", "b", "1"}, ev.Path)
+	ev = <-evEmitter.C()
+	require.Equal(t, event.TypeOverflow, ev.TypeMask)
+	require.Equal(t, file.Path{"a"}, ev.Path)
+}
+
+func TestEventEmitterTypeMask(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_localfs")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+
+	stor := NewStorage(tmpDir, OptionWatcherBackend{Backend: WatcherBackendINotify})
+	defer func() { assert.NoError(t, stor.Close()) }()
+
+	evEmitter, err := stor.Watch(nil, nil, nil, nil, nil,
+		event.OptionTypeMask{Mask: event.TypeCloseWrite | event.TypeMove})
+	require.NoError(t, err)
+
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "dir"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "dir", "a"), []byte("a"), 0600))
+
+	select {
+	case ev := <-evEmitter.C():
+		require.Equal(t, file.Path{"dir", "a"}, ev.Path)
+		require.Equal(t, event.TypeCloseWrite, ev.TypeMask)
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestEventEmitterObjID(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_localfs")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+
+	stor := NewStorage(tmpDir, OptionWatcherBackend{Backend: WatcherBackendINotify})
+	defer func() { assert.NoError(t, stor.Close()) }()
+
+	evEmitter, err := stor.Watch(nil, nil, nil, nil, nil,
+		event.OptionTypeMask{Mask: event.TypeCloseWrite | event.TypeMove})
+	require.NoError(t, err)
+
+	nextEvent := func() event.Event {
+		select {
+		case ev := <-evEmitter.C():
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+		panic("unreachable")
+	}
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "a"), []byte("a"), 0600))
+	evWrite := nextEvent()
+	require.Equal(t, file.Path{"a"}, evWrite.Path)
+	require.False(t, evWrite.ObjID.IsZero())
+
+	require.NoError(t, os.Rename(filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "b")))
+	evMove := nextEvent()
+	require.Equal(t, file.Path{"b"}, evMove.MovedTo)
+	require.Equal(t, evWrite.ObjID, evMove.ObjID)
+
+	obj, err := stor.Open(context.Background(), nil, file.Path{"b"}, file.FlagRead, 0)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, obj.Close()) }()
+	require.Equal(t, evWrite.ObjID, obj.ID())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "a"), []byte("a"), 0600))
+	evWrite = nextEvent()
+	require.Equal(t, file.Path{"a"}, evWrite.Path)
+	require.False(t, evWrite.ObjID.SameObject(evMove.ObjID))
+}
+
+func TestEventEmitterUnwatch(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_localfs")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+	outsideDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_localfs")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(outsideDir)) }()
+
+	stor := NewStorage(tmpDir, OptionWatcherBackend{Backend: WatcherBackendINotify})
+	defer func() { assert.NoError(t, stor.Close()) }()
+
+	evEmitter, err := stor.Watch(nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	backend := evEmitter.(*EventEmitter).backend.(*inotifyBackend)
+	watchCount := func() int {
+		backend.watchesLocker.Lock()
+		defer backend.watchesLocker.Unlock()
+		return len(backend.paths)
+	}
+
+	// waitFor returns false if there was no event on the path during the timeout
+	waitFor := func(path file.Path, timeout time.Duration) bool {
+		deadline := time.After(timeout)
+		for {
+			select {
+			case ev := <-evEmitter.C():
+				if ev.Path.LocalPath() == path.LocalPath() {
+					return true
+				}
+			case <-deadline:
+				return false
+			}
+		}
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, name, "sub"), 0700))
+		require.True(t, waitFor(file.Path{name, "sub"}, time.Second))
+	}
+	require.Equal(t, 7, watchCount())
+
+	t.Run("unwatch", func(t *testing.T) {
+		require.NoError(t, evEmitter.Unwatch(file.Path{"a"}, true))
+		require.Equal(t, 5, watchCount())
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "a", "sub", "x"), nil, 0600))
+		require.False(t, waitFor(file.Path{"a", "sub", "x"}, 100*time.Millisecond))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "b")))
+		require.True(t, waitFor(file.Path{"b"}, time.Second))
+		// the kernel removes the watches when the directories are released
+		require.Eventually(t, func() bool { return watchCount() == 3 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("move_away", func(t *testing.T) {
+		require.NoError(t, os.Rename(filepath.Join(tmpDir, "c"), filepath.Join(outsideDir, "c")))
+		require.True(t, waitFor(file.Path{"c"}, time.Second))
+		require.Equal(t, 1, watchCount())
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outsideDir, "c", "sub", "x"), nil, 0600))
+		require.False(t, waitFor(file.Path{"c", "sub", "x"}, 100*time.Millisecond))
+	})
+}
@@ -0,0 +1,33 @@
+// +build linux
+
+package localfs
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/sys/unix"
+)
+
+const atFDCWD = unix.AT_FDCWD
+
+// fileHandleAt returns the handle of the object at `path` relative
+// to the directory `dirFD` (see name_to_handle_at(2)) or an empty string
+// if the filesystem does not support handles. If isEmptyPath is true then
+// the handle of `dirFD` itself is returned.
+//
+// The result is the type of the handle (4 bytes, little endian)
+// followed by the handle itself.
+func fileHandleAt(dirFD int, path string, isEmptyPath bool) string {
+	var flags int
+	if isEmptyPath {
+		flags |= unix.AT_EMPTY_PATH
+	}
+	handle, _, err := unix.NameToHandleAt(dirFD, path, flags)
+	if err != nil {
+		return ""
+	}
+	b := make([]byte, 4+len(handle.Bytes()))
+	binary.LittleEndian.PutUint32(b, uint32(handle.Type()))
+	copy(b[4:], handle.Bytes())
+	return string(b)
+}
@@ -0,0 +1,11 @@
+// +build !linux
+
+package localfs
+
+const atFDCWD = -100
+
+// fileHandleAt always returns an empty string: file handles are
+// supported only on Linux.
+func fileHandleAt(dirFD int, path string, isEmptyPath bool) string {
+	return ""
+}
@@ -18,6 +18,9 @@ type Object struct {
 	StorageValue *Storage
 	LastInfo     os.FileInfo
 	LastPath     file.Path
+
+	// id is the cached result of ID()
+	id file.ObjectID
 }
 
 func (obj *Object) Name() string {
@@ -3,17 +3,66 @@
 package localfs
 
 import (
+	"os"
 	"syscall"
+
+	"github.com/my-network/fsutil/pkg/file"
 )
 
-func (obj *Object) ID() interface{} {
-	return obj.IDUNIX()
+// ID returns the (dev, ino) of the object and its handle (if
+// the filesystem supports handles).
+func (obj *Object) ID() file.ObjectID {
+	if !obj.id.IsZero() {
+		return obj.id
+	}
+	if obj.LastInfo == nil {
+		if _, err := obj.Stat(); err != nil {
+			return file.ObjectID{}
+		}
+	}
+	id := objectIDOf(obj.LastInfo)
+	id.Handle = fileHandleAt(int(obj.FD()), "", true)
+	obj.id = id
+	return id
 }
 
 func (obj *Object) IDUNIX() ObjectIDUNIX {
 	st := obj.LastInfo.Sys().(*syscall.Stat_t)
 	return ObjectIDUNIX{
-		Dev: st.Dev,
-		Ino: st.Ino,
+		Dev: uint64(st.Dev),
+		Ino: uint64(st.Ino),
+	}
+}
+
+// objectIDOf returns the (dev, ino) of the object. The handle
+// is not set.
+func objectIDOf(info os.FileInfo) file.ObjectID {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return file.ObjectID{}
+	}
+	return file.ObjectID{
+		Dev: uint64(st.Dev),
+		Ino: uint64(st.Ino),
+	}
+}
+
+// objectIDAt returns the ID of the object `name` in the directory
+// (the symlinks are not followed).
+func objectIDAt(dir file.Directory, info os.FileInfo) file.ObjectID {
+	id := objectIDOf(info)
+	id.Handle = fileHandleAt(int(dir.FD()), info.Name(), false)
+	return id
+}
+
+// objectIDOfPath returns the ID of the object at the local path
+// (the symlinks are not followed).
+func objectIDOfPath(localPath string) (file.ObjectID, error) {
+	info, err := os.Lstat(localPath)
+	if err != nil {
+		return file.ObjectID{}, err
 	}
+	id := objectIDOf(info)
+	id.Handle = fileHandleAt(atFDCWD, localPath, false)
+	return id, nil
 }
@@ -0,0 +1,21 @@
+package localfs
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionEventQueueSize struct {
+	Size uint
+}
+
+func (opt OptionEventQueueSize) apply(cfg *Config) {
+	cfg.EventQueueSize = opt.Size
+}
+
+type OptionWatcherBackend struct {
+	Backend WatcherBackend
+}
+
+func (opt OptionWatcherBackend) apply(cfg *Config) {
+	cfg.WatcherBackend = opt.Backend
+}
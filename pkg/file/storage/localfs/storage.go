@@ -2,29 +2,34 @@ package localfs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
 	"time"
 
-	"github.com/howeyc/fsnotify"
 	"github.com/my-network/fsutil/pkg/file"
 	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/my-network/fsutil/pkg/file/port"
 )
 
 var _ file.StorageWatchable = &Storage{}
 
 type Storage struct {
+	Config
 	ctx      context.Context
 	cancelFn context.CancelFunc
 	wg       sync.WaitGroup
 	workDir  file.Path
 }
 
-func NewStorage(workDir string) *Storage {
+func NewStorage(workDir string, opts ...Option) *Storage {
 	stor := &Storage{
 		workDir: file.ParseLocalPath(workDir),
 	}
+	for _, opt := range opts {
+		opt.apply(&stor.Config)
+	}
 	stor.ctx, stor.cancelFn = context.WithCancel(context.Background())
 	return stor
 }
@@ -41,16 +46,28 @@ func (stor *Storage) Watch(
 	shouldMarkFunc event.ShouldWatchFunc,
 	shouldWalkFunc file.ShouldWalkFunc,
 	errorHandlerFunc file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
 ) (event.Emitter, error) {
-	watcher, err := fsnotify.NewWatcher()
+	backend, err := newWatcherBackend(stor.WatcherBackend)
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize a watcher backend: %w", err)
 	}
 
-	evEmitter := newEventEmitter(stor.ctx, stor, watcher)
+	evEmitter := newEventEmitter(stor.ctx, stor, backend)
 
-	err = evEmitter.Watch(dirAt, path, shouldMarkFunc, shouldWalkFunc, errorHandlerFunc)
+	err = evEmitter.Watch(dirAt, path, shouldMarkFunc, shouldWalkFunc, errorHandlerFunc, opts...)
+	if err != nil && errors.As(err, &ErrFANotifyUnsupported{}) {
+		// for example the filesystem does not support file handles
+		_ = evEmitter.Close()
+		backend, err = newWatcherBackend(WatcherBackendAuto)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize a watcher backend: %w", err)
+		}
+		evEmitter = newEventEmitter(stor.ctx, stor, backend)
+		err = evEmitter.Watch(dirAt, path, shouldMarkFunc, shouldWalkFunc, errorHandlerFunc, opts...)
+	}
 	if err != nil {
+		_ = evEmitter.Close()
 		return nil, err
 	}
 
@@ -80,6 +97,12 @@ func (stor *Storage) ToLocalPath(path file.Path) string {
 	return stor.ToAbsPath(path).LocalPath()
 }
 
+// fromLocalPath converts a local path (as in the OS) into a path
+// relative to the working directory.
+func (stor *Storage) fromLocalPath(localPath string) file.Path {
+	return localToPath(localPath).RelativeTo(stor.workDir)
+}
+
 func (stor *Storage) ToLocalPathAt(dir file.Object, path file.Path) string {
 	if dir != nil {
 		return path.LocalPath()
@@ -130,6 +153,10 @@ func (stor *Storage) Open(
 		LastInfo:     fileInfo,
 		LastPath:     path,
 	}
+	if dirAt != nil {
+		// the path of the object is relative to the storage, not to dirAt
+		obj.LastPath = dirAt.Path().Append(path...)
+	}
 
 	switch fileInfo.Mode() & os.ModeType {
 	case os.ModeDir:
@@ -22,6 +22,15 @@ func localToPath(s string) file.Path {
 	return strings.Split(s, string(filepath.Separator))
 }
 
+// isLocalSubpath returns true if the local path is inside of
+// the directory `dir` (and is not `dir` itself).
+func isLocalSubpath(localPath, dir string) bool {
+	if dir == string(filepath.Separator) {
+		return localPath != dir && strings.HasPrefix(localPath, dir)
+	}
+	return strings.HasPrefix(localPath, dir+string(filepath.Separator))
+}
+
 func dummyErrorHandler(err error) error {
 	return err
 }
@@ -0,0 +1,119 @@
+package localfs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+// WatcherBackend selects the implementation used to receive filesystem
+// events from the OS.
+type WatcherBackend uint8
+
+const (
+	// WatcherBackendAuto is the native inotify backend on Linux and
+	// the fsnotify backend everywhere else.
+	WatcherBackendAuto = WatcherBackend(iota)
+
+	// WatcherBackendFSNotify is based on "github.com/howeyc/fsnotify".
+	// It is portable, but does not distinguish writes and closes and
+	// does not pair moves.
+	WatcherBackendFSNotify
+
+	// WatcherBackendINotify is the native inotify backend (Linux only).
+	WatcherBackendINotify
+
+	// WatcherBackendFANotify watches whole filesystems with fanotify
+	// (Linux 5.9+ and CAP_SYS_ADMIN are required). A single mark covers
+	// the whole watched subtree, so huge trees do not exhaust inotify
+	// watches. Falls back to WatcherBackendAuto if fanotify is
+	// not usable.
+	WatcherBackendFANotify
+)
+
+func (backendType WatcherBackend) String() string {
+	switch backendType {
+	case WatcherBackendAuto:
+		return "auto"
+	case WatcherBackendFSNotify:
+		return "fsnotify"
+	case WatcherBackendINotify:
+		return "inotify"
+	case WatcherBackendFANotify:
+		return "fanotify"
+	}
+	return fmt.Sprintf("unknown_%d", uint8(backendType))
+}
+
+// ParseWatcherBackend parses the output of WatcherBackend.String.
+func ParseWatcherBackend(s string) (WatcherBackend, error) {
+	for backendType := WatcherBackendAuto; backendType <= WatcherBackendFANotify; backendType++ {
+		if backendType.String() == s {
+			return backendType, nil
+		}
+	}
+	return WatcherBackendAuto, fmt.Errorf("unknown watcher backend: '%s'", s)
+}
+
+// backendEvent is an event reported by a watcherBackend.
+//
+// Paths are local (as in the OS).
+type backendEvent struct {
+	Path      string
+	TypeMask  event.TypeMask
+	Timestamp time.Time
+	MovedTo   string
+}
+
+// ErrWatcherBackend is an error of the source of filesystem events of
+// the OS (for example, a failed read of the queue of events).
+type ErrWatcherBackend struct {
+	Err error
+}
+
+func (err ErrWatcherBackend) Error() string {
+	return fmt.Sprintf("watcher backend error: %v", err.Err)
+}
+
+func (err ErrWatcherBackend) Unwrap() error {
+	return err.Err
+}
+
+// watcherBackend is a source of filesystem events of the OS.
+type watcherBackend interface {
+	// IsRecursive returns true if Watch covers the whole subtree of
+	// the directory (and not only the directory itself).
+	IsRecursive() bool
+
+	// Watch subscribes to events of the types from typeMask (at least) on
+	// the directory. The subscriptions of repeated calls are combined.
+	Watch(localPath string, typeMask event.TypeMask) error
+
+	// Unwatch removes the subscription on the directory (and on its
+	// subdirectories if recursive is true).
+	Unwatch(localPath string, recursive bool) error
+	Events() <-chan backendEvent
+	Errors() <-chan error
+	Close() error
+}
+
+func newWatcherBackend(backendType WatcherBackend) (watcherBackend, error) {
+	switch backendType {
+	case WatcherBackendAuto:
+		if backend, err := newINotifyBackend(); err == nil {
+			return backend, nil
+		}
+		return newFSNotifyBackend()
+	case WatcherBackendFSNotify:
+		return newFSNotifyBackend()
+	case WatcherBackendINotify:
+		return newINotifyBackend()
+	case WatcherBackendFANotify:
+		if backend, err := newFANotifyBackend(); err == nil {
+			return backend, nil
+		}
+		return newWatcherBackend(WatcherBackendAuto)
+	}
+	return nil, fmt.Errorf("unknown watcher backend: %v", backendType)
+}
@@ -0,0 +1,562 @@
+// +build linux
+
+package localfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/my-network/fsutil/pkg/file/event"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// fanotifyDirCacheSize limits the amount of remembered paths of
+	// directories (used to resolve events on already deleted directories).
+	fanotifyDirCacheSize = 1 << 16
+)
+
+var _ watcherBackend = &fanotifyBackend{}
+
+// fanotifyBackend watches whole filesystems using fanotify with
+// FAN_REPORT_DFID_NAME (Linux 5.9+, requires CAP_SYS_ADMIN).
+//
+// Events are reported only for paths inside of the watched directories
+// (except the ones excluded by Unwatch).
+type fanotifyBackend struct {
+	fd     int
+	file   *os.File
+	events chan backendEvent
+	errors chan error
+	closed chan struct{}
+	wg     sync.WaitGroup
+
+	locker    sync.Mutex
+	hasRename bool
+	roots     map[string]unix.Fsid // local path -> filesystem ID
+	excluded  map[string]bool      // local path -> is recursive
+	mountFDs  map[unix.Fsid]int
+	dirCache  map[string]string // file handle -> local path
+}
+
+// ErrFANotifyUnsupported means fanotify cannot be used to watch the path
+// (not permitted, too old kernel or not supported by the filesystem).
+type ErrFANotifyUnsupported struct {
+	Path string
+	Err  error
+}
+
+func (err ErrFANotifyUnsupported) Error() string {
+	return fmt.Sprintf("fanotify is not usable on '%s': %v", err.Path, err.Err)
+}
+
+func (err ErrFANotifyUnsupported) Unwrap() error {
+	return err.Err
+}
+
+func isFANotifyUnsupported(err error) bool {
+	for _, errno := range []unix.Errno{unix.EPERM, unix.EINVAL, unix.ENOSYS, unix.ENODEV, unix.EXDEV, unix.EOPNOTSUPP} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+func newFANotifyBackend() (watcherBackend, error) {
+	fd, err := unix.FanotifyInit(
+		unix.FAN_CLASS_NOTIF|unix.FAN_CLOEXEC|unix.FAN_NONBLOCK|unix.FAN_REPORT_DFID_NAME,
+		unix.O_RDONLY|unix.O_LARGEFILE|unix.O_CLOEXEC,
+	)
+	if err != nil {
+		err = os.NewSyscallError("fanotify_init", err)
+		if isFANotifyUnsupported(err) {
+			return nil, ErrFANotifyUnsupported{Err: err}
+		}
+		return nil, err
+	}
+
+	backend := &fanotifyBackend{
+		fd:        fd,
+		file:      os.NewFile(uintptr(fd), "fanotify"),
+		events:    make(chan backendEvent),
+		errors:    make(chan error, 1),
+		closed:    make(chan struct{}),
+		hasRename: true,
+		roots:     map[string]unix.Fsid{},
+		excluded:  map[string]bool{},
+		mountFDs:  map[unix.Fsid]int{},
+		dirCache:  map[string]string{},
+	}
+	backend.wg.Add(1)
+	go func() {
+		defer backend.wg.Done()
+		defer close(backend.events)
+		backend.readLoop()
+	}()
+	return backend, nil
+}
+
+// IsRecursive returns true: a watched directory is watched with
+// the whole subtree.
+func (backend *fanotifyBackend) IsRecursive() bool {
+	return true
+}
+
+func (backend *fanotifyBackend) Watch(localPath string, typeMask event.TypeMask) error {
+	localPath = filepath.Clean(localPath)
+
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(localPath, &statfs); err != nil {
+		return os.NewSyscallError("statfs", err)
+	}
+
+	backend.locker.Lock()
+	defer backend.locker.Unlock()
+
+	// the mask is added to the mask of the filesystem, so it is
+	// marked again even if it is already marked
+	err := backend.mark(localPath, fanotifyMaskOf(typeMask)|unix.FAN_ONDIR)
+	if err != nil {
+		if isFANotifyUnsupported(err) {
+			return ErrFANotifyUnsupported{Path: localPath, Err: err}
+		}
+		return err
+	}
+
+	if _, ok := backend.mountFDs[statfs.Fsid]; !ok {
+		mountFD, err := unix.Open(localPath, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return os.NewSyscallError("open", err)
+		}
+		backend.mountFDs[statfs.Fsid] = mountFD
+	}
+
+	backend.roots[localPath] = statfs.Fsid
+	backend.forgetExcluded(localPath)
+	return nil
+}
+
+// Unwatch excludes the directory from the watched subtrees. The mark
+// of a filesystem is removed when there are no watched directories on it.
+func (backend *fanotifyBackend) Unwatch(localPath string, recursive bool) error {
+	localPath = filepath.Clean(localPath)
+
+	backend.locker.Lock()
+	defer backend.locker.Unlock()
+
+	if recursive {
+		for root := range backend.roots {
+			if root == localPath || isLocalSubpath(root, localPath) {
+				delete(backend.roots, root)
+			}
+		}
+		backend.forgetExcluded(localPath)
+	}
+	if _, ok := backend.innermostRoot(localPath); ok {
+		backend.excluded[localPath] = recursive
+	}
+	return backend.releaseFilesystems()
+}
+
+// forgetExcluded removes the exclusions of the directory and its
+// subdirectories; it is called with locker held.
+func (backend *fanotifyBackend) forgetExcluded(localPath string) {
+	for dir := range backend.excluded {
+		if dir == localPath || isLocalSubpath(dir, localPath) {
+			delete(backend.excluded, dir)
+		}
+	}
+}
+
+// releaseFilesystems removes the marks of the filesystems which have
+// no watched directories anymore; it is called with locker held.
+func (backend *fanotifyBackend) releaseFilesystems() error {
+	var result error
+	for fsid, mountFD := range backend.mountFDs {
+		isUsed := false
+		for _, rootFSID := range backend.roots {
+			if rootFSID == fsid {
+				isUsed = true
+				break
+			}
+		}
+		if isUsed {
+			continue
+		}
+
+		mask := fanotifyMaskOf(event.TypeAll) | unix.FAN_ONDIR
+		if backend.hasRename {
+			mask |= unix.FAN_RENAME
+		}
+		err := unix.FanotifyMark(backend.fd, unix.FAN_MARK_REMOVE|unix.FAN_MARK_FILESYSTEM, mask, mountFD, "")
+		if err != nil && !errors.Is(err, unix.ENOENT) && result == nil {
+			// ENOENT means the filesystem is unmounted already
+			result = os.NewSyscallError("fanotify_mark", err)
+		}
+		_ = unix.Close(mountFD)
+		delete(backend.mountFDs, fsid)
+	}
+	return result
+}
+
+func (backend *fanotifyBackend) mark(localPath string, mask uint64) error {
+	flags := uint(unix.FAN_MARK_ADD | unix.FAN_MARK_FILESYSTEM)
+	if backend.hasRename && mask&(unix.FAN_MOVED_FROM|unix.FAN_MOVED_TO) != 0 {
+		err := unix.FanotifyMark(backend.fd, flags, mask|unix.FAN_RENAME, unix.AT_FDCWD, localPath)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, unix.EINVAL) {
+			return os.NewSyscallError("fanotify_mark", err)
+		}
+		// FAN_RENAME requires Linux 5.17+, so moves will not be paired
+		backend.hasRename = false
+	}
+	if err := unix.FanotifyMark(backend.fd, flags, mask, unix.AT_FDCWD, localPath); err != nil {
+		return os.NewSyscallError("fanotify_mark", err)
+	}
+	return nil
+}
+
+func (backend *fanotifyBackend) Events() <-chan backendEvent {
+	return backend.events
+}
+
+func (backend *fanotifyBackend) Errors() <-chan error {
+	return backend.errors
+}
+
+func (backend *fanotifyBackend) Close() error {
+	close(backend.closed)
+	err := backend.file.Close()
+	backend.wg.Wait()
+
+	backend.locker.Lock()
+	defer backend.locker.Unlock()
+	for _, mountFD := range backend.mountFDs {
+		_ = unix.Close(mountFD)
+	}
+	return err
+}
+
+func (backend *fanotifyBackend) readLoop() {
+	buf := make([]byte, 1<<16)
+	for {
+		n, err := backend.file.Read(buf)
+		switch {
+		case err == nil:
+		case errors.Is(err, os.ErrClosed):
+			return
+		default:
+			backend.sendError(os.NewSyscallError("read", err))
+			return
+		}
+
+		now := time.Now()
+		for offset := 0; offset+unix.FAN_EVENT_METADATA_LEN <= n; {
+			metadata := (*unix.FanotifyEventMetadata)(unsafe.Pointer(&buf[offset]))
+			if metadata.Vers != unix.FANOTIFY_METADATA_VERSION {
+				backend.sendError(fmt.Errorf("unsupported fanotify metadata version: %d", metadata.Vers))
+				return
+			}
+			if metadata.Event_len < unix.FAN_EVENT_METADATA_LEN || offset+int(metadata.Event_len) > n {
+				backend.sendError(errors.New("fanotify: truncated event"))
+				break
+			}
+			if metadata.Fd >= 0 {
+				// is not expected with FAN_REPORT_FID, but just in case
+				_ = unix.Close(int(metadata.Fd))
+			}
+			info := buf[offset+int(metadata.Metadata_len) : offset+int(metadata.Event_len)]
+			offset += int(metadata.Event_len)
+
+			if !backend.handle(metadata.Mask, info, now) {
+				return
+			}
+		}
+	}
+}
+
+// fanotifyFID is a parsed "struct fanotify_event_info_fid".
+type fanotifyFID struct {
+	InfoType uint8
+	FSID     unix.Fsid
+	Handle   unix.FileHandle
+	Name     string
+}
+
+func parseFANotifyInfo(info []byte) ([]fanotifyFID, error) {
+	var result []fanotifyFID
+	for len(info) >= 4 {
+		infoType := info[0]
+		infoLen := int(binary.LittleEndian.Uint16(info[2:4]))
+		if infoLen < 4 || infoLen > len(info) {
+			return nil, errors.New("fanotify: invalid info record length")
+		}
+		record := info[4:infoLen]
+		info = info[infoLen:]
+
+		switch infoType {
+		case unix.FAN_EVENT_INFO_TYPE_FID, unix.FAN_EVENT_INFO_TYPE_DFID,
+			unix.FAN_EVENT_INFO_TYPE_DFID_NAME,
+			unix.FAN_EVENT_INFO_TYPE_OLD_DFID_NAME, unix.FAN_EVENT_INFO_TYPE_NEW_DFID_NAME:
+		default:
+			continue
+		}
+
+		const fsidSize, handleHeaderSize = 8, 8
+		if len(record) < fsidSize+handleHeaderSize {
+			return nil, errors.New("fanotify: truncated fid record")
+		}
+		fid := fanotifyFID{InfoType: infoType}
+		fid.FSID = *(*unix.Fsid)(unsafe.Pointer(&record[0]))
+		handleBytes := int(*(*uint32)(unsafe.Pointer(&record[fsidSize])))
+		handleType := *(*int32)(unsafe.Pointer(&record[fsidSize+4]))
+		handleStart := fsidSize + handleHeaderSize
+		if handleStart+handleBytes > len(record) {
+			return nil, errors.New("fanotify: truncated file handle")
+		}
+		fid.Handle = unix.NewFileHandle(handleType, record[handleStart:handleStart+handleBytes])
+		if infoType != unix.FAN_EVENT_INFO_TYPE_FID && infoType != unix.FAN_EVENT_INFO_TYPE_DFID {
+			name := record[handleStart+handleBytes:]
+			if idx := bytes.IndexByte(name, 0); idx >= 0 {
+				name = name[:idx]
+			}
+			fid.Name = string(name)
+		}
+		result = append(result, fid)
+	}
+	return result, nil
+}
+
+// handle converts a raw fanotify event and sends it.
+//
+// Returns false if the backend is closed.
+func (backend *fanotifyBackend) handle(mask uint64, info []byte, now time.Time) bool {
+	if mask&unix.FAN_Q_OVERFLOW != 0 {
+		return backend.send(backendEvent{
+			TypeMask:  event.TypeOverflow,
+			Timestamp: now,
+		})
+	}
+
+	fids, err := parseFANotifyInfo(info)
+	if err != nil {
+		backend.sendError(err)
+		return true
+	}
+
+	ev := backendEvent{
+		TypeMask:  fanotifyTypeMask(mask),
+		Timestamp: now,
+	}
+	for _, fid := range fids {
+		localPath, err := backend.resolve(fid)
+		if err != nil {
+			backend.sendError(err)
+			return true
+		}
+		switch fid.InfoType {
+		case unix.FAN_EVENT_INFO_TYPE_NEW_DFID_NAME:
+			ev.MovedTo = localPath
+		default:
+			ev.Path = localPath
+		}
+	}
+
+	if mask&unix.FAN_RENAME != 0 {
+		switch {
+		case !backend.isWatched(ev.Path) && !backend.isWatched(ev.MovedTo):
+			return true
+		case !backend.isWatched(ev.MovedTo):
+			// moved out of the watched directories
+			return backend.send(backendEvent{Path: ev.Path, TypeMask: event.TypeDelete, Timestamp: now})
+		case !backend.isWatched(ev.Path):
+			// moved in from a not watched directory
+			return backend.send(backendEvent{Path: ev.MovedTo, TypeMask: event.TypeCreate, Timestamp: now})
+		}
+		ev.TypeMask = event.TypeMove
+		return backend.send(ev)
+	}
+
+	if mask&unix.FAN_ONDIR != 0 && ev.TypeMask.Any(event.TypeDelete|event.TypeDeleteSelf|event.TypeMoveFrom) {
+		// the exclusions are not inherited by a new directory
+		// with the same path
+		backend.locker.Lock()
+		backend.forgetExcluded(ev.Path)
+		backend.locker.Unlock()
+	}
+
+	if !backend.isWatched(ev.Path) {
+		return true
+	}
+	switch {
+	case backend.hasRename && ev.TypeMask.Any(event.TypeMove):
+		// reported by FAN_RENAME instead
+		return true
+	case ev.TypeMask.Has(event.TypeMoveFrom):
+		ev.TypeMask = ev.TypeMask&^event.TypeMoveFrom | event.TypeDelete
+	case ev.TypeMask.Has(event.TypeMoveTo):
+		ev.TypeMask = ev.TypeMask&^event.TypeMoveTo | event.TypeCreate
+	}
+	if ev.TypeMask == 0 {
+		return true
+	}
+	return backend.send(ev)
+}
+
+// resolve converts a file handle (and a name) into a local path.
+func (backend *fanotifyBackend) resolve(fid fanotifyFID) (string, error) {
+	backend.locker.Lock()
+	defer backend.locker.Unlock()
+
+	cacheKey := strconv.Itoa(int(fid.Handle.Type())) + ":" + string(fid.Handle.Bytes())
+	dirPath, err := backend.resolveHandle(fid)
+	if err != nil {
+		var ok bool
+		dirPath, ok = backend.dirCache[cacheKey]
+		if !ok {
+			return "", fmt.Errorf("unable to resolve a file handle: %w", err)
+		}
+	} else {
+		if len(backend.dirCache) >= fanotifyDirCacheSize {
+			backend.dirCache = map[string]string{}
+		}
+		backend.dirCache[cacheKey] = dirPath
+	}
+
+	if fid.Name == "" || fid.Name == "." {
+		return dirPath, nil
+	}
+	return filepath.Join(dirPath, fid.Name), nil
+}
+
+func (backend *fanotifyBackend) resolveHandle(fid fanotifyFID) (string, error) {
+	mountFD, ok := backend.mountFDs[fid.FSID]
+	if !ok {
+		return "", fmt.Errorf("unknown filesystem ID: %v", fid.FSID)
+	}
+
+	fd, err := unix.OpenByHandleAt(mountFD, fid.Handle, unix.O_PATH|unix.O_CLOEXEC)
+	if err != nil {
+		return "", os.NewSyscallError("open_by_handle_at", err)
+	}
+	defer func() { _ = unix.Close(fd) }()
+
+	localPath, err := os.Readlink("/proc/self/fd/" + strconv.Itoa(fd))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(localPath, " (deleted)"), nil
+}
+
+// isWatched returns true if the path is inside of a watched directory
+// and is not excluded by Unwatch.
+func (backend *fanotifyBackend) isWatched(localPath string) bool {
+	if localPath == "" {
+		return false
+	}
+
+	backend.locker.Lock()
+	defer backend.locker.Unlock()
+	root, ok := backend.innermostRoot(localPath)
+	if !ok {
+		return false
+	}
+	for dir, isRecursive := range backend.excluded {
+		if len(dir) < len(root) {
+			// overridden by a Watch inside of the excluded directory
+			continue
+		}
+		if isRecursive && isLocalSubpath(localPath, dir) {
+			return false
+		}
+		if !isRecursive && filepath.Dir(localPath) == dir {
+			return false
+		}
+	}
+	return true
+}
+
+// innermostRoot returns the innermost watched directory which contains
+// the path; it is called with locker held.
+func (backend *fanotifyBackend) innermostRoot(localPath string) (string, bool) {
+	var (
+		result string
+		found  bool
+	)
+	for root := range backend.roots {
+		if localPath != root && !isLocalSubpath(localPath, root) {
+			continue
+		}
+		if !found || len(root) > len(result) {
+			result, found = root, true
+		}
+	}
+	return result, found
+}
+
+func (backend *fanotifyBackend) send(ev backendEvent) bool {
+	select {
+	case backend.events <- ev:
+		return true
+	case <-backend.closed:
+		return false
+	}
+}
+
+func (backend *fanotifyBackend) sendError(err error) {
+	select {
+	case backend.errors <- err:
+	default:
+	}
+}
+
+var fanotifyTypeMasks = []struct {
+	fanotify uint64
+	typeMask event.TypeMask
+}{
+	{unix.FAN_ACCESS, event.TypeAccess},
+	{unix.FAN_ATTRIB, event.TypeAttrib},
+	{unix.FAN_MODIFY, event.TypeWrite},
+	{unix.FAN_OPEN, event.TypeOpen},
+	{unix.FAN_CLOSE_WRITE, event.TypeCloseWrite},
+	{unix.FAN_CLOSE_NOWRITE, event.TypeCloseNoWrite},
+	{unix.FAN_CREATE, event.TypeCreate},
+	{unix.FAN_DELETE, event.TypeDelete},
+	{unix.FAN_DELETE_SELF, event.TypeDeleteSelf},
+	{unix.FAN_MOVED_FROM, event.TypeMoveFrom},
+	{unix.FAN_MOVED_TO, event.TypeMoveTo},
+	{unix.FAN_MOVE_SELF, event.TypeMoveSelf},
+}
+
+func fanotifyTypeMask(mask uint64) event.TypeMask {
+	var result event.TypeMask
+	for _, pair := range fanotifyTypeMasks {
+		if mask&pair.fanotify != 0 {
+			result |= pair.typeMask
+		}
+	}
+	return result
+}
+
+// fanotifyMaskOf is the reverse of fanotifyTypeMask.
+func fanotifyMaskOf(typeMask event.TypeMask) uint64 {
+	var result uint64
+	for _, pair := range fanotifyTypeMasks {
+		if typeMask.Any(pair.typeMask) {
+			result |= pair.fanotify
+		}
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+// +build test_integration,linux
+
+package localfs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFANotifyBackend(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_localfs")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+	tmpDir, err = filepath.EvalSymlinks(tmpDir)
+	require.NoError(t, err)
+
+	watchedDir := filepath.Join(tmpDir, "watched")
+	require.NoError(t, os.MkdirAll(filepath.Join(watchedDir, "sub"), 0700))
+
+	backend, err := newFANotifyBackend()
+	if errors.As(err, &ErrFANotifyUnsupported{}) {
+		t.Skip(err)
+	}
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, backend.Close()) }()
+	err = backend.Watch(watchedDir, event.DefaultWatchTypeMask)
+	if errors.As(err, &ErrFANotifyUnsupported{}) {
+		t.Skip(err)
+	}
+	require.NoError(t, err)
+	require.True(t, backend.IsRecursive())
+
+	nextEvent := func() backendEvent {
+		select {
+		case ev := <-backend.Events():
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+		panic("unreachable")
+	}
+
+	t.Run("close_write_in_subdir", func(t *testing.T) {
+		localPath := filepath.Join(watchedDir, "sub", "a")
+		require.NoError(t, ioutil.WriteFile(localPath, []byte("a"), 0600))
+
+		// fanotify merges unread events on the same object
+		var typeMask event.TypeMask
+		for !typeMask.Has(event.TypeCloseWrite) {
+			ev := nextEvent()
+			require.Equal(t, localPath, ev.Path)
+			typeMask |= ev.TypeMask
+		}
+		require.True(t, typeMask.Has(event.TypeCreate|event.TypeWrite))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		require.NoError(t, os.Rename(filepath.Join(watchedDir, "sub", "a"), filepath.Join(watchedDir, "b")))
+		ev := nextEvent()
+		require.Equal(t, filepath.Join(watchedDir, "sub", "a"), ev.Path)
+		if backend.(*fanotifyBackend).hasRename {
+			require.Equal(t, event.TypeMove, ev.TypeMask)
+			require.Equal(t, filepath.Join(watchedDir, "b"), ev.MovedTo)
+		} else {
+			require.Equal(t, event.TypeDelete, ev.TypeMask)
+			require.Equal(t, event.TypeCreate, nextEvent().TypeMask)
+		}
+	})
+
+	t.Run("not_watched", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "x"), []byte("x"), 0600))
+		require.NoError(t, os.Remove(filepath.Join(watchedDir, "b")))
+		ev := nextEvent()
+		require.Equal(t, event.TypeDelete, ev.TypeMask)
+		require.Equal(t, filepath.Join(watchedDir, "b"), ev.Path)
+	})
+
+	t.Run("unwatch", func(t *testing.T) {
+		require.NoError(t, backend.Unwatch(filepath.Join(watchedDir, "sub"), true))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(watchedDir, "sub", "c"), nil, 0600))
+		require.NoError(t, os.Remove(filepath.Join(watchedDir, "sub", "c")))
+		require.NoError(t, os.Mkdir(filepath.Join(watchedDir, "d"), 0700))
+		ev := nextEvent()
+		require.Equal(t, filepath.Join(watchedDir, "d"), ev.Path)
+
+		require.NoError(t, backend.Unwatch(watchedDir, true))
+		require.Empty(t, backend.(*fanotifyBackend).mountFDs)
+	})
+}
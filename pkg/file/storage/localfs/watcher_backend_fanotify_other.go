@@ -0,0 +1,25 @@
+// +build !linux
+
+package localfs
+
+import (
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// ErrFANotifyUnsupported means fanotify cannot be used to watch the path.
+type ErrFANotifyUnsupported struct {
+	Path string
+	Err  error
+}
+
+func (err ErrFANotifyUnsupported) Error() string {
+	return "fanotify is supported only on Linux"
+}
+
+func (err ErrFANotifyUnsupported) Unwrap() error {
+	return err.Err
+}
+
+func newFANotifyBackend() (watcherBackend, error) {
+	return nil, ErrFANotifyUnsupported{Err: file.ErrNotImplemented{}}
+}
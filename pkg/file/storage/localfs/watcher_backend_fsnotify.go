@@ -0,0 +1,158 @@
+package localfs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/howeyc/fsnotify"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+var _ watcherBackend = &fsnotifyBackend{}
+
+type fsnotifyBackend struct {
+	watcher *fsnotify.Watcher
+	events  chan backendEvent
+	closed  chan struct{}
+	wg      sync.WaitGroup
+
+	flagsLocker sync.Mutex
+	flags       map[string]uint32 // local path -> fsnotify flags
+}
+
+func newFSNotifyBackend() (watcherBackend, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	backend := &fsnotifyBackend{
+		watcher: watcher,
+		events:  make(chan backendEvent),
+		closed:  make(chan struct{}),
+		flags:   map[string]uint32{},
+	}
+	backend.wg.Add(1)
+	go func() {
+		defer backend.wg.Done()
+		defer close(backend.events)
+		backend.loop()
+	}()
+	return backend, nil
+}
+
+func (backend *fsnotifyBackend) loop() {
+	for ev := range backend.watcher.Event {
+		now := time.Now()
+
+		var evTypeMask event.TypeMask
+		if ev.IsCreate() {
+			evTypeMask |= event.TypeCreate
+		}
+		if ev.IsModify() {
+			// fsnotify does not distinguish these
+			evTypeMask |= event.TypeWrite | event.TypeOpenWrite | event.TypeCloseWrite
+		}
+		if ev.IsDelete() {
+			evTypeMask |= event.TypeDelete
+		}
+		if ev.IsRename() {
+			evTypeMask |= event.TypeMoveFrom
+		}
+		if ev.IsAttrib() {
+			evTypeMask |= event.TypeAttrib
+		}
+
+		if evTypeMask.Any(event.TypeDelete|event.TypeMoveFrom) && backend.isWatched(ev.Name) {
+			// the kernel removes the watch of a deleted directory itself,
+			// and a moved directory is watched again by its new path
+			_ = backend.Unwatch(ev.Name, true)
+		}
+
+		select {
+		case backend.events <- backendEvent{
+			Path:      ev.Name,
+			TypeMask:  evTypeMask,
+			Timestamp: now,
+		}:
+		case <-backend.closed:
+			return
+		}
+	}
+}
+
+func (backend *fsnotifyBackend) IsRecursive() bool {
+	return false
+}
+
+func (backend *fsnotifyBackend) Watch(localPath string, typeMask event.TypeMask) error {
+	backend.flagsLocker.Lock()
+	defer backend.flagsLocker.Unlock()
+
+	flags := backend.flags[localPath] | fsnotifyFlagsOf(typeMask)
+	if err := backend.watcher.WatchFlags(localPath, flags); err != nil {
+		return err
+	}
+	backend.flags[localPath] = flags
+	return nil
+}
+
+func (backend *fsnotifyBackend) isWatched(localPath string) bool {
+	backend.flagsLocker.Lock()
+	defer backend.flagsLocker.Unlock()
+	_, ok := backend.flags[localPath]
+	return ok
+}
+
+func (backend *fsnotifyBackend) Unwatch(localPath string, recursive bool) error {
+	backend.flagsLocker.Lock()
+	defer backend.flagsLocker.Unlock()
+
+	var result error
+	for path := range backend.flags {
+		if path != localPath && !(recursive && isLocalSubpath(path, localPath)) {
+			continue
+		}
+		delete(backend.flags, path)
+		if err := backend.watcher.RemoveWatch(path); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// fsnotifyFlagsOf returns the flags of fsnotify which cover typeMask.
+//
+// fsnotify filters the events in the user space, so this does not
+// save any kernel resources.
+func fsnotifyFlagsOf(typeMask event.TypeMask) uint32 {
+	var result uint32
+	if typeMask.Any(event.TypeCreate) {
+		result |= fsnotify.FSN_CREATE
+	}
+	if typeMask.Any(event.TypeWrite | event.TypeOpenWrite | event.TypeCloseWrite | event.TypeAttrib) {
+		result |= fsnotify.FSN_MODIFY
+	}
+	if typeMask.Any(event.TypeDelete | event.TypeDeleteSelf) {
+		result |= fsnotify.FSN_DELETE
+	}
+	if typeMask.Any(event.TypeMove | event.TypeMoveSelf) {
+		result |= fsnotify.FSN_RENAME
+	}
+	return result
+}
+
+func (backend *fsnotifyBackend) Events() <-chan backendEvent {
+	return backend.events
+}
+
+func (backend *fsnotifyBackend) Errors() <-chan error {
+	return backend.watcher.Error
+}
+
+func (backend *fsnotifyBackend) Close() error {
+	close(backend.closed)
+	err := backend.watcher.Close()
+	backend.wg.Wait()
+	return err
+}
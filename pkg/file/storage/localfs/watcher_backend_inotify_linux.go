@@ -0,0 +1,359 @@
+// +build linux
+
+package localfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// moveCookieTimeout is how long to wait for IN_MOVED_TO after
+	// IN_MOVED_FROM if they were not delivered by the same read().
+	moveCookieTimeout = 10 * time.Millisecond
+)
+
+var _ watcherBackend = &inotifyBackend{}
+
+type inotifyBackend struct {
+	fd     int
+	file   *os.File
+	events chan backendEvent
+	errors chan error
+	closed chan struct{}
+	wg     sync.WaitGroup
+
+	watchesLocker sync.Mutex
+	watches       map[int32]string // watch descriptor -> local path
+	paths         map[string]int32 // local path -> watch descriptor
+
+	// pendingMove is an IN_MOVED_FROM event waiting for its IN_MOVED_TO
+	pendingMove       *backendEvent
+	pendingMoveCookie uint32
+	pendingMoveIsDir  bool
+}
+
+func newINotifyBackend() (watcherBackend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	backend := &inotifyBackend{
+		fd: fd,
+		// the descriptor is non-blocking, so os.File uses the runtime
+		// poller: Close() interrupts Read() and read deadlines work.
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		events:  make(chan backendEvent),
+		errors:  make(chan error, 1),
+		closed:  make(chan struct{}),
+		watches: map[int32]string{},
+		paths:   map[string]int32{},
+	}
+	backend.wg.Add(1)
+	go func() {
+		defer backend.wg.Done()
+		defer close(backend.events)
+		backend.readLoop()
+	}()
+	return backend, nil
+}
+
+func (backend *inotifyBackend) IsRecursive() bool {
+	return false
+}
+
+func (backend *inotifyBackend) Watch(localPath string, typeMask event.TypeMask) error {
+	mask := inotifyMaskOf(typeMask)
+	if mask == 0 {
+		// inotify_add_watch would fail with EINVAL
+		return fmt.Errorf("none of event types '%v' is supported by inotify: %w", typeMask, file.ErrNotImplemented{})
+	}
+	wd, err := unix.InotifyAddWatch(backend.fd, localPath, mask|unix.IN_MASK_ADD)
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	backend.watchesLocker.Lock()
+	defer backend.watchesLocker.Unlock()
+	if oldWD, ok := backend.paths[localPath]; ok && oldWD != int32(wd) {
+		// the directory was replaced
+		delete(backend.watches, oldWD)
+	}
+	if oldPath, ok := backend.watches[int32(wd)]; ok && oldPath != localPath {
+		// the same directory is reachable by another path (for example,
+		// it was moved by a not paired rename)
+		delete(backend.paths, oldPath)
+	}
+	backend.watches[int32(wd)] = localPath
+	backend.paths[localPath] = int32(wd)
+	return nil
+}
+
+func (backend *inotifyBackend) Unwatch(localPath string, recursive bool) error {
+	backend.watchesLocker.Lock()
+	defer backend.watchesLocker.Unlock()
+	return backend.unwatch(localPath, recursive)
+}
+
+// unwatch removes the watches; it is called with watchesLocker held.
+func (backend *inotifyBackend) unwatch(localPath string, recursive bool) error {
+	var result error
+	for path, wd := range backend.paths {
+		if path != localPath && !(recursive && isLocalSubpath(path, localPath)) {
+			continue
+		}
+		delete(backend.paths, path)
+		delete(backend.watches, wd)
+		// EINVAL means the watch is already removed by the kernel (the
+		// directory is deleted); IN_IGNORED for it is ignored
+		if _, err := unix.InotifyRmWatch(backend.fd, uint32(wd)); err != nil && err != unix.EINVAL && result == nil {
+			result = os.NewSyscallError("inotify_rm_watch", err)
+		}
+	}
+	return result
+}
+
+// rename updates the paths of the watches of the moved directory
+// and its subdirectories.
+func (backend *inotifyBackend) rename(oldPath, newPath string) {
+	backend.watchesLocker.Lock()
+	defer backend.watchesLocker.Unlock()
+	for path, wd := range backend.paths {
+		if path != oldPath && !isLocalSubpath(path, oldPath) {
+			continue
+		}
+		movedPath := newPath + path[len(oldPath):]
+		delete(backend.paths, path)
+		backend.paths[movedPath] = wd
+		backend.watches[wd] = movedPath
+	}
+}
+
+func (backend *inotifyBackend) Events() <-chan backendEvent {
+	return backend.events
+}
+
+func (backend *inotifyBackend) Errors() <-chan error {
+	return backend.errors
+}
+
+func (backend *inotifyBackend) Close() error {
+	close(backend.closed)
+	err := backend.file.Close()
+	backend.wg.Wait()
+	return err
+}
+
+func (backend *inotifyBackend) readLoop() {
+	var buf [unix.SizeofInotifyEvent * 4096]byte
+	for {
+		n, err := backend.file.Read(buf[:])
+		switch {
+		case err == nil:
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			// IN_MOVED_TO did not come, so it was moved out of
+			// the watched directories
+			if !backend.flushPendingMove() {
+				return
+			}
+			_ = backend.file.SetReadDeadline(time.Time{})
+			continue
+		case errors.Is(err, os.ErrClosed):
+			return
+		default:
+			backend.sendError(os.NewSyscallError("read", err))
+			return
+		}
+
+		if !backend.parse(buf[:n], time.Now()) {
+			return
+		}
+
+		if backend.pendingMove != nil {
+			_ = backend.file.SetReadDeadline(backend.pendingMove.Timestamp.Add(moveCookieTimeout))
+		} else {
+			_ = backend.file.SetReadDeadline(time.Time{})
+		}
+	}
+}
+
+// parse handles the events from the buffer returned by read().
+//
+// Returns false if the backend is closed.
+func (backend *inotifyBackend) parse(buf []byte, now time.Time) bool {
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buf); {
+		raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		offset = nameStart + int(raw.Len)
+		if offset > len(buf) {
+			backend.sendError(errors.New("inotify: truncated event"))
+			return true
+		}
+		name := string(bytes.TrimRight(buf[nameStart:offset], "\x00"))
+
+		if !backend.handle(raw.Wd, raw.Mask, raw.Cookie, name, now) {
+			return false
+		}
+	}
+	return true
+}
+
+// handle converts a raw inotify event and sends it.
+//
+// Returns false if the backend is closed.
+func (backend *inotifyBackend) handle(wd int32, mask, cookie uint32, name string, now time.Time) bool {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		return backend.flushPendingMove() && backend.send(backendEvent{
+			TypeMask:  event.TypeOverflow,
+			Timestamp: now,
+		})
+	}
+
+	backend.watchesLocker.Lock()
+	dirPath, ok := backend.watches[wd]
+	if mask&unix.IN_IGNORED != 0 {
+		// the directory is deleted, unmounted or unwatched
+		delete(backend.watches, wd)
+		if ok && backend.paths[dirPath] == wd {
+			delete(backend.paths, dirPath)
+		}
+	}
+	backend.watchesLocker.Unlock()
+	if !ok {
+		return true
+	}
+
+	ev := backendEvent{
+		Path:      dirPath,
+		TypeMask:  inotifyTypeMask(mask),
+		Timestamp: now,
+	}
+	if name != "" {
+		ev.Path = filepath.Join(dirPath, name)
+	}
+	if ev.TypeMask == 0 {
+		return true
+	}
+
+	if backend.pendingMove != nil && mask&unix.IN_MOVED_TO != 0 && cookie == backend.pendingMoveCookie {
+		// a paired move
+		movedFrom := backend.pendingMove
+		backend.pendingMove = nil
+		if mask&unix.IN_ISDIR != 0 {
+			backend.rename(movedFrom.Path, ev.Path)
+		}
+		return backend.send(backendEvent{
+			Path:      movedFrom.Path,
+			TypeMask:  movedFrom.TypeMask | ev.TypeMask,
+			Timestamp: movedFrom.Timestamp,
+			MovedTo:   ev.Path,
+		})
+	}
+
+	// IN_MOVED_FROM and IN_MOVED_TO of the same rename() are queued
+	// consecutively, so anything else means the pending move is unpaired
+	if !backend.flushPendingMove() {
+		return false
+	}
+
+	switch {
+	case mask&unix.IN_MOVED_FROM != 0:
+		backend.pendingMove = &ev
+		backend.pendingMoveCookie = cookie
+		backend.pendingMoveIsDir = mask&unix.IN_ISDIR != 0
+		return true
+	case mask&unix.IN_MOVED_TO != 0:
+		// moved in from a not watched directory
+		ev.TypeMask = ev.TypeMask&^event.TypeMoveTo | event.TypeCreate
+	}
+	return backend.send(ev)
+}
+
+// flushPendingMove sends the not paired IN_MOVED_FROM event (if any) as
+// a deletion.
+//
+// Returns false if the backend is closed.
+func (backend *inotifyBackend) flushPendingMove() bool {
+	if backend.pendingMove == nil {
+		return true
+	}
+	ev := *backend.pendingMove
+	backend.pendingMove = nil
+	if backend.pendingMoveIsDir {
+		// the directory is still watched, but it is outside of
+		// the watched directories now
+		_ = backend.Unwatch(ev.Path, true)
+	}
+	ev.TypeMask = ev.TypeMask&^event.TypeMoveFrom | event.TypeDelete
+	return backend.send(ev)
+}
+
+func (backend *inotifyBackend) send(ev backendEvent) bool {
+	select {
+	case backend.events <- ev:
+		return true
+	case <-backend.closed:
+		return false
+	}
+}
+
+func (backend *inotifyBackend) sendError(err error) {
+	select {
+	case backend.errors <- err:
+	default:
+	}
+}
+
+var inotifyTypeMasks = []struct {
+	inotify  uint32
+	typeMask event.TypeMask
+}{
+	{unix.IN_ACCESS, event.TypeAccess},
+	{unix.IN_ATTRIB, event.TypeAttrib},
+	{unix.IN_MODIFY, event.TypeWrite},
+	{unix.IN_OPEN, event.TypeOpen},
+	{unix.IN_CLOSE_WRITE, event.TypeCloseWrite},
+	{unix.IN_CLOSE_NOWRITE, event.TypeCloseNoWrite},
+	{unix.IN_CREATE, event.TypeCreate},
+	{unix.IN_DELETE, event.TypeDelete},
+	{unix.IN_DELETE_SELF, event.TypeDeleteSelf},
+	{unix.IN_MOVED_FROM, event.TypeMoveFrom},
+	{unix.IN_MOVED_TO, event.TypeMoveTo},
+	{unix.IN_MOVE_SELF, event.TypeMoveSelf},
+	{unix.IN_UNMOUNT, event.TypeUnmount},
+}
+
+func inotifyTypeMask(mask uint32) event.TypeMask {
+	var result event.TypeMask
+	for _, pair := range inotifyTypeMasks {
+		if mask&pair.inotify != 0 {
+			result |= pair.typeMask
+		}
+	}
+	return result
+}
+
+// inotifyMaskOf is the reverse of inotifyTypeMask.
+//
+// event.TypeOpenWrite has no equivalent: IN_OPEN does not tell the access
+// mode, so it is not reported by inotify.
+func inotifyMaskOf(typeMask event.TypeMask) uint32 {
+	var result uint32
+	for _, pair := range inotifyTypeMasks {
+		if typeMask.Any(pair.typeMask) {
+			result |= pair.inotify
+		}
+	}
+	return result
+}
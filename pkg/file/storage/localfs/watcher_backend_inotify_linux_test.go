@@ -0,0 +1,77 @@
+// +build test_integration,linux
+
+package localfs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestINotifyBackend(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "tests_my-network_fsutil_pkg_file_localfs")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, os.RemoveAll(tmpDir)) }()
+
+	watchedDir := filepath.Join(tmpDir, "watched")
+	require.NoError(t, os.Mkdir(watchedDir, 0700))
+
+	backend, err := newINotifyBackend()
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, backend.Close()) }()
+	require.NoError(t, backend.Watch(watchedDir, event.DefaultWatchTypeMask))
+
+	nextEvent := func() backendEvent {
+		select {
+		case ev := <-backend.Events():
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+		panic("unreachable")
+	}
+
+	t.Run("close_write", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(watchedDir, "a"), []byte("a"), 0600))
+		require.Equal(t, event.TypeCreate, nextEvent().TypeMask)
+		require.Equal(t, event.TypeWrite, nextEvent().TypeMask)
+		ev := nextEvent()
+		require.Equal(t, event.TypeCloseWrite, ev.TypeMask)
+		require.Equal(t, filepath.Join(watchedDir, "a"), ev.Path)
+	})
+
+	t.Run("move_paired", func(t *testing.T) {
+		require.NoError(t, os.Rename(filepath.Join(watchedDir, "a"), filepath.Join(watchedDir, "b")))
+		ev := nextEvent()
+		require.Equal(t, event.TypeMove, ev.TypeMask)
+		require.Equal(t, filepath.Join(watchedDir, "a"), ev.Path)
+		require.Equal(t, filepath.Join(watchedDir, "b"), ev.MovedTo)
+	})
+
+	t.Run("move_out", func(t *testing.T) {
+		require.NoError(t, os.Rename(filepath.Join(watchedDir, "b"), filepath.Join(tmpDir, "b")))
+		ev := nextEvent()
+		require.Equal(t, event.TypeDelete, ev.TypeMask)
+		require.Equal(t, filepath.Join(watchedDir, "b"), ev.Path)
+	})
+
+	t.Run("move_in", func(t *testing.T) {
+		require.NoError(t, os.Rename(filepath.Join(tmpDir, "b"), filepath.Join(watchedDir, "c")))
+		ev := nextEvent()
+		require.Equal(t, event.TypeCreate, ev.TypeMask)
+		require.Equal(t, filepath.Join(watchedDir, "c"), ev.Path)
+	})
+
+	t.Run("unsupported_type", func(t *testing.T) {
+		err := backend.Watch(watchedDir, event.TypeOpenWrite)
+		require.True(t, errors.As(err, &file.ErrNotImplemented{}), err)
+	})
+}
@@ -0,0 +1,11 @@
+// +build !linux
+
+package localfs
+
+import (
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+func newINotifyBackend() (watcherBackend, error) {
+	return nil, file.ErrNotImplemented{}
+}
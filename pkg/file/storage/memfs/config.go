@@ -0,0 +1,29 @@
+package memfs
+
+import (
+	"time"
+)
+
+const (
+	DefaultEventQueueSize = 1 << 16
+)
+
+type Config struct {
+	// EventQueueSize is the capacity of the channel of an EventEmitter
+	// and of its queue of the changes to be processed. Zero means
+	// DefaultEventQueueSize.
+	EventQueueSize uint
+
+	// NowFunc returns the current time; it is used for timestamps of
+	// objects and events. Nil means time.Now. A fake clock makes tests
+	// deterministic.
+	NowFunc func() time.Time
+}
+
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
@@ -0,0 +1,61 @@
+package memfs
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Directory = &Directory{}
+
+type Directory struct {
+	Object
+
+	// names are the names of the entries to be returned by Readdir
+	// (they are collected on the first call)
+	names         []string
+	isNamesLoaded bool
+}
+
+// Readdir returns the entries in the lexical order of their names.
+// The semantics of `n` are the same as of os.File.Readdir.
+func (dir *Directory) Readdir(n int) ([]os.FileInfo, error) {
+	stor := dir.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if dir.isClosed {
+		return nil, dir.pathError("readdirent", os.ErrClosed)
+	}
+
+	if !dir.isNamesLoaded {
+		dir.names = sortedNames(dir.node)
+		dir.isNamesLoaded = true
+	}
+
+	var result []os.FileInfo
+	for len(dir.names) > 0 && (n <= 0 || len(result) < n) {
+		name := dir.names[0]
+		dir.names = dir.names[1:]
+		child := dir.node.children[name]
+		if child == nil {
+			// removed since the first call
+			continue
+		}
+		result = append(result, stor.infoOf(name, child))
+	}
+	if n > 0 && len(result) == 0 {
+		return nil, io.EOF
+	}
+	return result, nil
+}
+
+func (dir *Directory) Open(
+	ctx context.Context,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return dir.StorageValue.Open(ctx, dir, path, flags, defaultPerm)
+}
@@ -0,0 +1,483 @@
+package memfs
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+var _ event.Emitter = &EventEmitter{}
+
+// EventEmitter reports the changes of a Storage similar to inotify:
+// a change is reported if the directory containing the changed entry is
+// watched. Directories created (or moved) inside of a watched subtree
+// are watched automatically.
+//
+// The Storage is never blocked by a slow consumer: up to EventQueueSize
+// changes are queued, and the changes beyond that are folded into
+// a TypeOverflow event. The path of the overflow event is the narrowest
+// directory which contains all the dropped changes.
+type EventEmitter struct {
+	ctx       context.Context
+	cancelFn  context.CancelFunc
+	storage   *Storage
+	wg        sync.WaitGroup
+	eventChan chan event.Event
+	errChan   chan error
+
+	// queueLocker protects the queue of events reported by the Storage.
+	// If queueOverflowed is true then the last event of the queue is
+	// a TypeOverflow event which the new changes are folded into.
+	queueLocker     sync.Mutex
+	queue           []event.Event
+	queueSize       int
+	queueOverflowed bool
+	queueSignal     chan struct{}
+
+	// watchLocker protects the fields below
+	watchLocker   sync.Mutex
+	watchRequests []watchRequest
+	watchedDirs   map[string]file.Path
+}
+
+// watchRequest is the arguments of a Watch call. They are reused
+// to watch directories created inside of the watched subtree.
+type watchRequest struct {
+	Path            file.Path
+	ShouldWatchFunc event.ShouldWatchFunc
+	ShouldWalkFunc  file.ShouldWalkFunc
+	ErrorHandler    file.ErrorHandlerFunc
+	Config          event.WatchConfig
+}
+
+func newEventEmitter(ctx context.Context, storage *Storage) *EventEmitter {
+	queueSize := storage.EventQueueSize
+	if queueSize == 0 {
+		queueSize = DefaultEventQueueSize
+	}
+	evEmitter := &EventEmitter{
+		storage:     storage,
+		eventChan:   make(chan event.Event, queueSize),
+		errChan:     make(chan error, event.ErrorQueueSize),
+		queueSize:   int(queueSize),
+		queueSignal: make(chan struct{}, 1),
+		watchedDirs: map[string]file.Path{},
+	}
+	evEmitter.ctx, evEmitter.cancelFn = context.WithCancel(ctx)
+	evEmitter.initPipeline()
+	return evEmitter
+}
+
+func (evEmitter *EventEmitter) initPipeline() {
+	evEmitter.wg.Add(1)
+	go func() {
+		defer func() {
+			close(evEmitter.eventChan)
+			close(evEmitter.errChan)
+			evEmitter.wg.Done()
+		}()
+		evEmitter.pipelineLoop()
+	}()
+}
+
+func (evEmitter *EventEmitter) pipelineLoop() {
+	for {
+		ev, ok := evEmitter.pop()
+		if !ok {
+			return
+		}
+		if !evEmitter.process(ev) {
+			return
+		}
+	}
+}
+
+// push queues a change of the storage. It is called by the Storage
+// (with Storage.locker held), so it never blocks.
+func (evEmitter *EventEmitter) push(ev event.Event) {
+	evEmitter.queueLocker.Lock()
+	switch {
+	case evEmitter.queueOverflowed:
+		overflow := &evEmitter.queue[len(evEmitter.queue)-1]
+		overflow.Path = overflow.Path.CommonPrefix(overflowScopeOf(ev))
+	case len(evEmitter.queue) >= evEmitter.queueSize:
+		evEmitter.queueOverflowed = true
+		evEmitter.queue = append(evEmitter.queue, event.Event{
+			Path:      overflowScopeOf(ev),
+			TypeMask:  event.TypeOverflow,
+			Timestamp: ev.Timestamp,
+		})
+	default:
+		evEmitter.queue = append(evEmitter.queue, ev)
+	}
+	evEmitter.queueLocker.Unlock()
+
+	select {
+	case evEmitter.queueSignal <- struct{}{}:
+	default:
+	}
+}
+
+// overflowScopeOf returns the narrowest path which should be rescanned
+// to find out what the dropped change was.
+func overflowScopeOf(ev event.Event) file.Path {
+	if ev.TypeMask.Has(event.TypeOverflow) {
+		return ev.Path
+	}
+	scope := ev.Path.Up()
+	if ev.MovedTo != nil {
+		scope = scope.CommonPrefix(ev.MovedTo.Up())
+	}
+	return scope
+}
+
+// pop waits for the next change of the storage.
+//
+// Returns false if the emitter is closed.
+func (evEmitter *EventEmitter) pop() (event.Event, bool) {
+	for {
+		evEmitter.queueLocker.Lock()
+		if len(evEmitter.queue) > 0 {
+			ev := evEmitter.queue[0]
+			evEmitter.queue[0] = event.Event{}
+			evEmitter.queue = evEmitter.queue[1:]
+			if len(evEmitter.queue) == 0 {
+				// the overflow event (if any) is the last one
+				evEmitter.queueOverflowed = false
+			}
+			evEmitter.queueLocker.Unlock()
+			return ev, true
+		}
+		evEmitter.queueLocker.Unlock()
+
+		select {
+		case <-evEmitter.queueSignal:
+		case <-evEmitter.ctx.Done():
+			return event.Event{}, false
+		}
+	}
+}
+
+// process reports the change of the storage (if it is watched) and
+// updates the set of watched directories.
+//
+// Returns false if the emitter is closed.
+func (evEmitter *EventEmitter) process(ev event.Event) bool {
+	if ev.TypeMask.Has(event.TypeOverflow) {
+		// the content of the storage is replaced (see Storage.Restore)
+		// or the changes are dropped, so new directories might be missed
+		evEmitter.rewatch()
+		return evEmitter.emit(ev)
+	}
+
+	if convertedEvent, ok := evEmitter.convert(ev); ok {
+		if filteredEvent, ok := evEmitter.filter(convertedEvent); ok && !evEmitter.emit(filteredEvent) {
+			return false
+		}
+	}
+	evEmitter.forgetDeleted(ev)
+	return evEmitter.watchNewDirectory(ev)
+}
+
+// convert returns the event as it is seen from the watched directories:
+// a move from an unwatched directory is reported as a creation, and
+// a move to an unwatched directory is reported as a deletion.
+//
+// Returns false if the event is not watched at all.
+func (evEmitter *EventEmitter) convert(ev event.Event) (event.Event, bool) {
+	isFromWatched := evEmitter.isWatched(ev.Path.Up())
+	if ev.MovedTo == nil {
+		return ev, isFromWatched
+	}
+
+	isToWatched := evEmitter.isWatched(ev.MovedTo.Up())
+	switch {
+	case isFromWatched && isToWatched:
+	case isFromWatched:
+		ev.TypeMask = ev.TypeMask&^event.TypeMove | event.TypeDelete
+		ev.MovedTo = nil
+	case isToWatched:
+		ev.TypeMask = ev.TypeMask&^event.TypeMove | event.TypeCreate
+		ev.Path, ev.MovedTo = ev.MovedTo, nil
+	default:
+		return ev, false
+	}
+	return ev, true
+}
+
+// filter applies the WatchConfig of the Watch call which covers the path
+// of the event.
+func (evEmitter *EventEmitter) filter(ev event.Event) (event.Event, bool) {
+	req, ok := evEmitter.watchRequestFor(ev.Path)
+	if !ok && ev.MovedTo != nil {
+		req, ok = evEmitter.watchRequestFor(ev.MovedTo)
+	}
+	if !ok {
+		return ev, true
+	}
+	return req.Config.Filter(ev)
+}
+
+// emit sends the event to the consumer.
+//
+// Returns false if the emitter is closed.
+func (evEmitter *EventEmitter) emit(ev event.Event) bool {
+	select {
+	case evEmitter.eventChan <- ev:
+		return true
+	case <-evEmitter.ctx.Done():
+		return false
+	}
+}
+
+func (evEmitter *EventEmitter) sendError(err error) {
+	select {
+	case evEmitter.errChan <- err:
+	default:
+	}
+}
+
+// forgetDeleted stops watching the directories deleted or moved away
+// by the event (and inside of them). A moved directory is watched again
+// by watchNewDirectory if its new place is watched.
+func (evEmitter *EventEmitter) forgetDeleted(ev event.Event) {
+	if !ev.TypeMask.Any(event.TypeDelete | event.TypeMoveFrom) {
+		return
+	}
+	evEmitter.unwatch(ev.Path, true)
+}
+
+// watchNewDirectory starts watching a directory created (or moved in)
+// by the event, if the directory containing it is watched.
+//
+// The functions of the Watch call which covers the directory are reused:
+// the directory is watched if ShouldWatchFunc approves it and its
+// subdirectories are watched if ShouldWalkFunc approves it.
+//
+// Returns false if the emitter is closed.
+func (evEmitter *EventEmitter) watchNewDirectory(ev event.Event) bool {
+	path := ev.Path
+	switch {
+	case ev.MovedTo != nil:
+		path = ev.MovedTo
+	case !ev.TypeMask.Has(event.TypeCreate):
+		return true
+	}
+	if len(path) == 0 || !evEmitter.isWatched(path.Up()) {
+		return true
+	}
+
+	req, ok := evEmitter.watchRequestFor(path)
+	if !ok {
+		return true
+	}
+
+	info, err := evEmitter.storage.Stat(evEmitter.ctx, nil, path, true)
+	if err != nil || !info.IsDir() {
+		// already removed or replaced; it is reported by a later event
+		return true
+	}
+
+	if req.ShouldWalkFunc != nil || req.ShouldWatchFunc != nil {
+		parentObj, err := evEmitter.storage.Open(evEmitter.ctx, nil, path.Up(), file.FlagWalkDefaults, 0000)
+		if err != nil {
+			return evEmitter.ctx.Err() == nil
+		}
+		defer func() { _ = parentObj.Close() }()
+		parent, ok := parentObj.(file.Directory)
+		if !ok {
+			return true
+		}
+
+		if req.ShouldWatchFunc != nil && !req.ShouldWatchFunc(parent, info) {
+			return true
+		}
+		if req.ShouldWalkFunc != nil && !req.ShouldWalkFunc(parent, info) {
+			evEmitter.markWatched(path)
+			return true
+		}
+	}
+
+	err = evEmitter.watch(path, req)
+	if evEmitter.ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		evEmitter.sendError(err)
+	}
+	return true
+}
+
+// rewatch forgets the watched directories and finds them again
+// according to the Watch calls.
+func (evEmitter *EventEmitter) rewatch() {
+	evEmitter.watchLocker.Lock()
+	evEmitter.watchedDirs = map[string]file.Path{}
+	watchRequests := append([]watchRequest{}, evEmitter.watchRequests...)
+	evEmitter.watchLocker.Unlock()
+
+	for _, req := range watchRequests {
+		if _, err := evEmitter.storage.Stat(evEmitter.ctx, nil, req.Path, true); file.IsNotExist(err) {
+			continue
+		}
+		if err := evEmitter.watch(req.Path, req); err != nil {
+			evEmitter.sendError(err)
+		}
+	}
+}
+
+// watch walks through the subtree and marks the directories as watched.
+func (evEmitter *EventEmitter) watch(path file.Path, req watchRequest) error {
+	return file.Walk(
+		evEmitter.ctx,
+		evEmitter.storage,
+		nil,
+		path,
+		func(dir file.Directory, objectInfo os.FileInfo) error {
+			if !objectInfo.IsDir() {
+				return nil
+			}
+			if req.ShouldWatchFunc != nil && !req.ShouldWatchFunc(dir, objectInfo) {
+				return nil
+			}
+			pathFull := dir.Path()
+			if objectInfo.Name() != "." {
+				pathFull = pathFull.Append(objectInfo.Name())
+			}
+			evEmitter.markWatched(pathFull)
+			return nil
+		},
+		req.ShouldWalkFunc,
+		req.ErrorHandler,
+	)
+}
+
+func (evEmitter *EventEmitter) markWatched(path file.Path) {
+	evEmitter.watchLocker.Lock()
+	defer evEmitter.watchLocker.Unlock()
+	evEmitter.watchedDirs[path.Key()] = path
+}
+
+func (evEmitter *EventEmitter) isWatched(path file.Path) bool {
+	evEmitter.watchLocker.Lock()
+	defer evEmitter.watchLocker.Unlock()
+	_, ok := evEmitter.watchedDirs[path.Key()]
+	return ok
+}
+
+// unwatch stops watching the directory. If recursive is true then
+// the directories inside of it and the Watch calls on them are
+// forgotten too.
+func (evEmitter *EventEmitter) unwatch(path file.Path, recursive bool) {
+	evEmitter.watchLocker.Lock()
+	defer evEmitter.watchLocker.Unlock()
+
+	if !recursive {
+		delete(evEmitter.watchedDirs, path.Key())
+		return
+	}
+
+	for key, watchedPath := range evEmitter.watchedDirs {
+		if watchedPath.HasPrefix(path) {
+			delete(evEmitter.watchedDirs, key)
+		}
+	}
+	watchRequests := evEmitter.watchRequests[:0]
+	for _, req := range evEmitter.watchRequests {
+		if req.Path.HasPrefix(path) {
+			continue
+		}
+		watchRequests = append(watchRequests, req)
+	}
+	evEmitter.watchRequests = watchRequests
+}
+
+// watchRequestFor returns the request of the innermost watched subtree
+// which contains the path.
+func (evEmitter *EventEmitter) watchRequestFor(path file.Path) (watchRequest, bool) {
+	evEmitter.watchLocker.Lock()
+	defer evEmitter.watchLocker.Unlock()
+
+	var (
+		result watchRequest
+		found  bool
+	)
+	for _, req := range evEmitter.watchRequests {
+		if !path.HasPrefix(req.Path) {
+			continue
+		}
+		if found && len(req.Path) < len(result.Path) {
+			continue
+		}
+		result, found = req, true
+	}
+	return result, found
+}
+
+func (evEmitter *EventEmitter) C() <-chan event.Event {
+	return evEmitter.eventChan
+}
+
+// Errors returns the errors of watching new directories which are not
+// handled by the ErrorHandlerFunc of the Watch call.
+func (evEmitter *EventEmitter) Errors() <-chan error {
+	return evEmitter.errChan
+}
+
+func (evEmitter *EventEmitter) Close() error {
+	evEmitter.cancelFn()
+	evEmitter.wg.Wait()
+	evEmitter.storage.removeEmitter(evEmitter)
+	return nil
+}
+
+// Watch starts watching the directory and (recursively) its
+// subdirectories approved by shouldWatchFunc and shouldWalkFunc.
+// The changes are reported starting from the moment of the call.
+func (evEmitter *EventEmitter) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc event.ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandler file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
+) error {
+	if errorHandler == nil {
+		errorHandler = dummyErrorHandler
+	}
+
+	if dirAt != nil {
+		return file.ErrNotImplemented{}
+	}
+
+	req := watchRequest{
+		Path:            path,
+		ShouldWatchFunc: shouldWatchFunc,
+		ShouldWalkFunc:  shouldWalkFunc,
+		ErrorHandler:    errorHandler,
+		Config:          *event.NewWatchConfig(opts...),
+	}
+
+	if err := evEmitter.watch(path, req); err != nil {
+		return &file.ErrWatch{
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	evEmitter.watchLocker.Lock()
+	evEmitter.watchRequests = append(evEmitter.watchRequests, req)
+	evEmitter.watchLocker.Unlock()
+	return nil
+}
+
+// Unwatch stops watching the directory. If recursive is true then
+// the Watch calls on the directory and inside of it are forgotten too,
+// so new subdirectories are not watched anymore.
+func (evEmitter *EventEmitter) Unwatch(path file.Path, recursive bool) error {
+	evEmitter.unwatch(path, recursive)
+	return nil
+}
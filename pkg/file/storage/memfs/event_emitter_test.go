@@ -0,0 +1,138 @@
+package memfs
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextEvent(t *testing.T, evEmitter event.Emitter) event.Event {
+	select {
+	case ev := <-evEmitter.C():
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+	panic("unreachable")
+}
+
+func TestEventEmitter(t *testing.T) {
+	stor := NewStorage()
+	defer func() { assert.NoError(t, stor.Close()) }()
+	ctx := context.Background()
+
+	shouldWatch := func(dir file.Directory, info os.FileInfo) bool {
+		return info.Name() != "ignored"
+	}
+	evEmitter, err := stor.Watch(nil, nil, shouldWatch, nil, nil,
+		event.OptionTypeMask{Mask: event.TypeCreate | event.TypeCloseWrite | event.TypeMove | event.TypeDelete})
+	require.NoError(t, err)
+
+	expect := func(typeMask event.TypeMask, path, movedTo file.Path) {
+		ev := nextEvent(t, evEmitter)
+		require.Equal(t, typeMask, ev.TypeMask, ev.Path)
+		require.Equal(t, path, ev.Path)
+		require.Equal(t, movedTo, ev.MovedTo)
+		require.False(t, ev.ObjID.IsZero())
+	}
+
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"a", "b"}, 0755, true))
+	storagetest.WriteFile(t, stor, file.Path{"a", "b", "f"}, "f")
+	expect(event.TypeCreate, file.Path{"a"}, nil)
+	expect(event.TypeCreate, file.Path{"a", "b"}, nil)
+	expect(event.TypeCreate, file.Path{"a", "b", "f"}, nil)
+	expect(event.TypeCloseWrite, file.Path{"a", "b", "f"}, nil)
+
+	require.NoError(t, stor.Rename(ctx, nil, file.Path{"a", "b"}, file.Path{"c"}))
+	require.NoError(t, stor.Remove(ctx, nil, file.Path{"c", "f"}, false))
+	expect(event.TypeMove, file.Path{"a", "b"}, file.Path{"c"})
+	expect(event.TypeDelete, file.Path{"c", "f"}, nil)
+
+	// the content of an ignored directory is not reported, and
+	// moving to it is reported as a deletion
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"ignored"}, 0755, false))
+	storagetest.WriteFile(t, stor, file.Path{"ignored", "x"}, "x")
+	require.NoError(t, stor.Rename(ctx, nil, file.Path{"c"}, file.Path{"ignored", "c"}))
+	require.NoError(t, stor.Rename(ctx, nil, file.Path{"ignored", "x"}, file.Path{"x"}))
+	expect(event.TypeCreate, file.Path{"ignored"}, nil)
+	expect(event.TypeDelete, file.Path{"c"}, nil)
+	expect(event.TypeCreate, file.Path{"x"}, nil)
+
+	// the directory is not watched anymore
+	require.NoError(t, evEmitter.Unwatch(file.Path{"a"}, false))
+	storagetest.WriteFile(t, stor, file.Path{"a", "y"}, "y")
+	require.NoError(t, stor.Remove(ctx, nil, file.Path{"a"}, true))
+	expect(event.TypeDelete, file.Path{"a"}, nil)
+
+	require.NoError(t, evEmitter.Close())
+	_, ok := <-evEmitter.C()
+	require.False(t, ok)
+}
+
+func TestEventEmitterRestore(t *testing.T) {
+	stor := NewStorage()
+	defer func() { assert.NoError(t, stor.Close()) }()
+	ctx := context.Background()
+
+	snapshot := stor.Snapshot()
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"a"}, 0755, false))
+
+	evEmitter, err := stor.Watch(nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	stor.Restore(snapshot)
+	ev := nextEvent(t, evEmitter)
+	require.Equal(t, event.TypeOverflow, ev.TypeMask)
+	require.Equal(t, file.Path{}, ev.Path)
+
+	// new directories are watched after the restore
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"b"}, 0755, false))
+	storagetest.WriteFile(t, stor, file.Path{"b", "f"}, "f")
+	require.Equal(t, file.Path{"b"}, nextEvent(t, evEmitter).Path)
+	for {
+		ev := nextEvent(t, evEmitter)
+		require.Equal(t, file.Path{"b", "f"}, ev.Path)
+		if ev.TypeMask.Has(event.TypeCloseWrite) {
+			break
+		}
+	}
+}
+
+func TestEventEmitterOverflow(t *testing.T) {
+	stor := NewStorage(OptionEventQueueSize{Size: 1})
+	defer func() { assert.NoError(t, stor.Close()) }()
+	ctx := context.Background()
+
+	evEmitter, err := stor.Watch(nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	// nothing is read, so the changes beyond the queues are dropped
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"d"}, 0755, false))
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		storagetest.WriteFile(t, stor, file.Path{"d", name}, name)
+	}
+
+	var count int
+	for {
+		ev := nextEvent(t, evEmitter)
+		if ev.TypeMask.Has(event.TypeOverflow) {
+			require.Equal(t, file.Path{"d"}, ev.Path)
+			break
+		}
+		count++
+	}
+	require.LessOrEqual(t, count, 3)
+
+	// the changes after the overflow are reported again
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"d", "sub"}, 0755, false))
+	ev := nextEvent(t, evEmitter)
+	require.Equal(t, event.TypeCreate, ev.TypeMask)
+	require.Equal(t, file.Path{"d", "sub"}, ev.Path)
+}
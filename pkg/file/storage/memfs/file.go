@@ -0,0 +1,151 @@
+package memfs
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+var _ file.File = &File{}
+
+type File struct {
+	Object
+	offset int64
+}
+
+func (f *File) Read(b []byte) (int, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	n, err := f.readAt(b, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *File) ReadAt(b []byte, offset int64) (int, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	return f.readAt(b, offset)
+}
+
+func (f *File) readAt(b []byte, offset int64) (int, error) {
+	switch {
+	case f.isClosed:
+		return 0, f.pathError("read", os.ErrClosed)
+	case f.flags&file.FlagRead == 0:
+		return 0, f.pathError("read", syscall.EBADF)
+	case offset < 0:
+		return 0, f.pathError("read", syscall.EINVAL)
+	}
+	if offset >= int64(len(f.node.data)) {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(b, f.node.data[offset:])
+	if !f.flags.HasNoATime() {
+		f.node.atime = f.StorageValue.now()
+	}
+	f.StorageValue.notify(event.TypeAccess, f.LastPath, f.node, nil)
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *File) Write(b []byte) (int, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	offset := f.offset
+	if f.flags.HasAppend() {
+		offset = int64(len(f.node.data))
+	}
+	n, err := f.writeAt(b, offset)
+	f.offset = offset + int64(n)
+	return n, err
+}
+
+func (f *File) WriteAt(b []byte, offset int64) (int, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if f.flags.HasAppend() {
+		// the same as os.File.WriteAt
+		return 0, f.pathError("writeat", syscall.EINVAL)
+	}
+	return f.writeAt(b, offset)
+}
+
+func (f *File) writeAt(b []byte, offset int64) (int, error) {
+	switch {
+	case f.isClosed:
+		return 0, f.pathError("write", os.ErrClosed)
+	case !isWritable(f.flags):
+		return 0, f.pathError("write", syscall.EBADF)
+	case offset < 0:
+		return 0, f.pathError("write", syscall.EINVAL)
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	end := offset + int64(len(b))
+	if end > int64(len(f.node.data)) {
+		data := make([]byte, end)
+		copy(data, f.node.data)
+		f.node.data = data
+	}
+	copy(f.node.data[offset:], b)
+	now := f.StorageValue.now()
+	f.node.mtime, f.node.ctime = now, now
+	f.StorageValue.notify(event.TypeWrite, f.LastPath, f.node, nil)
+	return len(b), nil
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	stor := f.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if f.isClosed {
+		return 0, f.pathError("seek", os.ErrClosed)
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	default:
+		return 0, f.pathError("seek", syscall.EINVAL)
+	}
+	if offset < 0 {
+		return 0, f.pathError("seek", syscall.EINVAL)
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Sync does nothing: the data is always "on the storage".
+func (f *File) Sync() error {
+	return nil
+}
+
+// SetDeadline is not supported (the same as for regular files of the OS).
+func (f *File) SetDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetReadDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetWriteDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
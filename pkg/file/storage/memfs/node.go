@@ -0,0 +1,298 @@
+package memfs
+
+import (
+	"os"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+const (
+	// maxSymlinkDepth is the maximal amount of symlinks followed while
+	// resolving a path (the same as MAXSYMLINKS of Linux).
+	maxSymlinkDepth = 40
+
+	// modeMask is the part of os.FileMode which may be changed by Chmod.
+	modeMask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+)
+
+// node is an object of the storage (similar to an inode). Directory
+// entries refer to nodes, so a node with several entries is
+// a hardlinked file.
+//
+// All the fields are protected by Storage.locker.
+type node struct {
+	ino   uint64
+	mode  os.FileMode
+	uid   int
+	gid   int
+	nlink uint64
+	atime time.Time
+	mtime time.Time
+	ctime time.Time
+
+	// data is the content of a regular file
+	data []byte
+
+	// target is the destination of a symlink
+	target file.Path
+
+	// children, parent and name are used by directories only (they
+	// cannot be hardlinked, so they have a single parent). The parent
+	// of the root is the root itself; the parent of a removed directory
+	// is nil.
+	children map[string]*node
+	parent   *node
+	name     string
+}
+
+func (n *node) isDir() bool {
+	return n.mode.IsDir()
+}
+
+func (n *node) isSymlink() bool {
+	return n.mode&os.ModeSymlink != 0
+}
+
+// path returns the path of the directory.
+func (n *node) path() file.Path {
+	var names []string
+	for cur := n; cur.parent != nil && cur.parent != cur; cur = cur.parent {
+		names = append(names, cur.name)
+	}
+	result := make(file.Path, len(names))
+	for idx, name := range names {
+		result[len(names)-1-idx] = name
+	}
+	return result
+}
+
+// isInside returns true if the directory is `dir` or is inside of it.
+func (n *node) isInside(dir *node) bool {
+	for cur := n; cur != nil; cur = cur.parent {
+		if cur == dir {
+			return true
+		}
+		if cur.parent == cur {
+			break
+		}
+	}
+	return false
+}
+
+func (n *node) chmod(mode os.FileMode) {
+	n.mode = n.mode&^modeMask | mode&modeMask
+}
+
+// chown changes the owner; a negative ID means "do not change".
+func (n *node) chown(uid, gid int) {
+	if uid >= 0 {
+		n.uid = uid
+	}
+	if gid >= 0 {
+		n.gid = gid
+	}
+}
+
+func (n *node) size() int64 {
+	switch {
+	case n.isSymlink():
+		return int64(len(n.target.LocalPath()))
+	case n.isDir():
+		return 0
+	}
+	return int64(len(n.data))
+}
+
+// sortedNames returns the names of the entries of the directory in
+// the lexical order, so the results are deterministic.
+func sortedNames(dir *node) []string {
+	names := make([]string, 0, len(dir.children))
+	for name := range dir.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// entry is a directory entry found by Storage.resolveEntry.
+type entry struct {
+	// dir is the directory containing the entry (nil for the root)
+	dir  *node
+	name string
+
+	// node is nil if there is no such entry
+	node *node
+}
+
+func (e entry) path() file.Path {
+	if e.dir == nil {
+		return file.Path{}
+	}
+	return e.dir.path().Append(e.name)
+}
+
+// Stat is the result of os.FileInfo.Sys() of the objects of a Storage.
+type Stat struct {
+	Dev   uint64
+	Ino   uint64
+	Nlink uint64
+	UID   int
+	GID   int
+	Atime time.Time
+	Ctime time.Time
+}
+
+var _ os.FileInfo = &fileInfo{}
+
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+	stat  Stat
+}
+
+func (info *fileInfo) Name() string {
+	return info.name
+}
+
+func (info *fileInfo) Size() int64 {
+	return info.size
+}
+
+func (info *fileInfo) Mode() os.FileMode {
+	return info.mode
+}
+
+func (info *fileInfo) ModTime() time.Time {
+	return info.mtime
+}
+
+func (info *fileInfo) IsDir() bool {
+	return info.mode.IsDir()
+}
+
+// Sys returns *Stat.
+func (info *fileInfo) Sys() interface{} {
+	return &info.stat
+}
+
+// resolve returns the node of the path relative to the directory `dir`.
+// Symlinks are followed, except the last component if noFollow is true.
+func (stor *Storage) resolve(dir *node, path file.Path, noFollow bool, depth *int) (*node, error) {
+	cur := dir
+	for idx, name := range path {
+		if idx == 0 && name == "" {
+			// an absolute path
+			cur = stor.root
+			continue
+		}
+		if !cur.isDir() {
+			return nil, syscall.ENOTDIR
+		}
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			if cur.parent != nil {
+				cur = cur.parent
+			}
+			continue
+		}
+		child := cur.children[name]
+		if child == nil {
+			return nil, syscall.ENOENT
+		}
+		if child.isSymlink() && !(noFollow && idx == len(path)-1) {
+			*depth++
+			if *depth > maxSymlinkDepth {
+				return nil, syscall.ELOOP
+			}
+			var err error
+			child, err = stor.resolve(cur, child.target, false, depth)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cur = child
+	}
+	return cur, nil
+}
+
+// resolveEntry returns the directory entry of the path relative to
+// the directory `dir`. The node of the entry is nil if the entry does
+// not exist (but its directory does). A symlink in the last component
+// is followed unless noFollow is true.
+func (stor *Storage) resolveEntry(dir *node, path file.Path, noFollow bool, depth *int) (entry, error) {
+	if len(path) == 0 {
+		return stor.entryOf(dir), nil
+	}
+	name := path[len(path)-1]
+	switch name {
+	case "", ".", "..":
+		n, err := stor.resolve(dir, path, false, depth)
+		if err != nil {
+			return entry{}, err
+		}
+		return stor.entryOf(n), nil
+	}
+
+	parent, err := stor.resolve(dir, path[:len(path)-1], false, depth)
+	if err != nil {
+		return entry{}, err
+	}
+	if !parent.isDir() {
+		return entry{}, syscall.ENOTDIR
+	}
+	child := parent.children[name]
+	if child != nil && child.isSymlink() && !noFollow {
+		*depth++
+		if *depth > maxSymlinkDepth {
+			return entry{}, syscall.ELOOP
+		}
+		return stor.resolveEntry(parent, child.target, false, depth)
+	}
+	return entry{dir: parent, name: name, node: child}, nil
+}
+
+// entryOf returns the entry of the directory in its parent.
+func (stor *Storage) entryOf(dir *node) entry {
+	if dir == stor.root || dir.parent == nil {
+		return entry{node: dir}
+	}
+	return entry{dir: dir.parent, name: dir.name, node: dir}
+}
+
+// cloneTree returns a deep copy of the tree of nodes. Hardlinks are
+// preserved.
+func cloneTree(root *node) *node {
+	clones := map[*node]*node{}
+	var cloneNode func(n, parent *node) *node
+	cloneNode = func(n, parent *node) *node {
+		if clone := clones[n]; clone != nil {
+			return clone
+		}
+		clone := *n
+		clones[n] = &clone
+		if n.data != nil {
+			clone.data = append([]byte{}, n.data...)
+		}
+		if n.target != nil {
+			clone.target = append(file.Path{}, n.target...)
+		}
+		if n.isDir() {
+			clone.parent = parent
+			clone.children = make(map[string]*node, len(n.children))
+			for name, child := range n.children {
+				clone.children[name] = cloneNode(child, &clone)
+			}
+		}
+		return &clone
+	}
+	result := cloneNode(root, nil)
+	result.parent = result
+	return result
+}
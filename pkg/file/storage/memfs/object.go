@@ -0,0 +1,110 @@
+package memfs
+
+import (
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+var _ file.Object = &Object{}
+
+// Object is an opened object of a Storage. It refers to the object
+// itself (not to the path), so it is usable after the object is renamed
+// or removed (the same as a file descriptor).
+type Object struct {
+	StorageValue *Storage
+	LastInfo     os.FileInfo
+	LastPath     file.Path
+
+	node     *node
+	flags    file.OpenFlag
+	isClosed bool
+}
+
+func (obj *Object) ID() file.ObjectID {
+	return obj.StorageValue.objectID(obj.node)
+}
+
+func (obj *Object) Name() string {
+	return obj.LastInfo.Name()
+}
+
+func (obj *Object) Stat() (os.FileInfo, error) {
+	stor := obj.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if obj.isClosed {
+		return nil, obj.pathError("stat", os.ErrClosed)
+	}
+	obj.LastInfo = stor.infoOf(obj.LastInfo.Name(), obj.node)
+	return obj.LastInfo, nil
+}
+
+func (obj *Object) LastStat() os.FileInfo {
+	return obj.LastInfo
+}
+
+// Path returns the path the object was opened by.
+func (obj *Object) Path() file.Path {
+	return obj.LastPath
+}
+
+func (obj *Object) Close() error {
+	stor := obj.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if obj.isClosed {
+		return obj.pathError("close", os.ErrClosed)
+	}
+	obj.isClosed = true
+
+	if !obj.node.mode.IsRegular() || obj.flags.HasPath() {
+		return nil
+	}
+	typeMask := event.TypeCloseNoWrite
+	if isWritable(obj.flags) {
+		typeMask = event.TypeCloseWrite
+	}
+	stor.notify(typeMask, obj.LastPath, obj.node, nil)
+	return nil
+}
+
+func (obj *Object) Chmod(mode os.FileMode) error {
+	return obj.modify("chmod", func(n *node) {
+		n.chmod(mode)
+	})
+}
+
+func (obj *Object) Chown(uid, gid int) error {
+	return obj.modify("chown", func(n *node) {
+		n.chown(uid, gid)
+	})
+}
+
+func (obj *Object) modify(op string, fn func(n *node)) error {
+	stor := obj.StorageValue
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	if obj.isClosed {
+		return obj.pathError(op, os.ErrClosed)
+	}
+	fn(obj.node)
+	obj.node.ctime = stor.now()
+	stor.notify(event.TypeAttrib, obj.LastPath, obj.node, nil)
+	return nil
+}
+
+func (obj *Object) Storage() file.Storage {
+	return obj.StorageValue
+}
+
+// FD returns an invalid file descriptor: the objects of the storage
+// are not backed by the OS.
+func (obj *Object) FD() uintptr {
+	return ^uintptr(0)
+}
+
+func (obj *Object) pathError(op string, err error) error {
+	return pathError(op, obj.LastPath, err)
+}
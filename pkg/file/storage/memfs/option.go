@@ -0,0 +1,25 @@
+package memfs
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionEventQueueSize struct {
+	Size uint
+}
+
+func (opt OptionEventQueueSize) apply(cfg *Config) {
+	cfg.EventQueueSize = opt.Size
+}
+
+type OptionNowFunc struct {
+	Func func() time.Time
+}
+
+func (opt OptionNowFunc) apply(cfg *Config) {
+	cfg.NowFunc = opt.Func
+}
@@ -0,0 +1,23 @@
+package memfs
+
+import (
+	"context"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.PathDescriptor = &PathDescriptor{}
+
+// PathDescriptor is a regular file opened with file.FlagPath.
+type PathDescriptor struct {
+	Object
+}
+
+func (pathDesc *PathDescriptor) Open(
+	ctx context.Context,
+	mask file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return pathDesc.StorageValue.Open(ctx, nil, pathDesc.Path(), mask&^file.FlagPath, defaultPerm)
+}
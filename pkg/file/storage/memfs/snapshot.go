@@ -0,0 +1,40 @@
+package memfs
+
+import (
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+// Snapshot is a copy of the content of a Storage (see Storage.Snapshot).
+// It is not affected by later changes of the Storage.
+type Snapshot struct {
+	root    *node
+	lastIno uint64
+}
+
+// Snapshot returns a copy of the current content of the storage.
+func (stor *Storage) Snapshot() *Snapshot {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	return &Snapshot{
+		root:    cloneTree(stor.root),
+		lastIno: stor.lastIno,
+	}
+}
+
+// Restore replaces the content of the storage with the content of
+// the snapshot. The snapshot may be restored many times.
+//
+// The objects keep the IDs they had in the snapshot. The opened objects
+// keep referring to the replaced content (as if it was removed).
+// The emitters report a TypeOverflow event on the root, since anything
+// could change.
+func (stor *Storage) Restore(snapshot *Snapshot) {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	stor.root = cloneTree(snapshot.root)
+	if snapshot.lastIno > stor.lastIno {
+		stor.lastIno = snapshot.lastIno
+	}
+	stor.notify(event.TypeOverflow, file.Path{}, nil, nil)
+}
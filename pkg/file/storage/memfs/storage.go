@@ -0,0 +1,682 @@
+package memfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+)
+
+var _ file.StorageWatchable = &Storage{}
+
+// lastDev is the last device number assigned to a Storage, so objects
+// of different storages have different IDs.
+var lastDev uint64
+
+// Storage is a file.StorageWatchable which keeps everything in memory.
+// It is supposed to be used in tests instead of a temporary directory.
+//
+// Regular files, directories, symlinks and hardlinks are supported
+// (with permissions, owners and timestamps). The storage reports its
+// changes to the emitters returned by Watch similar to inotify.
+type Storage struct {
+	Config
+	ctx      context.Context
+	cancelFn context.CancelFunc
+	dev      uint64
+
+	// locker protects the fields below and all the nodes
+	locker   sync.Mutex
+	root     *node
+	lastIno  uint64
+	emitters []*EventEmitter
+}
+
+// NewStorage returns an empty Storage (with only the root directory).
+func NewStorage(opts ...Option) *Storage {
+	stor := &Storage{
+		dev: atomic.AddUint64(&lastDev, 1),
+	}
+	for _, opt := range opts {
+		opt.apply(&stor.Config)
+	}
+	stor.ctx, stor.cancelFn = context.WithCancel(context.Background())
+	stor.root = stor.newNode(os.ModeDir | 0755)
+	stor.root.parent = stor.root
+	return stor
+}
+
+func (stor *Storage) now() time.Time {
+	if stor.NowFunc != nil {
+		return stor.NowFunc()
+	}
+	return time.Now()
+}
+
+func (stor *Storage) newNode(mode os.FileMode) *node {
+	stor.lastIno++
+	now := stor.now()
+	n := &node{
+		ino:   stor.lastIno,
+		mode:  mode,
+		uid:   os.Getuid(),
+		gid:   os.Getgid(),
+		atime: now,
+		mtime: now,
+		ctime: now,
+	}
+	if mode.IsDir() {
+		n.children = map[string]*node{}
+	}
+	return n
+}
+
+// baseNode returns the directory the paths are relative to.
+func (stor *Storage) baseNode(dirAt file.Object) (*node, error) {
+	if dirAt == nil {
+		return stor.root, nil
+	}
+	dir, ok := dirAt.(*Directory)
+	if !ok || dir.StorageValue != stor {
+		return nil, file.ErrNotImplemented{}
+	}
+	return dir.node, nil
+}
+
+func (stor *Storage) objectID(n *node) file.ObjectID {
+	return file.ObjectID{
+		Dev: stor.dev,
+		Ino: n.ino,
+	}
+}
+
+func (stor *Storage) infoOf(name string, n *node) *fileInfo {
+	if name == "" {
+		name = "/"
+	}
+	return &fileInfo{
+		name:  name,
+		size:  n.size(),
+		mode:  n.mode,
+		mtime: n.mtime,
+		stat: Stat{
+			Dev:   stor.dev,
+			Ino:   n.ino,
+			Nlink: n.nlink,
+			UID:   n.uid,
+			GID:   n.gid,
+			Atime: n.atime,
+			Ctime: n.ctime,
+		},
+	}
+}
+
+// notify delivers the event to the emitters. It is called with
+// the locker held, so the emitters receive the events in the order
+// of the changes.
+func (stor *Storage) notify(typeMask event.TypeMask, path file.Path, n *node, movedTo file.Path) {
+	ev := event.Event{
+		Path:      path,
+		TypeMask:  typeMask,
+		Timestamp: stor.now(),
+		MovedTo:   movedTo,
+	}
+	if n != nil {
+		ev.ObjID = stor.objectID(n)
+	}
+	for _, emitter := range stor.emitters {
+		emitter.push(ev)
+	}
+}
+
+// link adds the entry to the directory.
+func (stor *Storage) link(dir *node, name string, n *node) {
+	dir.children[name] = n
+	n.nlink++
+	if n.isDir() {
+		n.parent = dir
+		n.name = name
+	}
+	now := stor.now()
+	dir.mtime, dir.ctime = now, now
+	n.ctime = now
+}
+
+// unlink removes the entry from the directory.
+func (stor *Storage) unlink(dir *node, name string) {
+	n := dir.children[name]
+	delete(dir.children, name)
+	n.nlink--
+	if n.isDir() {
+		n.parent = nil
+	}
+	now := stor.now()
+	dir.mtime, dir.ctime = now, now
+	n.ctime = now
+}
+
+func pathError(op string, path file.Path, err error) error {
+	return &os.PathError{
+		Op:   op,
+		Path: path.LocalPath(),
+		Err:  err,
+	}
+}
+
+// Watch returns an emitter of the changes of the storage inside of
+// the directory. The arguments are the same as of EventEmitter.Watch.
+func (stor *Storage) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc event.ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandlerFunc file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
+) (event.Emitter, error) {
+	evEmitter := newEventEmitter(stor.ctx, stor)
+
+	stor.locker.Lock()
+	stor.emitters = append(stor.emitters, evEmitter)
+	stor.locker.Unlock()
+
+	err := evEmitter.Watch(dirAt, path, shouldWatchFunc, shouldWalkFunc, errorHandlerFunc, opts...)
+	if err != nil {
+		_ = evEmitter.Close()
+		return nil, err
+	}
+	return evEmitter, nil
+}
+
+func (stor *Storage) removeEmitter(evEmitter *EventEmitter) {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+	for idx, candidate := range stor.emitters {
+		if candidate == evEmitter {
+			stor.emitters = append(stor.emitters[:idx], stor.emitters[idx+1:]...)
+			return
+		}
+	}
+}
+
+// Close closes all the emitters returned by Watch.
+func (stor *Storage) Close() error {
+	stor.cancelFn()
+
+	stor.locker.Lock()
+	emitters := append([]*EventEmitter{}, stor.emitters...)
+	stor.locker.Unlock()
+
+	for _, evEmitter := range emitters {
+		_ = evEmitter.Close()
+	}
+	return nil
+}
+
+// ToAbsPath returns the path relative to the root of the storage.
+func (stor *Storage) ToAbsPath(pathRel file.Path) file.Path {
+	return file.Path{""}.Append(pathRel...)
+}
+
+// ToLocalPath returns the path as if the storage was mounted to "/".
+func (stor *Storage) ToLocalPath(path file.Path) string {
+	return stor.ToAbsPath(path).LocalPath()
+}
+
+func (stor *Storage) Open(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	select {
+	case <-ctx.Done():
+		return nil, file.ErrAborted{}
+	default:
+	}
+
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := stor.open(base, path, flags, defaultPerm)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open '%s': %w",
+			path.LocalPath(), pathError("open", path, err))
+	}
+
+	obj := Object{
+		StorageValue: stor,
+		LastInfo:     stor.infoOf(e.name, e.node),
+		LastPath:     e.path(),
+		node:         e.node,
+		flags:        flags,
+	}
+	switch {
+	case e.node.isDir():
+		return &Directory{Object: obj}, nil
+	case e.node.isSymlink():
+		return &Symlink{Object: obj}, nil
+	case flags.HasPath():
+		return &PathDescriptor{Object: obj}, nil
+	}
+	return &File{Object: obj}, nil
+}
+
+func (stor *Storage) open(
+	base *node,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (entry, error) {
+	var depth int
+	e, err := stor.resolveEntry(base, path, flags.HasNoFollow(), &depth)
+	if err != nil {
+		return entry{}, err
+	}
+
+	switch {
+	case e.node == nil && !flags.HasCreate():
+		return entry{}, syscall.ENOENT
+	case e.node == nil:
+		e.node = stor.newNode(defaultPerm & modeMask)
+		stor.link(e.dir, e.name, e.node)
+		stor.notify(event.TypeCreate, e.path(), e.node, nil)
+	case flags.HasCreate() && flags.HasExcl():
+		return entry{}, syscall.EEXIST
+	}
+
+	switch {
+	case flags.HasPath():
+		return e, nil
+	case e.node.isSymlink():
+		// the same as O_NOFOLLOW without O_PATH
+		return entry{}, syscall.ELOOP
+	case e.node.isDir() && isWritable(flags):
+		return entry{}, syscall.EISDIR
+	}
+
+	if e.node.isDir() {
+		return e, nil
+	}
+	stor.notify(event.TypeOpen, e.path(), e.node, nil)
+	if flags.HasTrunc() && isWritable(flags) && len(e.node.data) > 0 {
+		e.node.data = nil
+		now := stor.now()
+		e.node.mtime, e.node.ctime = now, now
+		stor.notify(event.TypeWrite, e.path(), e.node, nil)
+	}
+	return e, nil
+}
+
+// isWritable returns true if the object is opened for writing.
+func isWritable(flags file.OpenFlag) bool {
+	return flags&file.FlagWrite != 0
+}
+
+func (stor *Storage) Stat(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	noFollow bool,
+) (os.FileInfo, error) {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, noFollow, &depth)
+	if err == nil && e.node == nil {
+		err = syscall.ENOENT
+	}
+	if err != nil {
+		return nil, pathError("stat", path, err)
+	}
+	return stor.infoOf(e.name, e.node), nil
+}
+
+func (stor *Storage) Symlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	destination file.Path,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, true, &depth)
+	if err == nil && e.node != nil {
+		err = syscall.EEXIST
+	}
+	if err != nil {
+		return pathError("symlink", path, err)
+	}
+
+	n := stor.newNode(os.ModeSymlink | os.ModePerm)
+	n.target = append(file.Path{}, destination...)
+	stor.link(e.dir, e.name, n)
+	stor.notify(event.TypeCreate, e.path(), n, nil)
+	return nil
+}
+
+func (stor *Storage) Readlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+) (file.Path, error) {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, true, &depth)
+	switch {
+	case err != nil:
+	case e.node == nil:
+		err = syscall.ENOENT
+	case !e.node.isSymlink():
+		err = syscall.EINVAL
+	}
+	if err != nil {
+		return nil, pathError("readlink", path, err)
+	}
+	return append(file.Path{}, e.node.target...), nil
+}
+
+func (stor *Storage) Mkdir(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	perms os.FileMode,
+	recursive bool,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	if !recursive {
+		if err := stor.mkdir(base, path, perms, false); err != nil {
+			return pathError("mkdir", path, err)
+		}
+		return nil
+	}
+
+	for idx := range path {
+		if err := stor.mkdir(base, path[:idx+1], perms, true); err != nil {
+			return pathError("mkdir", path, err)
+		}
+	}
+	return nil
+}
+
+// mkdir creates the directory. If mayExist is true then an existing
+// directory is not an error.
+func (stor *Storage) mkdir(base *node, path file.Path, perms os.FileMode, mayExist bool) error {
+	var depth int
+	e, err := stor.resolveEntry(base, path, !mayExist, &depth)
+	switch {
+	case err != nil:
+		return err
+	case e.node == nil:
+	case mayExist && e.node.isDir():
+		return nil
+	case mayExist:
+		return syscall.ENOTDIR
+	default:
+		return syscall.EEXIST
+	}
+
+	n := stor.newNode(os.ModeDir | perms&modeMask)
+	stor.link(e.dir, e.name, n)
+	stor.notify(event.TypeCreate, e.path(), n, nil)
+	return nil
+}
+
+func (stor *Storage) Remove(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	isRecursive bool,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, true, &depth)
+	switch {
+	case isRecursive && (file.IsNotExist(err) || err == nil && e.node == nil):
+		return nil
+	case err != nil:
+	case e.node == nil:
+		err = syscall.ENOENT
+	case e.dir == nil:
+		err = syscall.EBUSY
+	case !isRecursive && e.node.isDir() && len(e.node.children) > 0:
+		err = syscall.ENOTEMPTY
+	}
+	if err != nil {
+		return pathError("remove", path, err)
+	}
+
+	stor.removeTree(e)
+	return nil
+}
+
+// removeTree removes the entry. The content of a directory is removed
+// first, so the events are reported bottom-up (as by "rm -r").
+func (stor *Storage) removeTree(e entry) {
+	if e.node.isDir() {
+		for _, name := range sortedNames(e.node) {
+			stor.removeTree(entry{dir: e.node, name: name, node: e.node.children[name]})
+		}
+	}
+	path := e.path()
+	stor.unlink(e.dir, e.name)
+	stor.notify(event.TypeDelete, path, e.node, nil)
+}
+
+func (stor *Storage) Rename(
+	ctx context.Context,
+	dirAt file.Object,
+	path, newPath file.Path,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	if err := stor.rename(base, path, newPath); err != nil {
+		return &os.LinkError{
+			Op:  "rename",
+			Old: path.LocalPath(),
+			New: newPath.LocalPath(),
+			Err: err,
+		}
+	}
+	return nil
+}
+
+func (stor *Storage) rename(base *node, path, newPath file.Path) error {
+	var depth int
+	src, err := stor.resolveEntry(base, path, true, &depth)
+	if err != nil {
+		return err
+	}
+	dst, err := stor.resolveEntry(base, newPath, true, &depth)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case src.node == nil:
+		return syscall.ENOENT
+	case src.dir == nil || dst.dir == nil:
+		return syscall.EBUSY
+	case src.node == dst.node:
+		// the same entry or hardlinks of the same file
+		return nil
+	case src.node.isDir() && dst.dir.isInside(src.node):
+		return syscall.EINVAL
+	case dst.node == nil:
+	case src.node.isDir() && !dst.node.isDir():
+		return syscall.ENOTDIR
+	case src.node.isDir() && len(dst.node.children) > 0:
+		return syscall.ENOTEMPTY
+	case !src.node.isDir() && dst.node.isDir():
+		return syscall.EISDIR
+	}
+
+	srcPath := src.path()
+	if dst.node != nil {
+		// the replaced object is not reported (the same as by inotify)
+		stor.unlink(dst.dir, dst.name)
+	}
+	stor.unlink(src.dir, src.name)
+	stor.link(dst.dir, dst.name, src.node)
+	stor.notify(event.TypeMove, srcPath, src.node, dst.path())
+	return nil
+}
+
+func (stor *Storage) Link(
+	ctx context.Context,
+	dirAt file.Object,
+	path, destination file.Path,
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	var depth int
+	src, err := stor.resolveEntry(base, path, true, &depth)
+	var dst entry
+	if err == nil {
+		dst, err = stor.resolveEntry(base, destination, true, &depth)
+	}
+	switch {
+	case err != nil:
+	case src.node == nil:
+		err = syscall.ENOENT
+	case src.node.isDir():
+		err = syscall.EPERM
+	case dst.node != nil:
+		err = syscall.EEXIST
+	}
+	if err != nil {
+		return &os.LinkError{
+			Op:  "link",
+			Old: path.LocalPath(),
+			New: destination.LocalPath(),
+			Err: err,
+		}
+	}
+
+	stor.link(dst.dir, dst.name, src.node)
+	stor.notify(event.TypeCreate, dst.path(), src.node, nil)
+	return nil
+}
+
+// modify resolves the path and calls `fn` for the found node. Then
+// a TypeAttrib event is reported.
+func (stor *Storage) modify(
+	op string,
+	dirAt file.Object,
+	path file.Path,
+	noFollow bool,
+	fn func(n *node),
+) error {
+	stor.locker.Lock()
+	defer stor.locker.Unlock()
+
+	base, err := stor.baseNode(dirAt)
+	if err != nil {
+		return err
+	}
+
+	var depth int
+	e, err := stor.resolveEntry(base, path, noFollow, &depth)
+	if err == nil && e.node == nil {
+		err = syscall.ENOENT
+	}
+	if err != nil {
+		return pathError(op, path, err)
+	}
+
+	fn(e.node)
+	e.node.ctime = stor.now()
+	stor.notify(event.TypeAttrib, e.path(), e.node, nil)
+	return nil
+}
+
+func (stor *Storage) Chmod(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	mode os.FileMode,
+) error {
+	return stor.modify("chmod", dirAt, path, false, func(n *node) {
+		n.chmod(mode)
+	})
+}
+
+func (stor *Storage) Chown(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	uid, gid int,
+	noFollow bool,
+) error {
+	return stor.modify("chown", dirAt, path, noFollow, func(n *node) {
+		n.chown(uid, gid)
+	})
+}
+
+func (stor *Storage) Chtimes(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	atime, mtime time.Time,
+) error {
+	return stor.modify("chtimes", dirAt, path, false, func(n *node) {
+		n.atime, n.mtime = atime, mtime
+	})
+}
@@ -0,0 +1,223 @@
+package memfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageConformance(t *testing.T) {
+	storagetest.Run(t, NewStorage())
+}
+
+func TestStorage(t *testing.T) {
+	ts := time.Unix(1000, 0)
+	stor := NewStorage(OptionNowFunc{Func: func() time.Time { return ts }})
+	ctx := context.Background()
+
+	t.Run("file", func(t *testing.T) {
+		t.Run("create", func(t *testing.T) {
+			obj, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagWrite|file.FlagCreate|file.FlagExcl, 0600)
+			require.NoError(t, err)
+			require.IsType(t, &File{}, obj)
+			_, err = obj.(*File).Write([]byte("hello"))
+			require.NoError(t, err)
+			require.NoError(t, obj.Close())
+
+			_, err = stor.Open(ctx, nil, file.Path{"file"}, file.FlagWrite|file.FlagCreate|file.FlagExcl, 0600)
+			require.True(t, errors.Is(err, os.ErrExist), err)
+		})
+		t.Run("existing", func(t *testing.T) {
+			require.Equal(t, "hello", storagetest.ReadFile(t, stor, file.Path{"file"}))
+
+			obj, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagReadWrite, 0600)
+			require.NoError(t, err)
+			f := obj.(*File)
+			_, err = f.WriteAt([]byte("J"), 0)
+			require.NoError(t, err)
+			offset, err := f.Seek(-1, io.SeekEnd)
+			require.NoError(t, err)
+			require.Equal(t, int64(4), offset)
+			buf := make([]byte, 2)
+			n, err := f.Read(buf)
+			require.Equal(t, 1, n)
+			require.Equal(t, io.EOF, err)
+			require.NoError(t, f.Close())
+
+			require.Equal(t, "Jello", storagetest.ReadFile(t, stor, file.Path{"file"}))
+		})
+		t.Run("not_exist", func(t *testing.T) {
+			_, err := stor.Open(ctx, nil, file.Path{"nothing"}, file.FlagRead, 0000)
+			require.True(t, file.IsNotExist(err), err)
+			_, err = stor.Stat(ctx, nil, file.Path{"file", "nothing"}, false)
+			require.Error(t, err)
+		})
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		t.Run("create", func(t *testing.T) {
+			require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0700, false))
+			require.Error(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0700, false))
+			require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir", "a", "b"}, 0700, true))
+			require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir", "a", "b"}, 0700, true))
+
+			info, err := stor.Stat(ctx, nil, file.Path{"dir", "a"}, false)
+			require.NoError(t, err)
+			require.True(t, info.IsDir())
+			require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+		})
+		t.Run("readdir", func(t *testing.T) {
+			storagetest.WriteFile(t, stor, file.Path{"dir", "c"}, "c")
+
+			obj, err := stor.Open(ctx, nil, file.Path{"dir"}, file.FlagWalkDefaults, 0000)
+			require.NoError(t, err)
+			dir := obj.(*Directory)
+			infos, err := dir.Readdir(1)
+			require.NoError(t, err)
+			require.Len(t, infos, 1)
+			require.Equal(t, "a", infos[0].Name())
+			infos, err = dir.Readdir(-1)
+			require.NoError(t, err)
+			require.Len(t, infos, 1)
+			require.Equal(t, "c", infos[0].Name())
+			_, err = dir.Readdir(1)
+			require.Equal(t, io.EOF, err)
+
+			// relative to the directory
+			obj, err = dir.Open(ctx, file.Path{"..", "file"}, file.FlagRead, 0000)
+			require.NoError(t, err)
+			require.Equal(t, file.Path{"file"}, obj.Path())
+			require.NoError(t, obj.Close())
+			require.NoError(t, dir.Close())
+		})
+		t.Run("remove", func(t *testing.T) {
+			require.Error(t, stor.Remove(ctx, nil, file.Path{"dir", "a"}, false))
+			require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir", "a"}, true))
+			require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir", "a"}, true))
+			_, err := stor.Stat(ctx, nil, file.Path{"dir", "a", "b"}, false)
+			require.True(t, file.IsNotExist(err), err)
+		})
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		t.Run("create", func(t *testing.T) {
+			require.NoError(t, stor.Symlink(ctx, nil, file.Path{"dir", "symlink"}, file.Path{"..", "file"}))
+		})
+		t.Run("open_nofollow", func(t *testing.T) {
+			obj, err := stor.Open(ctx, nil, file.Path{"dir", "symlink"}, file.FlagWrite|file.FlagNoFollow|file.FlagPath, 0600)
+			require.NoError(t, err)
+			require.IsType(t, &Symlink{}, obj)
+			destination, err := obj.(*Symlink).Destination()
+			require.NoError(t, err)
+			require.Equal(t, file.Path{"..", "file"}, destination)
+		})
+		t.Run("open_follow", func(t *testing.T) {
+			obj, err := stor.Open(ctx, nil, file.Path{"dir", "symlink"}, file.FlagWrite, 0600)
+			require.NoError(t, err)
+			require.IsType(t, &File{}, obj)
+			require.Equal(t, file.Path{"file"}, obj.Path())
+		})
+		t.Run("open_abs", func(t *testing.T) {
+			require.NoError(t, stor.Symlink(ctx, nil, file.Path{"dir", "symlink_abs"}, stor.ToAbsPath(file.Path{"file"})))
+			require.Equal(t, "Jello", storagetest.ReadFile(t, stor, file.Path{"dir", "symlink_abs"}))
+		})
+		t.Run("loop", func(t *testing.T) {
+			require.NoError(t, stor.Symlink(ctx, nil, file.Path{"loop"}, file.Path{"loop"}))
+			_, err := stor.Stat(ctx, nil, file.Path{"loop"}, false)
+			require.Error(t, err)
+			require.NoError(t, stor.Remove(ctx, nil, file.Path{"loop"}, false))
+		})
+		t.Run("readlink", func(t *testing.T) {
+			destination, err := stor.Readlink(ctx, nil, file.Path{"dir", "symlink"})
+			require.NoError(t, err)
+			require.Equal(t, file.Path{"..", "file"}, destination)
+		})
+	})
+
+	t.Run("hardlink", func(t *testing.T) {
+		require.NoError(t, stor.Link(ctx, nil, file.Path{"file"}, file.Path{"dir", "link"}))
+		storagetest.WriteFile(t, stor, file.Path{"dir", "link"}, "linked")
+		require.Equal(t, "linked", storagetest.ReadFile(t, stor, file.Path{"file"}))
+
+		info0, err := stor.Stat(ctx, nil, file.Path{"file"}, true)
+		require.NoError(t, err)
+		info1, err := stor.Stat(ctx, nil, file.Path{"dir", "link"}, true)
+		require.NoError(t, err)
+		require.Equal(t, info0.Sys().(*Stat).Ino, info1.Sys().(*Stat).Ino)
+		require.Equal(t, uint64(2), info1.Sys().(*Stat).Nlink)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		obj, err := stor.Open(ctx, nil, file.Path{"dir", "link"}, file.FlagRead, 0000)
+		require.NoError(t, err)
+		require.NoError(t, stor.Rename(ctx, nil, file.Path{"dir", "link"}, file.Path{"moved"}))
+		require.NoError(t, stor.Remove(ctx, nil, file.Path{"moved"}, false))
+		require.NoError(t, stor.Remove(ctx, nil, file.Path{"file"}, false))
+
+		// the opened file is still readable
+		content, err := ioutil.ReadAll(obj.(*File))
+		require.NoError(t, err)
+		require.Equal(t, "linked", string(content))
+		require.NoError(t, obj.Close())
+
+		require.Error(t, stor.Rename(ctx, nil, file.Path{"dir"}, file.Path{"dir", "inside"}))
+	})
+
+	t.Run("attributes", func(t *testing.T) {
+		storagetest.WriteFile(t, stor, file.Path{"attr"}, "")
+		mtime := time.Unix(2000, 0)
+		require.NoError(t, stor.Chmod(ctx, nil, file.Path{"attr"}, 0640))
+		require.NoError(t, stor.Chown(ctx, nil, file.Path{"attr"}, 1, -1, false))
+		require.NoError(t, stor.Chtimes(ctx, nil, file.Path{"attr"}, mtime, mtime))
+
+		info, err := stor.Stat(ctx, nil, file.Path{"attr"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0640), info.Mode())
+		require.Equal(t, mtime, info.ModTime())
+		require.Equal(t, 1, info.Sys().(*Stat).UID)
+		require.Equal(t, os.Getgid(), info.Sys().(*Stat).GID)
+		require.Equal(t, ts, info.Sys().(*Stat).Ctime)
+	})
+}
+
+func TestStorageSnapshot(t *testing.T) {
+	stor := NewStorage()
+	ctx := context.Background()
+
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0755, false))
+	storagetest.WriteFile(t, stor, file.Path{"dir", "a"}, "a")
+	require.NoError(t, stor.Link(ctx, nil, file.Path{"dir", "a"}, file.Path{"b"}))
+	info, err := stor.Stat(ctx, nil, file.Path{"dir", "a"}, false)
+	require.NoError(t, err)
+
+	snapshot := stor.Snapshot()
+
+	storagetest.WriteFile(t, stor, file.Path{"dir", "a"}, "changed")
+	require.NoError(t, stor.Remove(ctx, nil, file.Path{"b"}, false))
+	storagetest.WriteFile(t, stor, file.Path{"c"}, "c")
+
+	stor.Restore(snapshot)
+	require.Equal(t, "a", storagetest.ReadFile(t, stor, file.Path{"dir", "a"}))
+	require.Equal(t, "a", storagetest.ReadFile(t, stor, file.Path{"b"}))
+	_, err = stor.Stat(ctx, nil, file.Path{"c"}, false)
+	require.True(t, file.IsNotExist(err), err)
+
+	// hardlinks and IDs are preserved
+	storagetest.WriteFile(t, stor, file.Path{"b"}, "b")
+	require.Equal(t, "b", storagetest.ReadFile(t, stor, file.Path{"dir", "a"}))
+	restoredInfo, err := stor.Stat(ctx, nil, file.Path{"dir", "a"}, false)
+	require.NoError(t, err)
+	require.Equal(t, info.Sys().(*Stat).Ino, restoredInfo.Sys().(*Stat).Ino)
+
+	// the snapshot is not affected by the changes after restoring
+	stor.Restore(snapshot)
+	require.Equal(t, "a", storagetest.ReadFile(t, stor, file.Path{"b"}))
+}
@@ -0,0 +1,5 @@
+package memfs
+
+func dummyErrorHandler(err error) error {
+	return err
+}
@@ -0,0 +1,436 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrResponse is an error response of the S3 server.
+type ErrResponse struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Key        string
+}
+
+func (err ErrResponse) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("S3 error %d %s on key '%s'", err.StatusCode, err.Code, err.Key)
+	}
+	return fmt.Sprintf("S3 error %d %s on key '%s': %s", err.StatusCode, err.Code, err.Key, err.Message)
+}
+
+// Unwrap returns os.ErrNotExist or os.ErrPermission if the status code
+// is 404 or 403 (so errors.Is works).
+func (err ErrResponse) Unwrap() error {
+	switch err.StatusCode {
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusForbidden:
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// errorResponse is the XML body of an error response.
+type errorResponse struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// object is an object of a bucket listing or a HEAD/GET response.
+type object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+	Meta         metadata
+}
+
+// objectURL returns the URL of the key (of the bucket itself if the
+// key is empty).
+func (stor *Storage) objectURL(key string, query url.Values) *url.URL {
+	u := *stor.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if stor.VirtualHostedStyle {
+		u.Host = stor.bucket + "." + u.Host
+	} else {
+		path += "/" + stor.bucket
+	}
+	path += "/" + key
+	u.Path = path
+	u.RawPath = escapePath(path)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// do sends the request and returns the response if its status is 2xx
+// (or ErrResponse otherwise). The body of the response should be closed
+// by the caller.
+func (stor *Storage) do(
+	ctx context.Context,
+	method string,
+	key string,
+	query url.Values,
+	header http.Header,
+	body io.Reader,
+	size int64,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, stor.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			// otherwise the request is sent without Content-Length
+			req.Body = http.NoBody
+		}
+	}
+	stor.sign(req, time.Now())
+
+	resp, err := stor.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, errResponseOf(resp, key)
+	}
+	return resp, nil
+}
+
+// doXML sends the request and decodes the XML body of the response into
+// `result` (if it is not nil).
+func (stor *Storage) doXML(
+	ctx context.Context,
+	method string,
+	key string,
+	query url.Values,
+	header http.Header,
+	body []byte,
+	result interface{},
+) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	resp, err := stor.do(ctx, method, key, query, header, bodyReader, int64(len(body)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// CopyObject and CompleteMultipartUpload may fail after the status
+	// 200 is sent, so the body is checked for an error
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var errResp errorResponse
+	if xml.Unmarshal(data, &errResp) == nil {
+		return ErrResponse{StatusCode: resp.StatusCode, Code: errResp.Code, Message: errResp.Message, Key: key}
+	}
+	if result == nil {
+		return nil
+	}
+	if err := xml.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("unable to parse the response on key '%s': %w", key, err)
+	}
+	return nil
+}
+
+func errResponseOf(resp *http.Response, key string) ErrResponse {
+	result := ErrResponse{
+		StatusCode: resp.StatusCode,
+		Code:       http.StatusText(resp.StatusCode),
+		Key:        key,
+	}
+	var errResp errorResponse
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if xml.Unmarshal(data, &errResp) == nil {
+		result.Code = errResp.Code
+		result.Message = errResp.Message
+	}
+	return result
+}
+
+// objectOf returns the object described by the headers of a HEAD or
+// GET response.
+func objectOf(key string, resp *http.Response) *object {
+	size := resp.ContentLength
+	if contentRange := resp.Header.Get("Content-Range"); contentRange != "" {
+		// "bytes 0-9/100"
+		if idx := strings.LastIndexByte(contentRange, '/'); idx >= 0 {
+			if total, err := strconv.ParseInt(contentRange[idx+1:], 10, 64); err == nil {
+				size = total
+			}
+		}
+	}
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &object{
+		Key:          key,
+		Size:         size,
+		LastModified: lastModified,
+		ETag:         resp.Header.Get("ETag"),
+		Meta:         metadataOf(resp.Header),
+	}
+}
+
+func (stor *Storage) headObject(ctx context.Context, key string) (*object, error) {
+	resp, err := stor.do(ctx, http.MethodHead, key, nil, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return objectOf(key, resp), nil
+}
+
+// getObject returns the content of the object starting at `offset`
+// (and at most `size` bytes, if `size` is not negative).
+func (stor *Storage) getObject(ctx context.Context, key string, offset, size int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	switch {
+	case size >= 0:
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
+	case offset > 0:
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := stor.do(ctx, http.MethodGet, key, nil, header, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (stor *Storage) putObject(ctx context.Context, key string, body io.Reader, size int64, meta metadata) error {
+	if body == nil {
+		body = http.NoBody
+	}
+	resp, err := stor.do(ctx, http.MethodPut, key, nil, meta.header(), body, size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// copyObject copies the object `srcKey` of size `size` to `key`, replacing
+// its metadata with `meta` if it is not nil (the key may be the same to
+// change only the metadata). The objects larger than
+// Config.MultipartCopyThreshold are copied by parts.
+func (stor *Storage) copyObject(ctx context.Context, srcKey, key string, size int64, meta *metadata) error {
+	if size > stor.multipartCopyThreshold() {
+		if meta == nil {
+			// the metadata of a multipart upload are not copied
+			obj, err := stor.headObject(ctx, srcKey)
+			if err != nil {
+				return err
+			}
+			meta = &obj.Meta
+		}
+		return stor.copyObjectMultipart(ctx, srcKey, key, size, *meta)
+	}
+
+	header := http.Header{}
+	if meta != nil {
+		header = meta.header()
+		header.Set("X-Amz-Metadata-Directive", "REPLACE")
+	}
+	header.Set("X-Amz-Copy-Source", stor.copySourceOf(srcKey))
+	return stor.doXML(ctx, http.MethodPut, key, nil, header, nil, nil)
+}
+
+func (stor *Storage) copySourceOf(key string) string {
+	return escapePath("/" + stor.bucket + "/" + key)
+}
+
+func (stor *Storage) deleteObject(ctx context.Context, key string) error {
+	resp, err := stor.do(ctx, http.MethodDelete, key, nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// listObjects calls fn for the objects and the common prefixes (with
+// an empty object) of the keys starting with `prefix` (ListObjectsV2).
+// It stops at the first error of fn, or after `limit` keys if it is
+// positive.
+func (stor *Storage) listObjects(
+	ctx context.Context,
+	prefix string,
+	delimiter string,
+	limit int,
+	fn func(obj *object, commonPrefix string) error,
+) error {
+	var token string
+	count := 0
+	for {
+		query := url.Values{
+			"list-type": {"2"},
+			"prefix":    {prefix},
+		}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		if limit > 0 {
+			query.Set("max-keys", strconv.Itoa(limit-count))
+		}
+
+		var result listBucketResult
+		if err := stor.doXML(ctx, http.MethodGet, "", query, nil, nil, &result); err != nil {
+			return err
+		}
+		for _, content := range result.Contents {
+			err := fn(&object{
+				Key:          content.Key,
+				Size:         content.Size,
+				LastModified: content.LastModified,
+				ETag:         content.ETag,
+			}, "")
+			if err != nil {
+				return err
+			}
+			count++
+		}
+		for _, commonPrefix := range result.CommonPrefixes {
+			if err := fn(nil, commonPrefix.Prefix); err != nil {
+				return err
+			}
+			count++
+		}
+		if !result.IsTruncated || (limit > 0 && count >= limit) {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name                `xml:"CompleteMultipartUpload"`
+	Parts   []completeMultipartPart `xml:"Part"`
+}
+
+type completeMultipartPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type copyPartResult struct {
+	ETag string `xml:"ETag"`
+}
+
+// putObjectMultipart uploads the object by parts of PartSize.
+func (stor *Storage) putObjectMultipart(ctx context.Context, key string, body io.ReaderAt, size int64, meta metadata) error {
+	return stor.multipartUpload(ctx, key, size, meta, func(query url.Values, offset, n int64) (string, error) {
+		resp, err := stor.do(ctx, http.MethodPut, key, query, nil, io.NewSectionReader(body, offset, n), n)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		return resp.Header.Get("ETag"), nil
+	})
+}
+
+// copyObjectMultipart copies the object `srcKey` to `key` by parts of
+// PartSize (UploadPartCopy), as CopyObject is limited by MaxCopySize.
+func (stor *Storage) copyObjectMultipart(ctx context.Context, srcKey, key string, size int64, meta metadata) error {
+	return stor.multipartUpload(ctx, key, size, meta, func(query url.Values, offset, n int64) (string, error) {
+		header := http.Header{}
+		header.Set("X-Amz-Copy-Source", stor.copySourceOf(srcKey))
+		header.Set("X-Amz-Copy-Source-Range", fmt.Sprintf("bytes=%d-%d", offset, offset+n-1))
+		var result copyPartResult
+		if err := stor.doXML(ctx, http.MethodPut, key, query, header, nil, &result); err != nil {
+			return "", err
+		}
+		return result.ETag, nil
+	})
+}
+
+// multipartUpload creates the object `key` of size `size` by parts of
+// PartSize; uploadPartFn uploads the part at `offset` of size `n` and
+// returns its ETag. The upload is aborted on an error.
+func (stor *Storage) multipartUpload(
+	ctx context.Context,
+	key string,
+	size int64,
+	meta metadata,
+	uploadPartFn func(query url.Values, offset, n int64) (string, error),
+) (_err error) {
+	var initResult initiateMultipartUploadResult
+	err := stor.doXML(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, meta.header(), nil, &initResult)
+	if err != nil {
+		return err
+	}
+	uploadQuery := url.Values{"uploadId": {initResult.UploadID}}
+	defer func() {
+		if _err != nil {
+			// the parts are stored (and billed) until the upload is aborted
+			_ = stor.doXML(context.Background(), http.MethodDelete, key, uploadQuery, nil, nil, nil)
+		}
+	}()
+
+	partSize := stor.partSize()
+	var complete completeMultipartUpload
+	for offset, partNumber := int64(0), 1; offset < size || partNumber == 1; offset, partNumber = offset+partSize, partNumber+1 {
+		n := size - offset
+		if n > partSize {
+			n = partSize
+		}
+		query := url.Values{
+			"partNumber": {strconv.Itoa(partNumber)},
+			"uploadId":   {initResult.UploadID},
+		}
+		etag, err := uploadPartFn(query, offset, n)
+		if err != nil {
+			return err
+		}
+		complete.Parts = append(complete.Parts, completeMultipartPart{
+			PartNumber: partNumber,
+			ETag:       etag,
+		})
+	}
+
+	data, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	return stor.doXML(ctx, http.MethodPost, key, uploadQuery, nil, data, nil)
+}
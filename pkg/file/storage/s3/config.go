@@ -0,0 +1,108 @@
+package s3
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	DefaultRegion   = "us-east-1"
+	DefaultPartSize = 16 << 20
+
+	// MinPartSize is the minimal size of a part of a multipart upload
+	// (except the last one) allowed by S3.
+	MinPartSize = 5 << 20
+
+	// MaxCopySize is the maximal size of an object copied by one request
+	// allowed by S3.
+	MaxCopySize = 5 << 30
+)
+
+type Config struct {
+	// Prefix is the prefix of the keys of the storage within the bucket
+	// (for example, "backups/host1"). Empty means the whole bucket.
+	Prefix string
+
+	// Region is the region used to sign the requests. Empty means
+	// DefaultRegion.
+	Region string
+
+	// AccessKeyID and SecretAccessKey are the credentials of the requests.
+	// The requests are anonymous if AccessKeyID is empty.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// VirtualHostedStyle makes the bucket a part of the host name
+	// ("bucket.s3.example.org"), instead of the path ("s3.example.org/bucket").
+	// The path style is the one supported by most of S3-compatible servers.
+	VirtualHostedStyle bool
+
+	// PartSize is the size of the parts of a multipart upload; the files
+	// which are not larger are uploaded by one request. Zero means
+	// DefaultPartSize.
+	PartSize uint
+
+	// MultipartCopyThreshold is the size of the objects which are copied
+	// (on a rename or a change of the metadata) by parts of PartSize,
+	// instead of one request. Zero means MaxCopySize.
+	MultipartCopyThreshold int64
+
+	// NoDirectoryMarkers disables the marker objects ("dir/") of
+	// directories. Without them a directory exists only while it has
+	// entries, and its metadata (mode, owner and mtime) is not stored.
+	NoDirectoryMarkers bool
+
+	// HTTPClient is the client of the requests. Nil means
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
+
+func (cfg Config) Validate() error {
+	if cfg.PartSize != 0 && cfg.PartSize < MinPartSize {
+		return fmt.Errorf("cfg.PartSize (%d) < MinPartSize (%d)", cfg.PartSize, MinPartSize)
+	}
+	if cfg.MultipartCopyThreshold < 0 || cfg.MultipartCopyThreshold > MaxCopySize {
+		return fmt.Errorf("cfg.MultipartCopyThreshold (%d) is not in [0, MaxCopySize (%d)]",
+			cfg.MultipartCopyThreshold, int64(MaxCopySize))
+	}
+	if cfg.SecretAccessKey != "" && cfg.AccessKeyID == "" {
+		return fmt.Errorf("cfg.SecretAccessKey is set, but cfg.AccessKeyID is empty")
+	}
+	return nil
+}
+
+func (cfg Config) region() string {
+	if cfg.Region == "" {
+		return DefaultRegion
+	}
+	return cfg.Region
+}
+
+func (cfg Config) partSize() int64 {
+	if cfg.PartSize == 0 {
+		return DefaultPartSize
+	}
+	return int64(cfg.PartSize)
+}
+
+func (cfg Config) multipartCopyThreshold() int64 {
+	if cfg.MultipartCopyThreshold == 0 {
+		return MaxCopySize
+	}
+	return cfg.MultipartCopyThreshold
+}
+
+func (cfg Config) httpClient() *http.Client {
+	if cfg.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return cfg.HTTPClient
+}
@@ -0,0 +1,89 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Directory = &Directory{}
+
+type Directory struct {
+	Object
+
+	// entries are the entries not yet returned by Readdir (nil until
+	// the first call)
+	entries []os.FileInfo
+}
+
+// Readdir has the same semantics as os.File.Readdir. The directory is
+// listed entirely on the first call; the metadata of each entry are
+// requested separately (S3 does not list them).
+func (dir *Directory) Readdir(n int) ([]os.FileInfo, error) {
+	if dir.entries == nil {
+		entries, err := dir.list()
+		if err != nil {
+			return nil, err
+		}
+		dir.entries = entries
+	}
+
+	if n <= 0 || n > len(dir.entries) {
+		if n > 0 && len(dir.entries) == 0 {
+			return nil, io.EOF
+		}
+		n = len(dir.entries)
+	}
+	result := dir.entries[:n:n]
+	dir.entries = dir.entries[n:]
+	return result, nil
+}
+
+func (dir *Directory) list() ([]os.FileInfo, error) {
+	stor := dir.StorageValue
+	ctx := stor.ctx
+	prefix := stor.dirPrefixOf(dir.LastPath)
+	var names []string
+	err := stor.listObjects(ctx, prefix, "/", 0, func(obj *object, commonPrefix string) error {
+		var name string
+		if obj != nil {
+			name = strings.TrimPrefix(obj.Key, prefix)
+		} else {
+			name = strings.TrimSuffix(strings.TrimPrefix(commonPrefix, prefix), "/")
+		}
+		// the marker itself and the keys with empty components
+		if name != "" {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errorOf("readdir", prefix, err)
+	}
+
+	entries := make([]os.FileInfo, 0, len(names))
+	for _, name := range names {
+		info, err := stor.statNoFollow(ctx, dir.LastPath.Append(name))
+		switch {
+		case os.IsNotExist(err):
+			// removed after the listing
+			continue
+		case err != nil:
+			return nil, err
+		}
+		entries = append(entries, info)
+	}
+	return entries, nil
+}
+
+func (dir *Directory) Open(
+	ctx context.Context,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return dir.StorageValue.Open(ctx, dir, path, flags, defaultPerm)
+}
@@ -0,0 +1,36 @@
+package s3
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"syscall"
+)
+
+// errorOf converts an error of a request to an *os.PathError (so
+// os.IsNotExist and similar work).
+func errorOf(op string, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	var respErr ErrResponse
+	if errors.As(err, &respErr) {
+		switch respErr.StatusCode {
+		case http.StatusNotFound:
+			err = syscall.ENOENT
+		case http.StatusForbidden:
+			err = syscall.EACCES
+		}
+	}
+	return &os.PathError{Op: op, Path: key, Err: err}
+}
+
+// isNotFound returns true if the error is the response 404.
+func isNotFound(err error) bool {
+	var respErr ErrResponse
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,350 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeServer is an in-memory S3 stand-in: a single bucket with the
+// operations used by Storage.
+type fakeServer struct {
+	locker sync.Mutex
+	bucket string
+
+	// maxKeys is the page size of the listings (to test the pagination)
+	maxKeys int
+	// maxCopySize (if positive) is the size limit of CopyObject
+	maxCopySize int
+
+	objects      map[string]*fakeObject
+	uploads      map[string]*fakeUpload
+	nextUploadID int
+
+	// multipartCount is the amount of completed multipart uploads
+	multipartCount int
+	// authorizations are the Authorization headers of the requests
+	authorizations []string
+}
+
+type fakeObject struct {
+	data    []byte
+	meta    http.Header
+	modTime time.Time
+}
+
+type fakeUpload struct {
+	key   string
+	meta  http.Header
+	parts map[int][]byte
+}
+
+func newFakeServer(bucket string) *fakeServer {
+	return &fakeServer{
+		bucket:  bucket,
+		maxKeys: 2,
+		objects: map[string]*fakeObject{},
+		uploads: map[string]*fakeUpload{},
+	}
+}
+
+func (srv *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.locker.Lock()
+	defer srv.locker.Unlock()
+	srv.authorizations = append(srv.authorizations, r.Header.Get("Authorization"))
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+srv.bucket)
+	if path == r.URL.Path {
+		srv.writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	key := strings.TrimPrefix(path, "/")
+	query := r.URL.Query()
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		srv.list(w, query)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		srv.get(w, r, key)
+	case r.Method == http.MethodPut && query.Get("uploadId") != "":
+		srv.uploadPart(w, r, query)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		srv.copy(w, r, key)
+	case r.Method == http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		srv.objects[key] = &fakeObject{data: data, meta: metaHeaderOf(r.Header), modTime: time.Now()}
+	case r.Method == http.MethodPost && query["uploads"] != nil:
+		srv.nextUploadID++
+		uploadID := strconv.Itoa(srv.nextUploadID)
+		srv.uploads[uploadID] = &fakeUpload{key: key, meta: metaHeaderOf(r.Header), parts: map[int][]byte{}}
+		srv.writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Key      string
+			UploadID string `xml:"UploadId"`
+		}{Key: key, UploadID: uploadID})
+	case r.Method == http.MethodPost && query.Get("uploadId") != "":
+		srv.completeUpload(w, r, key, query.Get("uploadId"))
+	case r.Method == http.MethodDelete && query.Get("uploadId") != "":
+		delete(srv.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(srv.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		srv.writeError(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (srv *fakeServer) writeError(w http.ResponseWriter, statusCode int, code string) {
+	w.WriteHeader(statusCode)
+	_ = xml.NewEncoder(w).Encode(errorResponse{Code: code, Message: code})
+}
+
+func (srv *fakeServer) writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func metaHeaderOf(header http.Header) http.Header {
+	result := http.Header{}
+	for name, values := range header {
+		if strings.HasPrefix(name, "X-Amz-Meta-") {
+			result[name] = values
+		}
+	}
+	return result
+}
+
+func etagOf(data []byte) string {
+	hash := md5.Sum(data)
+	return `"` + hex.EncodeToString(hash[:]) + `"`
+}
+
+func (srv *fakeServer) get(w http.ResponseWriter, r *http.Request, key string) {
+	obj := srv.objects[key]
+	if obj == nil {
+		srv.writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	for name, values := range obj.meta {
+		w.Header()[name] = values
+	}
+	w.Header().Set("ETag", etagOf(obj.data))
+	w.Header().Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+
+	data := obj.data
+	statusCode := http.StatusOK
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		var start, end int
+		if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end); err != nil {
+			end = len(data) - 1
+		}
+		if end >= len(data) {
+			end = len(data) - 1
+		}
+		if start > end {
+			srv.writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		statusCode = http.StatusPartialContent
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(statusCode)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+// copySourceOf returns the object of the header X-Amz-Copy-Source (or
+// writes an error and returns nil).
+func (srv *fakeServer) copySourceOf(w http.ResponseWriter, r *http.Request) *fakeObject {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil || !strings.HasPrefix(source, "/"+srv.bucket+"/") {
+		srv.writeError(w, http.StatusBadRequest, "InvalidArgument")
+		return nil
+	}
+	srcObj := srv.objects[strings.TrimPrefix(source, "/"+srv.bucket+"/")]
+	if srcObj == nil {
+		srv.writeError(w, http.StatusNotFound, "NoSuchKey")
+		return nil
+	}
+	return srcObj
+}
+
+func (srv *fakeServer) copy(w http.ResponseWriter, r *http.Request, key string) {
+	srcObj := srv.copySourceOf(w, r)
+	if srcObj == nil {
+		return
+	}
+	if srv.maxCopySize > 0 && len(srcObj.data) > srv.maxCopySize {
+		srv.writeError(w, http.StatusBadRequest, "InvalidRequest")
+		return
+	}
+	meta := srcObj.meta
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		meta = metaHeaderOf(r.Header)
+	}
+	srv.objects[key] = &fakeObject{data: srcObj.data, meta: meta, modTime: time.Now()}
+	srv.writeXML(w, struct {
+		XMLName xml.Name `xml:"CopyObjectResult"`
+		ETag    string
+	}{ETag: etagOf(srcObj.data)})
+}
+
+func (srv *fakeServer) uploadPart(w http.ResponseWriter, r *http.Request, query url.Values) {
+	upload := srv.uploads[query.Get("uploadId")]
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if upload == nil || err != nil {
+		srv.writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	if r.Header.Get("X-Amz-Copy-Source") == "" {
+		data, _ := ioutil.ReadAll(r.Body)
+		upload.parts[partNumber] = data
+		w.Header().Set("ETag", etagOf(data))
+		return
+	}
+
+	// UploadPartCopy
+	srcObj := srv.copySourceOf(w, r)
+	if srcObj == nil {
+		return
+	}
+	var start, end int
+	_, err = fmt.Sscanf(r.Header.Get("X-Amz-Copy-Source-Range"), "bytes=%d-%d", &start, &end)
+	if err != nil || start > end || end >= len(srcObj.data) {
+		srv.writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+		return
+	}
+	data := append([]byte{}, srcObj.data[start:end+1]...)
+	upload.parts[partNumber] = data
+	srv.writeXML(w, struct {
+		XMLName xml.Name `xml:"CopyPartResult"`
+		ETag    string
+	}{ETag: etagOf(data)})
+}
+
+func (srv *fakeServer) completeUpload(w http.ResponseWriter, r *http.Request, key string, uploadID string) {
+	upload := srv.uploads[uploadID]
+	var complete completeMultipartUpload
+	body, _ := ioutil.ReadAll(r.Body)
+	if upload == nil || upload.key != key || xml.Unmarshal(body, &complete) != nil {
+		srv.writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	var data []byte
+	for idx, part := range complete.Parts {
+		partData, ok := upload.parts[part.PartNumber]
+		if !ok || part.ETag != etagOf(partData) {
+			srv.writeError(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		if idx < len(complete.Parts)-1 && len(partData) < MinPartSize {
+			// S3 reports it with the status 200
+			srv.writeXML(w, errorResponse{Code: "EntityTooSmall"})
+			return
+		}
+		data = append(data, partData...)
+	}
+	delete(srv.uploads, uploadID)
+	srv.objects[key] = &fakeObject{data: data, meta: upload.meta, modTime: time.Now()}
+	srv.multipartCount++
+	srv.writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Key     string
+	}{Key: key})
+}
+
+func (srv *fakeServer) list(w http.ResponseWriter, query url.Values) {
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	token := query.Get("continuation-token")
+	maxKeys := srv.maxKeys
+	if value, err := strconv.Atoi(query.Get("max-keys")); err == nil && value < maxKeys {
+		maxKeys = value
+	}
+
+	var keys []string
+	for key := range srv.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string
+		LastModified time.Time
+		ETag         string
+		Size         int
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	var result struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		IsTruncated           bool
+		NextContinuationToken string         `xml:",omitempty"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}
+	count := 0
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= token {
+			continue
+		}
+		if count == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		if idx := strings.Index(key[len(prefix):], delimiter); delimiter != "" && idx >= 0 {
+			commonPrefixValue := key[:len(prefix)+idx+len(delimiter)]
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: commonPrefixValue})
+			// the next page starts after all the keys of the common prefix
+			result.NextContinuationToken = commonPrefixValue + "\xff"
+			token = result.NextContinuationToken
+		} else {
+			obj := srv.objects[key]
+			result.Contents = append(result.Contents, content{
+				Key:          key,
+				LastModified: obj.modTime,
+				ETag:         etagOf(obj.data),
+				Size:         len(obj.data),
+			})
+			result.NextContinuationToken = key
+		}
+		count++
+	}
+	if !result.IsTruncated {
+		result.NextContinuationToken = ""
+	}
+	srv.writeXML(w, result)
+}
+
+// keys returns the keys of the stored objects.
+func (srv *fakeServer) keys() []string {
+	srv.locker.Lock()
+	defer srv.locker.Unlock()
+	var keys []string
+	for key := range srv.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (srv *fakeServer) object(key string) *fakeObject {
+	srv.locker.Lock()
+	defer srv.locker.Unlock()
+	return srv.objects[key]
+}
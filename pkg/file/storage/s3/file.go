@@ -0,0 +1,342 @@
+package s3
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.File = &File{}
+
+// File is a regular file (an object of the bucket).
+//
+// The reads are ranged GET requests. The written content is collected
+// in a local temporary file (with the existing content, unless the file
+// is new or truncated) and is uploaded on Sync and Close.
+type File struct {
+	Object
+
+	locker  sync.Mutex
+	flags   file.OpenFlag
+	offset  int64
+	meta    metadata
+	isDirty bool
+
+	// buffer is the local copy of the content (nil until the first write)
+	buffer *os.File
+}
+
+func newFile(obj Object, flags file.OpenFlag, isNew bool) *File {
+	f := &File{
+		Object: obj,
+		flags:  flags,
+		meta:   obj.LastInfo.(*fileInfo).meta,
+	}
+	if !isNew && flags.HasTrunc() && f.isWritable() && obj.LastInfo.Size() > 0 {
+		// the truncation is uploaded even if nothing is written
+		f.isDirty = true
+	}
+	return f
+}
+
+func (f *File) isWritable() bool {
+	return f.flags&(file.FlagWrite|file.FlagAppend) != 0
+}
+
+func (f *File) key() string {
+	return f.StorageValue.keyOf(f.LastPath)
+}
+
+func (f *File) Stat() (os.FileInfo, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	if f.buffer == nil && !f.isDirty {
+		return f.Object.Stat()
+	}
+
+	// the content is not uploaded, yet
+	info := *f.LastInfo.(*fileInfo)
+	size, err := f.size()
+	if err != nil {
+		return nil, err
+	}
+	info.size = size
+	if f.meta.HasMode {
+		info.mode = f.meta.Mode &^ os.ModeType
+	}
+	return &info, nil
+}
+
+// size returns the current size of the content.
+func (f *File) size() (int64, error) {
+	switch {
+	case f.buffer != nil:
+		info, err := f.buffer.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	case f.isDirty:
+		// truncated
+		return 0, nil
+	}
+	return f.LastInfo.Size(), nil
+}
+
+// Close uploads the written content.
+func (f *File) Close() error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	err := f.upload()
+	if f.buffer != nil {
+		_ = f.buffer.Close()
+		_ = os.Remove(f.buffer.Name())
+		f.buffer = nil
+	}
+	return err
+}
+
+// Chmod is applied on the upload if the file has written content.
+func (f *File) Chmod(mode os.FileMode) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	f.meta.Mode = mode &^ os.ModeType
+	f.meta.HasMode = true
+	if f.isDirty {
+		return nil
+	}
+	return f.Object.Chmod(mode)
+}
+
+// Chown is applied on the upload if the file has written content.
+func (f *File) Chown(uid, gid int) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	if uid != -1 {
+		f.meta.UID = uid
+		f.meta.HasUID = true
+	}
+	if gid != -1 {
+		f.meta.GID = gid
+		f.meta.HasGID = true
+	}
+	if f.isDirty {
+		return nil
+	}
+	return f.Object.Chown(uid, gid)
+}
+
+func (f *File) Read(b []byte) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	n, err := f.readAt(b, f.offset)
+	f.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *File) ReadAt(b []byte, offset int64) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	return f.readAt(b, offset)
+}
+
+// readAt has the semantics of io.ReaderAt.
+func (f *File) readAt(b []byte, offset int64) (int, error) {
+	if f.flags&(file.FlagWrite|file.FlagAppend) != 0 && !f.flags.HasRead() {
+		return 0, &os.PathError{Op: "read", Path: f.key(), Err: syscall.EBADF}
+	}
+	if f.buffer != nil {
+		return f.buffer.ReadAt(b, offset)
+	}
+	size, err := f.size()
+	if err != nil {
+		return 0, err
+	}
+	if offset >= size || len(b) == 0 {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+
+	length := int64(len(b))
+	if offset+length > size {
+		length = size - offset
+	}
+	stor := f.StorageValue
+	body, err := stor.getObject(stor.ctx, f.key(), offset, length)
+	if err != nil {
+		return 0, errorOf("read", f.key(), err)
+	}
+	defer body.Close()
+	n, err := io.ReadFull(body, b[:length])
+	if err != nil {
+		// the object was changed concurrently
+		return n, errorOf("read", f.key(), err)
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *File) Write(b []byte) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	offset := f.offset
+	if f.flags.HasAppend() {
+		size, err := f.size()
+		if err != nil {
+			return 0, err
+		}
+		offset = size
+	}
+	n, err := f.writeAt(b, offset)
+	f.offset = offset + int64(n)
+	return n, err
+}
+
+func (f *File) WriteAt(b []byte, offset int64) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	return f.writeAt(b, offset)
+}
+
+func (f *File) writeAt(b []byte, offset int64) (int, error) {
+	if !f.isWritable() {
+		return 0, &os.PathError{Op: "write", Path: f.key(), Err: syscall.EBADF}
+	}
+	if err := f.initBuffer(); err != nil {
+		return 0, err
+	}
+	f.isDirty = true
+	return f.buffer.WriteAt(b, offset)
+}
+
+// initBuffer creates the local copy of the content.
+func (f *File) initBuffer() error {
+	if f.buffer != nil {
+		return nil
+	}
+	buffer, err := ioutil.TempFile("", "fsutil-s3-")
+	if err != nil {
+		return fmt.Errorf("unable to create a temporary file: %w", err)
+	}
+	size, err := f.size()
+	if err == nil && size > 0 {
+		// the existing content
+		var body io.ReadCloser
+		stor := f.StorageValue
+		body, err = stor.getObject(stor.ctx, f.key(), 0, -1)
+		if err == nil {
+			_, err = io.Copy(buffer, body)
+			_ = body.Close()
+			err = errorOf("read", f.key(), err)
+		}
+	}
+	if err != nil {
+		_ = buffer.Close()
+		_ = os.Remove(buffer.Name())
+		return err
+	}
+	f.buffer = buffer
+	return nil
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		size, err := f.size()
+		if err != nil {
+			return 0, err
+		}
+		offset += size
+	default:
+		return 0, &os.PathError{Op: "seek", Path: f.key(), Err: syscall.EINVAL}
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.key(), Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Sync uploads the written content.
+func (f *File) Sync() error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	return f.upload()
+}
+
+// upload stores the content by one request or by a multipart upload (if
+// it is larger than Config.PartSize).
+func (f *File) upload() error {
+	if !f.isDirty {
+		return nil
+	}
+	stor := f.StorageValue
+	key := f.key()
+	size, err := f.size()
+	if err != nil {
+		return err
+	}
+	meta := f.meta
+	meta.Mtime = time.Now()
+
+	var body io.ReaderAt = emptyReaderAt{}
+	if f.buffer != nil {
+		body = f.buffer
+	}
+	if size > stor.partSize() {
+		err = stor.putObjectMultipart(stor.ctx, key, body, size, meta)
+	} else {
+		err = stor.putObject(stor.ctx, key, io.NewSectionReader(body, 0, size), size, meta)
+	}
+	if err != nil {
+		return errorOf("write", key, err)
+	}
+	f.isDirty = false
+
+	obj, err := stor.headObject(stor.ctx, key)
+	if err != nil {
+		return errorOf("stat", key, err)
+	}
+	f.LastInfo = fileInfoOf(f.LastInfo.Name(), obj, false)
+	f.meta = obj.Meta
+	return nil
+}
+
+// emptyReaderAt is the content of a truncated file.
+type emptyReaderAt struct{}
+
+func (emptyReaderAt) ReadAt(b []byte, offset int64) (int, error) {
+	return 0, io.EOF
+}
+
+// SetDeadline is not supported: the requests are limited only by
+// Config.HTTPClient.
+func (f *File) SetDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetReadDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetWriteDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
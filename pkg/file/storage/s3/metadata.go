@@ -0,0 +1,183 @@
+package s3
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// The user-metadata (the "X-Amz-Meta-*" headers) of the objects.
+const (
+	metaMode    = "X-Amz-Meta-Mode"
+	metaUID     = "X-Amz-Meta-Uid"
+	metaGID     = "X-Amz-Meta-Gid"
+	metaMtime   = "X-Amz-Meta-Mtime"
+	metaSymlink = "X-Amz-Meta-Symlink"
+)
+
+// metadata are the attributes of an object which S3 does not store
+// itself. The zero values mean "unknown".
+type metadata struct {
+	Mode    os.FileMode
+	HasMode bool
+	UID     int
+	GID     int
+	HasUID  bool
+	HasGID  bool
+	Mtime   time.Time
+
+	// Symlink is the destination of a symlink (the object is a symlink
+	// if it is not nil)
+	Symlink file.Path
+}
+
+func metadataOf(header http.Header) metadata {
+	var meta metadata
+	if mode, err := strconv.ParseUint(header.Get(metaMode), 8, 32); err == nil {
+		meta.Mode = os.FileMode(mode)
+		meta.HasMode = true
+	}
+	if uid, err := strconv.Atoi(header.Get(metaUID)); err == nil {
+		meta.UID = uid
+		meta.HasUID = true
+	}
+	if gid, err := strconv.Atoi(header.Get(metaGID)); err == nil {
+		meta.GID = gid
+		meta.HasGID = true
+	}
+	if mtime, err := strconv.ParseInt(header.Get(metaMtime), 10, 64); err == nil {
+		meta.Mtime = time.Unix(0, mtime)
+	}
+	if destination, ok := header[metaSymlink]; ok && len(destination) > 0 {
+		// the metadata are ASCII only
+		if unescaped, err := url.PathUnescape(destination[0]); err == nil {
+			meta.Symlink = strings.Split(unescaped, "/")
+		}
+	}
+	return meta
+}
+
+// header returns the headers to store the metadata.
+func (meta metadata) header() http.Header {
+	header := http.Header{}
+	if meta.HasMode {
+		header.Set(metaMode, strconv.FormatUint(uint64(meta.Mode), 8))
+	}
+	if meta.HasUID {
+		header.Set(metaUID, strconv.Itoa(meta.UID))
+	}
+	if meta.HasGID {
+		header.Set(metaGID, strconv.Itoa(meta.GID))
+	}
+	if !meta.Mtime.IsZero() {
+		header.Set(metaMtime, strconv.FormatInt(meta.Mtime.UnixNano(), 10))
+	}
+	if meta.Symlink != nil {
+		header.Set(metaSymlink, url.PathEscape(strings.Join(meta.Symlink, "/")))
+	}
+	return header
+}
+
+// Stat is the result of os.FileInfo.Sys() of the objects of a Storage.
+type Stat struct {
+	// Key is the key of the object (of the marker object, for
+	// a directory); empty if the object is not stored (for example,
+	// a directory without a marker).
+	Key  string
+	ETag string
+
+	// UID and GID are -1 if they are not stored.
+	UID int
+	GID int
+}
+
+var _ os.FileInfo = &fileInfo{}
+
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+	stat  Stat
+
+	// meta are the stored metadata of the object (to preserve them on
+	// updates)
+	meta metadata
+}
+
+// fileInfoOf returns the os.FileInfo of the object. The permissions of
+// objects without the stored mode are 0644 (0755 for directories), and
+// the mtime is LastModified.
+func fileInfoOf(name string, obj *object, isDir bool) *fileInfo {
+	info := &fileInfo{
+		name: name,
+		stat: Stat{UID: -1, GID: -1},
+	}
+	if obj == nil {
+		info.mode = os.ModeDir | 0755
+		return info
+	}
+
+	meta := obj.Meta
+	info.meta = meta
+	info.size = obj.Size
+	info.mtime = obj.LastModified
+	info.stat.Key = obj.Key
+	info.stat.ETag = obj.ETag
+	switch {
+	case meta.HasMode:
+		info.mode = meta.Mode
+	case isDir:
+		info.mode = 0755
+	default:
+		info.mode = 0644
+	}
+	switch {
+	case isDir:
+		info.mode = info.mode&^os.ModeType | os.ModeDir
+		info.size = 0
+	case meta.Symlink != nil:
+		info.mode = info.mode&^os.ModeType | os.ModeSymlink
+		info.size = int64(len(strings.Join(meta.Symlink, "/")))
+	}
+	if !meta.Mtime.IsZero() {
+		info.mtime = meta.Mtime
+	}
+	if meta.HasUID {
+		info.stat.UID = meta.UID
+	}
+	if meta.HasGID {
+		info.stat.GID = meta.GID
+	}
+	return info
+}
+
+func (info *fileInfo) Name() string {
+	return info.name
+}
+
+func (info *fileInfo) Size() int64 {
+	return info.size
+}
+
+func (info *fileInfo) Mode() os.FileMode {
+	return info.mode
+}
+
+func (info *fileInfo) ModTime() time.Time {
+	return info.mtime
+}
+
+func (info *fileInfo) IsDir() bool {
+	return info.mode.IsDir()
+}
+
+// Sys returns *Stat.
+func (info *fileInfo) Sys() interface{} {
+	return &info.stat
+}
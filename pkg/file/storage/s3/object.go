@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Object = &Object{}
+
+// Object is an object of the bucket accessed by its path.
+type Object struct {
+	StorageValue *Storage
+	LastInfo     os.FileInfo
+	LastPath     file.Path
+}
+
+// ID returns the zero ID: S3 has no inode numbers.
+func (obj *Object) ID() file.ObjectID {
+	return file.ObjectID{}
+}
+
+func (obj *Object) Name() string {
+	return obj.LastInfo.Name()
+}
+
+// Stat does not follow the object if it is a symlink.
+func (obj *Object) Stat() (os.FileInfo, error) {
+	isSymlink := obj.LastInfo.Mode()&os.ModeSymlink != 0
+	info, _, err := obj.StorageValue.stat(obj.StorageValue.ctx, obj.LastPath, isSymlink)
+	if err != nil {
+		return nil, err
+	}
+	obj.LastInfo = info
+	return info, nil
+}
+
+func (obj *Object) LastStat() os.FileInfo {
+	return obj.LastInfo
+}
+
+// Path returns the path of the object (of the destination, if it was
+// opened by a symlink).
+func (obj *Object) Path() file.Path {
+	return obj.LastPath
+}
+
+// Close does nothing: the objects are not opened on the server (see
+// File.Close).
+func (obj *Object) Close() error {
+	return nil
+}
+
+func (obj *Object) Chmod(mode os.FileMode) error {
+	stor := obj.StorageValue
+	return stor.Chmod(stor.ctx, nil, obj.LastPath, mode)
+}
+
+func (obj *Object) Chown(uid, gid int) error {
+	stor := obj.StorageValue
+	isSymlink := obj.LastInfo.Mode()&os.ModeSymlink != 0
+	return stor.Chown(stor.ctx, nil, obj.LastPath, uid, gid, isSymlink)
+}
+
+func (obj *Object) Storage() file.Storage {
+	return obj.StorageValue
+}
+
+// FD returns an invalid file descriptor: the object is on another
+// machine.
+func (obj *Object) FD() uintptr {
+	return ^uintptr(0)
+}
@@ -0,0 +1,75 @@
+package s3
+
+import (
+	"net/http"
+)
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionPrefix struct {
+	Prefix string
+}
+
+func (opt OptionPrefix) apply(cfg *Config) {
+	cfg.Prefix = opt.Prefix
+}
+
+type OptionRegion struct {
+	Region string
+}
+
+func (opt OptionRegion) apply(cfg *Config) {
+	cfg.Region = opt.Region
+}
+
+type OptionCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+func (opt OptionCredentials) apply(cfg *Config) {
+	cfg.AccessKeyID = opt.AccessKeyID
+	cfg.SecretAccessKey = opt.SecretAccessKey
+}
+
+type OptionVirtualHostedStyle struct {
+	Enable bool
+}
+
+func (opt OptionVirtualHostedStyle) apply(cfg *Config) {
+	cfg.VirtualHostedStyle = opt.Enable
+}
+
+type OptionPartSize struct {
+	Size uint
+}
+
+func (opt OptionPartSize) apply(cfg *Config) {
+	cfg.PartSize = opt.Size
+}
+
+type OptionMultipartCopyThreshold struct {
+	Size int64
+}
+
+func (opt OptionMultipartCopyThreshold) apply(cfg *Config) {
+	cfg.MultipartCopyThreshold = opt.Size
+}
+
+type OptionNoDirectoryMarkers struct {
+	Enable bool
+}
+
+func (opt OptionNoDirectoryMarkers) apply(cfg *Config) {
+	cfg.NoDirectoryMarkers = opt.Enable
+}
+
+type OptionHTTPClient struct {
+	Client *http.Client
+}
+
+func (opt OptionHTTPClient) apply(cfg *Config) {
+	cfg.HTTPClient = opt.Client
+}
@@ -0,0 +1,23 @@
+package s3
+
+import (
+	"context"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.PathDescriptor = &PathDescriptor{}
+
+// PathDescriptor is an object opened with file.FlagPath.
+type PathDescriptor struct {
+	Object
+}
+
+func (pathDesc *PathDescriptor) Open(
+	ctx context.Context,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return pathDesc.StorageValue.Open(ctx, nil, pathDesc.LastPath, flags&^file.FlagPath, defaultPerm)
+}
@@ -0,0 +1,671 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// maxSymlinkDepth is the maximal amount of symlinks followed while
+// resolving a path.
+const maxSymlinkDepth = 40
+
+var _ file.Storage = &Storage{}
+
+// Storage is a file.Storage of a bucket of an S3-compatible object
+// storage (AWS S3, MinIO, Ceph RGW and so on).
+//
+// A path is mapped to the key of its components joined with "/" (after
+// Config.Prefix). A directory is the prefix of the keys of its entries
+// with an empty marker object "dir/" (unless Config.NoDirectoryMarkers),
+// and the parent directories of an object are not required to exist.
+// The mode, the owner and the mtime of the objects, and the destinations
+// of symlinks are stored in the user-metadata of the objects. The files
+// larger than Config.PartSize are uploaded by multipart uploads.
+//
+// Only the symlinks in the last component of a path are followed (the
+// keys of the objects are not resolved component by component), the
+// absolute destinations are relative to the root of the storage.
+// Renames are not atomic: the objects are copied and then removed.
+// Hardlinks are not supported (Link returns file.ErrNotImplemented).
+type Storage struct {
+	Config
+	ctx      context.Context
+	cancelFn context.CancelFunc
+	endpoint *url.URL
+	bucket   string
+	prefix   string
+}
+
+// NewStorage returns the storage of the bucket of the S3 server
+// `endpoint` (for example, "https://s3.eu-west-1.amazonaws.com" or
+// "http://127.0.0.1:9000").
+func NewStorage(endpoint string, bucket string, opts ...Option) (*Storage, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the endpoint '%s': %w", endpoint, err)
+	}
+	if endpointURL.Scheme != "http" && endpointURL.Scheme != "https" {
+		return nil, fmt.Errorf("the endpoint '%s' is not an HTTP(S) URL", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("the bucket is not set")
+	}
+
+	stor := &Storage{
+		Config:   *NewConfig(opts...),
+		endpoint: endpointURL,
+		bucket:   bucket,
+	}
+	if err := stor.Config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	stor.prefix = strings.Trim(stor.Prefix, "/")
+	stor.ctx, stor.cancelFn = context.WithCancel(context.Background())
+	return stor, nil
+}
+
+// Close aborts the requests of the opened objects.
+func (stor *Storage) Close() error {
+	stor.cancelFn()
+	return nil
+}
+
+// Bucket returns the name of the bucket of the storage.
+func (stor *Storage) Bucket() string {
+	return stor.bucket
+}
+
+// ToLocalPath returns the key of the object of the path.
+func (stor *Storage) ToLocalPath(path file.Path) string {
+	return stor.keyOf(path)
+}
+
+// keyOf returns the key of the object of the path.
+func (stor *Storage) keyOf(path file.Path) string {
+	if stor.prefix == "" {
+		return strings.Join(path, "/")
+	}
+	if len(path) == 0 {
+		return stor.prefix
+	}
+	return stor.prefix + "/" + strings.Join(path, "/")
+}
+
+// dirPrefixOf returns the prefix of the keys of the entries of the
+// directory (it is also the key of its marker).
+func (stor *Storage) dirPrefixOf(path file.Path) string {
+	key := stor.keyOf(path)
+	if key == "" {
+		return ""
+	}
+	return key + "/"
+}
+
+// fullPathOf returns the path relative to the root of the storage. Only
+// the directories of the storage are supported as `dirAt`.
+func (stor *Storage) fullPathOf(dirAt file.Object, path file.Path) (file.Path, error) {
+	if dirAt == nil {
+		return path, nil
+	}
+	dir, ok := dirAt.(*Directory)
+	if !ok || dir.StorageValue != stor {
+		return nil, file.ErrNotImplemented{}
+	}
+	return dir.LastPath.Append(path...), nil
+}
+
+// stat returns the info of the object of the path and the path itself
+// after following the symlink (unless noFollow).
+func (stor *Storage) stat(ctx context.Context, path file.Path, noFollow bool) (*fileInfo, file.Path, error) {
+	for depth := 0; ; depth++ {
+		info, err := stor.statNoFollow(ctx, path)
+		if err != nil || noFollow || info.meta.Symlink == nil || info.IsDir() {
+			return info, path, err
+		}
+		if depth >= maxSymlinkDepth {
+			return nil, nil, &os.PathError{Op: "stat", Path: stor.keyOf(path), Err: syscall.ELOOP}
+		}
+		path = resolveDestination(path, info.meta.Symlink)
+	}
+}
+
+// resolveDestination returns the path of the destination of the
+// symlink `path`.
+func resolveDestination(path file.Path, destination file.Path) file.Path {
+	var result file.Path
+	if len(destination) == 0 || destination[0] != "" {
+		result = path.Up().Append()
+	}
+	for _, name := range destination {
+		switch name {
+		case "", ".":
+		case "..":
+			result = result.Up()
+		default:
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// statNoFollow returns the info of the object (or of the directory) of
+// the path.
+func (stor *Storage) statNoFollow(ctx context.Context, path file.Path) (*fileInfo, error) {
+	if len(path) == 0 {
+		// the root always exists; its metadata are not stored
+		return fileInfoOf("/", nil, true), nil
+	}
+	name := path[len(path)-1]
+	key := stor.keyOf(path)
+	obj, err := stor.headObject(ctx, key)
+	switch {
+	case err == nil:
+		return fileInfoOf(name, obj, false), nil
+	case !isNotFound(err):
+		return nil, errorOf("stat", key, err)
+	}
+
+	prefix := stor.dirPrefixOf(path)
+	if !stor.NoDirectoryMarkers {
+		obj, err := stor.headObject(ctx, prefix)
+		switch {
+		case err == nil:
+			return fileInfoOf(name, obj, true), nil
+		case !isNotFound(err):
+			return nil, errorOf("stat", prefix, err)
+		}
+	}
+
+	// a directory without a marker
+	isFound := false
+	err = stor.listObjects(ctx, prefix, "", 1, func(*object, string) error {
+		isFound = true
+		return nil
+	})
+	if err != nil {
+		return nil, errorOf("stat", prefix, err)
+	}
+	if !isFound {
+		return nil, &os.PathError{Op: "stat", Path: key, Err: syscall.ENOENT}
+	}
+	return fileInfoOf(name, nil, true), nil
+}
+
+func (stor *Storage) Open(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	select {
+	case <-ctx.Done():
+		return nil, file.ErrAborted{}
+	default:
+	}
+
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, fullPath, err := stor.stat(ctx, fullPath, flags.HasNoFollow())
+	switch {
+	case err == nil:
+		if flags.HasCreate() && flags.HasExcl() {
+			return nil, &os.PathError{Op: "open", Path: stor.keyOf(fullPath), Err: syscall.EEXIST}
+		}
+	case os.IsNotExist(err) && flags.HasCreate():
+		return stor.createFile(ctx, fullPath, flags, defaultPerm)
+	default:
+		return nil, err
+	}
+
+	obj := Object{
+		StorageValue: stor,
+		LastInfo:     info,
+		LastPath:     fullPath,
+	}
+	mode := info.Mode()
+	switch {
+	case mode&os.ModeSymlink != 0:
+		return &Symlink{Object: obj}, nil
+	case flags.HasPath():
+		return &PathDescriptor{Object: obj}, nil
+	case mode.IsDir():
+		return &Directory{Object: obj}, nil
+	}
+	return newFile(obj, flags, false), nil
+}
+
+// createFile stores an empty object of the new file (so it exists
+// while it is written).
+func (stor *Storage) createFile(
+	ctx context.Context,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (*File, error) {
+	key := stor.keyOf(path)
+	meta := metadata{
+		Mode:    defaultPerm &^ os.ModeType,
+		HasMode: true,
+		Mtime:   time.Now(),
+	}
+	if err := stor.putObject(ctx, key, nil, 0, meta); err != nil {
+		return nil, errorOf("open", key, err)
+	}
+	obj, err := stor.headObject(ctx, key)
+	if err != nil {
+		return nil, errorOf("open", key, err)
+	}
+	return newFile(Object{
+		StorageValue: stor,
+		LastInfo:     fileInfoOf(path[len(path)-1], obj, false),
+		LastPath:     path,
+	}, flags, true), nil
+}
+
+func (stor *Storage) Stat(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	noFollow bool,
+) (os.FileInfo, error) {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+	info, _, err := stor.stat(ctx, fullPath, noFollow)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Symlink stores an empty object with the destination in its metadata.
+func (stor *Storage) Symlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	destination file.Path,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	key := stor.keyOf(fullPath)
+	if err := stor.checkNotExist(ctx, "symlink", fullPath); err != nil {
+		return err
+	}
+	meta := metadata{
+		Mode:    os.ModeSymlink | 0777,
+		HasMode: true,
+		Mtime:   time.Now(),
+		Symlink: append(file.Path{}, destination...),
+	}
+	return errorOf("symlink", key, stor.putObject(ctx, key, nil, 0, meta))
+}
+
+func (stor *Storage) Readlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+) (file.Path, error) {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := stor.statNoFollow(ctx, fullPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.meta.Symlink == nil || info.IsDir() {
+		return nil, &os.PathError{Op: "readlink", Path: stor.keyOf(fullPath), Err: syscall.EINVAL}
+	}
+	return info.meta.Symlink, nil
+}
+
+// checkNotExist returns EEXIST if there is an object of the path.
+func (stor *Storage) checkNotExist(ctx context.Context, op string, path file.Path) error {
+	_, err := stor.statNoFollow(ctx, path)
+	switch {
+	case err == nil:
+		return &os.PathError{Op: op, Path: stor.keyOf(path), Err: syscall.EEXIST}
+	case os.IsNotExist(err):
+		return nil
+	}
+	return err
+}
+
+// Mkdir stores the marker object of the directory. It does nothing with
+// Config.NoDirectoryMarkers (the directory will appear with its first
+// entry).
+func (stor *Storage) Mkdir(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	perms os.FileMode,
+	isRecursive bool,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	if !isRecursive {
+		if err := stor.checkNotExist(ctx, "mkdir", fullPath); err != nil {
+			return err
+		}
+		return stor.mkdir(ctx, fullPath, perms)
+	}
+
+	for idx := range fullPath {
+		info, err := stor.statNoFollow(ctx, fullPath[:idx+1])
+		switch {
+		case err == nil:
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: stor.keyOf(fullPath[:idx+1]), Err: syscall.ENOTDIR}
+			}
+			continue
+		case !os.IsNotExist(err):
+			return err
+		}
+		if err := stor.mkdir(ctx, fullPath[:idx+1], perms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (stor *Storage) mkdir(ctx context.Context, path file.Path, perms os.FileMode) error {
+	if stor.NoDirectoryMarkers {
+		return nil
+	}
+	prefix := stor.dirPrefixOf(path)
+	meta := metadata{
+		Mode:    os.ModeDir | perms&^os.ModeType,
+		HasMode: true,
+		Mtime:   time.Now(),
+	}
+	return errorOf("mkdir", prefix, stor.putObject(ctx, prefix, nil, 0, meta))
+}
+
+func (stor *Storage) Remove(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	isRecursive bool,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	info, err := stor.statNoFollow(ctx, fullPath)
+	if err != nil {
+		if isRecursive && os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !info.IsDir() {
+		key := stor.keyOf(fullPath)
+		return errorOf("remove", key, stor.deleteObject(ctx, key))
+	}
+
+	prefix := stor.dirPrefixOf(fullPath)
+	if !isRecursive {
+		isEmpty, err := stor.isEmptyDir(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		if !isEmpty {
+			return &os.PathError{Op: "remove", Path: prefix, Err: syscall.ENOTEMPTY}
+		}
+		if info.stat.Key == "" {
+			// no marker
+			return nil
+		}
+		return errorOf("remove", prefix, stor.deleteObject(ctx, prefix))
+	}
+
+	// S3 has no recursive removal; the keys are removed one by one
+	objects, err := stor.objectsOf(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		select {
+		case <-ctx.Done():
+			return file.ErrAborted{}
+		default:
+		}
+		if err := stor.deleteObject(ctx, obj.Key); err != nil && !isNotFound(err) {
+			return errorOf("remove", obj.Key, err)
+		}
+	}
+	return nil
+}
+
+// isEmptyDir returns true if there are no keys with the prefix except
+// the marker.
+func (stor *Storage) isEmptyDir(ctx context.Context, prefix string) (bool, error) {
+	isEmpty := true
+	err := stor.listObjects(ctx, prefix, "", 2, func(obj *object, _ string) error {
+		if obj.Key != prefix {
+			isEmpty = false
+		}
+		return nil
+	})
+	if err != nil {
+		return false, errorOf("readdir", prefix, err)
+	}
+	return isEmpty, nil
+}
+
+// objectsOf returns all the objects with the prefix (their metadata
+// is not set).
+func (stor *Storage) objectsOf(ctx context.Context, prefix string) ([]*object, error) {
+	var objects []*object
+	err := stor.listObjects(ctx, prefix, "", 0, func(obj *object, _ string) error {
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, errorOf("readdir", prefix, err)
+	}
+	return objects, nil
+}
+
+// Rename copies the object (all the objects of a directory) and removes
+// the source. It is not atomic.
+func (stor *Storage) Rename(
+	ctx context.Context,
+	dirAt file.Object,
+	path, newPath file.Path,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	newFullPath, err := stor.fullPathOf(dirAt, newPath)
+	if err != nil {
+		return err
+	}
+	key, newKey := stor.keyOf(fullPath), stor.keyOf(newFullPath)
+	linkErr := func(err error) error {
+		return &os.LinkError{Op: "rename", Old: key, New: newKey, Err: err}
+	}
+	if len(fullPath) == 0 || newFullPath.HasPrefix(fullPath) {
+		if len(newFullPath) == len(fullPath) {
+			return nil
+		}
+		return linkErr(syscall.EINVAL)
+	}
+
+	info, err := stor.statNoFollow(ctx, fullPath)
+	if err != nil {
+		return linkErr(unwrapPathError(err))
+	}
+	newInfo, err := stor.statNoFollow(ctx, newFullPath)
+	switch {
+	case err == nil:
+		switch {
+		case info.IsDir() && !newInfo.IsDir():
+			return linkErr(syscall.ENOTDIR)
+		case !info.IsDir() && newInfo.IsDir():
+			return linkErr(syscall.EISDIR)
+		case newInfo.IsDir():
+			// an empty directory is replaced
+			if err := stor.Remove(ctx, nil, newFullPath, false); err != nil {
+				return linkErr(unwrapPathError(err))
+			}
+		}
+	case !os.IsNotExist(err):
+		return linkErr(unwrapPathError(err))
+	}
+
+	if !info.IsDir() {
+		if err := stor.copyObject(ctx, key, newKey, info.Size(), nil); err != nil {
+			return linkErr(err)
+		}
+		if err := stor.deleteObject(ctx, key); err != nil {
+			return linkErr(err)
+		}
+		return nil
+	}
+
+	prefix, newPrefix := stor.dirPrefixOf(fullPath), stor.dirPrefixOf(newFullPath)
+	objects, err := stor.objectsOf(ctx, prefix)
+	if err != nil {
+		return linkErr(unwrapPathError(err))
+	}
+	for _, obj := range objects {
+		select {
+		case <-ctx.Done():
+			return file.ErrAborted{}
+		default:
+		}
+		if err := stor.copyObject(ctx, obj.Key, newPrefix+strings.TrimPrefix(obj.Key, prefix), obj.Size, nil); err != nil {
+			return linkErr(err)
+		}
+		if err := stor.deleteObject(ctx, obj.Key); err != nil {
+			return linkErr(err)
+		}
+	}
+	return nil
+}
+
+// unwrapPathError returns the cause of an *os.PathError (to be wrapped
+// into an *os.LinkError).
+func unwrapPathError(err error) error {
+	if pathErr, ok := err.(*os.PathError); ok {
+		return pathErr.Err
+	}
+	return err
+}
+
+// Link is not supported: S3 has no hardlinks.
+func (stor *Storage) Link(
+	ctx context.Context,
+	dirAt file.Object,
+	path, destination file.Path,
+) error {
+	return file.ErrNotImplemented{}
+}
+
+func (stor *Storage) Chmod(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	mode os.FileMode,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	return stor.updateMetadata(ctx, "chmod", fullPath, false, func(info *fileInfo, meta *metadata) {
+		meta.Mode = info.Mode()&os.ModeType | mode&^os.ModeType
+		meta.HasMode = true
+	})
+}
+
+func (stor *Storage) Chown(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	uid, gid int,
+	noFollow bool,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	return stor.updateMetadata(ctx, "chown", fullPath, noFollow, func(info *fileInfo, meta *metadata) {
+		// -1 keeps the value, as in chown(2)
+		if uid != -1 {
+			meta.UID = uid
+			meta.HasUID = true
+		}
+		if gid != -1 {
+			meta.GID = gid
+			meta.HasGID = true
+		}
+	})
+}
+
+// Chtimes stores only the mtime: S3 has no atime.
+func (stor *Storage) Chtimes(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	atime time.Time,
+	mtime time.Time,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	return stor.updateMetadata(ctx, "chtimes", fullPath, false, func(info *fileInfo, meta *metadata) {
+		meta.Mtime = mtime
+	})
+}
+
+// updateMetadata replaces the metadata of the object by copying it onto
+// itself. The metadata of a directory are stored in its marker (it is
+// created if missing); they are not stored for the root and with
+// Config.NoDirectoryMarkers.
+func (stor *Storage) updateMetadata(
+	ctx context.Context,
+	op string,
+	path file.Path,
+	noFollow bool,
+	updateFn func(info *fileInfo, meta *metadata),
+) error {
+	info, path, err := stor.stat(ctx, path, noFollow)
+	if err != nil {
+		return err
+	}
+	meta := info.meta
+	updateFn(info, &meta)
+
+	if !info.IsDir() {
+		key := stor.keyOf(path)
+		return errorOf(op, key, stor.copyObject(ctx, key, key, info.Size(), &meta))
+	}
+	if len(path) == 0 || stor.NoDirectoryMarkers {
+		return nil
+	}
+	if !meta.HasMode {
+		meta.Mode = info.Mode()
+		meta.HasMode = true
+	}
+	prefix := stor.dirPrefixOf(path)
+	return errorOf(op, prefix, stor.putObject(ctx, prefix, nil, 0, meta))
+}
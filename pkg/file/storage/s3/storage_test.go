@@ -0,0 +1,192 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBucket = "bucket"
+
+// newTestStorage returns a Storage of the bucket of an in-process S3
+// stand-in server.
+func newTestStorage(t *testing.T, opts ...Option) (*Storage, *fakeServer) {
+	srv := newFakeServer(testBucket)
+	httpSrv := httptest.NewServer(srv)
+	stor, err := NewStorage(httpSrv.URL, testBucket, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, stor.Close())
+		httpSrv.Close()
+	})
+	return stor, srv
+}
+
+func TestStorage(t *testing.T) {
+	stor, srv := newTestStorage(t,
+		OptionPrefix{Prefix: "/backup/"},
+		OptionCredentials{AccessKeyID: "key-id", SecretAccessKey: "secret"})
+	storagetest.Run(t, stor, storagetest.OptionNoHardlinks{Enable: true})
+
+	// the keys are under the prefix
+	require.Equal(t, "other", string(srv.object("backup/file").data))
+	require.NotNil(t, srv.object("backup/moved/"))
+	info, err := stor.Stat(context.Background(), nil, file.Path{"file"}, false)
+	require.NoError(t, err)
+	require.Equal(t, "backup/file", info.Sys().(*Stat).Key)
+
+	// all the requests are signed
+	for _, authorization := range srv.authorizations {
+		require.True(t, strings.HasPrefix(authorization, signAlgorithm+" Credential=key-id/"), authorization)
+	}
+}
+
+func TestStorageDirectories(t *testing.T) {
+	stor, srv := newTestStorage(t)
+	ctx := context.Background()
+
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir", "sub"}, 0750, true))
+	require.NotNil(t, srv.object("dir/"))
+	require.NotNil(t, srv.object("dir/sub/"))
+	for _, name := range []string{"a", "b", "c"} {
+		storagetest.WriteFile(t, stor, file.Path{"dir", name}, name)
+	}
+
+	// the listing is paginated by the server
+	obj, err := stor.Open(ctx, nil, file.Path{"dir"}, file.FlagWalkDefaults, 0000)
+	require.NoError(t, err)
+	dir := obj.(*Directory)
+	infos, err := dir.Readdir(1)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	infos, err = dir.Readdir(-1)
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+	_, err = dir.Readdir(1)
+	require.Equal(t, io.EOF, err)
+	require.NoError(t, dir.Close())
+
+	err = stor.Remove(ctx, nil, file.Path{"dir"}, false)
+	require.True(t, errors.Is(err, syscall.ENOTEMPTY), err)
+	require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir"}, true))
+	require.Empty(t, srv.keys())
+
+	// a directory without a marker gets one
+	storagetest.WriteFile(t, stor, file.Path{"implicit", "file"}, "")
+	require.Nil(t, srv.object("implicit/"))
+	require.NoError(t, stor.Chmod(ctx, nil, file.Path{"implicit"}, 0700))
+	require.NotNil(t, srv.object("implicit/"))
+	info, err := stor.Stat(ctx, nil, file.Path{"implicit"}, false)
+	require.NoError(t, err)
+	require.Equal(t, os.ModeDir|0700, info.Mode())
+
+	storagetest.WriteFile(t, stor, file.Path{"implicit2", "file"}, "")
+	require.NoError(t, stor.Rename(ctx, nil, file.Path{"implicit2"}, file.Path{"moved"}))
+	require.Equal(t, "", storagetest.ReadFile(t, stor, file.Path{"moved", "file"}))
+	_, err = stor.Stat(ctx, nil, file.Path{"implicit2"}, false)
+	require.True(t, os.IsNotExist(err), err)
+}
+
+func TestStorageMetadata(t *testing.T) {
+	stor, _ := newTestStorage(t)
+	ctx := context.Background()
+	storagetest.WriteFile(t, stor, file.Path{"file"}, "content")
+
+	// the timestamps are kept with nanoseconds, the owners are kept as is
+	mtime := time.Unix(1000000000, 123)
+	require.NoError(t, stor.Chtimes(ctx, nil, file.Path{"file"}, mtime, mtime))
+	require.NoError(t, stor.Chown(ctx, nil, file.Path{"file"}, 1000, -1, false))
+	info, err := stor.Stat(ctx, nil, file.Path{"file"}, true)
+	require.NoError(t, err)
+	require.True(t, mtime.Equal(info.ModTime()), info.ModTime())
+	require.Equal(t, 1000, info.Sys().(*Stat).UID)
+	require.Equal(t, -1, info.Sys().(*Stat).GID)
+	require.Equal(t, "content", storagetest.ReadFile(t, stor, file.Path{"file"}))
+
+	// the owner of the symlink itself
+	require.NoError(t, stor.Symlink(ctx, nil, file.Path{"symlink"}, file.Path{"file"}))
+	require.NoError(t, stor.Chown(ctx, nil, file.Path{"symlink"}, 1000, 1001, true))
+	info, err = stor.Stat(ctx, nil, file.Path{"symlink"}, true)
+	require.NoError(t, err)
+	require.Equal(t, os.ModeSymlink|0777, info.Mode())
+	require.Equal(t, 1000, info.Sys().(*Stat).UID)
+	require.Equal(t, 1001, info.Sys().(*Stat).GID)
+	destination, err := stor.Readlink(ctx, nil, file.Path{"symlink"})
+	require.NoError(t, err)
+	require.Equal(t, file.Path{"file"}, destination)
+
+	require.NoError(t, stor.Symlink(ctx, nil, file.Path{"loop"}, file.Path{"loop"}))
+	_, err = stor.Stat(ctx, nil, file.Path{"loop"}, false)
+	require.True(t, errors.Is(err, syscall.ELOOP), err)
+}
+
+func TestStorageNoDirectoryMarkers(t *testing.T) {
+	stor, srv := newTestStorage(t, OptionNoDirectoryMarkers{Enable: true})
+	ctx := context.Background()
+
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0700, false))
+	require.Empty(t, srv.keys())
+	storagetest.WriteFile(t, stor, file.Path{"dir", "file"}, "content")
+	info, err := stor.Stat(ctx, nil, file.Path{"dir"}, false)
+	require.NoError(t, err)
+	require.Equal(t, os.ModeDir|0755, info.Mode())
+	require.NoError(t, stor.Chmod(ctx, nil, file.Path{"dir"}, 0700))
+
+	require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir", "file"}, false))
+	_, err = stor.Stat(ctx, nil, file.Path{"dir"}, false)
+	require.True(t, os.IsNotExist(err), err)
+}
+
+func TestStorageMultipart(t *testing.T) {
+	stor, srv := newTestStorage(t,
+		OptionPartSize{Size: MinPartSize},
+		OptionMultipartCopyThreshold{Size: MinPartSize})
+	srv.maxCopySize = MinPartSize
+	ctx := context.Background()
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), (2*MinPartSize+1024)/16)
+	storagetest.WriteFile(t, stor, file.Path{"large"}, string(content))
+	require.Equal(t, 1, srv.multipartCount)
+	require.Equal(t, content, srv.object("large").data)
+	require.Equal(t, "600", srv.object("large").meta.Get(metaMode))
+
+	// small files are uploaded by one request
+	storagetest.WriteFile(t, stor, file.Path{"small"}, "small")
+	require.Equal(t, 1, srv.multipartCount)
+
+	// the large files are copied by parts on a change of the metadata...
+	require.NoError(t, stor.Chmod(ctx, nil, file.Path{"large"}, 0640))
+	require.Equal(t, 2, srv.multipartCount)
+	require.Equal(t, content, srv.object("large").data)
+	require.Equal(t, "640", srv.object("large").meta.Get(metaMode))
+
+	// ... and on a rename (keeping the metadata)
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0755, false))
+	require.NoError(t, stor.Rename(ctx, nil, file.Path{"large"}, file.Path{"dir", "large"}))
+	require.Equal(t, 3, srv.multipartCount)
+	require.NoError(t, stor.Rename(ctx, nil, file.Path{"dir"}, file.Path{"renamed"}))
+	require.Equal(t, 4, srv.multipartCount)
+	require.Nil(t, srv.object("large"))
+	require.Equal(t, content, srv.object("renamed/large").data)
+	require.Equal(t, "640", srv.object("renamed/large").meta.Get(metaMode))
+
+	require.NoError(t, stor.Chmod(ctx, nil, file.Path{"small"}, 0640))
+	require.Equal(t, 4, srv.multipartCount)
+
+	_, err := NewStorage("http://127.0.0.1", testBucket, OptionPartSize{Size: MinPartSize - 1})
+	require.Error(t, err)
+	_, err = NewStorage("http://127.0.0.1", testBucket, OptionMultipartCopyThreshold{Size: MaxCopySize + 1})
+	require.Error(t, err)
+}
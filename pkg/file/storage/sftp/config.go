@@ -0,0 +1,40 @@
+package sftp
+
+import (
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file/event/polling"
+)
+
+type Config struct {
+	// PollInterval is the period of scanning of the watched directories
+	// (SFTP does not report changes). Zero means the default of
+	// the polling package.
+	PollInterval time.Duration
+
+	// PollIntervalMax is the maximal period of scanning of a directory
+	// which does not change. Zero means the default of the polling
+	// package.
+	PollIntervalMax time.Duration
+}
+
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
+
+// pollingOptions returns the options of the polling.Watcher of
+// the storage.
+func (cfg Config) pollingOptions() []polling.Option {
+	var opts []polling.Option
+	if cfg.PollInterval != 0 {
+		opts = append(opts, polling.OptionInterval{Value: cfg.PollInterval})
+	}
+	if cfg.PollIntervalMax != 0 {
+		opts = append(opts, polling.OptionIntervalMax{Value: cfg.PollIntervalMax})
+	}
+	return opts
+}
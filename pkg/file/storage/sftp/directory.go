@@ -0,0 +1,51 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Directory = &Directory{}
+
+type Directory struct {
+	Object
+
+	// entries are the entries not yet returned by Readdir (nil until
+	// the first call)
+	entries []os.FileInfo
+}
+
+// Readdir has the same semantics as os.File.Readdir. The directory is
+// listed entirely on the first call.
+func (dir *Directory) Readdir(n int) ([]os.FileInfo, error) {
+	if dir.entries == nil {
+		remotePath := dir.remotePath()
+		entries, err := dir.StorageValue.client.ReadDir(remotePath)
+		if err != nil {
+			return nil, errorOf("readdir", remotePath, err)
+		}
+		dir.entries = append(make([]os.FileInfo, 0, len(entries)), entries...)
+	}
+
+	if n <= 0 || n > len(dir.entries) {
+		if n > 0 && len(dir.entries) == 0 {
+			return nil, io.EOF
+		}
+		n = len(dir.entries)
+	}
+	result := dir.entries[:n:n]
+	dir.entries = dir.entries[n:]
+	return result, nil
+}
+
+func (dir *Directory) Open(
+	ctx context.Context,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return dir.StorageValue.Open(ctx, dir, path, flags, defaultPerm)
+}
@@ -0,0 +1,43 @@
+package sftp
+
+import (
+	"errors"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+	pkgsftp "github.com/pkg/sftp"
+)
+
+// errorOf converts an error of the SFTP client to an *os.PathError (so
+// os.IsNotExist and similar work). The operations not supported by
+// the server are reported as file.ErrNotImplemented.
+func errorOf(op string, remotePath string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case isUnsupported(err):
+		return file.ErrNotImplemented{}
+	}
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	return &os.PathError{Op: op, Path: remotePath, Err: err}
+}
+
+// isUnsupported returns true if the error is SSH_FX_OP_UNSUPPORTED (for
+// example, the server has no extension required by the operation).
+func isUnsupported(err error) bool {
+	var statusErr *pkgsftp.StatusError
+	return errors.As(err, &statusErr) && statusErr.FxCode() == pkgsftp.ErrSSHFxOpUnsupported
+}
+
+// unwrapPathError returns the cause of an *os.PathError (to be wrapped
+// into an *os.LinkError).
+func unwrapPathError(err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
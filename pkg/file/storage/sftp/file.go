@@ -0,0 +1,77 @@
+package sftp
+
+import (
+	"os"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	pkgsftp "github.com/pkg/sftp"
+)
+
+var _ file.File = &File{}
+
+// File is a regular file opened as an SFTP handle.
+type File struct {
+	Object
+	handle *pkgsftp.File
+}
+
+func (f *File) Stat() (os.FileInfo, error) {
+	info, err := f.handle.Stat()
+	if err != nil {
+		return nil, errorOf("stat", f.remotePath(), err)
+	}
+	f.LastInfo = info
+	return info, nil
+}
+
+func (f *File) Close() error {
+	return errorOf("close", f.remotePath(), f.handle.Close())
+}
+
+func (f *File) Chmod(mode os.FileMode) error {
+	return errorOf("chmod", f.remotePath(), f.handle.Chmod(mode))
+}
+
+func (f *File) Chown(uid, gid int) error {
+	return errorOf("chown", f.remotePath(), f.handle.Chown(uid, gid))
+}
+
+func (f *File) Read(b []byte) (int, error) {
+	return f.handle.Read(b)
+}
+
+func (f *File) ReadAt(b []byte, offset int64) (int, error) {
+	return f.handle.ReadAt(b, offset)
+}
+
+func (f *File) Write(b []byte) (int, error) {
+	return f.handle.Write(b)
+}
+
+func (f *File) WriteAt(b []byte, offset int64) (int, error) {
+	return f.handle.WriteAt(b, offset)
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	return f.handle.Seek(offset, whence)
+}
+
+// Sync requires the "fsync@openssh.com" extension on the server.
+func (f *File) Sync() error {
+	return errorOf("sync", f.remotePath(), f.handle.Sync())
+}
+
+// SetDeadline is not supported: the calls are limited only by
+// the connection.
+func (f *File) SetDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetReadDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetWriteDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
@@ -0,0 +1,74 @@
+package sftp
+
+import (
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Object = &Object{}
+
+// Object is an object of the SFTP server accessed by its path.
+type Object struct {
+	StorageValue *Storage
+	LastInfo     os.FileInfo
+	LastPath     file.Path
+}
+
+// ID returns the zero ID: SFTP does not provide inode numbers.
+func (obj *Object) ID() file.ObjectID {
+	return file.ObjectID{}
+}
+
+func (obj *Object) Name() string {
+	return obj.LastInfo.Name()
+}
+
+// Stat does not follow the object if it is a symlink.
+func (obj *Object) Stat() (os.FileInfo, error) {
+	isSymlink := obj.LastInfo.Mode()&os.ModeSymlink != 0
+	info, err := obj.StorageValue.stat(obj.remotePath(), isSymlink)
+	if err != nil {
+		return nil, err
+	}
+	obj.LastInfo = info
+	return info, nil
+}
+
+func (obj *Object) LastStat() os.FileInfo {
+	return obj.LastInfo
+}
+
+// Path returns the path the object was opened by.
+func (obj *Object) Path() file.Path {
+	return obj.LastPath
+}
+
+// Close does nothing: only regular files have SFTP handles (see File).
+func (obj *Object) Close() error {
+	return nil
+}
+
+func (obj *Object) Chmod(mode os.FileMode) error {
+	remotePath := obj.remotePath()
+	return errorOf("chmod", remotePath, obj.StorageValue.client.Chmod(remotePath, mode))
+}
+
+func (obj *Object) Chown(uid, gid int) error {
+	remotePath := obj.remotePath()
+	return errorOf("chown", remotePath, obj.StorageValue.client.Chown(remotePath, uid, gid))
+}
+
+func (obj *Object) Storage() file.Storage {
+	return obj.StorageValue
+}
+
+// FD returns an invalid file descriptor: the object is on another
+// machine.
+func (obj *Object) FD() uintptr {
+	return ^uintptr(0)
+}
+
+func (obj *Object) remotePath() string {
+	return obj.StorageValue.ToLocalPath(obj.LastPath)
+}
@@ -0,0 +1,25 @@
+package sftp
+
+import (
+	"time"
+)
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionPollInterval struct {
+	Value time.Duration
+}
+
+func (opt OptionPollInterval) apply(cfg *Config) {
+	cfg.PollInterval = opt.Value
+}
+
+type OptionPollIntervalMax struct {
+	Value time.Duration
+}
+
+func (opt OptionPollIntervalMax) apply(cfg *Config) {
+	cfg.PollIntervalMax = opt.Value
+}
@@ -0,0 +1,491 @@
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	pathpkg "path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/my-network/fsutil/pkg/file/event/polling"
+	pkgsftp "github.com/pkg/sftp"
+)
+
+var _ file.StorageWatchable = &Storage{}
+
+// Storage is a file.Storage of a directory on an SFTP server (for
+// example, a plain SSH host).
+//
+// Regular files are opened as SFTP handles; directories, symlinks and
+// other objects are accessed by their paths. SFTP does not report
+// changes, so Watch scans the watched directories periodically (see
+// polling.Watcher).
+//
+// SFTP has no lchown(2), so Chown with noFollow of a symlink returns
+// file.ErrNotImplemented. The timestamps have a precision of one second.
+type Storage struct {
+	Config
+	ctx      context.Context
+	cancelFn context.CancelFunc
+	client   *pkgsftp.Client
+	root     string
+	watcher  *polling.Watcher
+}
+
+// NewStorage returns the storage of the directory `root` of the SFTP
+// server (a relative root is relative to the home directory of the SSH
+// user). The client should be closed by the caller after Storage.Close.
+func NewStorage(client *pkgsftp.Client, root string, opts ...Option) (*Storage, error) {
+	stor := &Storage{
+		client: client,
+		root:   pathpkg.Clean(root),
+	}
+	for _, opt := range opts {
+		opt.apply(&stor.Config)
+	}
+	stor.ctx, stor.cancelFn = context.WithCancel(context.Background())
+
+	var err error
+	stor.watcher, err = polling.NewWatcher(stor.ctx, stor, stor.pollingOptions()...)
+	if err != nil {
+		stor.cancelFn()
+		return nil, fmt.Errorf("unable to initialize the watcher: %w", err)
+	}
+	return stor, nil
+}
+
+// Close stops the watches of the storage.
+func (stor *Storage) Close() error {
+	stor.cancelFn()
+	return nil
+}
+
+// Client returns the SFTP client of the storage.
+func (stor *Storage) Client() *pkgsftp.Client {
+	return stor.client
+}
+
+func (stor *Storage) Watch(
+	dirAt file.Directory,
+	path file.Path,
+	shouldWatchFunc event.ShouldWatchFunc,
+	shouldWalkFunc file.ShouldWalkFunc,
+	errorHandlerFunc file.ErrorHandlerFunc,
+	opts ...event.WatchOption,
+) (event.Emitter, error) {
+	return stor.watcher.Watch(dirAt, path, shouldWatchFunc, shouldWalkFunc, errorHandlerFunc, opts...)
+}
+
+// ToLocalPath returns the path on the SFTP server.
+func (stor *Storage) ToLocalPath(path file.Path) string {
+	return pathpkg.Join(append([]string{stor.root}, path...)...)
+}
+
+// fullPathOf returns the path relative to the root of the storage. Only
+// the directories of the storage are supported as `dirAt`.
+func (stor *Storage) fullPathOf(dirAt file.Object, path file.Path) (file.Path, error) {
+	if dirAt == nil {
+		return path, nil
+	}
+	dir, ok := dirAt.(*Directory)
+	if !ok || dir.StorageValue != stor {
+		return nil, file.ErrNotImplemented{}
+	}
+	return dir.LastPath.Append(path...), nil
+}
+
+// remotePathOf is ToLocalPath of the path relative to `dirAt`.
+func (stor *Storage) remotePathOf(dirAt file.Object, path file.Path) (string, error) {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return "", err
+	}
+	return stor.ToLocalPath(fullPath), nil
+}
+
+func (stor *Storage) stat(remotePath string, noFollow bool) (os.FileInfo, error) {
+	var info os.FileInfo
+	var err error
+	if noFollow {
+		info, err = stor.client.Lstat(remotePath)
+	} else {
+		info, err = stor.client.Stat(remotePath)
+	}
+	if err != nil {
+		return nil, errorOf("stat", remotePath, err)
+	}
+	return info, nil
+}
+
+func (stor *Storage) Open(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	select {
+	case <-ctx.Done():
+		return nil, file.ErrAborted{}
+	default:
+	}
+
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+	remotePath := stor.ToLocalPath(fullPath)
+
+	info, err := stor.stat(remotePath, flags.HasNoFollow())
+	switch {
+	case err == nil:
+		if flags.HasCreate() && flags.HasExcl() {
+			return nil, &os.PathError{Op: "open", Path: remotePath, Err: syscall.EEXIST}
+		}
+	case os.IsNotExist(err) && flags.HasCreate():
+		return stor.openFile(fullPath, flags, defaultPerm, true)
+	default:
+		return nil, err
+	}
+
+	obj := Object{
+		StorageValue: stor,
+		LastInfo:     info,
+		LastPath:     fullPath,
+	}
+	mode := info.Mode()
+	switch {
+	case mode&os.ModeSymlink != 0:
+		return &Symlink{Object: obj}, nil
+	case flags.HasPath():
+		return &PathDescriptor{Object: obj}, nil
+	case mode.IsDir():
+		return &Directory{Object: obj}, nil
+	case mode.IsRegular():
+		return stor.openFile(fullPath, flags, defaultPerm, false)
+	}
+	return &Untyped{Object: obj}, nil
+}
+
+// openFile opens the regular file as an SFTP handle. The permissions of
+// a new file are set explicitly, since SFTP servers apply their own
+// defaults.
+func (stor *Storage) openFile(
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+	isNew bool,
+) (*File, error) {
+	remotePath := stor.ToLocalPath(path)
+	handle, err := stor.client.OpenFile(remotePath, osFlagsOf(flags))
+	if err != nil {
+		return nil, errorOf("open", remotePath, err)
+	}
+	if isNew {
+		if err := handle.Chmod(defaultPerm); err != nil {
+			_ = handle.Close()
+			return nil, errorOf("chmod", remotePath, err)
+		}
+	}
+	info, err := handle.Stat()
+	if err != nil {
+		_ = handle.Close()
+		return nil, errorOf("stat", remotePath, err)
+	}
+	if flags.HasAppend() {
+		// the writes of SFTP carry their offsets, and servers do not
+		// have to honor O_APPEND (not atomic: the file may be appended
+		// concurrently)
+		if _, err := handle.Seek(info.Size(), io.SeekStart); err != nil {
+			_ = handle.Close()
+			return nil, errorOf("seek", remotePath, err)
+		}
+	}
+	return &File{
+		Object: Object{
+			StorageValue: stor,
+			LastInfo:     info,
+			LastPath:     path,
+		},
+		handle: handle,
+	}, nil
+}
+
+// osFlagsOf returns the flags of os.OpenFile supported by SFTP.
+func osFlagsOf(flags file.OpenFlag) int {
+	var result int
+	isWrite := flags&(file.FlagWrite|file.FlagAppend) != 0
+	switch {
+	case flags.HasRead() && isWrite:
+		result = os.O_RDWR
+	case isWrite:
+		result = os.O_WRONLY
+	default:
+		result = os.O_RDONLY
+	}
+	if flags.HasAppend() {
+		result |= os.O_APPEND
+	}
+	if flags.HasCreate() {
+		result |= os.O_CREATE
+	}
+	if flags.HasExcl() {
+		result |= os.O_EXCL
+	}
+	if flags.HasTrunc() {
+		result |= os.O_TRUNC
+	}
+	return result
+}
+
+func (stor *Storage) Stat(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	noFollow bool,
+) (os.FileInfo, error) {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+	return stor.stat(remotePath, noFollow)
+}
+
+func (stor *Storage) Symlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	destination file.Path,
+) error {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	err = stor.client.Symlink(remotePathOfDestination(destination), remotePath)
+	if err != nil {
+		// SFTP v3 has no status for an existing path (as in mkdir)
+		if _, statErr := stor.client.Lstat(remotePath); statErr == nil {
+			return &os.PathError{Op: "symlink", Path: remotePath, Err: syscall.EEXIST}
+		}
+	}
+	return errorOf("symlink", remotePath, err)
+}
+
+func (stor *Storage) Readlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+) (file.Path, error) {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := stor.client.ReadLink(remotePath)
+	if err != nil {
+		return nil, errorOf("readlink", remotePath, err)
+	}
+	return strings.Split(destination, "/"), nil
+}
+
+// remotePathOfDestination returns the destination of a symlink as is
+// (it is not relative to the root of the storage).
+func remotePathOfDestination(destination file.Path) string {
+	return strings.Join(destination, "/")
+}
+
+func (stor *Storage) Mkdir(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	perms os.FileMode,
+	isRecursive bool,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	if !isRecursive {
+		return stor.mkdir(stor.ToLocalPath(fullPath), perms)
+	}
+
+	for idx := range fullPath {
+		remotePath := stor.ToLocalPath(fullPath[:idx+1])
+		info, err := stor.client.Stat(remotePath)
+		switch {
+		case err == nil:
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: remotePath, Err: syscall.ENOTDIR}
+			}
+			continue
+		case !os.IsNotExist(err):
+			return errorOf("mkdir", remotePath, err)
+		}
+		if err := stor.mkdir(remotePath, perms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mkdir creates the directory with the permissions (SFTP servers apply
+// their own defaults on creation).
+func (stor *Storage) mkdir(remotePath string, perms os.FileMode) error {
+	if err := stor.client.Mkdir(remotePath); err != nil {
+		if _, statErr := stor.client.Lstat(remotePath); statErr == nil {
+			return &os.PathError{Op: "mkdir", Path: remotePath, Err: syscall.EEXIST}
+		}
+		return errorOf("mkdir", remotePath, err)
+	}
+	return errorOf("chmod", remotePath, stor.client.Chmod(remotePath, perms))
+}
+
+func (stor *Storage) Remove(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	isRecursive bool,
+) error {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	if !isRecursive {
+		return errorOf("remove", remotePath, stor.client.Remove(remotePath))
+	}
+	return stor.removeAll(ctx, remotePath)
+}
+
+// removeAll is os.RemoveAll over SFTP.
+func (stor *Storage) removeAll(ctx context.Context, remotePath string) error {
+	select {
+	case <-ctx.Done():
+		return file.ErrAborted{}
+	default:
+	}
+
+	info, err := stor.client.Lstat(remotePath)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return errorOf("remove", remotePath, err)
+	case !info.IsDir():
+		return errorOf("remove", remotePath, stor.client.Remove(remotePath))
+	}
+
+	infos, err := stor.client.ReadDir(remotePath)
+	if err != nil {
+		return errorOf("remove", remotePath, err)
+	}
+	for _, info := range infos {
+		if err := stor.removeAll(ctx, pathpkg.Join(remotePath, info.Name())); err != nil {
+			return err
+		}
+	}
+	return errorOf("remove", remotePath, stor.client.RemoveDirectory(remotePath))
+}
+
+// Rename replaces newPath if it exists (as rename(2)) if the server
+// supports the "posix-rename@openssh.com" extension.
+func (stor *Storage) Rename(
+	ctx context.Context,
+	dirAt file.Object,
+	path, newPath file.Path,
+) error {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	newRemotePath, err := stor.remotePathOf(dirAt, newPath)
+	if err != nil {
+		return err
+	}
+	err = stor.client.PosixRename(remotePath, newRemotePath)
+	if isUnsupported(err) {
+		err = stor.client.Rename(remotePath, newRemotePath)
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: remotePath, New: newRemotePath, Err: unwrapPathError(err)}
+	}
+	return nil
+}
+
+// Link requires the "hardlink@openssh.com" extension on the server.
+func (stor *Storage) Link(
+	ctx context.Context,
+	dirAt file.Object,
+	path, destination file.Path,
+) error {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	remoteDestination, err := stor.remotePathOf(dirAt, destination)
+	if err != nil {
+		return err
+	}
+	err = stor.client.Link(remotePath, remoteDestination)
+	switch {
+	case err == nil:
+		return nil
+	case isUnsupported(err):
+		return file.ErrNotImplemented{}
+	}
+	return &os.LinkError{Op: "link", Old: remotePath, New: remoteDestination, Err: unwrapPathError(err)}
+}
+
+func (stor *Storage) Chmod(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	mode os.FileMode,
+) error {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	return errorOf("chmod", remotePath, stor.client.Chmod(remotePath, mode))
+}
+
+func (stor *Storage) Chown(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	uid, gid int,
+	noFollow bool,
+) error {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	if noFollow {
+		// SETSTAT follows symlinks
+		info, err := stor.stat(remotePath, true)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return file.ErrNotImplemented{}
+		}
+	}
+	return errorOf("chown", remotePath, stor.client.Chown(remotePath, uid, gid))
+}
+
+func (stor *Storage) Chtimes(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	atime time.Time,
+	mtime time.Time,
+) error {
+	remotePath, err := stor.remotePathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	return errorOf("chtimes", remotePath, stor.client.Chtimes(remotePath, atime, mtime))
+}
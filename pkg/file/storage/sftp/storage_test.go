@@ -0,0 +1,90 @@
+// +build test_integration
+
+package sftp
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/event"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	pkgsftp "github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage returns a Storage of a temporary directory served by
+// an in-process SFTP server.
+func newTestStorage(t *testing.T, opts ...Option) (*Storage, string) {
+	root := t.TempDir()
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	server, err := pkgsftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter})
+	require.NoError(t, err)
+	go func() { _ = server.Serve() }()
+
+	client, err := pkgsftp.NewClientPipe(clientReader, clientWriter)
+	require.NoError(t, err)
+	stor, err := NewStorage(client, root, opts...)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, stor.Close())
+		// the client waits for the server to close its end of the pipe
+		_ = server.Close()
+		assert.NoError(t, client.Close())
+	})
+	return stor, root
+}
+
+func TestStorage(t *testing.T) {
+	stor, root := newTestStorage(t)
+	storagetest.Run(t, stor)
+
+	// the paths are relative to the root directory
+	content, err := ioutil.ReadFile(filepath.Join(root, "moved", "child"))
+	require.NoError(t, err)
+	require.Equal(t, "child", string(content))
+}
+
+func TestStorageChown(t *testing.T) {
+	stor, _ := newTestStorage(t)
+	ctx := context.Background()
+	storagetest.WriteFile(t, stor, file.Path{"file"}, "content")
+	require.NoError(t, stor.Symlink(ctx, nil, file.Path{"symlink"}, file.Path{"file"}))
+
+	// SFTP cannot change the owner of a symlink itself
+	err := stor.Chown(ctx, nil, file.Path{"symlink"}, os.Getuid(), os.Getgid(), true)
+	require.Equal(t, file.ErrNotImplemented{}, err)
+	require.NoError(t, stor.Chown(ctx, nil, file.Path{"symlink"}, os.Getuid(), os.Getgid(), false))
+}
+
+func TestStorageWatch(t *testing.T) {
+	stor, _ := newTestStorage(t,
+		OptionPollInterval{Value: 10 * time.Millisecond},
+		OptionPollIntervalMax{Value: 40 * time.Millisecond})
+	ctx := context.Background()
+
+	emitter, err := stor.Watch(nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, emitter.Close()) }()
+
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0755, false))
+	select {
+	case ev := <-emitter.C():
+		require.Equal(t, file.Path{"dir"}, ev.Path)
+		require.Equal(t, event.TypeCreate, ev.TypeMask)
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
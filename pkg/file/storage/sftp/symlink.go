@@ -0,0 +1,24 @@
+package sftp
+
+import (
+	"context"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.SymLink = &Symlink{}
+
+type Symlink struct {
+	Object
+}
+
+func (symlink *Symlink) Destination() (file.Path, error) {
+	return symlink.StorageValue.Readlink(symlink.StorageValue.ctx, nil, symlink.LastPath)
+}
+
+// Open opens the destination of the symlink.
+func (symlink *Symlink) Open(ctx context.Context, flags file.OpenFlag, defaultPerm os.FileMode) (file.Object, error) {
+	flags &^= file.FlagNoFollow | file.FlagPath
+	return symlink.StorageValue.Open(ctx, nil, symlink.LastPath, flags, defaultPerm)
+}
@@ -0,0 +1,13 @@
+package sftp
+
+import (
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Object = &Untyped{}
+
+// Untyped is an object which is neither a directory, a regular file
+// nor a symlink (for example, a device or a socket).
+type Untyped struct {
+	Object
+}
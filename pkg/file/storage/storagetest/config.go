@@ -0,0 +1,10 @@
+package storagetest
+
+// Config describes the optional features of the tested storage.
+type Config struct {
+	// NoSymlinks means Symlink returns file.ErrNotImplemented.
+	NoSymlinks bool
+
+	// NoHardlinks means Link returns file.ErrNotImplemented.
+	NoHardlinks bool
+}
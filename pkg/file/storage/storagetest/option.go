@@ -0,0 +1,21 @@
+package storagetest
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionNoSymlinks struct {
+	Enable bool
+}
+
+func (opt OptionNoSymlinks) apply(cfg *Config) {
+	cfg.NoSymlinks = opt.Enable
+}
+
+type OptionNoHardlinks struct {
+	Enable bool
+}
+
+func (opt OptionNoHardlinks) apply(cfg *Config) {
+	cfg.NoHardlinks = opt.Enable
+}
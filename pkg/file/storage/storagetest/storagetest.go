@@ -0,0 +1,242 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/stretchr/testify/require"
+)
+
+// WriteFile creates or truncates the regular file and writes the content.
+func WriteFile(t *testing.T, stor file.Storage, path file.Path, content string) {
+	WriteFileAt(t, stor, nil, path, content)
+}
+
+// WriteFileAt is WriteFile of the path relative to `dirAt`.
+func WriteFileAt(t *testing.T, stor file.Storage, dirAt file.Object, path file.Path, content string) {
+	obj, err := stor.Open(context.Background(), dirAt, path, file.FlagWrite|file.FlagCreate|file.FlagTrunc, 0600)
+	require.NoError(t, err)
+	_, err = obj.(file.File).Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, obj.Close())
+}
+
+// ReadFile returns the content of the regular file.
+func ReadFile(t *testing.T, stor file.Storage, path file.Path) string {
+	obj, err := stor.Open(context.Background(), nil, path, file.FlagRead, 0000)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, obj.Close()) }()
+	content, err := ioutil.ReadAll(obj.(file.File))
+	require.NoError(t, err)
+	return string(content)
+}
+
+// Run checks the behavior every storage shares with memfs (the reference
+// one) by the subtests "file", "dir", "symlink", "metadata" and "rename".
+// The storage should be empty; the subtests depend on each other, so
+// they are not to be run separately.
+func Run(t *testing.T, stor file.Storage, opts ...Option) {
+	cfg := Config{}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	ctx := context.Background()
+
+	t.Run("file", func(t *testing.T) {
+		obj, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagReadWrite|file.FlagCreate|file.FlagExcl, 0640)
+		require.NoError(t, err)
+		require.Equal(t, file.Path{"file"}, obj.Path())
+		f, ok := obj.(file.File)
+		require.True(t, ok, obj)
+		_, err = f.Write([]byte("hello world"))
+		require.NoError(t, err)
+		_, err = f.WriteAt([]byte("W"), 6)
+		require.NoError(t, err)
+		offset, err := f.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		require.Zero(t, offset)
+		content, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		require.Equal(t, "hello World", string(content))
+		require.NoError(t, f.Close())
+
+		info, err := stor.Stat(ctx, nil, file.Path{"file"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0640), info.Mode())
+		require.Equal(t, int64(11), info.Size())
+
+		_, err = stor.Open(ctx, nil, file.Path{"file"}, file.FlagWrite|file.FlagCreate|file.FlagExcl, 0600)
+		require.True(t, errors.Is(err, os.ErrExist), err)
+		_, err = stor.Open(ctx, nil, file.Path{"missing"}, file.FlagRead, 0000)
+		require.True(t, file.IsNotExist(err), err)
+
+		// ranged reads
+		obj, err = stor.Open(ctx, nil, file.Path{"file"}, file.FlagRead, 0000)
+		require.NoError(t, err)
+		f = obj.(file.File)
+		b := make([]byte, 5)
+		n, err := f.ReadAt(b, 6)
+		require.NoError(t, err)
+		require.Equal(t, "World", string(b[:n]))
+		n, err = f.ReadAt(b, 8)
+		require.Equal(t, io.EOF, err)
+		require.Equal(t, "rld", string(b[:n]))
+		_, err = f.Write([]byte("x"))
+		require.Error(t, err)
+		require.NoError(t, f.Close())
+
+		// the write-only flags are not confused with the read-only ones
+		obj, err = stor.Open(ctx, nil, file.Path{"file"}, file.FlagWrite|file.FlagTrunc, 0000)
+		require.NoError(t, err)
+		_, err = obj.(file.File).Write([]byte("new"))
+		require.NoError(t, err)
+		require.NoError(t, obj.Close())
+		require.Equal(t, "new", ReadFile(t, stor, file.Path{"file"}))
+
+		obj, err = stor.Open(ctx, nil, file.Path{"file"}, file.FlagWrite|file.FlagAppend, 0000)
+		require.NoError(t, err)
+		_, err = obj.(file.File).Write([]byte(" content"))
+		require.NoError(t, err)
+		require.NoError(t, obj.Close())
+		require.Equal(t, "new content", ReadFile(t, stor, file.Path{"file"}))
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir", "sub"}, 0750, true))
+		info, err := stor.Stat(ctx, nil, file.Path{"dir", "sub"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.ModeDir|0750, info.Mode())
+		err = stor.Mkdir(ctx, nil, file.Path{"dir"}, 0750, false)
+		require.True(t, errors.Is(err, os.ErrExist), err)
+
+		obj, err := stor.Open(ctx, nil, file.Path{"dir"}, file.FlagWalkDefaults, 0000)
+		require.NoError(t, err)
+		dir, ok := obj.(file.Directory)
+		require.True(t, ok, obj)
+		for _, name := range []string{"a", "b"} {
+			WriteFile(t, stor, file.Path{"dir", name}, name)
+		}
+		sub, err := dir.Open(ctx, file.Path{"sub"}, file.FlagWalkDefaults, 0000)
+		require.NoError(t, err)
+		require.Equal(t, file.Path{"dir", "sub"}, sub.Path())
+		require.NoError(t, sub.Close())
+
+		infos, err := dir.Readdir(1)
+		require.NoError(t, err)
+		require.Len(t, infos, 1)
+		more, err := dir.Readdir(-1)
+		require.NoError(t, err)
+		require.Len(t, more, 2)
+		_, err = dir.Readdir(1)
+		require.Equal(t, io.EOF, err)
+		require.NoError(t, dir.Close())
+		var names []string
+		for _, info := range append(infos, more...) {
+			names = append(names, info.Name())
+			require.Equal(t, info.Name() == "sub", info.IsDir(), info.Name())
+		}
+		sort.Strings(names)
+		require.Equal(t, []string{"a", "b", "sub"}, names)
+		require.Equal(t, "b", ReadFile(t, stor, file.Path{"dir", "b"}))
+
+		require.Error(t, stor.Remove(ctx, nil, file.Path{"dir"}, false))
+		require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir", "a"}, false))
+		require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir"}, true))
+		_, err = stor.Stat(ctx, nil, file.Path{"dir"}, false)
+		require.True(t, file.IsNotExist(err), err)
+		_, err = stor.Stat(ctx, nil, file.Path{"dir", "b"}, false)
+		require.True(t, file.IsNotExist(err), err)
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		err := stor.Symlink(ctx, nil, file.Path{"symlink"}, file.Path{"file"})
+		if cfg.NoSymlinks {
+			require.Equal(t, file.ErrNotImplemented{}, err)
+			return
+		}
+		require.NoError(t, err)
+		destination, err := stor.Readlink(ctx, nil, file.Path{"symlink"})
+		require.NoError(t, err)
+		require.Equal(t, file.Path{"file"}, destination)
+		err = stor.Symlink(ctx, nil, file.Path{"symlink"}, file.Path{"file"})
+		require.True(t, errors.Is(err, os.ErrExist), err)
+
+		obj, err := stor.Open(ctx, nil, file.Path{"symlink"}, file.FlagRead|file.FlagNoFollow|file.FlagPath, 0000)
+		require.NoError(t, err)
+		symlink, ok := obj.(file.SymLink)
+		require.True(t, ok, obj)
+		destination, err = symlink.Destination()
+		require.NoError(t, err)
+		require.Equal(t, file.Path{"file"}, destination)
+		target, err := symlink.Open(ctx, file.FlagRead, 0000)
+		require.NoError(t, err)
+		_, ok = target.(file.File)
+		require.True(t, ok, target)
+		require.NoError(t, target.Close())
+		require.NoError(t, symlink.Close())
+
+		info, err := stor.Stat(ctx, nil, file.Path{"symlink"}, false)
+		require.NoError(t, err)
+		require.True(t, info.Mode().IsRegular())
+		info, err = stor.Stat(ctx, nil, file.Path{"symlink"}, true)
+		require.NoError(t, err)
+		require.NotZero(t, info.Mode()&os.ModeSymlink)
+		require.Equal(t, "new content", ReadFile(t, stor, file.Path{"symlink"}))
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		require.NoError(t, stor.Chmod(ctx, nil, file.Path{"file"}, 0604))
+		mtime := time.Unix(1000000000, 0)
+		require.NoError(t, stor.Chtimes(ctx, nil, file.Path{"file"}, mtime, mtime))
+		info, err := stor.Stat(ctx, nil, file.Path{"file"}, true)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0604), info.Mode())
+		require.True(t, mtime.Equal(info.ModTime()), info.ModTime())
+		require.Equal(t, "new content", ReadFile(t, stor, file.Path{"file"}))
+
+		require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"meta-dir"}, 0700, false))
+		require.NoError(t, stor.Chmod(ctx, nil, file.Path{"meta-dir"}, 0711))
+		info, err = stor.Stat(ctx, nil, file.Path{"meta-dir"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.ModeDir|0711, info.Mode())
+
+		err = stor.Chmod(ctx, nil, file.Path{"missing"}, 0600)
+		require.True(t, file.IsNotExist(err), err)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		WriteFile(t, stor, file.Path{"other"}, "other")
+		require.NoError(t, stor.Rename(ctx, nil, file.Path{"other"}, file.Path{"file"}))
+		require.Equal(t, "other", ReadFile(t, stor, file.Path{"file"}))
+		_, err := stor.Stat(ctx, nil, file.Path{"other"}, false)
+		require.True(t, file.IsNotExist(err), err)
+
+		// with the children
+		WriteFile(t, stor, file.Path{"meta-dir", "child"}, "child")
+		require.NoError(t, stor.Rename(ctx, nil, file.Path{"meta-dir"}, file.Path{"moved"}))
+		require.Equal(t, "child", ReadFile(t, stor, file.Path{"moved", "child"}))
+		info, err := stor.Stat(ctx, nil, file.Path{"moved"}, false)
+		require.NoError(t, err)
+		require.Equal(t, os.ModeDir|0711, info.Mode())
+		_, err = stor.Stat(ctx, nil, file.Path{"meta-dir"}, false)
+		require.True(t, file.IsNotExist(err), err)
+
+		err = stor.Rename(ctx, nil, file.Path{"missing"}, file.Path{"other"})
+		require.True(t, file.IsNotExist(err), err)
+
+		err = stor.Link(ctx, nil, file.Path{"file"}, file.Path{"link"})
+		if cfg.NoHardlinks {
+			require.Equal(t, file.ErrNotImplemented{}, err)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, "other", ReadFile(t, stor, file.Path{"link"}))
+	})
+}
@@ -0,0 +1,220 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// ErrResponse is an unexpected response of the WebDAV server.
+type ErrResponse struct {
+	Method     string
+	URL        string
+	StatusCode int
+}
+
+func (err ErrResponse) Error() string {
+	return fmt.Sprintf("%s '%s': %d %s", err.Method, err.URL, err.StatusCode, http.StatusText(err.StatusCode))
+}
+
+// Unwrap returns os.ErrNotExist or os.ErrPermission if the status code
+// is 404 or 401/403 (so errors.Is works).
+func (err ErrResponse) Unwrap() error {
+	switch err.StatusCode {
+	case http.StatusNotFound:
+		return os.ErrNotExist
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// urlOf returns the URL of the path. The URLs of collections end with
+// "/" (some servers redirect otherwise).
+func (stor *Storage) urlOf(path file.Path, isDir bool) string {
+	u := *stor.baseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.Join(path, "/")
+	if isDir && len(path) > 0 {
+		u.Path += "/"
+	}
+	u.RawPath = ""
+	return u.String()
+}
+
+// do sends the request and returns the response if its status is one of
+// `expectedStatusCodes` (or ErrResponse otherwise). The body of
+// the response should be closed by the caller.
+func (stor *Storage) do(
+	ctx context.Context,
+	method string,
+	url string,
+	header http.Header,
+	body io.Reader,
+	size int64,
+	expectedStatusCodes ...int,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			// otherwise the request is sent without Content-Length
+			req.Body = http.NoBody
+		}
+	}
+	if stor.Username != "" {
+		req.SetBasicAuth(stor.Username, stor.Password)
+	}
+
+	resp, err := stor.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, statusCode := range expectedStatusCodes {
+		if resp.StatusCode == statusCode {
+			return resp, nil
+		}
+	}
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+	_ = resp.Body.Close()
+	return nil, ErrResponse{Method: method, URL: url, StatusCode: resp.StatusCode}
+}
+
+// doNoBody is do which discards the body of the response.
+func (stor *Storage) doNoBody(
+	ctx context.Context,
+	method string,
+	url string,
+	header http.Header,
+	body io.Reader,
+	size int64,
+	expectedStatusCodes ...int,
+) error {
+	resp, err := stor.do(ctx, method, url, header, body, size, expectedStatusCodes...)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// doMultistatus sends the request and parses the 207 Multi-Status
+// response.
+func (stor *Storage) doMultistatus(
+	ctx context.Context,
+	method string,
+	url string,
+	header http.Header,
+	body []byte,
+) (*multistatus, error) {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+	resp, err := stor.do(ctx, method, url, header, bytes.NewReader(body), int64(len(body)), http.StatusMultiStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("unable to parse the response of %s '%s': %w", method, url, err)
+	}
+	return &result, nil
+}
+
+// propfind returns the responses of PROPFIND with the depth 0 or 1.
+func (stor *Storage) propfind(ctx context.Context, path file.Path, isDir bool, depth int) ([]response, error) {
+	header := http.Header{}
+	header.Set("Depth", strconv.Itoa(depth))
+	result, err := stor.doMultistatus(ctx, "PROPFIND", stor.urlOf(path, isDir), header, propfindBody)
+	if err != nil {
+		return nil, err
+	}
+	return result.Responses, nil
+}
+
+// proppatch sets the properties of PropertyNamespace (the values are
+// not escaped).
+func (stor *Storage) proppatch(ctx context.Context, path file.Path, isDir bool, props map[string]string) error {
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	body.WriteString(`<D:propertyupdate xmlns:D="DAV:" xmlns:F="` + PropertyNamespace + `"><D:set><D:prop>`)
+	for name, value := range props {
+		body.WriteString(`<F:` + name + `>` + value + `</F:` + name + `>`)
+	}
+	body.WriteString(`</D:prop></D:set></D:propertyupdate>`)
+
+	url := stor.urlOf(path, isDir)
+	result, err := stor.doMultistatus(ctx, "PROPPATCH", url, nil, []byte(body.String()))
+	if err != nil {
+		return err
+	}
+	for _, resp := range result.Responses {
+		for _, propstat := range resp.Propstats {
+			statusCode := statusCodeOf(propstat.Status)
+			if statusCode == http.StatusOK {
+				continue
+			}
+			if statusCode == http.StatusForbidden || statusCode == http.StatusConflict {
+				// the server does not store such properties
+				return file.ErrNotImplemented{}
+			}
+			return ErrResponse{Method: "PROPPATCH", URL: url, StatusCode: statusCode}
+		}
+	}
+	return nil
+}
+
+// getObject returns the content of the file starting at `offset` (and
+// at most `size` bytes, if `size` is not negative).
+func (stor *Storage) getObject(ctx context.Context, path file.Path, offset, size int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	switch {
+	case size >= 0:
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
+	case offset > 0:
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := stor.do(ctx, http.MethodGet, stor.urlOf(path, false), header, nil, 0,
+		http.StatusOK, http.StatusPartialContent)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK && offset > 0 {
+		// the server does not support ranges
+		if _, err := io.CopyN(ioutil.Discard, resp.Body, offset); err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+	}
+	return resp.Body, nil
+}
+
+// putObject replaces the content of the file (or its range, if
+// `offset` is not negative).
+func (stor *Storage) putObject(ctx context.Context, path file.Path, body io.Reader, size int64, offset int64) error {
+	header := http.Header{}
+	if offset >= 0 && size > 0 {
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/*", offset, offset+size-1))
+	}
+	if body == nil {
+		body = http.NoBody
+	}
+	return stor.doNoBody(ctx, http.MethodPut, stor.urlOf(path, false), header, body, size,
+		http.StatusOK, http.StatusCreated, http.StatusNoContent)
+}
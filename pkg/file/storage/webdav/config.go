@@ -0,0 +1,74 @@
+package webdav
+
+import (
+	"net/http"
+	"os"
+)
+
+const (
+	DefaultDirPerm  = os.FileMode(0755)
+	DefaultFilePerm = os.FileMode(0644)
+)
+
+type Config struct {
+	// Username and Password are the credentials of the basic
+	// authentication of a Storage. The requests are not authenticated
+	// if Username is empty.
+	Username string
+	Password string
+
+	// HTTPClient is the client of the requests of a Storage. Nil means
+	// http.DefaultClient.
+	HTTPClient *http.Client
+
+	// RangedPut makes a Storage send each write as a PUT request with
+	// the Content-Range header, instead of uploading the whole file on
+	// Sync and Close. Only some servers support it (for example, Apache
+	// mod_dav and Server); the others may replace the whole file with
+	// the written range.
+	RangedPut bool
+
+	// ReadOnly makes a Server deny the requests which modify the storage
+	// (403 Forbidden).
+	ReadOnly bool
+
+	// DirPerm and FilePerm are the maximal permissions of the directories
+	// and the files created by a Server (the WebDAV clients do not send
+	// permissions, so they are masked as by umask). Zero means
+	// DefaultDirPerm and DefaultFilePerm.
+	DirPerm  os.FileMode
+	FilePerm os.FileMode
+
+	// Prefix is the prefix of the URL paths served by a Server (see
+	// webdav.Handler).
+	Prefix string
+}
+
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+	return cfg
+}
+
+func (cfg Config) httpClient() *http.Client {
+	if cfg.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return cfg.HTTPClient
+}
+
+func (cfg Config) dirPerm() os.FileMode {
+	if cfg.DirPerm == 0 {
+		return DefaultDirPerm
+	}
+	return cfg.DirPerm
+}
+
+func (cfg Config) filePerm() os.FileMode {
+	if cfg.FilePerm == 0 {
+		return DefaultFilePerm
+	}
+	return cfg.FilePerm
+}
@@ -0,0 +1,72 @@
+package webdav
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Directory = &Directory{}
+
+// Directory is a collection of the share.
+type Directory struct {
+	Object
+
+	// entries are the entries not yet returned by Readdir (nil until
+	// the first call)
+	entries []os.FileInfo
+}
+
+// Readdir has the same semantics as os.File.Readdir. The collection is
+// listed entirely (by one PROPFIND request) on the first call.
+func (dir *Directory) Readdir(n int) ([]os.FileInfo, error) {
+	if dir.entries == nil {
+		entries, err := dir.list()
+		if err != nil {
+			return nil, err
+		}
+		dir.entries = entries
+	}
+
+	if n <= 0 || n > len(dir.entries) {
+		if n > 0 && len(dir.entries) == 0 {
+			return nil, io.EOF
+		}
+		n = len(dir.entries)
+	}
+	result := dir.entries[:n:n]
+	dir.entries = dir.entries[n:]
+	return result, nil
+}
+
+func (dir *Directory) list() ([]os.FileInfo, error) {
+	stor := dir.StorageValue
+	responses, err := stor.propfind(stor.ctx, dir.LastPath, true, 1)
+	if err != nil {
+		return nil, errorOf("readdir", stor.urlOf(dir.LastPath, true), err)
+	}
+
+	dirPath := hrefPathOf(stor.urlOf(dir.LastPath, true))
+	entries := make([]os.FileInfo, 0, len(responses))
+	for idx := range responses {
+		hrefPath := hrefPathOf(responses[idx].Href)
+		if hrefPath == dirPath {
+			// the collection itself
+			continue
+		}
+		entries = append(entries, fileInfoOf(path.Base(hrefPath), &responses[idx]))
+	}
+	return entries, nil
+}
+
+func (dir *Directory) Open(
+	ctx context.Context,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	return dir.StorageValue.Open(ctx, dir, path, flags, defaultPerm)
+}
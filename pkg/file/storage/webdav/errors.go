@@ -0,0 +1,58 @@
+package webdav
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"syscall"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// errnoOf returns the errno which corresponds to the status code of
+// the response (or the error itself if it is not a response).
+func errnoOf(err error) error {
+	var respErr ErrResponse
+	if !errors.As(err, &respErr) {
+		return err
+	}
+	switch respErr.StatusCode {
+	case http.StatusNotFound:
+		return syscall.ENOENT
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return syscall.EACCES
+	case http.StatusMethodNotAllowed:
+		if respErr.Method == "MKCOL" {
+			// the collection exists
+			return syscall.EEXIST
+		}
+	case http.StatusConflict:
+		// the parent collection does not exist
+		return syscall.ENOENT
+	case http.StatusPreconditionFailed:
+		// If-None-Match or Overwrite
+		return syscall.EEXIST
+	case http.StatusInsufficientStorage:
+		return syscall.ENOSPC
+	}
+	return err
+}
+
+// errorOf converts an error of a request to an *os.PathError (so
+// os.IsNotExist and similar work).
+func errorOf(op string, url string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return err
+	}
+	return &os.PathError{Op: op, Path: url, Err: errnoOf(err)}
+}
+
+// isNotImplemented returns true if the error is file.ErrNotImplemented.
+func isNotImplemented(err error) bool {
+	var notImplErr file.ErrNotImplemented
+	return errors.As(err, &notImplErr)
+}
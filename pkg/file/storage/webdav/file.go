@@ -0,0 +1,320 @@
+package webdav
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.File = &File{}
+
+// File is a regular file of the share.
+//
+// The reads are ranged GET requests. By default the written content is
+// collected in a local temporary file (with the existing content,
+// unless the file is new or truncated) and is uploaded by one PUT
+// request on Sync and Close. With Config.RangedPut each write is sent
+// immediately as a PUT request with Content-Range.
+type File struct {
+	Object
+
+	locker  sync.Mutex
+	flags   file.OpenFlag
+	offset  int64
+	isDirty bool
+
+	// size is the current size of the content if it is written by
+	// ranged PUT requests
+	size int64
+
+	// buffer is the local copy of the content (nil until the first write)
+	buffer *os.File
+}
+
+func newFile(obj Object, flags file.OpenFlag, isNew bool) (*File, error) {
+	f := &File{
+		Object: obj,
+		flags:  flags,
+		size:   obj.LastInfo.Size(),
+	}
+	if isNew || !flags.HasTrunc() || !f.isWritable() || obj.LastInfo.Size() == 0 {
+		return f, nil
+	}
+	if !f.StorageValue.RangedPut {
+		// the truncation is uploaded even if nothing is written
+		f.isDirty = true
+		return f, nil
+	}
+	stor := f.StorageValue
+	if err := stor.putObject(stor.ctx, f.LastPath, nil, 0, -1); err != nil {
+		return nil, errorOf("truncate", f.url(), err)
+	}
+	f.size = 0
+	return f, nil
+}
+
+func (f *File) isWritable() bool {
+	return f.flags&(file.FlagWrite|file.FlagAppend) != 0
+}
+
+func (f *File) url() string {
+	return f.StorageValue.urlOf(f.LastPath, false)
+}
+
+func (f *File) Stat() (os.FileInfo, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	if f.buffer == nil && !f.isDirty {
+		info, err := f.Object.Stat()
+		if err != nil {
+			return nil, err
+		}
+		f.size = info.Size()
+		return info, nil
+	}
+
+	// the content is not uploaded, yet
+	info := *f.LastInfo.(*fileInfo)
+	size, err := f.currentSize()
+	if err != nil {
+		return nil, err
+	}
+	info.size = size
+	return &info, nil
+}
+
+// currentSize returns the current size of the content.
+func (f *File) currentSize() (int64, error) {
+	switch {
+	case f.buffer != nil:
+		info, err := f.buffer.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	case f.isDirty:
+		// truncated
+		return 0, nil
+	}
+	return f.size, nil
+}
+
+// Close uploads the written content.
+func (f *File) Close() error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	err := f.upload()
+	if f.buffer != nil {
+		_ = f.buffer.Close()
+		_ = os.Remove(f.buffer.Name())
+		f.buffer = nil
+	}
+	return err
+}
+
+func (f *File) Read(b []byte) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	n, err := f.readAt(b, f.offset)
+	f.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *File) ReadAt(b []byte, offset int64) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	return f.readAt(b, offset)
+}
+
+// readAt has the semantics of io.ReaderAt.
+func (f *File) readAt(b []byte, offset int64) (int, error) {
+	if f.isWritable() && !f.flags.HasRead() {
+		return 0, &os.PathError{Op: "read", Path: f.url(), Err: syscall.EBADF}
+	}
+	if f.buffer != nil {
+		return f.buffer.ReadAt(b, offset)
+	}
+	size, err := f.currentSize()
+	if err != nil {
+		return 0, err
+	}
+	if offset >= size || len(b) == 0 {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+
+	length := int64(len(b))
+	if offset+length > size {
+		length = size - offset
+	}
+	stor := f.StorageValue
+	body, err := stor.getObject(stor.ctx, f.LastPath, offset, length)
+	if err != nil {
+		return 0, errorOf("read", f.url(), err)
+	}
+	defer body.Close()
+	n, err := io.ReadFull(body, b[:length])
+	if err != nil {
+		// the file was changed concurrently
+		return n, errorOf("read", f.url(), err)
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *File) Write(b []byte) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	offset := f.offset
+	if f.flags.HasAppend() {
+		size, err := f.currentSize()
+		if err != nil {
+			return 0, err
+		}
+		offset = size
+	}
+	n, err := f.writeAt(b, offset)
+	f.offset = offset + int64(n)
+	return n, err
+}
+
+func (f *File) WriteAt(b []byte, offset int64) (int, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	return f.writeAt(b, offset)
+}
+
+func (f *File) writeAt(b []byte, offset int64) (int, error) {
+	if !f.isWritable() {
+		return 0, &os.PathError{Op: "write", Path: f.url(), Err: syscall.EBADF}
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	stor := f.StorageValue
+	if stor.RangedPut {
+		err := stor.putObject(stor.ctx, f.LastPath, bytes.NewReader(b), int64(len(b)), offset)
+		if err != nil {
+			return 0, errorOf("write", f.url(), err)
+		}
+		if end := offset + int64(len(b)); end > f.size {
+			f.size = end
+		}
+		return len(b), nil
+	}
+	if err := f.initBuffer(); err != nil {
+		return 0, err
+	}
+	f.isDirty = true
+	return f.buffer.WriteAt(b, offset)
+}
+
+// initBuffer creates the local copy of the content.
+func (f *File) initBuffer() error {
+	if f.buffer != nil {
+		return nil
+	}
+	buffer, err := ioutil.TempFile("", "fsutil-webdav-")
+	if err != nil {
+		return fmt.Errorf("unable to create a temporary file: %w", err)
+	}
+	size, err := f.currentSize()
+	if err == nil && size > 0 {
+		// the existing content
+		var body io.ReadCloser
+		stor := f.StorageValue
+		body, err = stor.getObject(stor.ctx, f.LastPath, 0, -1)
+		if err == nil {
+			_, err = io.Copy(buffer, body)
+			_ = body.Close()
+			err = errorOf("read", f.url(), err)
+		}
+	}
+	if err != nil {
+		_ = buffer.Close()
+		_ = os.Remove(buffer.Name())
+		return err
+	}
+	f.buffer = buffer
+	return nil
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		size, err := f.currentSize()
+		if err != nil {
+			return 0, err
+		}
+		offset += size
+	default:
+		return 0, &os.PathError{Op: "seek", Path: f.url(), Err: syscall.EINVAL}
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.url(), Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// Sync uploads the written content.
+func (f *File) Sync() error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	return f.upload()
+}
+
+// upload stores the buffered content by one PUT request.
+func (f *File) upload() error {
+	if !f.isDirty {
+		return nil
+	}
+	stor := f.StorageValue
+	size, err := f.currentSize()
+	if err != nil {
+		return err
+	}
+	var body io.Reader
+	if f.buffer != nil {
+		body = io.NewSectionReader(f.buffer, 0, size)
+	}
+	if err := stor.putObject(stor.ctx, f.LastPath, body, size, -1); err != nil {
+		return errorOf("write", f.url(), err)
+	}
+	f.isDirty = false
+	f.size = size
+	return nil
+}
+
+// SetDeadline is not supported: the requests are limited only by
+// Config.HTTPClient.
+func (f *File) SetDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetReadDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (f *File) SetWriteDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
@@ -0,0 +1,293 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	pkgwebdav "golang.org/x/net/webdav"
+)
+
+var _ pkgwebdav.FileSystem = &fileSystem{}
+
+// fileSystem is the webdav.FileSystem of the storage of a Server.
+type fileSystem struct {
+	srv *Server
+}
+
+// pathOf returns the path of the name of webdav.FileSystem.
+func pathOf(name string) file.Path {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return nil
+	}
+	return file.Path(strings.Split(name, "/"))
+}
+
+// osErrorOf converts the error of the storage to an *os.PathError with
+// an errno (webdav.Handler checks the errors by os.IsNotExist and
+// os.IsExist, which do not unwrap the other errors).
+func osErrorOf(op string, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var errno syscall.Errno
+	switch {
+	case errors.As(err, &errno):
+		return &os.PathError{Op: op, Path: name, Err: errno}
+	case errors.Is(err, os.ErrNotExist):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	case errors.Is(err, os.ErrExist):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrExist}
+	case errors.Is(err, os.ErrPermission):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+	}
+	return err
+}
+
+// Mkdir masks the permissions by Config.DirPerm.
+func (fs *fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	err := fs.srv.storage.Mkdir(ctx, nil, pathOf(name), perm&fs.srv.dirPerm(), false)
+	return record(ctx, osErrorOf("mkdir", name, err))
+}
+
+// openFlagOf returns the file.OpenFlag of the os.OpenFile flags.
+func openFlagOf(flag int) file.OpenFlag {
+	var flags file.OpenFlag
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_WRONLY:
+		flags = file.FlagWrite
+	case os.O_RDWR:
+		flags = file.FlagReadWrite
+	default:
+		flags = file.FlagRead
+	}
+	if flag&os.O_APPEND != 0 {
+		flags |= file.FlagAppend
+	}
+	if flag&os.O_CREATE != 0 {
+		flags |= file.FlagCreate
+	}
+	if flag&os.O_EXCL != 0 {
+		flags |= file.FlagExcl
+	}
+	if flag&os.O_TRUNC != 0 {
+		flags |= file.FlagTrunc
+	}
+	return flags
+}
+
+// OpenFile masks the permissions by Config.FilePerm. The directories
+// are opened for reading even if the writing is requested (it is done
+// by PROPPATCH), unless the flags create or truncate the file.
+func (fs *fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (pkgwebdav.File, error) {
+	filePath := pathOf(name)
+	flags := openFlagOf(flag)
+	obj, err := fs.srv.storage.Open(ctx, nil, filePath, flags, perm&fs.srv.filePerm())
+	if errors.Is(err, syscall.EISDIR) && flags&(file.FlagCreate|file.FlagTrunc) == 0 {
+		obj, err = fs.srv.storage.Open(ctx, nil, filePath, file.FlagRead, 0)
+	}
+	if err != nil {
+		return nil, record(ctx, osErrorOf("open", name, err))
+	}
+	record(ctx, nil)
+	return &serverFile{
+		ctx:     ctx,
+		storage: fs.srv.storage,
+		obj:     obj,
+		path:    filePath,
+		name:    name,
+	}, nil
+}
+
+// RemoveAll refuses to remove the root.
+func (fs *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	filePath := pathOf(name)
+	if len(filePath) == 0 {
+		return record(ctx, &os.PathError{Op: "remove", Path: name, Err: os.ErrInvalid})
+	}
+	err := fs.srv.storage.Remove(ctx, nil, filePath, true)
+	return record(ctx, osErrorOf("remove", name, err))
+}
+
+func (fs *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	oldPath, newPath := pathOf(oldName), pathOf(newName)
+	if len(oldPath) == 0 || len(newPath) == 0 {
+		return record(ctx, &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: os.ErrInvalid})
+	}
+	err := fs.srv.storage.Rename(ctx, nil, oldPath, newPath)
+	return record(ctx, osErrorOf("rename", oldName, err))
+}
+
+func (fs *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	info, err := fs.srv.storage.Stat(ctx, nil, pathOf(name), false)
+	return info, record(ctx, osErrorOf("stat", name, err))
+}
+
+var (
+	_ pkgwebdav.File            = &serverFile{}
+	_ pkgwebdav.DeadPropsHolder = &serverFile{}
+)
+
+// serverFile is an opened object of the storage of a Server.
+type serverFile struct {
+	ctx     context.Context
+	storage file.Storage
+	obj     file.Object
+	path    file.Path
+	name    string
+}
+
+func (f *serverFile) pathError(op string, err error) error {
+	return record(f.ctx, &os.PathError{Op: op, Path: f.name, Err: err})
+}
+
+func (f *serverFile) Close() error {
+	return record(f.ctx, osErrorOf("close", f.name, f.obj.Close()))
+}
+
+func (f *serverFile) Read(b []byte) (int, error) {
+	ioObj, ok := f.obj.(file.File)
+	if !ok {
+		return 0, f.pathError("read", syscall.EISDIR)
+	}
+	return ioObj.Read(b)
+}
+
+func (f *serverFile) Write(b []byte) (int, error) {
+	ioObj, ok := f.obj.(file.File)
+	if !ok {
+		return 0, f.pathError("write", syscall.EISDIR)
+	}
+	n, err := ioObj.Write(b)
+	return n, record(f.ctx, err)
+}
+
+func (f *serverFile) Seek(offset int64, whence int) (int64, error) {
+	ioObj, ok := f.obj.(file.File)
+	if !ok {
+		return 0, f.pathError("seek", syscall.EISDIR)
+	}
+	return ioObj.Seek(offset, whence)
+}
+
+func (f *serverFile) Readdir(count int) ([]os.FileInfo, error) {
+	dir, ok := f.obj.(file.Directory)
+	if !ok {
+		return nil, f.pathError("readdir", syscall.ENOTDIR)
+	}
+	return dir.Readdir(count)
+}
+
+func (f *serverFile) Stat() (os.FileInfo, error) {
+	info, err := f.obj.Stat()
+	return info, record(f.ctx, osErrorOf("stat", f.name, err))
+}
+
+// propertyOf returns the property of PropertyNamespace.
+func propertyOf(name string, value string) pkgwebdav.Property {
+	return pkgwebdav.Property{
+		XMLName:  xml.Name{Space: PropertyNamespace, Local: name},
+		InnerXML: []byte(value),
+	}
+}
+
+// DeadProps returns the attributes of the object as the properties of
+// PropertyNamespace (the owner only if it is known).
+func (f *serverFile) DeadProps() (map[xml.Name]pkgwebdav.Property, error) {
+	info, err := f.obj.Stat()
+	if err != nil {
+		return nil, record(f.ctx, osErrorOf("stat", f.name, err))
+	}
+	props := map[xml.Name]pkgwebdav.Property{}
+	for _, prop := range []pkgwebdav.Property{
+		propertyOf(propMode, formatMode(info.Mode())),
+		propertyOf(propMtime, strconv.FormatInt(info.ModTime().UnixNano(), 10)),
+	} {
+		props[prop.XMLName] = prop
+	}
+	if uid, gid, ok := ownerOf(info.Sys()); ok {
+		for _, prop := range []pkgwebdav.Property{
+			propertyOf(propUID, strconv.Itoa(uid)),
+			propertyOf(propGID, strconv.Itoa(gid)),
+		} {
+			props[prop.XMLName] = prop
+		}
+	}
+	return props, nil
+}
+
+// Patch applies the properties of PropertyNamespace. The other
+// properties and the removals are forbidden (and then nothing is
+// applied, as required by RFC 4918). The atime is set to the mtime.
+func (f *serverFile) Patch(patches []pkgwebdav.Proppatch) ([]pkgwebdav.Propstat, error) {
+	var applied, forbidden []pkgwebdav.Property
+	var mode *os.FileMode
+	var mtime *time.Time
+	uid, gid := -1, -1
+	for _, patch := range patches {
+		for _, prop := range patch.Props {
+			value := strings.TrimSpace(string(prop.InnerXML))
+			var err error
+			switch {
+			case prop.XMLName.Space != PropertyNamespace || patch.Remove:
+				err = os.ErrInvalid
+			case prop.XMLName.Local == propMode:
+				var v uint64
+				v, err = strconv.ParseUint(value, 8, 32)
+				m := os.FileMode(v) &^ os.ModeType
+				mode = &m
+			case prop.XMLName.Local == propUID:
+				uid, err = strconv.Atoi(value)
+			case prop.XMLName.Local == propGID:
+				gid, err = strconv.Atoi(value)
+			case prop.XMLName.Local == propMtime:
+				var v int64
+				v, err = strconv.ParseInt(value, 10, 64)
+				t := time.Unix(0, v)
+				mtime = &t
+			default:
+				err = os.ErrInvalid
+			}
+			nameOnly := pkgwebdav.Property{XMLName: prop.XMLName}
+			if err != nil {
+				forbidden = append(forbidden, nameOnly)
+				continue
+			}
+			applied = append(applied, nameOnly)
+		}
+	}
+	if len(forbidden) > 0 {
+		propstats := []pkgwebdav.Propstat{{Props: forbidden, Status: http.StatusForbidden}}
+		if len(applied) > 0 {
+			propstats = append(propstats, pkgwebdav.Propstat{Props: applied, Status: http.StatusFailedDependency})
+		}
+		return propstats, nil
+	}
+
+	ctx := f.ctx
+	if mode != nil {
+		if err := f.storage.Chmod(ctx, nil, f.path, *mode); err != nil {
+			return nil, record(ctx, osErrorOf("chmod", f.name, err))
+		}
+	}
+	if uid != -1 || gid != -1 {
+		if err := f.storage.Chown(ctx, nil, f.path, uid, gid, false); err != nil {
+			return nil, record(ctx, osErrorOf("chown", f.name, err))
+		}
+	}
+	if mtime != nil {
+		if err := f.storage.Chtimes(ctx, nil, f.path, *mtime, *mtime); err != nil {
+			return nil, record(ctx, osErrorOf("chtimes", f.name, err))
+		}
+	}
+	return []pkgwebdav.Propstat{{Props: applied, Status: http.StatusOK}}, nil
+}
@@ -0,0 +1,97 @@
+package webdav
+
+import (
+	"strings"
+
+	pkgwebdav "golang.org/x/net/webdav"
+)
+
+// ifList is a list of the conditions of the If header (RFC 4918,
+// section 10.4) which should be all true; the header is true if any of
+// its lists is true. The conditions apply to the resource of the request
+// if resourceTag is empty.
+type ifList struct {
+	resourceTag string
+	conditions  []pkgwebdav.Condition
+}
+
+// parseIfHeader parses the value of the If header: either lists
+// "(<token> [etag])" or lists tagged by resources "<url> (Not <token>)".
+func parseIfHeader(header string) ([]ifList, bool) {
+	var lists []ifList
+	var resourceTag string
+	isTagged := false
+	s := strings.TrimSpace(header)
+	for s != "" {
+		switch s[0] {
+		case '<':
+			if len(lists) > 0 && !isTagged {
+				// the tagged and the untagged lists are not mixed
+				return nil, false
+			}
+			isTagged = true
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				return nil, false
+			}
+			resourceTag, s = s[1:end], strings.TrimSpace(s[end+1:])
+			if !strings.HasPrefix(s, "(") {
+				return nil, false
+			}
+		case '(':
+			list, rest, ok := parseIfList(s[1:])
+			if !ok {
+				return nil, false
+			}
+			list.resourceTag = resourceTag
+			lists = append(lists, list)
+			s = strings.TrimSpace(rest)
+		default:
+			return nil, false
+		}
+	}
+	return lists, len(lists) > 0
+}
+
+// parseIfList parses the conditions of a list up to its ")" and returns
+// the rest of the header.
+func parseIfList(s string) (ifList, string, bool) {
+	var list ifList
+	for {
+		s = strings.TrimSpace(s)
+		var cond pkgwebdav.Condition
+		if strings.HasPrefix(s, "Not") {
+			cond.Not = true
+			s = strings.TrimSpace(s[len("Not"):])
+		}
+		if s == "" {
+			return ifList{}, "", false
+		}
+
+		var end int
+		switch s[0] {
+		case ')':
+			if cond.Not || len(list.conditions) == 0 {
+				return ifList{}, "", false
+			}
+			return list, s[1:], true
+		case '<':
+			end = strings.IndexByte(s, '>')
+			if end >= 0 {
+				cond.Token = s[1:end]
+			}
+		case '[':
+			end = strings.IndexByte(s, ']')
+			if end >= 0 {
+				cond.ETag = s[1:end]
+			}
+		default:
+			return ifList{}, "", false
+		}
+		if end < 0 {
+			return ifList{}, "", false
+		}
+		list.conditions = append(list.conditions, cond)
+		s = s[end+1:]
+	}
+}
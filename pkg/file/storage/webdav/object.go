@@ -0,0 +1,68 @@
+package webdav
+
+import (
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Object = &Object{}
+
+// Object is an object of the share accessed by its path.
+type Object struct {
+	StorageValue *Storage
+	LastInfo     os.FileInfo
+	LastPath     file.Path
+}
+
+// ID returns the zero ID: WebDAV has no inode numbers.
+func (obj *Object) ID() file.ObjectID {
+	return file.ObjectID{}
+}
+
+func (obj *Object) Name() string {
+	return obj.LastInfo.Name()
+}
+
+func (obj *Object) Stat() (os.FileInfo, error) {
+	info, err := obj.StorageValue.stat(obj.StorageValue.ctx, obj.LastPath)
+	if err != nil {
+		return nil, err
+	}
+	obj.LastInfo = info
+	return info, nil
+}
+
+func (obj *Object) LastStat() os.FileInfo {
+	return obj.LastInfo
+}
+
+func (obj *Object) Path() file.Path {
+	return obj.LastPath
+}
+
+// Close does nothing: the objects are not opened on the server (see
+// File.Close).
+func (obj *Object) Close() error {
+	return nil
+}
+
+func (obj *Object) Chmod(mode os.FileMode) error {
+	stor := obj.StorageValue
+	return stor.Chmod(stor.ctx, nil, obj.LastPath, mode)
+}
+
+func (obj *Object) Chown(uid, gid int) error {
+	stor := obj.StorageValue
+	return stor.Chown(stor.ctx, nil, obj.LastPath, uid, gid, false)
+}
+
+func (obj *Object) Storage() file.Storage {
+	return obj.StorageValue
+}
+
+// FD returns an invalid file descriptor: the object is on another
+// machine.
+func (obj *Object) FD() uintptr {
+	return ^uintptr(0)
+}
@@ -0,0 +1,68 @@
+package webdav
+
+import (
+	"net/http"
+	"os"
+)
+
+type Option interface {
+	apply(*Config)
+}
+
+type OptionCredentials struct {
+	Username string
+	Password string
+}
+
+func (opt OptionCredentials) apply(cfg *Config) {
+	cfg.Username = opt.Username
+	cfg.Password = opt.Password
+}
+
+type OptionHTTPClient struct {
+	Client *http.Client
+}
+
+func (opt OptionHTTPClient) apply(cfg *Config) {
+	cfg.HTTPClient = opt.Client
+}
+
+type OptionRangedPut struct {
+	Enable bool
+}
+
+func (opt OptionRangedPut) apply(cfg *Config) {
+	cfg.RangedPut = opt.Enable
+}
+
+type OptionReadOnly struct {
+	Enable bool
+}
+
+func (opt OptionReadOnly) apply(cfg *Config) {
+	cfg.ReadOnly = opt.Enable
+}
+
+type OptionDirPerm struct {
+	Perm os.FileMode
+}
+
+func (opt OptionDirPerm) apply(cfg *Config) {
+	cfg.DirPerm = opt.Perm
+}
+
+type OptionFilePerm struct {
+	Perm os.FileMode
+}
+
+func (opt OptionFilePerm) apply(cfg *Config) {
+	cfg.FilePerm = opt.Perm
+}
+
+type OptionPrefix struct {
+	Prefix string
+}
+
+func (opt OptionPrefix) apply(cfg *Config) {
+	cfg.Prefix = opt.Prefix
+}
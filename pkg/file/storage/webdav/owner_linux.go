@@ -0,0 +1,17 @@
+// +build linux
+
+package webdav
+
+import (
+	"syscall"
+)
+
+// sysOwnerOf returns the owner of the object if `sys` is
+// *syscall.Stat_t.
+func sysOwnerOf(sys interface{}) (uid, gid int, ok bool) {
+	stat, ok := sys.(*syscall.Stat_t)
+	if !ok {
+		return -1, -1, false
+	}
+	return int(stat.Uid), int(stat.Gid), true
+}
@@ -0,0 +1,9 @@
+// +build !linux
+
+package webdav
+
+// sysOwnerOf reports no owner: the OS-specific attributes are supported
+// only on Linux.
+func sysOwnerOf(sys interface{}) (uid, gid int, ok bool) {
+	return -1, -1, false
+}
@@ -0,0 +1,172 @@
+package webdav
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PropertyNamespace is the XML namespace of the properties with
+// the attributes of the objects which WebDAV has no properties for: the
+// mode, the owner and the mtime with the nanoseconds. A Storage stores
+// them as dead properties (by PROPPATCH), and a Server maps them to
+// the attributes of the objects of its storage.
+const PropertyNamespace = "https://github.com/my-network/fsutil/webdav/"
+
+const (
+	propMode  = "mode"
+	propUID   = "uid"
+	propGID   = "gid"
+	propMtime = "mtime"
+)
+
+// propfindBody is the body of PROPFIND requests of a Storage.
+var propfindBody = []byte(`<?xml version="1.0" encoding="utf-8"?>` +
+	`<D:propfind xmlns:D="DAV:" xmlns:F="` + PropertyNamespace + `"><D:prop>` +
+	`<D:resourcetype/><D:getcontentlength/><D:getlastmodified/><D:getetag/>` +
+	`<F:` + propMode + `/><F:` + propUID + `/><F:` + propGID + `/><F:` + propMtime + `/>` +
+	`</D:prop></D:propfind>`)
+
+type multistatus struct {
+	Responses []response `xml:"DAV: response"`
+}
+
+type response struct {
+	Href      string     `xml:"DAV: href"`
+	Status    string     `xml:"DAV: status"`
+	Propstats []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   prop   `xml:"DAV: prop"`
+}
+
+type prop struct {
+	ResourceType *struct {
+		Collection *struct{} `xml:"DAV: collection"`
+	} `xml:"DAV: resourcetype"`
+	ContentLength string `xml:"DAV: getcontentlength"`
+	LastModified  string `xml:"DAV: getlastmodified"`
+	ETag          string `xml:"DAV: getetag"`
+	Mode          string `xml:"https://github.com/my-network/fsutil/webdav/ mode"`
+	UID           string `xml:"https://github.com/my-network/fsutil/webdav/ uid"`
+	GID           string `xml:"https://github.com/my-network/fsutil/webdav/ gid"`
+	Mtime         string `xml:"https://github.com/my-network/fsutil/webdav/ mtime"`
+}
+
+// formatMode returns the value of the mode property.
+func formatMode(mode os.FileMode) string {
+	return strconv.FormatUint(uint64(mode&^os.ModeType), 8)
+}
+
+// statusCodeOf returns the code of a status line ("HTTP/1.1 200 OK").
+func statusCodeOf(status string) int {
+	fields := strings.Fields(status)
+	if len(fields) < 2 {
+		return 0
+	}
+	code, _ := strconv.Atoi(fields[1])
+	return code
+}
+
+// Stat is the result of os.FileInfo.Sys() of the objects of a Storage.
+type Stat struct {
+	ETag string
+
+	// UID and GID are -1 if the server does not report them.
+	UID int
+	GID int
+}
+
+var _ os.FileInfo = &fileInfo{}
+
+type fileInfo struct {
+	name  string
+	size  int64
+	mode  os.FileMode
+	mtime time.Time
+	stat  Stat
+}
+
+// fileInfoOf returns the os.FileInfo of the response of PROPFIND. Only
+// the properties with the status 200 are taken into account. The
+// permissions of objects without the mode are 0644 (0755 for
+// directories).
+func fileInfoOf(name string, resp *response) *fileInfo {
+	info := &fileInfo{
+		name: name,
+		stat: Stat{UID: -1, GID: -1},
+	}
+	var isDir bool
+	var hasMode bool
+	for _, propstat := range resp.Propstats {
+		if statusCodeOf(propstat.Status) != http.StatusOK {
+			continue
+		}
+		p := &propstat.Prop
+		if p.ResourceType != nil && p.ResourceType.Collection != nil {
+			isDir = true
+		}
+		if size, err := strconv.ParseInt(p.ContentLength, 10, 64); err == nil {
+			info.size = size
+		}
+		if mtime, err := http.ParseTime(p.LastModified); err == nil && info.mtime.IsZero() {
+			info.mtime = mtime
+		}
+		if p.ETag != "" {
+			info.stat.ETag = p.ETag
+		}
+		if mode, err := strconv.ParseUint(strings.TrimSpace(p.Mode), 8, 32); err == nil {
+			info.mode = os.FileMode(mode) &^ os.ModeType
+			hasMode = true
+		}
+		if uid, err := strconv.Atoi(strings.TrimSpace(p.UID)); err == nil {
+			info.stat.UID = uid
+		}
+		if gid, err := strconv.Atoi(strings.TrimSpace(p.GID)); err == nil {
+			info.stat.GID = gid
+		}
+		if mtime, err := strconv.ParseInt(strings.TrimSpace(p.Mtime), 10, 64); err == nil {
+			info.mtime = time.Unix(0, mtime)
+		}
+	}
+	switch {
+	case !hasMode && isDir:
+		info.mode = 0755
+	case !hasMode:
+		info.mode = 0644
+	}
+	if isDir {
+		info.mode |= os.ModeDir
+		info.size = 0
+	}
+	return info
+}
+
+func (info *fileInfo) Name() string {
+	return info.name
+}
+
+func (info *fileInfo) Size() int64 {
+	return info.size
+}
+
+func (info *fileInfo) Mode() os.FileMode {
+	return info.mode
+}
+
+func (info *fileInfo) ModTime() time.Time {
+	return info.mtime
+}
+
+func (info *fileInfo) IsDir() bool {
+	return info.mode.IsDir()
+}
+
+// Sys returns *Stat.
+func (info *fileInfo) Sys() interface{} {
+	return &info.stat
+}
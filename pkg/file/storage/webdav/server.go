@@ -0,0 +1,280 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	pkgwebdav "golang.org/x/net/webdav"
+)
+
+// Server exposes a file.Storage over WebDAV (it is an http.Handler).
+// The client side is Storage, but any WebDAV client may be used.
+//
+// Besides the requests handled by webdav.Handler it supports PUT
+// requests with Content-Range (the ranged writes of
+// Config.RangedPut) and with "If-None-Match: *". The properties of
+// PropertyNamespace are mapped to the mode, the owner and the mtime of
+// the objects.
+type Server struct {
+	Config
+	storage file.Storage
+	handler *pkgwebdav.Handler
+}
+
+// NewServer returns a server of the storage. The locks are kept in
+// memory (webdav.NewMemLS).
+func NewServer(storage file.Storage, opts ...Option) *Server {
+	srv := &Server{
+		storage: storage,
+	}
+	for _, opt := range opts {
+		opt.apply(&srv.Config)
+	}
+	srv.handler = &pkgwebdav.Handler{
+		Prefix:     srv.Prefix,
+		FileSystem: &fileSystem{srv: srv},
+		LockSystem: pkgwebdav.NewMemLS(),
+	}
+	return srv
+}
+
+// modifyingMethods are the methods denied by Config.ReadOnly.
+var modifyingMethods = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+	"MKCOL":           true,
+	"COPY":            true,
+	"MOVE":            true,
+	"PROPPATCH":       true,
+	"LOCK":            true,
+	"UNLOCK":          true,
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if srv.ReadOnly && modifyingMethods[r.Method] {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	recorder := &errRecorder{}
+	r = r.WithContext(context.WithValue(r.Context(), errRecorderKey{}, recorder))
+	if r.Method == http.MethodPut && (r.Header.Get("If-None-Match") == "*" || r.Header.Get("Content-Range") != "") {
+		name, ok := srv.stripPrefix(r.URL.Path)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" {
+			// not atomic: the file may be created concurrently
+			if _, err := srv.handler.FileSystem.Stat(r.Context(), name); err == nil {
+				http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+				return
+			}
+		}
+		if r.Header.Get("Content-Range") != "" {
+			srv.putRange(w, r, name)
+			return
+		}
+	}
+	srv.handler.ServeHTTP(&statusWriter{ResponseWriter: w, recorder: recorder}, r)
+}
+
+// stripPrefix returns the URL path relative to Config.Prefix, and false
+// if the path is out of the prefix (as webdav.Handler does).
+func (srv *Server) stripPrefix(urlPath string) (string, bool) {
+	if srv.Prefix == "" {
+		return urlPath, true
+	}
+	name := strings.TrimPrefix(urlPath, srv.Prefix)
+	return name, len(name) < len(urlPath)
+}
+
+// confirmLocks checks the If header of the request which modifies `name`
+// against the locks of the LockSystem (as webdav.Handler does). The
+// returned function releases the locks, it is nil if the status code is
+// not zero.
+func (srv *Server) confirmLocks(r *http.Request, name string) (func(), int) {
+	lockSystem := srv.handler.LockSystem
+	header := r.Header.Get("If")
+	if header == "" {
+		// the resource should not be locked: lock it for the request
+		token, err := lockSystem.Create(time.Now(), pkgwebdav.LockDetails{
+			Root:      name,
+			Duration:  -1,
+			ZeroDepth: true,
+		})
+		if err != nil {
+			if errors.Is(err, pkgwebdav.ErrLocked) {
+				return nil, pkgwebdav.StatusLocked
+			}
+			return nil, http.StatusInternalServerError
+		}
+		return func() { _ = lockSystem.Unlock(time.Now(), token) }, 0
+	}
+
+	lists, ok := parseIfHeader(header)
+	if !ok {
+		return nil, http.StatusBadRequest
+	}
+	for _, list := range lists {
+		resourceName := name
+		if list.resourceTag != "" {
+			u, err := url.Parse(list.resourceTag)
+			if err != nil || u.Host != r.Host {
+				continue
+			}
+			resourceName, ok = srv.stripPrefix(u.Path)
+			if !ok {
+				return nil, http.StatusNotFound
+			}
+		}
+		release, err := lockSystem.Confirm(time.Now(), resourceName, "", list.conditions...)
+		if errors.Is(err, pkgwebdav.ErrConfirmationFailed) {
+			continue
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError
+		}
+		return release, 0
+	}
+	return nil, http.StatusPreconditionFailed
+}
+
+// putRange writes the body of the PUT request at the offset of its
+// Content-Range ("bytes first-last/total", the total is ignored).
+func (srv *Server) putRange(w http.ResponseWriter, r *http.Request, name string) {
+	var first, last int64
+	_, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/", &first, &last)
+	if err != nil || first < 0 || last < first {
+		http.Error(w, "invalid Content-Range", http.StatusBadRequest)
+		return
+	}
+	release, statusCode := srv.confirmLocks(r, name)
+	if statusCode != 0 {
+		http.Error(w, http.StatusText(statusCode), statusCode)
+		return
+	}
+	defer release()
+
+	ctx := r.Context()
+	path := pathOf(name)
+	_, statErr := srv.storage.Stat(ctx, nil, path, false)
+	obj, err := srv.storage.Open(ctx, nil, path, file.FlagWrite|file.FlagCreate, srv.filePerm())
+	if err != nil {
+		// as webdav.Handler does
+		statusCode := http.StatusNotFound
+		if errors.Is(err, os.ErrNotExist) {
+			statusCode = http.StatusConflict
+		}
+		http.Error(w, http.StatusText(statusCode), refinedStatusCode(err, statusCode))
+		return
+	}
+	defer obj.Close()
+	f, ok := obj.(file.File)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	buf := make([]byte, 1<<16)
+	offset, end := first, last+1
+	for offset < end {
+		chunk := buf
+		if int64(len(chunk)) > end-offset {
+			chunk = chunk[:end-offset]
+		}
+		n, readErr := io.ReadFull(r.Body, chunk)
+		if n > 0 {
+			if _, err := f.WriteAt(chunk[:n], offset); err != nil {
+				http.Error(w, err.Error(), refinedStatusCode(err, http.StatusInternalServerError))
+				return
+			}
+			offset += int64(n)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if offset != end {
+		http.Error(w, "the body is shorter than Content-Range", http.StatusBadRequest)
+		return
+	}
+	if err := f.Close(); err != nil {
+		http.Error(w, err.Error(), refinedStatusCode(err, http.StatusInternalServerError))
+		return
+	}
+	if statErr != nil {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// refinedStatusCode returns the status code which describes the error better
+// than `statusCode` (the one chosen by webdav.Handler, which knows only
+// "not exist" and "exist"), or `statusCode` itself.
+func refinedStatusCode(err error, statusCode int) int {
+	var notImplErr file.ErrNotImplemented
+	switch {
+	case errors.As(err, &notImplErr):
+		return http.StatusNotImplemented
+	case errors.Is(err, os.ErrPermission), errors.Is(err, syscall.EROFS):
+		return http.StatusForbidden
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, os.ErrNotExist) &&
+		(statusCode == http.StatusForbidden || statusCode == http.StatusInternalServerError):
+		// for example, MOVE reports all the errors as 403
+		return http.StatusNotFound
+	}
+	return statusCode
+}
+
+type errRecorderKey struct{}
+
+// errRecorder keeps the result of the last operation of fileSystem
+// within a request (webdav.Handler reports only the status code).
+type errRecorder struct {
+	err error
+}
+
+// record stores the error of the operation (if the context is the one
+// of a request of the Server) and returns it.
+func record(ctx context.Context, err error) error {
+	if recorder, ok := ctx.Value(errRecorderKey{}).(*errRecorder); ok {
+		recorder.err = err
+	}
+	return err
+}
+
+// statusWriter refines the error status codes of webdav.Handler by
+// the recorded error.
+type statusWriter struct {
+	http.ResponseWriter
+	recorder *errRecorder
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if statusCode >= http.StatusBadRequest && w.recorder.err != nil {
+		statusCode = refinedStatusCode(w.recorder.err, statusCode)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// ownerOf returns the owner of the object by the result of
+// os.FileInfo.Sys().
+func ownerOf(sys interface{}) (uid, gid int, ok bool) {
+	if stat, isStat := sys.(*Stat); isStat {
+		return stat.UID, stat.GID, stat.UID != -1 || stat.GID != -1
+	}
+	return sysOwnerOf(sys)
+}
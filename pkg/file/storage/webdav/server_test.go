@@ -0,0 +1,141 @@
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/memfs"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/require"
+	pkgwebdav "golang.org/x/net/webdav"
+)
+
+func TestServerReadOnly(t *testing.T) {
+	backend := memfs.NewStorage()
+	ctx := context.Background()
+	require.NoError(t, backend.Mkdir(ctx, nil, file.Path{"dir"}, 0755, false))
+	httpSrv := httptest.NewServer(NewServer(backend, OptionReadOnly{Enable: true}))
+	defer httpSrv.Close()
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete, "MKCOL", "MOVE", "PROPPATCH"} {
+		req, err := http.NewRequest(method, httpSrv.URL+"/dir/file", strings.NewReader("content"))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, method)
+	}
+	_, err := backend.Stat(ctx, nil, file.Path{"dir", "file"}, false)
+	require.Error(t, err)
+
+	stor, err := NewStorage(httpSrv.URL)
+	require.NoError(t, err)
+	defer stor.Close()
+	info, err := stor.Stat(ctx, nil, file.Path{"dir"}, false)
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+}
+
+// putRange sends a ranged PUT of the content at the offset and returns the
+// status code.
+func putRange(t *testing.T, url string, offset int, content string, header http.Header) int {
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader(content))
+	require.NoError(t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/*", offset, offset+len(content)-1))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return resp.StatusCode
+}
+
+func TestServerPutRangeLocked(t *testing.T) {
+	backend := memfs.NewStorage()
+	httpSrv := httptest.NewServer(NewServer(backend))
+	defer httpSrv.Close()
+	require.Equal(t, http.StatusCreated, putRange(t, httpSrv.URL+"/file", 0, "hello", nil))
+
+	req, err := http.NewRequest("LOCK", httpSrv.URL+"/file", strings.NewReader(
+		`<?xml version="1.0" encoding="utf-8"?>`+
+			`<D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope>`+
+			`<D:locktype><D:write/></D:locktype></D:lockinfo>`))
+	require.NoError(t, err)
+	req.Header.Set("Timeout", "Second-60")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	token := resp.Header.Get("Lock-Token")
+	require.NotEmpty(t, token)
+
+	require.Equal(t, http.StatusLocked, putRange(t, httpSrv.URL+"/file", 0, "J", nil))
+	require.Equal(t, http.StatusPreconditionFailed, putRange(t, httpSrv.URL+"/file", 0, "J",
+		http.Header{"If": {"(<opaquelocktoken:unknown>)"}}))
+	require.Equal(t, http.StatusBadRequest, putRange(t, httpSrv.URL+"/file", 0, "J",
+		http.Header{"If": {"(" + token}}))
+	require.Equal(t, "hello", storagetest.ReadFile(t, backend, file.Path{"file"}))
+
+	require.Equal(t, http.StatusNoContent, putRange(t, httpSrv.URL+"/file", 0, "J",
+		http.Header{"If": {"(" + token + ")"}}))
+	require.Equal(t, http.StatusNoContent, putRange(t, httpSrv.URL+"/file", 1, "E",
+		http.Header{"If": {"<" + httpSrv.URL + "/file> (" + token + ")"}}))
+	require.Equal(t, "JEllo", storagetest.ReadFile(t, backend, file.Path{"file"}))
+}
+
+func TestServerPutRangePrefix(t *testing.T) {
+	backend := memfs.NewStorage()
+	httpSrv := httptest.NewServer(NewServer(backend, OptionPrefix{Prefix: "/dav"}))
+	defer httpSrv.Close()
+
+	require.Equal(t, http.StatusNotFound, putRange(t, httpSrv.URL+"/file", 0, "hello", nil))
+	_, err := backend.Stat(context.Background(), nil, file.Path{"file"}, false)
+	require.True(t, file.IsNotExist(err), err)
+
+	require.Equal(t, http.StatusCreated, putRange(t, httpSrv.URL+"/dav/file", 0, "hello", nil))
+	require.Equal(t, "hello", storagetest.ReadFile(t, backend, file.Path{"file"}))
+}
+
+func TestParseIfHeader(t *testing.T) {
+	lists, ok := parseIfHeader(`(<token1> ["etag"]) (Not <token2>)`)
+	require.True(t, ok)
+	require.Equal(t, []ifList{
+		{conditions: []pkgwebdav.Condition{{Token: "token1"}, {ETag: `"etag"`}}},
+		{conditions: []pkgwebdav.Condition{{Not: true, Token: "token2"}}},
+	}, lists)
+
+	lists, ok = parseIfHeader(`<http://host/a> (<token1>) <http://host/b> (<token2>)`)
+	require.True(t, ok)
+	require.Equal(t, []ifList{
+		{resourceTag: "http://host/a", conditions: []pkgwebdav.Condition{{Token: "token1"}}},
+		{resourceTag: "http://host/b", conditions: []pkgwebdav.Condition{{Token: "token2"}}},
+	}, lists)
+
+	for _, header := range []string{"", "()", "(Not)", "(<token>", "<http://host/a>", "(<token1>) <http://host/a> (<token2>)", "token"} {
+		_, ok = parseIfHeader(header)
+		require.False(t, ok, header)
+	}
+}
+
+func TestRefinedStatusCode(t *testing.T) {
+	require.Equal(t, http.StatusNotImplemented, refinedStatusCode(file.ErrNotImplemented{}, http.StatusInternalServerError))
+	require.Equal(t, http.StatusForbidden, refinedStatusCode(syscall.EACCES, http.StatusInternalServerError))
+	require.Equal(t, http.StatusForbidden, refinedStatusCode(syscall.EROFS, http.StatusMethodNotAllowed))
+	require.Equal(t, http.StatusInsufficientStorage, refinedStatusCode(syscall.ENOSPC, http.StatusInternalServerError))
+	require.Equal(t, http.StatusConflict, refinedStatusCode(syscall.ENOENT, http.StatusConflict))
+	require.Equal(t, http.StatusNotFound, refinedStatusCode(syscall.ENOENT, http.StatusForbidden))
+}
+
+func TestPathOf(t *testing.T) {
+	require.Nil(t, pathOf("/"))
+	require.Nil(t, pathOf(""))
+	require.Equal(t, file.Path{"a", "b"}, pathOf("/a/./b/"))
+	require.Equal(t, file.Path{"b"}, pathOf("/../a/../b"))
+}
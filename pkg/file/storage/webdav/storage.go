@@ -0,0 +1,429 @@
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+var _ file.Storage = &Storage{}
+
+// Storage is a file.Storage of a WebDAV share (the client side of
+// Server).
+//
+// The attributes of the objects are requested by PROPFIND; the mode,
+// the owner and the mtime are stored as the dead properties of
+// PropertyNamespace (by PROPPATCH), so Chmod, Chown and Chtimes return
+// file.ErrNotImplemented if the server does not store them. Files are
+// read by ranged GET requests and written by PUT requests (see
+// Config.RangedPut).
+//
+// WebDAV has neither symlinks nor hardlinks: Symlink and Link return
+// file.ErrNotImplemented.
+type Storage struct {
+	Config
+	ctx      context.Context
+	cancelFn context.CancelFunc
+	baseURL  *url.URL
+}
+
+// NewStorage returns the storage of the collection `baseURL` (for
+// example, "https://dav.example.org/remote.php/webdav/backup").
+func NewStorage(baseURL string, opts ...Option) (*Storage, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the URL '%s': %w", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("the URL '%s' is not an HTTP(S) one", baseURL)
+	}
+	stor := &Storage{
+		Config:  *NewConfig(opts...),
+		baseURL: u,
+	}
+	stor.ctx, stor.cancelFn = context.WithCancel(context.Background())
+	return stor, nil
+}
+
+// Close aborts the requests of the opened objects.
+func (stor *Storage) Close() error {
+	stor.cancelFn()
+	return nil
+}
+
+// ToLocalPath returns the URL of the path.
+func (stor *Storage) ToLocalPath(path file.Path) string {
+	return stor.urlOf(path, false)
+}
+
+// fullPathOf returns the path relative to the root of the storage. Only
+// the directories of the storage are supported as `dirAt`.
+func (stor *Storage) fullPathOf(dirAt file.Object, path file.Path) (file.Path, error) {
+	if dirAt == nil {
+		return path, nil
+	}
+	dir, ok := dirAt.(*Directory)
+	if !ok || dir.StorageValue != stor {
+		return nil, file.ErrNotImplemented{}
+	}
+	return dir.LastPath.Append(path...), nil
+}
+
+// stat returns the info of the object by PROPFIND.
+func (stor *Storage) stat(ctx context.Context, path file.Path) (*fileInfo, error) {
+	responses, err := stor.propfind(ctx, path, false, 0)
+	if err != nil {
+		return nil, errorOf("stat", stor.urlOf(path, false), err)
+	}
+	if len(responses) == 0 {
+		return nil, &os.PathError{Op: "stat", Path: stor.urlOf(path, false), Err: syscall.ENOENT}
+	}
+	name := "/"
+	if len(path) > 0 {
+		name = path[len(path)-1]
+	}
+	return fileInfoOf(name, &responses[0]), nil
+}
+
+// hrefPathOf returns the unescaped path of the href of a response
+// (without the trailing "/" of collections).
+func hrefPathOf(href string) string {
+	if u, err := url.Parse(href); err == nil {
+		href = u.Path
+	}
+	return strings.TrimSuffix(href, "/")
+}
+
+func (stor *Storage) Open(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (file.Object, error) {
+	select {
+	case <-ctx.Done():
+		return nil, file.ErrAborted{}
+	default:
+	}
+
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := stor.stat(ctx, fullPath)
+	switch {
+	case err == nil:
+		if flags.HasCreate() && flags.HasExcl() {
+			return nil, &os.PathError{Op: "open", Path: stor.urlOf(fullPath, false), Err: syscall.EEXIST}
+		}
+	case os.IsNotExist(err) && flags.HasCreate():
+		return stor.createFile(ctx, fullPath, flags, defaultPerm)
+	default:
+		return nil, err
+	}
+
+	obj := Object{
+		StorageValue: stor,
+		LastInfo:     info,
+		LastPath:     fullPath,
+	}
+	switch {
+	case flags.HasPath():
+		return &PathDescriptor{Object: obj}, nil
+	case info.IsDir():
+		return &Directory{Object: obj}, nil
+	}
+	return newFile(obj, flags, false)
+}
+
+// createFile stores an empty file (so it exists while it is written)
+// and sets its permissions if the server supports it.
+func (stor *Storage) createFile(
+	ctx context.Context,
+	path file.Path,
+	flags file.OpenFlag,
+	defaultPerm os.FileMode,
+) (*File, error) {
+	fileURL := stor.urlOf(path, false)
+	header := http.Header{}
+	if flags.HasExcl() {
+		header.Set("If-None-Match", "*")
+	}
+	err := stor.doNoBody(ctx, http.MethodPut, fileURL, header, http.NoBody, 0,
+		http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return nil, errorOf("open", fileURL, err)
+	}
+	err = stor.proppatch(ctx, path, false, map[string]string{
+		propMode: formatMode(defaultPerm),
+	})
+	if err != nil && !isNotImplemented(err) {
+		return nil, errorOf("chmod", fileURL, err)
+	}
+	info, err := stor.stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return newFile(Object{
+		StorageValue: stor,
+		LastInfo:     info,
+		LastPath:     path,
+	}, flags, true)
+}
+
+func (stor *Storage) Stat(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	noFollow bool,
+) (os.FileInfo, error) {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := stor.stat(ctx, fullPath)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Symlink is not supported: WebDAV has no symlinks.
+func (stor *Storage) Symlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	destination file.Path,
+) error {
+	return file.ErrNotImplemented{}
+}
+
+// Readlink returns EINVAL for the existing objects: none of them is
+// a symlink.
+func (stor *Storage) Readlink(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+) (file.Path, error) {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stor.stat(ctx, fullPath); err != nil {
+		return nil, err
+	}
+	return nil, &os.PathError{Op: "readlink", Path: stor.urlOf(fullPath, false), Err: syscall.EINVAL}
+}
+
+func (stor *Storage) Mkdir(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	perms os.FileMode,
+	isRecursive bool,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	if !isRecursive {
+		return stor.mkdir(ctx, fullPath, perms)
+	}
+
+	for idx := range fullPath {
+		info, err := stor.stat(ctx, fullPath[:idx+1])
+		switch {
+		case err == nil:
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: stor.urlOf(fullPath[:idx+1], false), Err: syscall.ENOTDIR}
+			}
+			continue
+		case !os.IsNotExist(err):
+			return err
+		}
+		if err := stor.mkdir(ctx, fullPath[:idx+1], perms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mkdir creates the collection by MKCOL and sets its permissions if
+// the server supports it.
+func (stor *Storage) mkdir(ctx context.Context, path file.Path, perms os.FileMode) error {
+	dirURL := stor.urlOf(path, true)
+	err := stor.doNoBody(ctx, "MKCOL", dirURL, nil, nil, 0, http.StatusCreated)
+	if err != nil {
+		return errorOf("mkdir", dirURL, err)
+	}
+	err = stor.proppatch(ctx, path, true, map[string]string{
+		propMode: formatMode(perms),
+	})
+	if err != nil && !isNotImplemented(err) {
+		return errorOf("chmod", dirURL, err)
+	}
+	return nil
+}
+
+// Remove deletes the collections recursively by DELETE; without
+// isRecursive it checks if the collection is empty first.
+func (stor *Storage) Remove(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	isRecursive bool,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	info, err := stor.stat(ctx, fullPath)
+	if err != nil {
+		if isRecursive && os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	objURL := stor.urlOf(fullPath, info.IsDir())
+	if info.IsDir() && !isRecursive {
+		responses, err := stor.propfind(ctx, fullPath, true, 1)
+		if err != nil {
+			return errorOf("remove", objURL, err)
+		}
+		// the collection itself is one of the responses
+		if len(responses) > 1 {
+			return &os.PathError{Op: "remove", Path: objURL, Err: syscall.ENOTEMPTY}
+		}
+	}
+	err = stor.doNoBody(ctx, http.MethodDelete, objURL, nil, nil, 0, http.StatusOK, http.StatusNoContent)
+	return errorOf("remove", objURL, err)
+}
+
+// Rename moves the object by MOVE (replacing the destination).
+func (stor *Storage) Rename(
+	ctx context.Context,
+	dirAt file.Object,
+	path, newPath file.Path,
+) error {
+	return stor.copyOrMove(ctx, "MOVE", dirAt, path, newPath)
+}
+
+// Copy copies the object (recursively) by COPY on the server side,
+// replacing the destination.
+func (stor *Storage) Copy(
+	ctx context.Context,
+	dirAt file.Object,
+	path, newPath file.Path,
+) error {
+	return stor.copyOrMove(ctx, "COPY", dirAt, path, newPath)
+}
+
+func (stor *Storage) copyOrMove(
+	ctx context.Context,
+	method string,
+	dirAt file.Object,
+	path, newPath file.Path,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	newFullPath, err := stor.fullPathOf(dirAt, newPath)
+	if err != nil {
+		return err
+	}
+	srcURL, dstURL := stor.urlOf(fullPath, false), stor.urlOf(newFullPath, false)
+	header := http.Header{}
+	header.Set("Destination", dstURL)
+	header.Set("Overwrite", "T")
+	if method == "COPY" {
+		header.Set("Depth", "infinity")
+	}
+	err = stor.doNoBody(ctx, method, srcURL, header, nil, 0, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return &os.LinkError{Op: strings.ToLower(method), Old: srcURL, New: dstURL, Err: errnoOf(err)}
+	}
+	return nil
+}
+
+// Link is not supported: WebDAV has no hardlinks.
+func (stor *Storage) Link(
+	ctx context.Context,
+	dirAt file.Object,
+	path, destination file.Path,
+) error {
+	return file.ErrNotImplemented{}
+}
+
+func (stor *Storage) Chmod(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	mode os.FileMode,
+) error {
+	return stor.setProps(ctx, "chmod", dirAt, path, map[string]string{
+		propMode: formatMode(mode),
+	})
+}
+
+func (stor *Storage) Chown(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	uid, gid int,
+	noFollow bool,
+) error {
+	props := map[string]string{}
+	// -1 keeps the value, as in chown(2)
+	if uid != -1 {
+		props[propUID] = strconv.Itoa(uid)
+	}
+	if gid != -1 {
+		props[propGID] = strconv.Itoa(gid)
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	return stor.setProps(ctx, "chown", dirAt, path, props)
+}
+
+// Chtimes stores only the mtime: WebDAV has no atime.
+func (stor *Storage) Chtimes(
+	ctx context.Context,
+	dirAt file.Object,
+	path file.Path,
+	atime time.Time,
+	mtime time.Time,
+) error {
+	return stor.setProps(ctx, "chtimes", dirAt, path, map[string]string{
+		propMtime: strconv.FormatInt(mtime.UnixNano(), 10),
+	})
+}
+
+func (stor *Storage) setProps(
+	ctx context.Context,
+	op string,
+	dirAt file.Object,
+	path file.Path,
+	props map[string]string,
+) error {
+	fullPath, err := stor.fullPathOf(dirAt, path)
+	if err != nil {
+		return err
+	}
+	err = stor.proppatch(ctx, fullPath, false, props)
+	if isNotImplemented(err) {
+		return err
+	}
+	return errorOf(op, stor.urlOf(fullPath, false), err)
+}
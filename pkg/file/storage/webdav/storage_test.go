@@ -0,0 +1,136 @@
+package webdav
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/memfs"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStorage returns a Storage of a Server (of a memfs storage) on
+// an in-process HTTP server.
+func newTestStorage(t *testing.T, opts ...Option) (*Storage, *memfs.Storage) {
+	backend := memfs.NewStorage()
+	httpSrv := httptest.NewServer(NewServer(backend, OptionPrefix{Prefix: "/dav"}))
+	stor, err := NewStorage(httpSrv.URL+"/dav/", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, stor.Close())
+		httpSrv.Close()
+	})
+	return stor, backend
+}
+
+func TestNewStorage(t *testing.T) {
+	_, err := NewStorage("ftp://example.org/")
+	require.Error(t, err)
+}
+
+func TestStorage(t *testing.T) {
+	stor, backend := newTestStorage(t)
+	storagetest.Run(t, stor,
+		storagetest.OptionNoSymlinks{Enable: true},
+		storagetest.OptionNoHardlinks{Enable: true})
+
+	// the paths are relative to the prefix of the server
+	require.Equal(t, "other", storagetest.ReadFile(t, backend, file.Path{"file"}))
+	info, err := stor.Stat(context.Background(), nil, file.Path{"file"}, false)
+	require.NoError(t, err)
+	require.NotEmpty(t, info.Sys().(*Stat).ETag)
+	_, err = stor.Readlink(context.Background(), nil, file.Path{"file"})
+	require.Error(t, err)
+}
+
+func TestStorageNames(t *testing.T) {
+	stor, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	// the names are escaped in the URLs
+	require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0750, false))
+	for _, name := range []string{"b c", "d%"} {
+		storagetest.WriteFile(t, stor, file.Path{"dir", name}, name)
+	}
+	obj, err := stor.Open(ctx, nil, file.Path{"dir"}, file.FlagWalkDefaults, 0000)
+	require.NoError(t, err)
+	entries, err := obj.(*Directory).Readdir(0)
+	require.NoError(t, err)
+	require.NoError(t, obj.Close())
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"b c", "d%"}, names)
+	require.Equal(t, "b c", storagetest.ReadFile(t, stor, file.Path{"dir", "b c"}))
+
+	// the parent directories are not created implicitly
+	_, err = stor.Open(ctx, nil, file.Path{"missing", "file"}, file.FlagWrite|file.FlagCreate, 0600)
+	require.True(t, os.IsNotExist(err), err)
+	err = stor.Mkdir(ctx, nil, file.Path{"missing", "dir"}, 0750, false)
+	require.True(t, os.IsNotExist(err), err)
+
+	require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir"}, true))
+	require.NoError(t, stor.Remove(ctx, nil, file.Path{"dir"}, true))
+}
+
+func TestStorageMetadata(t *testing.T) {
+	stor, backend := newTestStorage(t)
+	ctx := context.Background()
+
+	// the properties are mapped to the metadata of the backend
+	storagetest.WriteFile(t, stor, file.Path{"meta"}, "content")
+	require.NoError(t, stor.Chmod(ctx, nil, file.Path{"meta"}, 0604))
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	require.NoError(t, stor.Chtimes(ctx, nil, file.Path{"meta"}, mtime, mtime))
+	require.NoError(t, stor.Chown(ctx, nil, file.Path{"meta"}, 1000, -1, false))
+
+	info, err := stor.Stat(ctx, nil, file.Path{"meta"}, false)
+	require.NoError(t, err)
+	require.True(t, mtime.Equal(info.ModTime()), info.ModTime())
+	backendInfo, err := backend.Stat(ctx, nil, file.Path{"meta"}, false)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0604), backendInfo.Mode())
+	require.True(t, mtime.Equal(backendInfo.ModTime()))
+	require.Equal(t, 1000, backendInfo.Sys().(*memfs.Stat).UID)
+
+	require.NoError(t, stor.Copy(ctx, nil, file.Path{"meta"}, file.Path{"copy"}))
+	require.Equal(t, "content", storagetest.ReadFile(t, stor, file.Path{"copy"}))
+	require.Equal(t, "content", storagetest.ReadFile(t, stor, file.Path{"meta"}))
+}
+
+func TestStorageRangedPut(t *testing.T) {
+	stor, backend := newTestStorage(t, OptionRangedPut{Enable: true})
+	ctx := context.Background()
+
+	obj, err := stor.Open(ctx, nil, file.Path{"file"}, file.FlagReadWrite|file.FlagCreate, 0600)
+	require.NoError(t, err)
+	f := obj.(*File)
+	_, err = f.Write([]byte("hello"))
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte(" world"), 5)
+	require.NoError(t, err)
+
+	// the writes are sent immediately
+	require.Equal(t, "hello world", storagetest.ReadFile(t, backend, file.Path{"file"}))
+
+	b := make([]byte, 5)
+	n, err := f.ReadAt(b, 6)
+	require.NoError(t, err)
+	require.Equal(t, "world", string(b[:n]))
+	require.NoError(t, f.Close())
+
+	obj, err = stor.Open(ctx, nil, file.Path{"file"}, file.FlagWrite|file.FlagTrunc, 0000)
+	require.NoError(t, err)
+	_, err = obj.(*File).Write([]byte("new"))
+	require.NoError(t, err)
+	require.NoError(t, obj.Close())
+	require.Equal(t, "new", storagetest.ReadFile(t, stor, file.Path{"file"}))
+}
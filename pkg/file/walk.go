@@ -39,13 +39,17 @@ func Walk(
 		if err := errorHandlerFn(ErrWalkOpen{Dir: nil, Child: nil, Err: err}); err != nil {
 			return err
 		}
+		return nil
 	}
+	// an open descriptor keeps a deleted directory alive (and watched)
+	defer func() { _ = dirObj.Close() }()
 
 	dir, ok := dirObj.(Directory)
 	if !ok {
-		if err := errorHandlerFn(ErrWalkNotDir{Dir: dir, Child: dir}); err != nil {
+		if err := errorHandlerFn(ErrWalkNotDir{Dir: nil, Child: dirObj}); err != nil {
 			return err
 		}
+		return nil
 	}
 
 	dirInfo := curDirInfo{FileInfo: dir.LastStat()}
@@ -92,16 +96,21 @@ func walkDir(
 			if err := errorHandlerFn(ErrWalkOpen{Dir: dir, Child: childInfo, Err: err}); err != nil {
 				return err
 			}
+			continue
 		}
 
 		child, ok := childObj.(Directory)
 		if !ok {
-			if err := errorHandlerFn(ErrWalkNotDir{Dir: dir, Child: childObj}); err != nil {
+			err := errorHandlerFn(ErrWalkNotDir{Dir: dir, Child: childObj})
+			_ = childObj.Close()
+			if err != nil {
 				return err
 			}
+			continue
 		}
 
 		err = walkDir(ctx, child, callback, shouldWalkFn, errorHandlerFn)
+		_ = child.Close()
 		if err != nil {
 			return err
 		}
@@ -13,6 +13,18 @@ var (
 		EnableChecksums:    false,
 		AggregationTimeMin: time.Second,
 		AggregationTimeMax: time.Second * 10,
+		TaskKindPriorities: TaskKindPriorities{
+			TaskKindMetadata:  2,
+			TaskKindSmallFile: 2,
+			TaskKindDeletion:  1,
+			TaskKindHugeFile:  -2,
+			TaskKindSubtree:   -1,
+		},
+		PriorityAgingInterval:    time.Second,
+		SmallFileSizeMax:         1 << 16,
+		HugeFileSizeMin:          1 << 30,
+		TaskCountMax:             1 << 20,
+		SubtreeCoalesceThreshold: 1 << 10,
 	}
 )
 
@@ -25,6 +37,46 @@ type Config struct {
 	EnableChecksums    bool
 	AggregationTimeMin time.Duration
 	AggregationTimeMax time.Duration
+
+	// TaskKindPriorities and PriorityRules define the priority of a task,
+	// see Config.taskPriority.
+	TaskKindPriorities TaskKindPriorities
+	PriorityRules      []PriorityRule
+
+	// PriorityAgingInterval is the head start given by each point of
+	// priority: an expired task with priority N is taken by a worker as if
+	// it expired N*PriorityAgingInterval earlier. Thus low-priority tasks
+	// are delayed, but never starved. Zero disables priorities.
+	PriorityAgingInterval time.Duration
+
+	SmallFileSizeMax int64
+	HugeFileSizeMin  int64 // zero means no file is considered huge
+
+	// TaskCountMax limits the amount of queued tasks (and so the memory
+	// consumption). If the limit is reached, tasks are collapsed into
+	// recursive "resync subtree" tasks until 90% of the limit is used
+	// (see taskCountLowWater). Zero means no limit.
+	TaskCountMax int
+
+	// SubtreeCoalesceThreshold is the amount of tasks inside of one
+	// directory to collapse them into a single recursive task.
+	// Zero disables the collapsing (unless TaskCountMax is reached).
+	SubtreeCoalesceThreshold int
+
+	// ScrubInterval is the period to verify the whole destination against
+	// the source, see Syncer.Scrub. Zero disables periodic scrubs.
+	ScrubInterval time.Duration
+
+	// ScrubRateLimit is the maximal amount of objects checked per second
+	// by a scrub (up to one per nanosecond). Zero means no limit.
+	ScrubRateLimit uint
+
+	// ScrubChecksums enables comparing the content of files by a scrub.
+	ScrubChecksums bool
+
+	// ScrubReportHandler (if not nil) is called with the result of each
+	// periodic scrub.
+	ScrubReportHandler func(*ScrubReport)
 }
 
 func NewConfig(opts ...Option) *Config {
@@ -35,10 +87,37 @@ func NewConfig(opts ...Option) *Config {
 	return cfg
 }
 
+// taskCountLowWater returns the amount of tasks to collapse the queue
+// down to once TaskCountMax is reached.
+func (cfg Config) taskCountLowWater() int {
+	return cfg.TaskCountMax - cfg.TaskCountMax/10
+}
+
 func (cfg Config) Validate() error {
 	if cfg.AggregationTimeMax < cfg.AggregationTimeMin {
 		return fmt.Errorf("cfg.AggregationTimeMax (%v) < cfg.AggregationTimeMin (%v)",
 			cfg.AggregationTimeMax, cfg.AggregationTimeMin)
 	}
+	if cfg.PriorityAgingInterval < 0 {
+		return fmt.Errorf("cfg.PriorityAgingInterval (%v) < 0",
+			cfg.PriorityAgingInterval)
+	}
+	if cfg.HugeFileSizeMin > 0 && cfg.HugeFileSizeMin <= cfg.SmallFileSizeMax {
+		return fmt.Errorf("cfg.HugeFileSizeMin (%d) <= cfg.SmallFileSizeMax (%d)",
+			cfg.HugeFileSizeMin, cfg.SmallFileSizeMax)
+	}
+	if cfg.ScrubInterval < 0 {
+		return fmt.Errorf("cfg.ScrubInterval (%v) < 0", cfg.ScrubInterval)
+	}
+	if cfg.ScrubRateLimit > uint(time.Second) {
+		// the interval between checks would be zero
+		return fmt.Errorf("cfg.ScrubRateLimit (%d) > %d", cfg.ScrubRateLimit, uint(time.Second))
+	}
+	if cfg.TaskCountMax < 0 {
+		return fmt.Errorf("cfg.TaskCountMax (%d) < 0", cfg.TaskCountMax)
+	}
+	if cfg.SubtreeCoalesceThreshold < 0 {
+		return fmt.Errorf("cfg.SubtreeCoalesceThreshold (%d) < 0", cfg.SubtreeCoalesceThreshold)
+	}
 	return nil
 }
@@ -0,0 +1,125 @@
+package syncer
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// sync makes the object on `path` in the destination the same as in
+// the source: it is created, replaced or removed as needed. Children of
+// a directory are not synced (they have tasks of their own).
+func (syncer *Syncer) sync(path file.Path) error {
+	ctx := syncer.ctx
+	srcInfo, err := syncer.src.Stat(ctx, nil, path, true)
+	if err != nil {
+		if !file.IsNotExist(err) {
+			return fmt.Errorf("unable to stat src '%s': %w", path.LocalPath(), err)
+		}
+		err = syncer.dst.Remove(ctx, nil, path, true)
+		if err != nil {
+			return fmt.Errorf("unable to remove dst '%s': %w", path.LocalPath(), err)
+		}
+		return nil
+	}
+
+	dstInfo, err := syncer.dst.Stat(ctx, nil, path, true)
+	switch {
+	case err == nil:
+	case file.IsNotExist(err):
+		dstInfo = nil
+	default:
+		return fmt.Errorf("unable to stat dst '%s': %w", path.LocalPath(), err)
+	}
+	if dstInfo != nil && (srcInfo.Mode()&os.ModeType != dstInfo.Mode()&os.ModeType || srcInfo.Mode()&os.ModeSymlink != 0) {
+		// the type could not be changed in-place (and a symlink could
+		// not be re-targeted)
+		err = syncer.dst.Remove(ctx, nil, path, true)
+		if err != nil {
+			return fmt.Errorf("unable to remove dst '%s': %w", path.LocalPath(), err)
+		}
+		dstInfo = nil
+	}
+
+	switch {
+	case srcInfo.Mode().IsDir():
+		if dstInfo == nil {
+			err = syncer.dst.Mkdir(ctx, nil, path, srcInfo.Mode().Perm(), false)
+			if err != nil {
+				return fmt.Errorf("unable to create dst directory '%s': %w", path.LocalPath(), err)
+			}
+		}
+	case srcInfo.Mode()&os.ModeSymlink != 0:
+		destination, err := syncer.src.Readlink(ctx, nil, path)
+		if err != nil {
+			return fmt.Errorf("unable to read src symlink '%s': %w", path.LocalPath(), err)
+		}
+		err = syncer.dst.Symlink(ctx, nil, path, destination)
+		if err != nil {
+			return fmt.Errorf("unable to create dst symlink '%s': %w", path.LocalPath(), err)
+		}
+		// there is no portable way to change the metadata of a symlink
+		return nil
+	case srcInfo.Mode().IsRegular():
+		err = syncer.copyContent(path, srcInfo)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unable to sync '%s' of type %v: %w",
+			path.LocalPath(), srcInfo.Mode()&os.ModeType, file.ErrNotImplemented{})
+	}
+
+	if dstInfo == nil || dstInfo.Mode() != srcInfo.Mode() {
+		err = syncer.dst.Chmod(ctx, nil, path, srcInfo.Mode())
+		if err != nil {
+			return fmt.Errorf("unable to chmod dst '%s': %w", path.LocalPath(), err)
+		}
+	}
+	if srcInfo.Mode().IsRegular() {
+		// the modification time is compared to find drifts, see compareInfo
+		err = syncer.dst.Chtimes(ctx, nil, path, srcInfo.ModTime(), srcInfo.ModTime())
+		if err != nil {
+			return fmt.Errorf("unable to chtimes dst '%s': %w", path.LocalPath(), err)
+		}
+	}
+	return nil
+}
+
+// copyContent overwrites the content of the regular file on `path` in
+// the destination by the content in the source.
+func (syncer *Syncer) copyContent(path file.Path, srcInfo os.FileInfo) error {
+	ctx := syncer.ctx
+	srcObj, err := syncer.src.Open(ctx, nil, path, file.FlagRead|file.FlagNoFollow|file.FlagNoATime, 0000)
+	if err != nil {
+		return fmt.Errorf("unable to open src file '%s': %w", path.LocalPath(), err)
+	}
+	defer func() { _ = srcObj.Close() }()
+	src, ok := srcObj.(file.File)
+	if !ok {
+		return fmt.Errorf("src '%s' is not a regular file: %T", path.LocalPath(), srcObj)
+	}
+
+	dstObj, err := syncer.dst.Open(ctx, nil, path, file.FlagWrite|file.FlagCreate|file.FlagTrunc|file.FlagNoFollow, srcInfo.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("unable to open dst file '%s': %w", path.LocalPath(), err)
+	}
+	dst, ok := dstObj.(file.File)
+	if !ok {
+		_ = dstObj.Close()
+		return fmt.Errorf("dst '%s' is not a regular file: %T", path.LocalPath(), dstObj)
+	}
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		_ = dst.Close()
+		return fmt.Errorf("unable to copy '%s': %w", path.LocalPath(), err)
+	}
+	err = dst.Close()
+	if err != nil {
+		return fmt.Errorf("unable to close dst file '%s': %w", path.LocalPath(), err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package syncer
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/memfs"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSyncer returns a Syncer between two memfs storages without any
+// background routines.
+func newTestSyncer(ctx context.Context, opts ...Option) *Syncer {
+	cfg := DefaultConfig
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	return &Syncer{
+		config: cfg,
+		ctx:    ctx,
+		src:    memfs.NewStorage(),
+		dst:    memfs.NewStorage(),
+	}
+}
+
+func TestSyncerSync(t *testing.T) {
+	ctx := context.Background()
+	syncer := newTestSyncer(ctx)
+	src, dst := syncer.src, syncer.dst
+	mtime := time.Unix(1000, 0)
+
+	require.NoError(t, src.Mkdir(ctx, nil, file.Path{"dir"}, 0750, false))
+	storagetest.WriteFile(t, src, file.Path{"dir", "file"}, "hello")
+	require.NoError(t, src.Chmod(ctx, nil, file.Path{"dir", "file"}, 0600))
+	require.NoError(t, src.Chtimes(ctx, nil, file.Path{"dir", "file"}, mtime, mtime))
+	require.NoError(t, src.Symlink(ctx, nil, file.Path{"symlink"}, file.Path{"dir", "file"}))
+
+	t.Run("create", func(t *testing.T) {
+		for _, path := range []file.Path{{"dir"}, {"dir", "file"}, {"symlink"}} {
+			require.NoError(t, syncer.sync(path))
+		}
+		info, err := dst.Stat(ctx, nil, file.Path{"dir"}, true)
+		require.NoError(t, err)
+		require.Equal(t, os.ModeDir|0750, info.Mode())
+		info, err = dst.Stat(ctx, nil, file.Path{"dir", "file"}, true)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode())
+		require.True(t, mtime.Equal(info.ModTime()))
+		require.Equal(t, "hello", storagetest.ReadFile(t, dst, file.Path{"dir", "file"}))
+		target, err := dst.Readlink(ctx, nil, file.Path{"symlink"})
+		require.NoError(t, err)
+		require.Equal(t, file.Path{"dir", "file"}, target)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		storagetest.WriteFile(t, src, file.Path{"dir", "file"}, "hi")
+		require.NoError(t, syncer.sync(file.Path{"dir", "file"}))
+		require.Equal(t, "hi", storagetest.ReadFile(t, dst, file.Path{"dir", "file"}))
+
+		// the type is changed
+		require.NoError(t, src.Remove(ctx, nil, file.Path{"symlink"}, false))
+		require.NoError(t, src.Mkdir(ctx, nil, file.Path{"symlink"}, 0700, false))
+		require.NoError(t, syncer.sync(file.Path{"symlink"}))
+		info, err := dst.Stat(ctx, nil, file.Path{"symlink"}, true)
+		require.NoError(t, err)
+		require.Equal(t, os.ModeDir|0700, info.Mode())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		require.NoError(t, src.Remove(ctx, nil, file.Path{"dir"}, true))
+		require.NoError(t, syncer.sync(file.Path{"dir"}))
+		_, err := dst.Stat(ctx, nil, file.Path{"dir"}, true)
+		require.True(t, file.IsNotExist(err), err)
+
+		// already removed
+		require.NoError(t, syncer.sync(file.Path{"dir"}))
+	})
+}
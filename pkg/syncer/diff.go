@@ -0,0 +1,203 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// DiffReason describes how an object differs between the source and
+// the destination.
+type DiffReason uint8
+
+const (
+	DiffReasonMissing = DiffReason(iota) // exists in the source only
+	DiffReasonExtra                      // exists in the destination only
+	DiffReasonType
+	DiffReasonMode
+	DiffReasonSize
+	DiffReasonModTime
+	DiffReasonContent
+)
+
+func (reason DiffReason) String() string {
+	switch reason {
+	case DiffReasonMissing:
+		return "missing"
+	case DiffReasonExtra:
+		return "extra"
+	case DiffReasonType:
+		return "type"
+	case DiffReasonMode:
+		return "mode"
+	case DiffReasonSize:
+		return "size"
+	case DiffReasonModTime:
+		return "mod_time"
+	case DiffReasonContent:
+		return "content"
+	}
+	return fmt.Sprintf("unknown_%d", uint8(reason))
+}
+
+// DiffFunc is called for each object which differs between the source
+// and the destination.
+type DiffFunc func(path file.Path, reason DiffReason) error
+
+// compareInfo compares the metadata of an object in the source and in
+// the destination. Returns false if they are considered equal.
+func compareInfo(src, dst os.FileInfo) (DiffReason, bool) {
+	if src.Mode()&os.ModeType != dst.Mode()&os.ModeType {
+		return DiffReasonType, true
+	}
+	if src.Mode() != dst.Mode() {
+		return DiffReasonMode, true
+	}
+	if !src.Mode().IsRegular() {
+		return 0, false
+	}
+	if src.Size() != dst.Size() {
+		return DiffReasonSize, true
+	}
+	if !src.ModTime().Equal(dst.ModTime()) {
+		return DiffReasonModTime, true
+	}
+	return 0, false
+}
+
+// diffOptions tunes diffSubtree.
+type diffOptions struct {
+	// CompareContent enables comparing hashes of the content of regular
+	// files which have equal metadata.
+	CompareContent bool
+
+	// VisitFn (if not nil) is called before checking each object.
+	VisitFn func(path file.Path) error
+}
+
+// walkPath returns the path of an object passed to a file.CallbackFunc.
+func walkPath(dir file.Directory, info os.FileInfo) file.Path {
+	if info.Name() == "." { // the root of the walk, see file.Walk
+		return dir.Path()
+	}
+	return dir.Path().Append(info.Name())
+}
+
+// diffSubtree walks the subtree on `path` in both storages and calls
+// `diffFn` for every object which differs.
+func (syncer *Syncer) diffSubtree(
+	ctx context.Context,
+	path file.Path,
+	opts diffOptions,
+	diffFn DiffFunc,
+	errorHandlerFn file.ErrorHandlerFunc,
+) error {
+	err := file.Walk(
+		ctx,
+		syncer.src,
+		nil,
+		path,
+		func(dir file.Directory, srcInfo os.FileInfo) error {
+			objPath := walkPath(dir, srcInfo)
+			if opts.VisitFn != nil {
+				if err := opts.VisitFn(objPath); err != nil {
+					return err
+				}
+			}
+			dstInfo, err := syncer.dst.Stat(ctx, nil, objPath, true)
+			if err != nil {
+				if file.IsNotExist(err) {
+					return diffFn(objPath, DiffReasonMissing)
+				}
+				return fmt.Errorf("unable to stat dst '%s': %w", objPath.LocalPath(), err)
+			}
+			if reason, isDifferent := compareInfo(srcInfo, dstInfo); isDifferent {
+				return diffFn(objPath, reason)
+			}
+			if !opts.CompareContent || !srcInfo.Mode().IsRegular() {
+				return nil
+			}
+			isEqual, err := syncer.compareContent(ctx, objPath)
+			if err != nil {
+				return err
+			}
+			if !isEqual {
+				return diffFn(objPath, DiffReasonContent)
+			}
+			return nil
+		},
+		nil,
+		errorHandlerFn,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to walk src: %w", err)
+	}
+
+	err = file.Walk(
+		ctx,
+		syncer.dst,
+		nil,
+		path,
+		func(dir file.Directory, dstInfo os.FileInfo) error {
+			objPath := walkPath(dir, dstInfo)
+			if opts.VisitFn != nil {
+				if err := opts.VisitFn(objPath); err != nil {
+					return err
+				}
+			}
+			_, err := syncer.src.Stat(ctx, nil, objPath, true)
+			if err != nil {
+				if file.IsNotExist(err) {
+					return diffFn(objPath, DiffReasonExtra)
+				}
+				return fmt.Errorf("unable to stat src '%s': %w", objPath.LocalPath(), err)
+			}
+			return nil
+		},
+		nil,
+		errorHandlerFn,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to walk dst: %w", err)
+	}
+
+	return nil
+}
+
+// compareContent returns true if the content of the file on `path` is
+// the same in the source and in the destination.
+func (syncer *Syncer) compareContent(ctx context.Context, path file.Path) (bool, error) {
+	srcHash, err := hashContent(ctx, syncer.src, path)
+	if err != nil {
+		return false, fmt.Errorf("unable to hash src '%s': %w", path.LocalPath(), err)
+	}
+	dstHash, err := hashContent(ctx, syncer.dst, path)
+	if err != nil {
+		return false, fmt.Errorf("unable to hash dst '%s': %w", path.LocalPath(), err)
+	}
+	return bytes.Equal(srcHash, dstHash), nil
+}
+
+func hashContent(ctx context.Context, storage file.Storage, path file.Path) ([]byte, error) {
+	obj, err := storage.Open(ctx, nil, path, file.FlagRead|file.FlagNoFollow|file.FlagNoATime, 0000)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = obj.Close() }()
+
+	f, ok := obj.(file.File)
+	if !ok {
+		return nil, fmt.Errorf("not a regular file: %T", obj)
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
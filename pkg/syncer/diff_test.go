@@ -0,0 +1,185 @@
+package syncer
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/my-network/fsutil/pkg/file/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// newDriftedSyncer returns a syncer which destination differs from the source
+// by each DiffReason (see the names of the objects).
+func newDriftedSyncer(t *testing.T, ctx context.Context, opts ...Option) *Syncer {
+	syncer := newTestSyncer(ctx, opts...)
+	src, dst := syncer.src, syncer.dst
+	mtime := time.Unix(1000, 0)
+
+	for _, stor := range []file.Storage{src, dst} {
+		require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"dir"}, 0755, false))
+		storagetest.WriteFile(t, stor, file.Path{"dir", "same"}, "same")
+		storagetest.WriteFile(t, stor, file.Path{"dir", "mode"}, "mode")
+		storagetest.WriteFile(t, stor, file.Path{"dir", "mtime"}, "mtime")
+	}
+	storagetest.WriteFile(t, src, file.Path{"dir", "size"}, "size")
+	storagetest.WriteFile(t, dst, file.Path{"dir", "size"}, "size+")
+	storagetest.WriteFile(t, src, file.Path{"dir", "content"}, "content")
+	storagetest.WriteFile(t, dst, file.Path{"dir", "content"}, "CONTENT")
+	storagetest.WriteFile(t, src, file.Path{"dir", "missing"}, "missing")
+	storagetest.WriteFile(t, dst, file.Path{"dir", "extra"}, "extra")
+	storagetest.WriteFile(t, src, file.Path{"type"}, "type")
+	require.NoError(t, dst.Mkdir(ctx, nil, file.Path{"type"}, 0755, false))
+	require.NoError(t, dst.Chmod(ctx, nil, file.Path{"dir", "mode"}, 0640))
+
+	for _, stor := range []file.Storage{src, dst} {
+		for _, name := range []string{"same", "mode", "size", "content"} {
+			require.NoError(t, stor.Chtimes(ctx, nil, file.Path{"dir", name}, mtime, mtime))
+		}
+	}
+	require.NoError(t, src.Chtimes(ctx, nil, file.Path{"dir", "mtime"}, mtime, mtime))
+	return syncer
+}
+
+func diffsOf(t *testing.T, syncer *Syncer, path file.Path, opts diffOptions) map[string]DiffReason {
+	diffs := map[string]DiffReason{}
+	err := syncer.diffSubtree(context.Background(), path, opts, func(path file.Path, reason DiffReason) error {
+		diffs[path.LocalPath()] = reason
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	return diffs
+}
+
+func TestDiffSubtree(t *testing.T) {
+	ctx := context.Background()
+	syncer := newDriftedSyncer(t, ctx)
+
+	expected := map[string]DiffReason{
+		"dir/mode":    DiffReasonMode,
+		"dir/size":    DiffReasonSize,
+		"dir/mtime":   DiffReasonModTime,
+		"dir/missing": DiffReasonMissing,
+		"dir/extra":   DiffReasonExtra,
+		"type":        DiffReasonType,
+	}
+	require.Equal(t, expected, diffsOf(t, syncer, file.Path{}, diffOptions{}))
+
+	expected["dir/content"] = DiffReasonContent
+	require.Equal(t, expected, diffsOf(t, syncer, file.Path{}, diffOptions{CompareContent: true}))
+
+	// a subtree only
+	delete(expected, "type")
+	require.Equal(t, expected, diffsOf(t, syncer, file.Path{"dir"}, diffOptions{CompareContent: true}))
+}
+
+func TestCompareInfo(t *testing.T) {
+	ctx := context.Background()
+	syncer := newDriftedSyncer(t, ctx)
+	for name, expected := range map[string]DiffReason{
+		"mode":  DiffReasonMode,
+		"size":  DiffReasonSize,
+		"mtime": DiffReasonModTime,
+		"same":  0,
+	} {
+		srcInfo, err := syncer.src.Stat(ctx, nil, file.Path{"dir", name}, true)
+		require.NoError(t, err)
+		dstInfo, err := syncer.dst.Stat(ctx, nil, file.Path{"dir", name}, true)
+		require.NoError(t, err)
+		reason, isDifferent := compareInfo(srcInfo, dstInfo)
+		require.Equal(t, name != "same", isDifferent, name)
+		require.Equal(t, expected, reason, name)
+	}
+
+	// the size of directories is not compared
+	srcInfo, err := syncer.src.Stat(ctx, nil, file.Path{"dir"}, true)
+	require.NoError(t, err)
+	dstInfo, err := syncer.dst.Stat(ctx, nil, file.Path{"dir"}, true)
+	require.NoError(t, err)
+	_, isDifferent := compareInfo(srcInfo, dstInfo)
+	require.False(t, isDifferent)
+}
+
+func TestHashContent(t *testing.T) {
+	ctx := context.Background()
+	syncer := newDriftedSyncer(t, ctx)
+
+	srcHash, err := hashContent(ctx, syncer.src, file.Path{"dir", "same"})
+	require.NoError(t, err)
+	dstHash, err := hashContent(ctx, syncer.dst, file.Path{"dir", "same"})
+	require.NoError(t, err)
+	require.Equal(t, srcHash, dstHash)
+
+	isEqual, err := syncer.compareContent(ctx, file.Path{"dir", "content"})
+	require.NoError(t, err)
+	require.False(t, isEqual)
+
+	_, err = hashContent(ctx, syncer.src, file.Path{"dir"})
+	require.Error(t, err)
+	_, err = hashContent(ctx, syncer.src, file.Path{"dir", "nonexistent"})
+	require.True(t, file.IsNotExist(err), err)
+}
+
+func TestScrub(t *testing.T) {
+	ctx := context.Background()
+	syncer := newDriftedSyncer(t, ctx, OptionScrubChecksums{Enable: true})
+	syncer.taskStorage = &taskStorage{}
+	syncer.taskStorage.initFields(syncer.config)
+	syncer.taskStorage.taskAddOrRefreshChan = make(chan *task, 100)
+
+	report := syncer.Scrub(ctx, nil)
+	require.NoError(t, report.Err)
+	require.False(t, report.IsClean())
+	// the objects are checked in the both trees
+	require.Equal(t, uint64(18), report.ObjectsChecked)
+
+	var drifted []string
+	for _, drift := range report.Drifts {
+		drifted = append(drifted, drift.Path.LocalPath())
+	}
+	sort.Strings(drifted)
+	require.Equal(t, []string{
+		"dir/content", "dir/extra", "dir/missing", "dir/mode", "dir/mtime", "dir/size", "type",
+	}, drifted)
+
+	// each drift is queued to be synced again
+	var queued []string
+	for len(syncer.taskStorage.taskAddOrRefreshChan) > 0 {
+		task := <-syncer.taskStorage.taskAddOrRefreshChan
+		queued = append(queued, task.Path.LocalPath())
+		if task.Path.LocalPath() == "dir/extra" {
+			require.Equal(t, TaskKindDeletion, task.Kind)
+		}
+	}
+	sort.Strings(queued)
+	require.Equal(t, drifted, queued)
+}
+
+func TestScrubRateLimit(t *testing.T) {
+	ctx := context.Background()
+	syncer := newTestSyncer(ctx, OptionScrubRateLimit{ObjectsPerSecond: 100})
+	for _, stor := range []file.Storage{syncer.src, syncer.dst} {
+		require.NoError(t, stor.Mkdir(ctx, nil, file.Path{"a", "b", "c"}, 0755, true))
+	}
+
+	report := syncer.Scrub(ctx, nil)
+	require.NoError(t, report.Err)
+	require.True(t, report.IsClean())
+	require.Equal(t, uint64(8), report.ObjectsChecked)
+	require.GreaterOrEqual(t, int64(report.EndTS.Sub(report.StartTS)), int64(8*10*time.Millisecond))
+
+	// an aborted scrub
+	syncer.config.ScrubRateLimit = 1
+	ctx, cancelFn := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelFn()
+	report = syncer.Scrub(ctx, nil)
+	require.True(t, errors.As(report.Err, &file.ErrAborted{}), report.Err)
+	require.Zero(t, report.ObjectsChecked)
+
+	// the interval between checks would be zero
+	syncer.config.ScrubRateLimit = uint(time.Second) + 1
+	require.Error(t, syncer.config.Validate())
+}
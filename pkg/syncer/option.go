@@ -39,3 +39,97 @@ type OptionAggregationTimeMax struct {
 func (opt OptionAggregationTimeMax) apply(cfg *Config) {
 	cfg.AggregationTimeMax = opt.Value
 }
+
+type OptionTaskKindPriority struct {
+	Kind     TaskKind
+	Priority Priority
+}
+
+func (opt OptionTaskKindPriority) apply(cfg *Config) {
+	priorities := make(TaskKindPriorities, len(cfg.TaskKindPriorities)+1)
+	for kind, priority := range cfg.TaskKindPriorities {
+		priorities[kind] = priority
+	}
+	priorities[opt.Kind] = opt.Priority
+	cfg.TaskKindPriorities = priorities
+}
+
+type OptionPriorityRule struct {
+	Rule PriorityRule
+}
+
+func (opt OptionPriorityRule) apply(cfg *Config) {
+	cfg.PriorityRules = append(cfg.PriorityRules[:len(cfg.PriorityRules):len(cfg.PriorityRules)], opt.Rule)
+}
+
+type OptionPriorityAgingInterval struct {
+	Value time.Duration
+}
+
+func (opt OptionPriorityAgingInterval) apply(cfg *Config) {
+	cfg.PriorityAgingInterval = opt.Value
+}
+
+type OptionSmallFileSizeMax struct {
+	Value int64
+}
+
+func (opt OptionSmallFileSizeMax) apply(cfg *Config) {
+	cfg.SmallFileSizeMax = opt.Value
+}
+
+type OptionHugeFileSizeMin struct {
+	Value int64
+}
+
+func (opt OptionHugeFileSizeMin) apply(cfg *Config) {
+	cfg.HugeFileSizeMin = opt.Value
+}
+
+type OptionTaskCountMax struct {
+	Value int
+}
+
+func (opt OptionTaskCountMax) apply(cfg *Config) {
+	cfg.TaskCountMax = opt.Value
+}
+
+type OptionSubtreeCoalesceThreshold struct {
+	Value int
+}
+
+func (opt OptionSubtreeCoalesceThreshold) apply(cfg *Config) {
+	cfg.SubtreeCoalesceThreshold = opt.Value
+}
+
+type OptionScrubInterval struct {
+	Value time.Duration
+}
+
+func (opt OptionScrubInterval) apply(cfg *Config) {
+	cfg.ScrubInterval = opt.Value
+}
+
+type OptionScrubRateLimit struct {
+	ObjectsPerSecond uint
+}
+
+func (opt OptionScrubRateLimit) apply(cfg *Config) {
+	cfg.ScrubRateLimit = opt.ObjectsPerSecond
+}
+
+type OptionScrubChecksums struct {
+	Enable bool
+}
+
+func (opt OptionScrubChecksums) apply(cfg *Config) {
+	cfg.ScrubChecksums = opt.Enable
+}
+
+type OptionScrubReportHandler struct {
+	Handler func(*ScrubReport)
+}
+
+func (opt OptionScrubReportHandler) apply(cfg *Config) {
+	cfg.ScrubReportHandler = opt.Handler
+}
@@ -0,0 +1,104 @@
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// Priority defines how urgent a task is. Tasks with a higher priority are
+// taken by workers before expired tasks with a lower priority.
+type Priority int
+
+// TaskKind is a coarse classification of a task, used to pick a priority.
+type TaskKind uint8
+
+const (
+	TaskKindUnknown = TaskKind(iota)
+	TaskKindMetadata
+	TaskKindSmallFile
+	TaskKindRegularFile
+	TaskKindHugeFile
+	TaskKindDeletion
+	TaskKindSubtree
+)
+
+func (kind TaskKind) String() string {
+	switch kind {
+	case TaskKindUnknown:
+		return "unknown"
+	case TaskKindMetadata:
+		return "metadata"
+	case TaskKindSmallFile:
+		return "small_file"
+	case TaskKindRegularFile:
+		return "regular_file"
+	case TaskKindHugeFile:
+		return "huge_file"
+	case TaskKindDeletion:
+		return "deletion"
+	case TaskKindSubtree:
+		return "subtree"
+	}
+	return "invalid"
+}
+
+// TaskKindPriorities maps a task kind to its priority.
+type TaskKindPriorities map[TaskKind]Priority
+
+// PriorityRule adds Priority to tasks on paths matching Pattern.
+//
+// Pattern has the syntax of filepath.Match. If it contains no path separator
+// it is matched against the name of the object only (like in ".gitignore"),
+// otherwise against the whole path (relative to the storage root).
+type PriorityRule struct {
+	Pattern  string
+	Priority Priority
+}
+
+func (rule PriorityRule) Match(path file.Path) bool {
+	var subject string
+	if strings.ContainsRune(rule.Pattern, filepath.Separator) {
+		subject = path.LocalPath()
+	} else {
+		if len(path) == 0 {
+			return false
+		}
+		subject = path[len(path)-1]
+	}
+	isMatch, _ := filepath.Match(rule.Pattern, subject)
+	return isMatch
+}
+
+// taskPriority returns the priority of a task of kind `kind` on path `path`:
+// the priority of the kind plus the priority of the first matching rule.
+func (cfg Config) taskPriority(path file.Path, kind TaskKind) Priority {
+	priority := cfg.TaskKindPriorities[kind]
+	for _, rule := range cfg.PriorityRules {
+		if rule.Match(path) {
+			priority += rule.Priority
+			break
+		}
+	}
+	return priority
+}
+
+// taskKind classifies a task by the current state of the object.
+func (cfg Config) taskKind(info os.FileInfo) TaskKind {
+	if info == nil {
+		return TaskKindDeletion
+	}
+	if !info.Mode().IsRegular() {
+		return TaskKindMetadata
+	}
+	size := info.Size()
+	switch {
+	case cfg.HugeFileSizeMin > 0 && size >= cfg.HugeFileSizeMin:
+		return TaskKindHugeFile
+	case size <= cfg.SmallFileSizeMax:
+		return TaskKindSmallFile
+	}
+	return TaskKindRegularFile
+}
@@ -0,0 +1,123 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+)
+
+// ScrubDrift is a discrepancy between the source and the destination
+// found by a scrub.
+type ScrubDrift struct {
+	Path   file.Path
+	Reason DiffReason
+}
+
+// ScrubReport is the result of a scrub.
+type ScrubReport struct {
+	Path           file.Path
+	StartTS        time.Time
+	EndTS          time.Time
+	ObjectsChecked uint64
+	Drifts         []ScrubDrift
+
+	// Err is the error which interrupted the scrub (if any).
+	Err error
+}
+
+// IsClean returns true if the scrub completed and found no drift.
+func (report *ScrubReport) IsClean() bool {
+	return report.Err == nil && len(report.Drifts) == 0
+}
+
+func (report *ScrubReport) String() string {
+	result := fmt.Sprintf("scrub of '%s' took %v: checked %d objects, found %d drifts",
+		report.Path.LocalPath(), report.EndTS.Sub(report.StartTS), report.ObjectsChecked, len(report.Drifts))
+	if report.Err != nil {
+		result += fmt.Sprintf(", interrupted by: %v", report.Err)
+	}
+	return result
+}
+
+func (syncer *Syncer) initScrubber() error {
+	if syncer.config.ScrubInterval <= 0 {
+		return nil
+	}
+
+	syncer.wg.Add(1)
+	go func() {
+		defer syncer.wg.Done()
+		syncer.scrubberLoop()
+	}()
+	return nil
+}
+
+func (syncer *Syncer) scrubberLoop() {
+	ticker := time.NewTicker(syncer.config.ScrubInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			report := syncer.Scrub(syncer.ctx, nil)
+			syncer.config.SyncLogger.Debugf("%v", report)
+			if syncer.config.ScrubReportHandler != nil {
+				syncer.config.ScrubReportHandler(report)
+			}
+		case <-syncer.ctx.Done():
+			return
+		}
+	}
+}
+
+// Scrub walks the subtree on `path` in both the source and the destination
+// (at rate Config.ScrubRateLimit), compares the metadata (and the content
+// if Config.ScrubChecksums is enabled) and queues every object which
+// differs to be synced again.
+func (syncer *Syncer) Scrub(ctx context.Context, path file.Path) *ScrubReport {
+	report := &ScrubReport{
+		Path:    path,
+		StartTS: time.Now(),
+	}
+
+	var rateLimiter <-chan time.Time
+	if syncer.config.ScrubRateLimit > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(syncer.config.ScrubRateLimit))
+		defer ticker.Stop()
+		rateLimiter = ticker.C
+	}
+
+	report.Err = syncer.diffSubtree(
+		ctx,
+		path,
+		diffOptions{
+			CompareContent: syncer.config.ScrubChecksums,
+			VisitFn: func(path file.Path) error {
+				if rateLimiter != nil {
+					select {
+					case <-rateLimiter:
+					case <-ctx.Done():
+						return file.ErrAborted{}
+					}
+				}
+				report.ObjectsChecked++
+				return nil
+			},
+		},
+		func(path file.Path, reason DiffReason) error {
+			report.Drifts = append(report.Drifts, ScrubDrift{Path: path, Reason: reason})
+			return syncer.Queue(path)
+		},
+		func(err error) error {
+			if file.IsNotExist(err) {
+				// changed while scrubbing, the change will be handled
+				// by the events
+				return nil
+			}
+			return err
+		},
+	)
+	report.EndTS = time.Now()
+	return report
+}
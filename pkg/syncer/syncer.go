@@ -63,6 +63,11 @@ func (syncer *Syncer) init() error {
 		return fmt.Errorf("unable to initialize a copier: %w", err)
 	}
 
+	err = syncer.initScrubber()
+	if err != nil {
+		return fmt.Errorf("unable to initialize a scrubber: %w", err)
+	}
+
 	return nil
 }
 
@@ -95,28 +100,111 @@ func (syncer *Syncer) initCopier() error {
 func (syncer *Syncer) copierLoop() {
 	for {
 		select {
-		case fileEvent := <-syncer.taskStorage.ExpiredChan:
-			fmt.Println("SYNC", fileEvent)
+		case t, ok := <-syncer.taskStorage.ExpiredChan:
+			if !ok {
+				return
+			}
+			if t.IsRecursive {
+				err := syncer.resyncSubtree(t.Path)
+				if err != nil {
+					syncer.config.SyncLogger.Debugf("unable to resync subtree '%s': %v",
+						t.Path.LocalPath(), err)
+				}
+				continue
+			}
+			err := syncer.sync(t.Path)
+			if err != nil {
+				syncer.config.SyncLogger.Debugf("unable to sync '%s': %v",
+					t.Path.LocalPath(), err)
+			}
 		case <-syncer.ctx.Done():
 			return
 		}
 	}
 }
 
+// resyncSubtree syncs the objects of the subtree on `path` which differ
+// between the source and the destination. It is used instead of
+// per-object tasks if there were too many events inside the subtree.
+func (syncer *Syncer) resyncSubtree(path file.Path) error {
+	return syncer.diffSubtree(
+		syncer.ctx,
+		path,
+		diffOptions{},
+		func(path file.Path, reason DiffReason) error {
+			syncer.config.SyncLogger.Debugf("'%s' differs: %v", path.LocalPath(), reason)
+			err := syncer.sync(path)
+			if err != nil {
+				// the rest of the subtree is still worth to be synced
+				syncer.config.SyncLogger.Debugf("unable to sync '%s': %v",
+					path.LocalPath(), err)
+			}
+			return nil
+		},
+		func(err error) error {
+			if file.IsNotExist(err) {
+				// the subtree is changing while we walk it, the new
+				// events will be handled by new tasks
+				return nil
+			}
+			return err
+		},
+	)
+}
+
 func (syncer *Syncer) Wait() {
 	syncer.wg.Wait()
 }
 
 func (syncer *Syncer) Queue(path file.Path) error {
 	now := time.Now()
-	err := syncer.warmupForSync(path)
+	kind, err := syncer.taskKind(path)
+	if err != nil {
+		return err
+	}
+	err = syncer.warmupForSync(path)
 	if err != nil {
 		return err
 	}
-	syncer.taskStorage.AddOrRefresh(path, now)
+	syncer.taskStorage.AddOrRefreshKind(path, kind, now)
 	return nil
 }
 
+// QueueSubtree queues a differential resync of the whole subtree on `path`.
+//
+// It is supposed to be used if events inside of the subtree were lost.
+func (syncer *Syncer) QueueSubtree(path file.Path) error {
+	syncer.taskStorage.AddOrRefreshSubtree(path, time.Now())
+	return nil
+}
+
+// QueueEvent queues the sync of the objects affected by the event.
+func (syncer *Syncer) QueueEvent(ev event.Event) error {
+	if ev.TypeMask.Has(event.TypeOverflow) {
+		return syncer.QueueSubtree(ev.Path)
+	}
+	err := syncer.Queue(ev.Path)
+	if err != nil {
+		return err
+	}
+	if ev.MovedTo != nil {
+		return syncer.Queue(ev.MovedTo)
+	}
+	return nil
+}
+
+func (syncer *Syncer) taskKind(path file.Path) (TaskKind, error) {
+	info, err := syncer.src.Stat(syncer.ctx, nil, path, true)
+	if err != nil {
+		if file.IsNotExist(err) {
+			return syncer.config.taskKind(nil), nil
+		}
+		return TaskKindUnknown, fmt.Errorf("unable to stat src file '%s': %w",
+			path.LocalPath(), err)
+	}
+	return syncer.config.taskKind(info), nil
+}
+
 func (syncer *Syncer) QueueRecursive(
 	ctx context.Context,
 	path file.Path,
@@ -172,7 +260,7 @@ func (syncer *Syncer) eventProcessorLoop(inChan chan event.Event) {
 	for {
 		select {
 		case fileEvent := <-inChan:
-			err := syncer.Queue(fileEvent.Path)
+			err := syncer.QueueEvent(fileEvent)
 			if err != nil {
 				panic(err)
 			}
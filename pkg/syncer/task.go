@@ -7,13 +7,19 @@ import (
 )
 
 type task struct {
-	Config Config
-	Path   file.Path
+	Config   Config
+	Path     file.Path
+	Kind     TaskKind
+	Priority Priority
 
 	FirstEventTS time.Time
 	LastEventTS  time.Time
+	ExpiredTS    time.Time
 	IsExpired    bool
 
+	// IsRecursive means the whole subtree on Path should be resynced
+	IsRecursive bool
+
 	HeapIdx *int
 }
 
@@ -32,6 +38,19 @@ func (t *task) Merge(addTask *task) {
 	if addTask.LastEventTS.After(t.LastEventTS) {
 		t.LastEventTS = addTask.LastEventTS
 	}
+	if addTask.IsRecursive {
+		t.IsRecursive = true
+	}
+	switch {
+	case t.IsRecursive:
+		t.Kind = TaskKindSubtree
+	case addTask.Kind != TaskKindUnknown:
+		// the latest known state of the object is the most relevant one
+		t.Kind = addTask.Kind
+	}
+	// the priority follows the kind, otherwise a stale kind (like the
+	// creation of a file which is already created) would keep its head start
+	t.Priority = t.Config.taskPriority(t.Path, t.Kind)
 }
 
 func (t *task) ExpirationDeadline() time.Time {
@@ -47,3 +66,9 @@ func (t *task) ExpirationDeadline() time.Time {
 
 	return deadline
 }
+
+// DispatchTS is the time used to order expired tasks: the expiration time
+// moved back by the head start given by the priority.
+func (t *task) DispatchTS() time.Time {
+	return t.ExpiredTS.Add(-time.Duration(t.Priority) * t.Config.PriorityAgingInterval)
+}
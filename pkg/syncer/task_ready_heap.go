@@ -0,0 +1,47 @@
+package syncer
+
+import (
+	"container/heap"
+)
+
+// taskReadyHeap is a heap of expired tasks, ordered by DispatchTS.
+type taskReadyHeapInt struct {
+	taskHeapInt
+}
+type taskReadyHeap taskReadyHeapInt
+
+func (tHeap *taskReadyHeapInt) Less(i, j int) bool {
+	a := tHeap.taskHeapInt[i]
+	b := tHeap.taskHeapInt[j]
+	aTS := a.DispatchTS()
+	bTS := b.DispatchTS()
+	if aTS.Equal(bTS) {
+		return a.Priority > b.Priority
+	}
+	return aTS.Before(bTS)
+}
+
+func (tHeap *taskReadyHeap) int() *taskReadyHeapInt {
+	return (*taskReadyHeapInt)(tHeap)
+}
+
+func (tHeap *taskReadyHeap) Len() int {
+	return tHeap.int().Len()
+}
+
+func (tHeap *taskReadyHeap) Push(t *task) {
+	heap.Push(tHeap.int(), t)
+}
+
+func (tHeap *taskReadyHeap) Pop() *task {
+	return heap.Pop(tHeap.int()).(*task)
+}
+
+func (tHeap *taskReadyHeap) Remove(t *task) {
+	heap.Remove(tHeap.int(), *t.HeapIdx)
+}
+
+// Peek returns the task which would be returned by Pop, without removing it.
+func (tHeap *taskReadyHeap) Peek() *task {
+	return tHeap.taskHeapInt[0]
+}
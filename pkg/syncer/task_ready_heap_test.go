@@ -0,0 +1,45 @@
+package syncer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/my-network/fsutil/pkg/file"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskReadyHeap(t *testing.T) {
+	now := time.Now()
+	newTask := func(name string, priority Priority, expiredTS time.Time) *task {
+		return &task{
+			Config:    DefaultConfig,
+			Path:      file.Path{name},
+			Priority:  priority,
+			ExpiredTS: expiredTS,
+			IsExpired: true,
+		}
+	}
+
+	h := taskReadyHeap{}
+	h.Push(newTask("huge", -2, now.Add(-time.Second)))
+	h.Push(newTask("regular", 0, now))
+	h.Push(newTask("small", 2, now))
+	h.Push(newTask("starving", -2, now.Add(-time.Hour)))
+
+	var order []string
+	for h.Len() > 0 {
+		order = append(order, h.Pop().Path[0])
+	}
+	require.Equal(t, []string{"starving", "small", "regular", "huge"}, order)
+}
+
+func TestConfigTaskPriority(t *testing.T) {
+	cfg := DefaultConfig
+	OptionPriorityRule{Rule: PriorityRule{Pattern: "*.conf", Priority: 10}}.apply(&cfg)
+	OptionPriorityRule{Rule: PriorityRule{Pattern: "etc/*", Priority: 5}}.apply(&cfg)
+
+	require.Equal(t, Priority(12), cfg.taskPriority(file.Path{"etc", "app.conf"}, TaskKindSmallFile))
+	require.Equal(t, Priority(3), cfg.taskPriority(file.Path{"etc", "app.db"}, TaskKindHugeFile))
+	require.Equal(t, Priority(0), cfg.taskPriority(file.Path{"var", "app.db"}, TaskKindRegularFile))
+	require.Equal(t, TaskKindDeletion, cfg.taskKind(nil))
+}
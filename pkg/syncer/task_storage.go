@@ -16,7 +16,11 @@ type taskStorage struct {
 	config Config
 
 	taskMap              map[string]*task
+	dirTasks             map[string]map[string]*task    // tasks directly in a directory
+	subdirs              map[string]map[string]struct{} // subdirectories (by names) with tasks inside
+	subtreeTaskCount     map[string]int                 // amount of tasks anywhere inside of a directory
 	taskWaitHeap         taskHeap
+	taskReadyHeap        taskReadyHeap
 	ExpiredChan          chan *task
 	taskAddOrRefreshChan chan *task
 	waitingTask          *task
@@ -40,6 +44,9 @@ func (storage *taskStorage) init(cfg Config) error {
 func (storage *taskStorage) initFields(cfg Config) {
 	storage.config = cfg
 	storage.taskMap = map[string]*task{}
+	storage.dirTasks = map[string]map[string]*task{}
+	storage.subdirs = map[string]map[string]struct{}{}
+	storage.subtreeTaskCount = map[string]int{}
 }
 
 func (storage *taskStorage) initTaskScheduler() {
@@ -47,7 +54,9 @@ func (storage *taskStorage) initTaskScheduler() {
 		storage.taskAddOrRefreshChan = make(chan *task, 100)
 	}
 	if storage.ExpiredChan == nil { // storage.ExpiredChan could also be set by unit-tests
-		storage.ExpiredChan = make(chan *task, 100)
+		// unbuffered: the task to send is chosen (by priority) only when
+		// a worker is ready to take it
+		storage.ExpiredChan = make(chan *task)
 	}
 
 	storage.wg.Add(1)
@@ -63,6 +72,18 @@ func (storage *taskStorage) initTaskScheduler() {
 
 func (storage *taskStorage) taskSchedulerLoop() {
 	for {
+		// process already queued tasks first, so that they are aggregated
+		// before anything expires
+		select {
+		case task, ok := <-storage.taskAddOrRefreshChan:
+			if !ok {
+				return
+			}
+			storage.handleAddOrRefresh(task)
+			continue
+		default:
+		}
+
 		var waitChan <-chan time.Time
 
 		if storage.waitingTask != nil {
@@ -72,22 +93,30 @@ func (storage *taskStorage) taskSchedulerLoop() {
 			waitChan = nil // a nil channel will never fire
 		}
 
+		var expiredChan chan *task
+		var readyTask *task
+		if storage.taskReadyHeap.Len() > 0 {
+			readyTask = storage.taskReadyHeap.Peek()
+			expiredChan = storage.ExpiredChan
+		} else {
+			expiredChan = nil // sending to a nil channel will never happen
+		}
+
 		select {
 		case task, ok := <-storage.taskAddOrRefreshChan:
 			if !ok {
 				return
 			}
-			storage.addOrRefresh(task)
-			if debug {
-				if len(storage.taskMap)-1 != storage.taskWaitHeap.Len() {
-					panic(fmt.Sprintf("%d %d", len(storage.taskMap), storage.taskWaitHeap.Len()))
-				}
-			}
+			storage.handleAddOrRefresh(task)
+
+		case expiredChan <- readyTask:
+			storage.taskReadyHeap.Pop()
+			storage.forgetTask(readyTask)
 
 		case <-waitChan:
+			storage.waitingTask.ExpiredTS = storage.waitingTask.ExpirationDeadline()
 			storage.waitingTask.IsExpired = true
-			storage.ExpiredChan <- storage.waitingTask
-			delete(storage.taskMap, storage.waitingTask.Path.Key())
+			storage.taskReadyHeap.Push(storage.waitingTask)
 			storage.waitingTask = nil
 
 			if storage.taskWaitHeap.Len() > 0 {
@@ -97,9 +126,26 @@ func (storage *taskStorage) taskSchedulerLoop() {
 	}
 }
 
+func (storage *taskStorage) handleAddOrRefresh(task *task) {
+	storage.addOrRefresh(task)
+	if debug {
+		if len(storage.taskMap)-1 != storage.taskWaitHeap.Len()+storage.taskReadyHeap.Len() {
+			panic(fmt.Sprintf("%d %d %d", len(storage.taskMap), storage.taskWaitHeap.Len(), storage.taskReadyHeap.Len()))
+		}
+	}
+}
+
 func (storage *taskStorage) AddOrRefresh(path file.Path, touchTime time.Time) {
+	storage.AddOrRefreshKind(path, TaskKindUnknown, touchTime)
+}
+
+// AddOrRefreshKind is the same as AddOrRefresh, but also sets the kind
+// of the task (which affects its priority).
+func (storage *taskStorage) AddOrRefreshKind(path file.Path, kind TaskKind, touchTime time.Time) {
 	task := &task{
 		Config:       storage.config,
+		Kind:         kind,
+		Priority:     storage.config.taskPriority(path, kind),
 		FirstEventTS: touchTime,
 		LastEventTS:  touchTime,
 	}
@@ -108,8 +154,54 @@ func (storage *taskStorage) AddOrRefresh(path file.Path, touchTime time.Time) {
 	storage.taskAddOrRefreshChan <- task
 }
 
+// AddOrRefreshSubtree adds (or refreshes) a recursive task which resyncs
+// the whole subtree on `path`. Tasks inside of the subtree are absorbed.
+func (storage *taskStorage) AddOrRefreshSubtree(path file.Path, touchTime time.Time) {
+	storage.taskAddOrRefreshChan <- storage.newSubtreeTask(path, touchTime)
+}
+
+func (storage *taskStorage) newSubtreeTask(path file.Path, touchTime time.Time) *task {
+	task := &task{
+		Config:       storage.config,
+		Kind:         TaskKindSubtree,
+		Priority:     storage.config.taskPriority(path, TaskKindSubtree),
+		FirstEventTS: touchTime,
+		LastEventTS:  touchTime,
+		IsRecursive:  true,
+	}
+	task.Path = make(file.Path, len(path))
+	copy(task.Path, path)
+	return task
+}
+
 func (storage *taskStorage) addOrRefresh(task *task) {
+	if recursiveTask := storage.recursiveTaskCovering(task.Path); recursiveTask != nil {
+		task.Path = recursiveTask.Path
+		task.IsRecursive = true
+	} else if task.IsRecursive {
+		storage.removeSubtree(task.Path)
+	} else if storage.taskMap[task.Path.Key()] == nil {
+		dir := storage.subtreeToCoalesce(task.Path)
+		switch {
+		case dir == nil:
+		case task.Path.HasPrefix(dir):
+			storage.removeSubtree(dir)
+			task.Path = dir
+			task.Kind = TaskKindSubtree
+			task.Priority = storage.config.taskPriority(dir, TaskKindSubtree)
+			task.IsRecursive = true
+		default:
+			// the new task is added as is after the collapse
+			storage.addOrRefresh(storage.newSubtreeTask(dir, task.LastEventTS))
+		}
+	}
+
 	oldTask := storage.taskMap[task.Path.Key()]
+	if oldTask != nil && oldTask.IsExpired {
+		// the old task is not taken by a worker, yet, so it will
+		// sync the latest state anyway
+		return
+	}
 	if oldTask != nil {
 		oldTask.Merge(task)
 		task = oldTask
@@ -123,6 +215,7 @@ func (storage *taskStorage) addOrRefresh(task *task) {
 		}
 	} else {
 		storage.taskMap[task.Path.Key()] = task
+		storage.indexTask(task, 1)
 		storage.taskWaitHeap.Push(task)
 	}
 
@@ -143,6 +236,161 @@ func (storage *taskStorage) addOrRefresh(task *task) {
 	storage.waitingTask = earliestTask
 }
 
+// recursiveTaskCovering returns the recursive task on `path` or on any
+// of its parents, if there is one.
+func (storage *taskStorage) recursiveTaskCovering(path file.Path) *task {
+	for depth := 0; depth <= len(path); depth++ {
+		t := storage.taskMap[path[:depth].Key()]
+		if t != nil && t.IsRecursive {
+			return t
+		}
+	}
+	return nil
+}
+
+// subtreeToCoalesce returns the directory which tasks should be collapsed
+// into a single recursive task if a new task on `path` is added, or nil
+// if the limits are not reached.
+//
+// If TaskCountMax is reached, the deepest directory which collapsing
+// frees enough tasks to get down to the low-water mark is chosen (the one
+// with the most tasks if there are a few). It is not necessary a parent
+// of `path`. The free room makes the next collapses rare, since each of
+// them walks the directories with enough tasks.
+func (storage *taskStorage) subtreeToCoalesce(path file.Path) file.Path {
+	if len(path) == 0 {
+		return nil
+	}
+	threshold := storage.config.SubtreeCoalesceThreshold
+	parent := path.Up()
+	if threshold > 0 && len(storage.dirTasks[parent.Key()])+1 >= threshold { // +1 is the task being added
+		return parent
+	}
+	if storage.config.TaskCountMax == 0 || len(storage.taskMap)+1 <= storage.config.TaskCountMax {
+		return nil
+	}
+	excess := len(storage.taskMap) + 1 - storage.config.taskCountLowWater()
+
+	var (
+		result      file.Path
+		resultFreed int
+	)
+	// the amount of tasks inside of a directory is not more than inside
+	// of its parent, so only the directories with enough tasks are walked
+	var walk func(dir file.Path)
+	walk = func(dir file.Path) {
+		key := dir.Key()
+		// the tasks removed by the collapse
+		freed := storage.subtreeTaskCount[key]
+		if storage.taskMap[key] != nil {
+			freed++
+		}
+		need := excess
+		if !path.HasPrefix(dir) {
+			// the new task is not absorbed by the recursive task
+			need++
+		}
+		if freed < need {
+			return
+		}
+		if result == nil || len(dir) > len(result) || len(dir) == len(result) && freed > resultFreed {
+			result, resultFreed = dir, freed
+		}
+		for name := range storage.subdirs[key] {
+			walk(dir.Append(name))
+		}
+	}
+	walk(file.Path{})
+	if result == nil {
+		// nothing else helps, resync everything
+		return file.Path{}
+	}
+	return result
+}
+
+// removeSubtree removes all the tasks on `dir` and inside of it.
+func (storage *taskStorage) removeSubtree(dir file.Path) {
+	key := dir.Key()
+	if t := storage.taskMap[key]; t != nil {
+		storage.removeTask(t)
+	}
+	for _, t := range storage.dirTasks[key] {
+		storage.removeTask(t)
+	}
+	for name := range storage.subdirs[key] {
+		storage.removeSubtree(dir.Append(name))
+	}
+}
+
+// removeTask removes the queued task.
+func (storage *taskStorage) removeTask(t *task) {
+	switch {
+	case t == storage.waitingTask:
+		storage.waitingTask = nil
+	case t.IsExpired:
+		storage.taskReadyHeap.Remove(t)
+	default:
+		storage.taskWaitHeap.Remove(t)
+	}
+	storage.forgetTask(t)
+}
+
+func (storage *taskStorage) forgetTask(t *task) {
+	delete(storage.taskMap, t.Path.Key())
+	storage.indexTask(t, -1)
+}
+
+// indexTask adds (`delta` is 1) or removes (`delta` is -1) the task
+// to/from the indexes of the parents of its path.
+func (storage *taskStorage) indexTask(t *task, delta int) {
+	path := t.Path
+	if len(path) == 0 {
+		return
+	}
+	parentKey := path.Up().Key()
+	if delta > 0 {
+		if storage.dirTasks[parentKey] == nil {
+			storage.dirTasks[parentKey] = map[string]*task{}
+		}
+		storage.dirTasks[parentKey][path.Key()] = t
+	} else {
+		delete(storage.dirTasks[parentKey], path.Key())
+		if len(storage.dirTasks[parentKey]) == 0 {
+			delete(storage.dirTasks, parentKey)
+		}
+	}
+
+	for depth := len(path) - 1; depth >= 0; depth-- {
+		key := path[:depth].Key()
+		addCount(storage.subtreeTaskCount, key, delta)
+		if depth == 0 {
+			break
+		}
+		// the directory path[:depth] has tasks inside (or not anymore)
+		upKey, name := path[:depth-1].Key(), path[depth-1]
+		if _, ok := storage.subtreeTaskCount[key]; ok {
+			if storage.subdirs[upKey] == nil {
+				storage.subdirs[upKey] = map[string]struct{}{}
+			}
+			storage.subdirs[upKey][name] = struct{}{}
+		} else {
+			delete(storage.subdirs[upKey], name)
+			if len(storage.subdirs[upKey]) == 0 {
+				delete(storage.subdirs, upKey)
+			}
+		}
+	}
+}
+
+func addCount(counters map[string]int, key string, delta int) {
+	count := counters[key] + delta
+	if count == 0 {
+		delete(counters, key)
+		return
+	}
+	counters[key] = count
+}
+
 func (storage *taskStorage) Close() error {
 	close(storage.taskAddOrRefreshChan)
 	storage.wg.Wait()
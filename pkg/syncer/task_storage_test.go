@@ -134,3 +134,163 @@ func BenchmarkTaskStorageScheduler(b *testing.B) {
 	_ = stor.Close()
 	wg.Wait()
 }
+
+func TestTaskStorageCoalesce(t *testing.T) {
+	newStorage := func(opts ...Option) *taskStorage {
+		cfg := DefaultConfig
+		for _, opt := range opts {
+			opt.apply(&cfg)
+		}
+		stor := &taskStorage{}
+		stor.initFields(cfg)
+		return stor
+	}
+	add := func(stor *taskStorage, path file.Path) {
+		stor.addOrRefresh(&task{
+			Config:       stor.config,
+			Path:         path,
+			FirstEventTS: time.Now(),
+			LastEventTS:  time.Now(),
+		})
+	}
+
+	t.Run("threshold", func(t *testing.T) {
+		stor := newStorage(OptionSubtreeCoalesceThreshold{Value: 3})
+		add(stor, file.Path{"a", "1"})
+		add(stor, file.Path{"a", "2"})
+		add(stor, file.Path{"b"})
+		require.Len(t, stor.taskMap, 3)
+
+		add(stor, file.Path{"a", "3"})
+		require.Len(t, stor.taskMap, 2)
+		subtreeTask := stor.taskMap[file.Path{"a"}.Key()]
+		require.NotNil(t, subtreeTask)
+		require.True(t, subtreeTask.IsRecursive)
+		require.Equal(t, TaskKindSubtree, subtreeTask.Kind)
+
+		add(stor, file.Path{"a", "c", "4"})
+		require.Len(t, stor.taskMap, 2)
+		require.Equal(t, 2, stor.subtreeTaskCount[file.Path{}.Key()])
+		require.Equal(t, 0, stor.subtreeTaskCount[file.Path{"a"}.Key()])
+	})
+
+	t.Run("count_max", func(t *testing.T) {
+		stor := newStorage(OptionSubtreeCoalesceThreshold{Value: 0}, OptionTaskCountMax{Value: 2})
+		add(stor, file.Path{"x"})
+		add(stor, file.Path{"y", "1"})
+		require.Len(t, stor.taskMap, 2)
+
+		add(stor, file.Path{"z"})
+		require.Len(t, stor.taskMap, 1)
+		require.True(t, stor.taskMap[file.Path{}.Key()].IsRecursive)
+		require.Nil(t, stor.taskMap[file.Path{"x"}.Key()])
+	})
+
+	t.Run("count_max_sibling", func(t *testing.T) {
+		stor := newStorage(OptionSubtreeCoalesceThreshold{Value: 0}, OptionTaskCountMax{Value: 4})
+		add(stor, file.Path{"a", "1"})
+		add(stor, file.Path{"a", "2"})
+		add(stor, file.Path{"b", "c", "1"})
+		add(stor, file.Path{"x"})
+		require.Len(t, stor.taskMap, 4)
+
+		// the deepest directory with enough tasks is collapsed, even if
+		// it does not contain the new task
+		add(stor, file.Path{"y"})
+		require.Len(t, stor.taskMap, 4)
+		require.True(t, stor.taskMap[file.Path{"a"}.Key()].IsRecursive)
+		require.Equal(t, TaskKindSubtree, stor.taskMap[file.Path{"a"}.Key()].Kind)
+		require.NotNil(t, stor.taskMap[file.Path{"b", "c", "1"}.Key()])
+		require.NotNil(t, stor.taskMap[file.Path{"x"}.Key()])
+		require.NotNil(t, stor.taskMap[file.Path{"y"}.Key()])
+	})
+
+	t.Run("count_max_storm", func(t *testing.T) {
+		const countMax = 20000
+		stor := newStorage(OptionSubtreeCoalesceThreshold{Value: 0}, OptionTaskCountMax{Value: countMax})
+		pathOf := func(idx int) file.Path {
+			return file.Path{fmt.Sprint("dir", idx%8), fmt.Sprint("sub", idx%64), fmt.Sprint("file", idx)}
+		}
+		for idx := 0; idx < countMax; idx++ {
+			add(stor, pathOf(idx))
+		}
+		require.Len(t, stor.taskMap, countMax)
+
+		// a collapse frees room for many events, so they stay cheap
+		collapses := 0
+		startTS := time.Now()
+		for idx := countMax; idx < 3*countMax; idx++ {
+			count := len(stor.taskMap)
+			add(stor, pathOf(idx))
+			require.LessOrEqual(t, len(stor.taskMap), countMax)
+			if len(stor.taskMap) < count {
+				collapses++
+			}
+		}
+		require.Less(t, time.Since(startTS), 10*time.Second)
+		require.LessOrEqual(t, collapses, 8)
+
+		// the indexes are consistent with the tasks
+		count := 0
+		for _, tasks := range stor.dirTasks {
+			count += len(tasks)
+		}
+		require.Nil(t, stor.taskMap[file.Path{}.Key()])
+		require.Equal(t, len(stor.taskMap), count)
+		require.Equal(t, len(stor.taskMap), stor.subtreeTaskCount[file.Path{}.Key()])
+	})
+
+	t.Run("subtree", func(t *testing.T) {
+		stor := newStorage(OptionSubtreeCoalesceThreshold{Value: 0})
+		add(stor, file.Path{"a", "1"})
+		add(stor, file.Path{"a", "b", "2"})
+		add(stor, file.Path{"c"})
+		stor.addOrRefresh(&task{
+			Config:       stor.config,
+			Path:         file.Path{"a"},
+			Kind:         TaskKindSubtree,
+			FirstEventTS: time.Now(),
+			LastEventTS:  time.Now(),
+			IsRecursive:  true,
+		})
+		require.Len(t, stor.taskMap, 2)
+		require.True(t, stor.taskMap[file.Path{"a"}.Key()].IsRecursive)
+		require.NotNil(t, stor.taskMap[file.Path{"c"}.Key()])
+
+		add(stor, file.Path{"a", "b", "3"})
+		require.Len(t, stor.taskMap, 2)
+	})
+}
+
+func TestTaskMerge(t *testing.T) {
+	newTask := func(kind TaskKind, isRecursive bool) *task {
+		return &task{
+			Config:       DefaultConfig,
+			Path:         file.Path{"file"},
+			Kind:         kind,
+			Priority:     DefaultConfig.taskPriority(file.Path{"file"}, kind),
+			FirstEventTS: time.Now(),
+			LastEventTS:  time.Now(),
+			IsRecursive:  isRecursive,
+		}
+	}
+
+	// the priority of a small file is not kept when the file becomes huge
+	merged := newTask(TaskKindSmallFile, false)
+	merged.Merge(newTask(TaskKindHugeFile, false))
+	require.Equal(t, TaskKindHugeFile, merged.Kind)
+	require.Equal(t, DefaultConfig.TaskKindPriorities[TaskKindHugeFile], merged.Priority)
+
+	// an unknown kind keeps the known one
+	merged.Merge(newTask(TaskKindUnknown, false))
+	require.Equal(t, TaskKindHugeFile, merged.Kind)
+
+	// a recursive task is a subtree task whatever was merged into it
+	merged.Merge(newTask(TaskKindUnknown, true))
+	require.True(t, merged.IsRecursive)
+	require.Equal(t, TaskKindSubtree, merged.Kind)
+	require.Equal(t, DefaultConfig.TaskKindPriorities[TaskKindSubtree], merged.Priority)
+	merged.Merge(newTask(TaskKindMetadata, false))
+	require.Equal(t, TaskKindSubtree, merged.Kind)
+	require.Equal(t, DefaultConfig.TaskKindPriorities[TaskKindSubtree], merged.Priority)
+}